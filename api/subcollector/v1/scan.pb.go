@@ -0,0 +1,1660 @@
+// gRPC API of the subcollector server (subcollector serve)
+// Regenerate the Go code with:
+//   protoc -I api --go_out=api --go_opt=paths=source_relative \
+//     --go-grpc_out=api --go-grpc_opt=paths=source_relative api/subcollector/v1/scan.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        (unknown)
+// source: subcollector/v1/scan.proto
+
+package subcollectorv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ScanMode int32
+
+const (
+	ScanMode_SCAN_MODE_UNSPECIFIED ScanMode = 0 // Treated as active
+	ScanMode_SCAN_MODE_ACTIVE      ScanMode = 1 // Brute force a wordlist against DNS
+	ScanMode_SCAN_MODE_PASSIVE     ScanMode = 2 // Query passive sources
+)
+
+// Enum value maps for ScanMode.
+var (
+	ScanMode_name = map[int32]string{
+		0: "SCAN_MODE_UNSPECIFIED",
+		1: "SCAN_MODE_ACTIVE",
+		2: "SCAN_MODE_PASSIVE",
+	}
+	ScanMode_value = map[string]int32{
+		"SCAN_MODE_UNSPECIFIED": 0,
+		"SCAN_MODE_ACTIVE":      1,
+		"SCAN_MODE_PASSIVE":     2,
+	}
+)
+
+func (x ScanMode) Enum() *ScanMode {
+	p := new(ScanMode)
+	*p = x
+	return p
+}
+
+func (x ScanMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ScanMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_subcollector_v1_scan_proto_enumTypes[0].Descriptor()
+}
+
+func (ScanMode) Type() protoreflect.EnumType {
+	return &file_subcollector_v1_scan_proto_enumTypes[0]
+}
+
+func (x ScanMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ScanMode.Descriptor instead.
+func (ScanMode) EnumDescriptor() ([]byte, []int) {
+	return file_subcollector_v1_scan_proto_rawDescGZIP(), []int{0}
+}
+
+type ScanRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Domain string   `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Mode   ScanMode `protobuf:"varint,2,opt,name=mode,proto3,enum=subcollector.v1.ScanMode" json:"mode,omitempty"`
+	// Active scans
+	Wordlist  string   `protobuf:"bytes,3,opt,name=wordlist,proto3" json:"wordlist,omitempty"` // Wordlist path on the server, the default list when empty
+	Recursive bool     `protobuf:"varint,4,opt,name=recursive,proto3" json:"recursive,omitempty"`
+	Depth     int32    `protobuf:"varint,5,opt,name=depth,proto3" json:"depth,omitempty"`        // Recursion depth, 1 when unset, -1 for unlimited
+	Takeover  bool     `protobuf:"varint,6,opt,name=takeover,proto3" json:"takeover,omitempty"`  // Check subdomains for takeover
+	Ports     []int32  `protobuf:"varint,7,rep,packed,name=ports,proto3" json:"ports,omitempty"` // TCP ports checked on resolved addresses
+	Tls       bool     `protobuf:"varint,8,opt,name=tls,proto3" json:"tls,omitempty"`            // Grab certificates on port 443
+	Http      bool     `protobuf:"varint,9,opt,name=http,proto3" json:"http,omitempty"`          // Probe web servers
+	Tech      bool     `protobuf:"varint,10,opt,name=tech,proto3" json:"tech,omitempty"`         // Detect technologies of probed web servers
+	Dangling  bool     `protobuf:"varint,11,opt,name=dangling,proto3" json:"dangling,omitempty"` // Report records pointing at missing resources
+	Dnssec    bool     `protobuf:"varint,12,opt,name=dnssec,proto3" json:"dnssec,omitempty"`     // Record DNSSEC validation status
+	Words     []string `protobuf:"bytes,20,rep,name=words,proto3" json:"words,omitempty"`        // Prefixes brute forced instead of the wordlist, under the domain as given (one shard of a distributed scan)
+	// Passive scans
+	Sources []string `protobuf:"bytes,13,rep,name=sources,proto3" json:"sources,omitempty"` // Sources to query, the default sources when empty
+	// Both modes
+	Resolvers      []string `protobuf:"bytes,14,rep,name=resolvers,proto3" json:"resolvers,omitempty"`
+	Workers        int32    `protobuf:"varint,15,opt,name=workers,proto3" json:"workers,omitempty"`                                     // Concurrent lookups, 10 when unset
+	RateLimitMs    int32    `protobuf:"varint,16,opt,name=rate_limit_ms,json=rateLimitMs,proto3" json:"rate_limit_ms,omitempty"`        // Delay between lookups, spread over the workers, 100 when unset
+	Exclude        []string `protobuf:"bytes,17,rep,name=exclude,proto3" json:"exclude,omitempty"`                                      // Hosts and wildcard patterns never queried nor reported
+	Verify         bool     `protobuf:"varint,18,opt,name=verify,proto3" json:"verify,omitempty"`                                       // Keep only results confirmed by trusted resolvers
+	TimeoutSeconds int64    `protobuf:"varint,19,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"` // Time budget of an active scan, unlimited when unset
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_subcollector_v1_scan_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subcollector_v1_scan_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_subcollector_v1_scan_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ScanRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetMode() ScanMode {
+	if x != nil {
+		return x.Mode
+	}
+	return ScanMode_SCAN_MODE_UNSPECIFIED
+}
+
+func (x *ScanRequest) GetWordlist() string {
+	if x != nil {
+		return x.Wordlist
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetRecursive() bool {
+	if x != nil {
+		return x.Recursive
+	}
+	return false
+}
+
+func (x *ScanRequest) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *ScanRequest) GetTakeover() bool {
+	if x != nil {
+		return x.Takeover
+	}
+	return false
+}
+
+func (x *ScanRequest) GetPorts() []int32 {
+	if x != nil {
+		return x.Ports
+	}
+	return nil
+}
+
+func (x *ScanRequest) GetTls() bool {
+	if x != nil {
+		return x.Tls
+	}
+	return false
+}
+
+func (x *ScanRequest) GetHttp() bool {
+	if x != nil {
+		return x.Http
+	}
+	return false
+}
+
+func (x *ScanRequest) GetTech() bool {
+	if x != nil {
+		return x.Tech
+	}
+	return false
+}
+
+func (x *ScanRequest) GetDangling() bool {
+	if x != nil {
+		return x.Dangling
+	}
+	return false
+}
+
+func (x *ScanRequest) GetDnssec() bool {
+	if x != nil {
+		return x.Dnssec
+	}
+	return false
+}
+
+func (x *ScanRequest) GetWords() []string {
+	if x != nil {
+		return x.Words
+	}
+	return nil
+}
+
+func (x *ScanRequest) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *ScanRequest) GetResolvers() []string {
+	if x != nil {
+		return x.Resolvers
+	}
+	return nil
+}
+
+func (x *ScanRequest) GetWorkers() int32 {
+	if x != nil {
+		return x.Workers
+	}
+	return 0
+}
+
+func (x *ScanRequest) GetRateLimitMs() int32 {
+	if x != nil {
+		return x.RateLimitMs
+	}
+	return 0
+}
+
+func (x *ScanRequest) GetExclude() []string {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+func (x *ScanRequest) GetVerify() bool {
+	if x != nil {
+		return x.Verify
+	}
+	return false
+}
+
+func (x *ScanRequest) GetTimeoutSeconds() int64 {
+	if x != nil {
+		return x.TimeoutSeconds
+	}
+	return 0
+}
+
+type ScanEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Event:
+	//	*ScanEvent_Started
+	//	*ScanEvent_Result
+	//	*ScanEvent_Finished
+	Event isScanEvent_Event `protobuf_oneof:"event"`
+}
+
+func (x *ScanEvent) Reset() {
+	*x = ScanEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_subcollector_v1_scan_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ScanEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanEvent) ProtoMessage() {}
+
+func (x *ScanEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_subcollector_v1_scan_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanEvent.ProtoReflect.Descriptor instead.
+func (*ScanEvent) Descriptor() ([]byte, []int) {
+	return file_subcollector_v1_scan_proto_rawDescGZIP(), []int{1}
+}
+
+func (m *ScanEvent) GetEvent() isScanEvent_Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (x *ScanEvent) GetStarted() *ScanStarted {
+	if x, ok := x.GetEvent().(*ScanEvent_Started); ok {
+		return x.Started
+	}
+	return nil
+}
+
+func (x *ScanEvent) GetResult() *SubdomainResult {
+	if x, ok := x.GetEvent().(*ScanEvent_Result); ok {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *ScanEvent) GetFinished() *ScanFinished {
+	if x, ok := x.GetEvent().(*ScanEvent_Finished); ok {
+		return x.Finished
+	}
+	return nil
+}
+
+type isScanEvent_Event interface {
+	isScanEvent_Event()
+}
+
+type ScanEvent_Started struct {
+	Started *ScanStarted `protobuf:"bytes,1,opt,name=started,proto3,oneof"`
+}
+
+type ScanEvent_Result struct {
+	Result *SubdomainResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+type ScanEvent_Finished struct {
+	Finished *ScanFinished `protobuf:"bytes,3,opt,name=finished,proto3,oneof"`
+}
+
+func (*ScanEvent_Started) isScanEvent_Event() {}
+
+func (*ScanEvent_Result) isScanEvent_Event() {}
+
+func (*ScanEvent_Finished) isScanEvent_Event() {}
+
+type ScanStarted struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Domain      string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Mode        ScanMode               `protobuf:"varint,2,opt,name=mode,proto3,enum=subcollector.v1.ScanMode" json:"mode,omitempty"`
+	StartedAt   *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	ToolVersion string                 `protobuf:"bytes,4,opt,name=tool_version,json=toolVersion,proto3" json:"tool_version,omitempty"`
+}
+
+func (x *ScanStarted) Reset() {
+	*x = ScanStarted{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_subcollector_v1_scan_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ScanStarted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanStarted) ProtoMessage() {}
+
+func (x *ScanStarted) ProtoReflect() protoreflect.Message {
+	mi := &file_subcollector_v1_scan_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanStarted.ProtoReflect.Descriptor instead.
+func (*ScanStarted) Descriptor() ([]byte, []int) {
+	return file_subcollector_v1_scan_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ScanStarted) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *ScanStarted) GetMode() ScanMode {
+	if x != nil {
+		return x.Mode
+	}
+	return ScanMode_SCAN_MODE_UNSPECIFIED
+}
+
+func (x *ScanStarted) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *ScanStarted) GetToolVersion() string {
+	if x != nil {
+		return x.ToolVersion
+	}
+	return ""
+}
+
+type ScanFinished struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Results    int32                  `protobuf:"varint,1,opt,name=results,proto3" json:"results,omitempty"` // Results streamed
+	FinishedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	Error      string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"` // Why the scan failed, empty on success
+}
+
+func (x *ScanFinished) Reset() {
+	*x = ScanFinished{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_subcollector_v1_scan_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ScanFinished) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanFinished) ProtoMessage() {}
+
+func (x *ScanFinished) ProtoReflect() protoreflect.Message {
+	mi := &file_subcollector_v1_scan_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanFinished.ProtoReflect.Descriptor instead.
+func (*ScanFinished) Descriptor() ([]byte, []int) {
+	return file_subcollector_v1_scan_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ScanFinished) GetResults() int32 {
+	if x != nil {
+		return x.Results
+	}
+	return 0
+}
+
+func (x *ScanFinished) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *ScanFinished) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// SubdomainResult mirrors the JSON output of a result
+type SubdomainResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Subdomain  string            `protobuf:"bytes,1,opt,name=subdomain,proto3" json:"subdomain,omitempty"`
+	Ips        []string          `protobuf:"bytes,2,rep,name=ips,proto3" json:"ips,omitempty"`
+	Ttl        uint32            `protobuf:"varint,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	ResponseMs float64           `protobuf:"fixed64,4,opt,name=response_ms,json=responseMs,proto3" json:"response_ms,omitempty"`
+	Takeover   string            `protobuf:"bytes,5,opt,name=takeover,proto3" json:"takeover,omitempty"`
+	Dnssec     string            `protobuf:"bytes,6,opt,name=dnssec,proto3" json:"dnssec,omitempty"`
+	Source     string            `protobuf:"bytes,7,opt,name=source,proto3" json:"source,omitempty"`
+	Tls        *Certificate      `protobuf:"bytes,8,opt,name=tls,proto3" json:"tls,omitempty"`
+	Ports      []int32           `protobuf:"varint,9,rep,packed,name=ports,proto3" json:"ports,omitempty"`
+	Cname      []string          `protobuf:"bytes,10,rep,name=cname,proto3" json:"cname,omitempty"`
+	Provider   string            `protobuf:"bytes,11,opt,name=provider,proto3" json:"provider,omitempty"`
+	Region     string            `protobuf:"bytes,12,opt,name=region,proto3" json:"region,omitempty"`
+	Evidence   string            `protobuf:"bytes,13,opt,name=evidence,proto3" json:"evidence,omitempty"`
+	Unverified bool              `protobuf:"varint,14,opt,name=unverified,proto3" json:"unverified,omitempty"`
+	Consensus  *Consensus        `protobuf:"bytes,15,opt,name=consensus,proto3" json:"consensus,omitempty"`
+	Http       *HTTPInfo         `protobuf:"bytes,16,opt,name=http,proto3" json:"http,omitempty"`
+	Dangling   []*DanglingRecord `protobuf:"bytes,17,rep,name=dangling,proto3" json:"dangling,omitempty"`
+	Email      *EmailPosture     `protobuf:"bytes,18,opt,name=email,proto3" json:"email,omitempty"`
+	Resolution string            `protobuf:"bytes,19,opt,name=resolution,proto3" json:"resolution,omitempty"`
+}
+
+func (x *SubdomainResult) Reset() {
+	*x = SubdomainResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_subcollector_v1_scan_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubdomainResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubdomainResult) ProtoMessage() {}
+
+func (x *SubdomainResult) ProtoReflect() protoreflect.Message {
+	mi := &file_subcollector_v1_scan_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubdomainResult.ProtoReflect.Descriptor instead.
+func (*SubdomainResult) Descriptor() ([]byte, []int) {
+	return file_subcollector_v1_scan_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SubdomainResult) GetSubdomain() string {
+	if x != nil {
+		return x.Subdomain
+	}
+	return ""
+}
+
+func (x *SubdomainResult) GetIps() []string {
+	if x != nil {
+		return x.Ips
+	}
+	return nil
+}
+
+func (x *SubdomainResult) GetTtl() uint32 {
+	if x != nil {
+		return x.Ttl
+	}
+	return 0
+}
+
+func (x *SubdomainResult) GetResponseMs() float64 {
+	if x != nil {
+		return x.ResponseMs
+	}
+	return 0
+}
+
+func (x *SubdomainResult) GetTakeover() string {
+	if x != nil {
+		return x.Takeover
+	}
+	return ""
+}
+
+func (x *SubdomainResult) GetDnssec() string {
+	if x != nil {
+		return x.Dnssec
+	}
+	return ""
+}
+
+func (x *SubdomainResult) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *SubdomainResult) GetTls() *Certificate {
+	if x != nil {
+		return x.Tls
+	}
+	return nil
+}
+
+func (x *SubdomainResult) GetPorts() []int32 {
+	if x != nil {
+		return x.Ports
+	}
+	return nil
+}
+
+func (x *SubdomainResult) GetCname() []string {
+	if x != nil {
+		return x.Cname
+	}
+	return nil
+}
+
+func (x *SubdomainResult) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *SubdomainResult) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *SubdomainResult) GetEvidence() string {
+	if x != nil {
+		return x.Evidence
+	}
+	return ""
+}
+
+func (x *SubdomainResult) GetUnverified() bool {
+	if x != nil {
+		return x.Unverified
+	}
+	return false
+}
+
+func (x *SubdomainResult) GetConsensus() *Consensus {
+	if x != nil {
+		return x.Consensus
+	}
+	return nil
+}
+
+func (x *SubdomainResult) GetHttp() *HTTPInfo {
+	if x != nil {
+		return x.Http
+	}
+	return nil
+}
+
+func (x *SubdomainResult) GetDangling() []*DanglingRecord {
+	if x != nil {
+		return x.Dangling
+	}
+	return nil
+}
+
+func (x *SubdomainResult) GetEmail() *EmailPosture {
+	if x != nil {
+		return x.Email
+	}
+	return nil
+}
+
+func (x *SubdomainResult) GetResolution() string {
+	if x != nil {
+		return x.Resolution
+	}
+	return ""
+}
+
+type Certificate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Subject   string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	Issuer    string                 `protobuf:"bytes,2,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	Sans      []string               `protobuf:"bytes,3,rep,name=sans,proto3" json:"sans,omitempty"`
+	NotBefore *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	NotAfter  *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+}
+
+func (x *Certificate) Reset() {
+	*x = Certificate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_subcollector_v1_scan_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Certificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Certificate) ProtoMessage() {}
+
+func (x *Certificate) ProtoReflect() protoreflect.Message {
+	mi := &file_subcollector_v1_scan_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Certificate.ProtoReflect.Descriptor instead.
+func (*Certificate) Descriptor() ([]byte, []int) {
+	return file_subcollector_v1_scan_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Certificate) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *Certificate) GetIssuer() string {
+	if x != nil {
+		return x.Issuer
+	}
+	return ""
+}
+
+func (x *Certificate) GetSans() []string {
+	if x != nil {
+		return x.Sans
+	}
+	return nil
+}
+
+func (x *Certificate) GetNotBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotBefore
+	}
+	return nil
+}
+
+func (x *Certificate) GetNotAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotAfter
+	}
+	return nil
+}
+
+type Consensus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Confirmed     int32    `protobuf:"varint,1,opt,name=confirmed,proto3" json:"confirmed,omitempty"`
+	Resolvers     int32    `protobuf:"varint,2,opt,name=resolvers,proto3" json:"resolvers,omitempty"`
+	Disagreements []string `protobuf:"bytes,3,rep,name=disagreements,proto3" json:"disagreements,omitempty"`
+}
+
+func (x *Consensus) Reset() {
+	*x = Consensus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_subcollector_v1_scan_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Consensus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Consensus) ProtoMessage() {}
+
+func (x *Consensus) ProtoReflect() protoreflect.Message {
+	mi := &file_subcollector_v1_scan_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Consensus.ProtoReflect.Descriptor instead.
+func (*Consensus) Descriptor() ([]byte, []int) {
+	return file_subcollector_v1_scan_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Consensus) GetConfirmed() int32 {
+	if x != nil {
+		return x.Confirmed
+	}
+	return 0
+}
+
+func (x *Consensus) GetResolvers() int32 {
+	if x != nil {
+		return x.Resolvers
+	}
+	return 0
+}
+
+func (x *Consensus) GetDisagreements() []string {
+	if x != nil {
+		return x.Disagreements
+	}
+	return nil
+}
+
+type HTTPInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Url          string        `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	StatusCode   int32         `protobuf:"varint,2,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	Title        string        `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Server       string        `protobuf:"bytes,4,opt,name=server,proto3" json:"server,omitempty"`
+	FaviconUrl   string        `protobuf:"bytes,5,opt,name=favicon_url,json=faviconUrl,proto3" json:"favicon_url,omitempty"`
+	FaviconMmh3  int32         `protobuf:"varint,6,opt,name=favicon_mmh3,json=faviconMmh3,proto3" json:"favicon_mmh3,omitempty"`
+	Technologies []*Technology `protobuf:"bytes,7,rep,name=technologies,proto3" json:"technologies,omitempty"`
+}
+
+func (x *HTTPInfo) Reset() {
+	*x = HTTPInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_subcollector_v1_scan_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HTTPInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HTTPInfo) ProtoMessage() {}
+
+func (x *HTTPInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_subcollector_v1_scan_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HTTPInfo.ProtoReflect.Descriptor instead.
+func (*HTTPInfo) Descriptor() ([]byte, []int) {
+	return file_subcollector_v1_scan_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HTTPInfo) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *HTTPInfo) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *HTTPInfo) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *HTTPInfo) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *HTTPInfo) GetFaviconUrl() string {
+	if x != nil {
+		return x.FaviconUrl
+	}
+	return ""
+}
+
+func (x *HTTPInfo) GetFaviconMmh3() int32 {
+	if x != nil {
+		return x.FaviconMmh3
+	}
+	return 0
+}
+
+func (x *HTTPInfo) GetTechnologies() []*Technology {
+	if x != nil {
+		return x.Technologies
+	}
+	return nil
+}
+
+type Technology struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name     string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Category string `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
+	Version  string `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *Technology) Reset() {
+	*x = Technology{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_subcollector_v1_scan_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Technology) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Technology) ProtoMessage() {}
+
+func (x *Technology) ProtoReflect() protoreflect.Message {
+	mi := &file_subcollector_v1_scan_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Technology.ProtoReflect.Descriptor instead.
+func (*Technology) Descriptor() ([]byte, []int) {
+	return file_subcollector_v1_scan_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Technology) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Technology) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Technology) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type DanglingRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type     string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Severity string `protobuf:"bytes,2,opt,name=severity,proto3" json:"severity,omitempty"`
+	Target   string `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	Detail   string `protobuf:"bytes,4,opt,name=detail,proto3" json:"detail,omitempty"`
+}
+
+func (x *DanglingRecord) Reset() {
+	*x = DanglingRecord{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_subcollector_v1_scan_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DanglingRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DanglingRecord) ProtoMessage() {}
+
+func (x *DanglingRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_subcollector_v1_scan_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DanglingRecord.ProtoReflect.Descriptor instead.
+func (*DanglingRecord) Descriptor() ([]byte, []int) {
+	return file_subcollector_v1_scan_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DanglingRecord) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *DanglingRecord) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *DanglingRecord) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *DanglingRecord) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+type EmailPosture struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Domain        string        `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Mx            []string      `protobuf:"bytes,2,rep,name=mx,proto3" json:"mx,omitempty"`
+	Spf           string        `protobuf:"bytes,3,opt,name=spf,proto3" json:"spf,omitempty"`
+	SpfIncludes   []string      `protobuf:"bytes,4,rep,name=spf_includes,json=spfIncludes,proto3" json:"spf_includes,omitempty"`
+	Dmarc         string        `protobuf:"bytes,5,opt,name=dmarc,proto3" json:"dmarc,omitempty"`
+	DkimSelectors []string      `protobuf:"bytes,6,rep,name=dkim_selectors,json=dkimSelectors,proto3" json:"dkim_selectors,omitempty"`
+	Issues        []*EmailIssue `protobuf:"bytes,7,rep,name=issues,proto3" json:"issues,omitempty"`
+}
+
+func (x *EmailPosture) Reset() {
+	*x = EmailPosture{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_subcollector_v1_scan_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EmailPosture) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmailPosture) ProtoMessage() {}
+
+func (x *EmailPosture) ProtoReflect() protoreflect.Message {
+	mi := &file_subcollector_v1_scan_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmailPosture.ProtoReflect.Descriptor instead.
+func (*EmailPosture) Descriptor() ([]byte, []int) {
+	return file_subcollector_v1_scan_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *EmailPosture) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *EmailPosture) GetMx() []string {
+	if x != nil {
+		return x.Mx
+	}
+	return nil
+}
+
+func (x *EmailPosture) GetSpf() string {
+	if x != nil {
+		return x.Spf
+	}
+	return ""
+}
+
+func (x *EmailPosture) GetSpfIncludes() []string {
+	if x != nil {
+		return x.SpfIncludes
+	}
+	return nil
+}
+
+func (x *EmailPosture) GetDmarc() string {
+	if x != nil {
+		return x.Dmarc
+	}
+	return ""
+}
+
+func (x *EmailPosture) GetDkimSelectors() []string {
+	if x != nil {
+		return x.DkimSelectors
+	}
+	return nil
+}
+
+func (x *EmailPosture) GetIssues() []*EmailIssue {
+	if x != nil {
+		return x.Issues
+	}
+	return nil
+}
+
+type EmailIssue struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Issue    string `protobuf:"bytes,1,opt,name=issue,proto3" json:"issue,omitempty"`
+	Severity string `protobuf:"bytes,2,opt,name=severity,proto3" json:"severity,omitempty"`
+	Detail   string `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`
+}
+
+func (x *EmailIssue) Reset() {
+	*x = EmailIssue{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_subcollector_v1_scan_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EmailIssue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmailIssue) ProtoMessage() {}
+
+func (x *EmailIssue) ProtoReflect() protoreflect.Message {
+	mi := &file_subcollector_v1_scan_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmailIssue.ProtoReflect.Descriptor instead.
+func (*EmailIssue) Descriptor() ([]byte, []int) {
+	return file_subcollector_v1_scan_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *EmailIssue) GetIssue() string {
+	if x != nil {
+		return x.Issue
+	}
+	return ""
+}
+
+func (x *EmailIssue) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *EmailIssue) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+var File_subcollector_v1_scan_proto protoreflect.FileDescriptor
+
+var file_subcollector_v1_scan_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x73, 0x75, 0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2f, 0x76,
+	0x31, 0x2f, 0x73, 0x63, 0x61, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0f, 0x73, 0x75,
+	0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xab,
+	0x04, 0x0a, 0x0b, 0x53, 0x63, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x2d, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x73, 0x75, 0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63,
+	0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x4d, 0x6f, 0x64, 0x65, 0x52,
+	0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x77, 0x6f, 0x72, 0x64, 0x6c, 0x69, 0x73,
+	0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x77, 0x6f, 0x72, 0x64, 0x6c, 0x69, 0x73,
+	0x74, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x63, 0x75, 0x72, 0x73, 0x69, 0x76, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x72, 0x65, 0x63, 0x75, 0x72, 0x73, 0x69, 0x76, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x64, 0x65, 0x70, 0x74, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05,
+	0x64, 0x65, 0x70, 0x74, 0x68, 0x12, 0x1a, 0x0a, 0x08, 0x74, 0x61, 0x6b, 0x65, 0x6f, 0x76, 0x65,
+	0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x74, 0x61, 0x6b, 0x65, 0x6f, 0x76, 0x65,
+	0x72, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x05,
+	0x52, 0x05, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x6c, 0x73, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x74, 0x6c, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x74, 0x74,
+	0x70, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x68, 0x74, 0x74, 0x70, 0x12, 0x12, 0x0a,
+	0x04, 0x74, 0x65, 0x63, 0x68, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x74, 0x65, 0x63,
+	0x68, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x61, 0x6e, 0x67, 0x6c, 0x69, 0x6e, 0x67, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x08, 0x64, 0x61, 0x6e, 0x67, 0x6c, 0x69, 0x6e, 0x67, 0x12, 0x16, 0x0a,
+	0x06, 0x64, 0x6e, 0x73, 0x73, 0x65, 0x63, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x64,
+	0x6e, 0x73, 0x73, 0x65, 0x63, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x14,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x77, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65,
+	0x72, 0x73, 0x18, 0x0e, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x73, 0x6f, 0x6c, 0x76,
+	0x65, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x73, 0x18, 0x0f,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x73, 0x12, 0x22, 0x0a,
+	0x0d, 0x72, 0x61, 0x74, 0x65, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0x10,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x72, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x4d,
+	0x73, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x18, 0x11, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x07, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x76,
+	0x65, 0x72, 0x69, 0x66, 0x79, 0x18, 0x12, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x76, 0x65, 0x72,
+	0x69, 0x66, 0x79, 0x12, 0x27, 0x0a, 0x0f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x73,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x13, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x74, 0x69,
+	0x6d, 0x65, 0x6f, 0x75, 0x74, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x22, 0xc7, 0x01, 0x0a,
+	0x09, 0x53, 0x63, 0x61, 0x6e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x38, 0x0a, 0x07, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x73, 0x75,
+	0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63,
+	0x61, 0x6e, 0x53, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x48, 0x00, 0x52, 0x07, 0x73, 0x74, 0x61,
+	0x72, 0x74, 0x65, 0x64, 0x12, 0x3a, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x73, 0x75, 0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63,
+	0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x48, 0x00, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x12, 0x3b, 0x0a, 0x08, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x73, 0x75, 0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65,
+	0x64, 0x48, 0x00, 0x52, 0x08, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x42, 0x07, 0x0a,
+	0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x22, 0xb2, 0x01, 0x0a, 0x0b, 0x53, 0x63, 0x61, 0x6e, 0x53,
+	0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x2d,
+	0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x73,
+	0x75, 0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53,
+	0x63, 0x61, 0x6e, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12, 0x39, 0x0a,
+	0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x6f, 0x6f, 0x6c,
+	0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x74, 0x6f, 0x6f, 0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x7b, 0x0a, 0x0c, 0x53,
+	0x63, 0x61, 0x6e, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x72,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x72, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x73, 0x12, 0x3b, 0x0a, 0x0b, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65,
+	0x64, 0x5f, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64,
+	0x41, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x87, 0x05, 0x0a, 0x0f, 0x53, 0x75, 0x62,
+	0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1c, 0x0a, 0x09,
+	0x73, 0x75, 0x62, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x73, 0x75, 0x62, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x69, 0x70,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x69, 0x70, 0x73, 0x12, 0x10, 0x0a, 0x03,
+	0x74, 0x74, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x74, 0x74, 0x6c, 0x12, 0x1f,
+	0x0a, 0x0b, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x5f, 0x6d, 0x73, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x0a, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x4d, 0x73, 0x12,
+	0x1a, 0x0a, 0x08, 0x74, 0x61, 0x6b, 0x65, 0x6f, 0x76, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x74, 0x61, 0x6b, 0x65, 0x6f, 0x76, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x64,
+	0x6e, 0x73, 0x73, 0x65, 0x63, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x6e, 0x73,
+	0x73, 0x65, 0x63, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x2e, 0x0a, 0x03, 0x74,
+	0x6c, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x73, 0x75, 0x62, 0x63, 0x6f,
+	0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x65, 0x72, 0x74, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x03, 0x74, 0x6c, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x70,
+	0x6f, 0x72, 0x74, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x05, 0x52, 0x05, 0x70, 0x6f, 0x72, 0x74,
+	0x73, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x05, 0x63, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x76, 0x69,
+	0x64, 0x65, 0x72, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x76, 0x69,
+	0x64, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x18, 0x0c, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x65,
+	0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x65,
+	0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x75, 0x6e, 0x76, 0x65, 0x72,
+	0x69, 0x66, 0x69, 0x65, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x75, 0x6e, 0x76,
+	0x65, 0x72, 0x69, 0x66, 0x69, 0x65, 0x64, 0x12, 0x38, 0x0a, 0x09, 0x63, 0x6f, 0x6e, 0x73, 0x65,
+	0x6e, 0x73, 0x75, 0x73, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x73, 0x75, 0x62,
+	0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6e,
+	0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x52, 0x09, 0x63, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75,
+	0x73, 0x12, 0x2d, 0x0a, 0x04, 0x68, 0x74, 0x74, 0x70, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x73, 0x75, 0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x48, 0x54, 0x54, 0x50, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x04, 0x68, 0x74, 0x74, 0x70,
+	0x12, 0x3b, 0x0a, 0x08, 0x64, 0x61, 0x6e, 0x67, 0x6c, 0x69, 0x6e, 0x67, 0x18, 0x11, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x73, 0x75, 0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x61, 0x6e, 0x67, 0x6c, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x63,
+	0x6f, 0x72, 0x64, 0x52, 0x08, 0x64, 0x61, 0x6e, 0x67, 0x6c, 0x69, 0x6e, 0x67, 0x12, 0x33, 0x0a,
+	0x05, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x73,
+	0x75, 0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45,
+	0x6d, 0x61, 0x69, 0x6c, 0x50, 0x6f, 0x73, 0x74, 0x75, 0x72, 0x65, 0x52, 0x05, 0x65, 0x6d, 0x61,
+	0x69, 0x6c, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x13, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x72, 0x65, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69,
+	0x6f, 0x6e, 0x22, 0xc7, 0x01, 0x0a, 0x0b, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x75, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x16, 0x0a, 0x06,
+	0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x69, 0x73,
+	0x73, 0x75, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x61, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x04, 0x73, 0x61, 0x6e, 0x73, 0x12, 0x39, 0x0a, 0x0a, 0x6e, 0x6f, 0x74, 0x5f,
+	0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x6e, 0x6f, 0x74, 0x42, 0x65, 0x66,
+	0x6f, 0x72, 0x65, 0x12, 0x37, 0x0a, 0x09, 0x6e, 0x6f, 0x74, 0x5f, 0x61, 0x66, 0x74, 0x65, 0x72,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x08, 0x6e, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x22, 0x6d, 0x0a, 0x09,
+	0x43, 0x6f, 0x6e, 0x73, 0x65, 0x6e, 0x73, 0x75, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6e,
+	0x66, 0x69, 0x72, 0x6d, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x63, 0x6f,
+	0x6e, 0x66, 0x69, 0x72, 0x6d, 0x65, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x73, 0x6f, 0x6c,
+	0x76, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x72, 0x65, 0x73, 0x6f,
+	0x6c, 0x76, 0x65, 0x72, 0x73, 0x12, 0x24, 0x0a, 0x0d, 0x64, 0x69, 0x73, 0x61, 0x67, 0x72, 0x65,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x64, 0x69,
+	0x73, 0x61, 0x67, 0x72, 0x65, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x22, 0xf0, 0x01, 0x0a, 0x08,
+	0x48, 0x54, 0x54, 0x50, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x0a, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74,
+	0x69, 0x74, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x12, 0x1f, 0x0a, 0x0b, 0x66, 0x61, 0x76,
+	0x69, 0x63, 0x6f, 0x6e, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x66, 0x61, 0x76, 0x69, 0x63, 0x6f, 0x6e, 0x55, 0x72, 0x6c, 0x12, 0x21, 0x0a, 0x0c, 0x66, 0x61,
+	0x76, 0x69, 0x63, 0x6f, 0x6e, 0x5f, 0x6d, 0x6d, 0x68, 0x33, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x0b, 0x66, 0x61, 0x76, 0x69, 0x63, 0x6f, 0x6e, 0x4d, 0x6d, 0x68, 0x33, 0x12, 0x3f, 0x0a,
+	0x0c, 0x74, 0x65, 0x63, 0x68, 0x6e, 0x6f, 0x6c, 0x6f, 0x67, 0x69, 0x65, 0x73, 0x18, 0x07, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x73, 0x75, 0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74,
+	0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x65, 0x63, 0x68, 0x6e, 0x6f, 0x6c, 0x6f, 0x67, 0x79,
+	0x52, 0x0c, 0x74, 0x65, 0x63, 0x68, 0x6e, 0x6f, 0x6c, 0x6f, 0x67, 0x69, 0x65, 0x73, 0x22, 0x56,
+	0x0a, 0x0a, 0x54, 0x65, 0x63, 0x68, 0x6e, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x12, 0x18, 0x0a, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x70, 0x0a, 0x0e, 0x44, 0x61, 0x6e, 0x67, 0x6c, 0x69,
+	0x6e, 0x67, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67,
+	0x65, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74,
+	0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x22, 0xdd, 0x01, 0x0a, 0x0c, 0x45, 0x6d, 0x61,
+	0x69, 0x6c, 0x50, 0x6f, 0x73, 0x74, 0x75, 0x72, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x6f, 0x6d,
+	0x61, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x6f, 0x6d, 0x61, 0x69,
+	0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x6d, 0x78, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x02, 0x6d,
+	0x78, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x70, 0x66, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x73, 0x70, 0x66, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x70, 0x66, 0x5f, 0x69, 0x6e, 0x63, 0x6c, 0x75,
+	0x64, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x70, 0x66, 0x49, 0x6e,
+	0x63, 0x6c, 0x75, 0x64, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x64, 0x6d, 0x61, 0x72, 0x63, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x64, 0x6d, 0x61, 0x72, 0x63, 0x12, 0x25, 0x0a, 0x0e,
+	0x64, 0x6b, 0x69, 0x6d, 0x5f, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x06,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x64, 0x6b, 0x69, 0x6d, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74,
+	0x6f, 0x72, 0x73, 0x12, 0x33, 0x0a, 0x06, 0x69, 0x73, 0x73, 0x75, 0x65, 0x73, 0x18, 0x07, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x73, 0x75, 0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74,
+	0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6d, 0x61, 0x69, 0x6c, 0x49, 0x73, 0x73, 0x75, 0x65,
+	0x52, 0x06, 0x69, 0x73, 0x73, 0x75, 0x65, 0x73, 0x22, 0x56, 0x0a, 0x0a, 0x45, 0x6d, 0x61, 0x69,
+	0x6c, 0x49, 0x73, 0x73, 0x75, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x73, 0x73, 0x75, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69, 0x73, 0x73, 0x75, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x74, 0x61,
+	0x69, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c,
+	0x2a, 0x52, 0x0a, 0x08, 0x53, 0x63, 0x61, 0x6e, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x19, 0x0a, 0x15,
+	0x53, 0x43, 0x41, 0x4e, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43,
+	0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x14, 0x0a, 0x10, 0x53, 0x43, 0x41, 0x4e, 0x5f,
+	0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x56, 0x45, 0x10, 0x01, 0x12, 0x15, 0x0a,
+	0x11, 0x53, 0x43, 0x41, 0x4e, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x50, 0x41, 0x53, 0x53, 0x49,
+	0x56, 0x45, 0x10, 0x02, 0x32, 0x51, 0x0a, 0x0b, 0x53, 0x63, 0x61, 0x6e, 0x53, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x12, 0x42, 0x0a, 0x04, 0x53, 0x63, 0x61, 0x6e, 0x12, 0x1c, 0x2e, 0x73, 0x75,
+	0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63,
+	0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x73, 0x75, 0x62, 0x63,
+	0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x61, 0x6e,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x30, 0x01, 0x42, 0x43, 0x5a, 0x41, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x66, 0x6b, 0x72, 0x30, 0x30, 0x74, 0x2f, 0x73, 0x75, 0x62,
+	0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x73, 0x75,
+	0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2f, 0x76, 0x31, 0x3b, 0x73, 0x75,
+	0x62, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_subcollector_v1_scan_proto_rawDescOnce sync.Once
+	file_subcollector_v1_scan_proto_rawDescData = file_subcollector_v1_scan_proto_rawDesc
+)
+
+func file_subcollector_v1_scan_proto_rawDescGZIP() []byte {
+	file_subcollector_v1_scan_proto_rawDescOnce.Do(func() {
+		file_subcollector_v1_scan_proto_rawDescData = protoimpl.X.CompressGZIP(file_subcollector_v1_scan_proto_rawDescData)
+	})
+	return file_subcollector_v1_scan_proto_rawDescData
+}
+
+var file_subcollector_v1_scan_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_subcollector_v1_scan_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_subcollector_v1_scan_proto_goTypes = []interface{}{
+	(ScanMode)(0),                 // 0: subcollector.v1.ScanMode
+	(*ScanRequest)(nil),           // 1: subcollector.v1.ScanRequest
+	(*ScanEvent)(nil),             // 2: subcollector.v1.ScanEvent
+	(*ScanStarted)(nil),           // 3: subcollector.v1.ScanStarted
+	(*ScanFinished)(nil),          // 4: subcollector.v1.ScanFinished
+	(*SubdomainResult)(nil),       // 5: subcollector.v1.SubdomainResult
+	(*Certificate)(nil),           // 6: subcollector.v1.Certificate
+	(*Consensus)(nil),             // 7: subcollector.v1.Consensus
+	(*HTTPInfo)(nil),              // 8: subcollector.v1.HTTPInfo
+	(*Technology)(nil),            // 9: subcollector.v1.Technology
+	(*DanglingRecord)(nil),        // 10: subcollector.v1.DanglingRecord
+	(*EmailPosture)(nil),          // 11: subcollector.v1.EmailPosture
+	(*EmailIssue)(nil),            // 12: subcollector.v1.EmailIssue
+	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
+}
+var file_subcollector_v1_scan_proto_depIdxs = []int32{
+	0,  // 0: subcollector.v1.ScanRequest.mode:type_name -> subcollector.v1.ScanMode
+	3,  // 1: subcollector.v1.ScanEvent.started:type_name -> subcollector.v1.ScanStarted
+	5,  // 2: subcollector.v1.ScanEvent.result:type_name -> subcollector.v1.SubdomainResult
+	4,  // 3: subcollector.v1.ScanEvent.finished:type_name -> subcollector.v1.ScanFinished
+	0,  // 4: subcollector.v1.ScanStarted.mode:type_name -> subcollector.v1.ScanMode
+	13, // 5: subcollector.v1.ScanStarted.started_at:type_name -> google.protobuf.Timestamp
+	13, // 6: subcollector.v1.ScanFinished.finished_at:type_name -> google.protobuf.Timestamp
+	6,  // 7: subcollector.v1.SubdomainResult.tls:type_name -> subcollector.v1.Certificate
+	7,  // 8: subcollector.v1.SubdomainResult.consensus:type_name -> subcollector.v1.Consensus
+	8,  // 9: subcollector.v1.SubdomainResult.http:type_name -> subcollector.v1.HTTPInfo
+	10, // 10: subcollector.v1.SubdomainResult.dangling:type_name -> subcollector.v1.DanglingRecord
+	11, // 11: subcollector.v1.SubdomainResult.email:type_name -> subcollector.v1.EmailPosture
+	13, // 12: subcollector.v1.Certificate.not_before:type_name -> google.protobuf.Timestamp
+	13, // 13: subcollector.v1.Certificate.not_after:type_name -> google.protobuf.Timestamp
+	9,  // 14: subcollector.v1.HTTPInfo.technologies:type_name -> subcollector.v1.Technology
+	12, // 15: subcollector.v1.EmailPosture.issues:type_name -> subcollector.v1.EmailIssue
+	1,  // 16: subcollector.v1.ScanService.Scan:input_type -> subcollector.v1.ScanRequest
+	2,  // 17: subcollector.v1.ScanService.Scan:output_type -> subcollector.v1.ScanEvent
+	17, // [17:18] is the sub-list for method output_type
+	16, // [16:17] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_subcollector_v1_scan_proto_init() }
+func file_subcollector_v1_scan_proto_init() {
+	if File_subcollector_v1_scan_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_subcollector_v1_scan_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScanRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_subcollector_v1_scan_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScanEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_subcollector_v1_scan_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScanStarted); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_subcollector_v1_scan_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ScanFinished); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_subcollector_v1_scan_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubdomainResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_subcollector_v1_scan_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Certificate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_subcollector_v1_scan_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Consensus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_subcollector_v1_scan_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HTTPInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_subcollector_v1_scan_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Technology); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_subcollector_v1_scan_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DanglingRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_subcollector_v1_scan_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EmailPosture); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_subcollector_v1_scan_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EmailIssue); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_subcollector_v1_scan_proto_msgTypes[1].OneofWrappers = []interface{}{
+		(*ScanEvent_Started)(nil),
+		(*ScanEvent_Result)(nil),
+		(*ScanEvent_Finished)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_subcollector_v1_scan_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_subcollector_v1_scan_proto_goTypes,
+		DependencyIndexes: file_subcollector_v1_scan_proto_depIdxs,
+		EnumInfos:         file_subcollector_v1_scan_proto_enumTypes,
+		MessageInfos:      file_subcollector_v1_scan_proto_msgTypes,
+	}.Build()
+	File_subcollector_v1_scan_proto = out.File
+	file_subcollector_v1_scan_proto_rawDesc = nil
+	file_subcollector_v1_scan_proto_goTypes = nil
+	file_subcollector_v1_scan_proto_depIdxs = nil
+}
@@ -16,11 +16,12 @@ func main() {
 	// Tangani signal interrupt
 	setupSignalHandler()
 
-	// Initialize logger
+	// Initialize logger, on stderr so stdout only carries output
 	err := utils.InitGlobalLogger(utils.LoggerConfig{
-		Level:        utils.LevelInfo, // Default level
-		OutputFile:   "",              // Does not write to a file by default
+		Level:        utils.LevelWarning, // Changed by --quiet, --verbose and --debug
+		OutputFile:   "",                 // Does not write to a file by default
 		ColorEnabled: true,
+		Writer:       os.Stderr,
 		TimeFormat:   "2006-01-02 15:04:05",
 	})
 
@@ -29,14 +30,12 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Log startup
-	utils.Info("Starting Subcollector...")
-
 	// Execute CLI
 	if err := cli.Execute(); err != nil {
 		utils.Error("Error executing command: %v", err)
-		os.Exit(1)
+		utils.Exit(cli.ExitError)
 	}
+	utils.Exit(cli.ExitCode())
 }
 
 // setupSignalHandler menangani signal interrupt dengan menampilkan pesan "Bye!"
@@ -47,6 +46,6 @@ func setupSignalHandler() {
 	go func() {
 		<-c
 		fmt.Println("\nBye!")
-		os.Exit(0)
+		utils.Exit(cli.ExitInterrupted)
 	}()
 }
@@ -1,23 +1,221 @@
 package cli
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/fkr00t/subcollector/internal/cloud"
+	"github.com/fkr00t/subcollector/internal/cluster"
+	"github.com/fkr00t/subcollector/internal/crawl"
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/monitor"
+	"github.com/fkr00t/subcollector/internal/notify"
+	"github.com/fkr00t/subcollector/internal/probe"
+	"github.com/fkr00t/subcollector/internal/project"
+	dnsresolvers "github.com/fkr00t/subcollector/internal/resolvers"
 	"github.com/fkr00t/subcollector/internal/scanner"
+	"github.com/fkr00t/subcollector/internal/server"
+	"github.com/fkr00t/subcollector/internal/sink"
+	"github.com/fkr00t/subcollector/internal/sources"
+	"github.com/fkr00t/subcollector/internal/storage"
+	"github.com/fkr00t/subcollector/internal/upload"
 	"github.com/fkr00t/subcollector/internal/utils"
+	"github.com/fkr00t/subcollector/internal/wordlist"
+	"github.com/fkr00t/subcollector/internal/worker"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
 	// Global flags
-	domain, listPath, output, jsonOutput, wordlistPath, proxy   string
+	domain, listPath, output, jsonOutput, htmlOutput, proxy     string
+	wordlistPath                                                string
+	recheckOutput, portSpec, importPath, exportDir              string
+	workspaceDir, evidenceDir, csvOutput, sortOrder             string
+	lineFormat, xmlOutput, sarifOutput, databasePath            string
+	uploadLocation                                              string
 	showIP, recursive, takeover, streamResults, realTimeDisplay bool
-	rateLimit, depth, numWorkers                                int
-	resolvers                                                   []string
+	dnssec, serviceRecords, grabTLS, groupHosts, resolveMerged  bool
+	probeHTTP, detectTech, findDangling, checkEmail             bool
+	secondOrder, lookupRDAP                                     bool
+	rateLimit, depth, numWorkers, parallelDomains, quorum       int
+	timeoutTotal, canaryInterval                                time.Duration
+	resolvers, excludePatterns, matchPatterns, filterPatterns   []string
+	verifyResolvers                                             []string
+	verifyResults, dropUnresolved                               bool
+	depthRules                                                  []string
+	maxQueries                                                  int64
+
+	// Verify command flags
+	verifyListPath string
+	keepUnresolved bool
+
+	// Takeover command flags
+	takeoverInputPath string
+	takeoverRateLimit int // takeover pauses nothing by default, unlike scans
+
+	// Takeover check flags
+	takeoverWorkers   int
+	takeoverTimeout   time.Duration
+	takeoverRedirects string
+	takeoverInsecure  bool
+	takeoverHTTP2     bool
+	takeoverMaxBody   string
+	takeoverPrints    string
+	reloadFiles       bool
+
+	// Outbound HTTP flags
+	requestHeaderSpecs []string
+	userAgent          string
+
+	// Elasticsearch/OpenSearch sink flags
+	esURL, esIndex, esAPIKey string
+
+	// Kafka/NATS sink flag
+	busURL string
+
+	// Syslog sink flags
+	syslogTarget, syslogFacility string
+
+	// Format of results streamed to stdout
+	outputStream string
+
+	// DNS query flags
+	dnsTimeout     time.Duration
+	dnsRetries     int
+	ednsBufferSize int
+	rateReport     string
+
+	// Limit key, concurrency cap and memory cap flags
+	limitBy         string
+	maxPerAuthority int
+	maxMemory       string
+
+	// DNS cache flags
+	cacheType, cacheFile       string
+	cacheImport, cacheExport   string
+	cacheSize                  int
+	cacheTTL, cacheNegativeTTL time.Duration
+
+	// Adaptive backoff flags
+	backoffEnabled               bool
+	backoffFactor, backoffJitter float64
+	backoffMaxDelay              time.Duration
+	backoffThreshold             int
+	backoffBy                    string
+
+	// Split DNS flags
+	resolverGroups, splitZones []string
+	systemResolvers            bool
+
+	// Passive source flags
+	sourceNames, excludeSources, sourceTimeouts []string
+	providersPath                               string
+	allSources                                  bool
+	sourceRequestTimeout                        time.Duration
+	sourceReport                                string
+	sourceRateLimits                            []string
+	sourceRetries, sourceCacheDays              int
+	sourceCacheDir                              string
+	keyCheckTimeout                             time.Duration
+
+	// Wordlist generation flags
+	maxPages, maxWords int
+
+	// Resolver benchmark flags
+	benchRounds, benchWorkers int
+	benchTimeout              time.Duration
+	minReliability            float64
+
+	// Monitor flags
+	monitorMode               string
+	monitorInterval           time.Duration
+	monitorOnce, trackRecords bool
+	webhooks, alertRules      []string
+
+	// gRPC server flags
+	serveAddress, serveToken, serveCertFile, serveKeyFile string
+	serveMaxScans                                         int
+
+	// Distributed scan flags
+	nodeAddresses         []string
+	nodeToken, nodeCAFile string
+	nodeTLS               bool
+	nodeScans, shardSize  int
+
+	// Dry run flag
+	dryRun bool
+
+	// Scan history flags
+	historyLimit  int
+	showSortOrder string // show sorts by name by default, unlike scans
+
+	// Tagging and annotation flags
+	resultTags, removedTags []string
+	resultNote              string
+	clearNote               bool
+
+	// Severity flags
+	severitySpecs []string
+
+	// Display form of internationalized domain names
+	idnForm string
+
+	// Wordlist flags
+	minWordLength, maxWordLength int
+	wordCharset, sortTempDir     string
+	rawWords                     bool
+
+	// Project flag, projectName is set once the file is loaded
+	projectPath, projectName string
+
+	// Project import flags
+	scopePlatform, scopeProjectName string
+
+	// Redis worker flags
+	redisURL, jobQueue, jobResults, jobGroup, workerName string
+	jobStream, drainQueue                                bool
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "subcollector",
 	Short: "Subcollector - Subdomain Enumeration Tool",
 	Long:  "Subcollector is a tool for enumerating subdomains using passive and active techniques.",
+	// Flags left out of the command line are read from SUBCOLLECTOR_* variables,
+	// then the log file and level are set, the profiling asked for with --pprof and
+	// --trace starts and the spans of the run are exported to --otlp-endpoint
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		err := applyEnvFlags(cmd)
+		if err == nil {
+			err = applyLogFile(cmd)
+		}
+		if err == nil {
+			err = applyVerbosity(cmd)
+		}
+		if err == nil {
+			err = startDebug(cmd)
+		}
+		if err == nil {
+			err = startTelemetry(cmd)
+		}
+		if err == nil {
+			err = validateFlags(cmd)
+		}
+		// The usage does not help with a bad variable, log file, debug flag or flag value
+		cmd.SilenceUsage = err != nil
+		return err
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if versionFlag, _ := cmd.Flags().GetBool("version"); versionFlag {
 			ShowVersion()
@@ -37,12 +235,165 @@ var passiveCmd = &cobra.Command{
 			return
 		}
 
-		if domain == "" && listPath == "" {
-			cmd.Println("[ERR] Please specify a domain (-d) or a domain list (-l)")
+		if !checkTargetFlags(cmd) {
+			return
+		}
+
+		handlePassiveCommand(cmd)
+	},
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Resolve, probe and takeover-check a subdomain list found by other tools, without enumerating",
+	Run: func(cmd *cobra.Command, args []string) {
+		if versionFlag, _ := cmd.Flags().GetBool("version"); versionFlag {
+			ShowVersion()
+			return
+		}
+		if verifyListPath == "" {
+			usageError(cmd, "Please specify a subdomain list (-l)")
+			return
+		}
+		if projectPath != "" && domain != "" {
+			usageError(cmd, "Please use either -d or --project")
+			return
+		}
+		handleVerifyCommand(cmd)
+	},
+}
+
+var takeoverCmd = &cobra.Command{
+	Use:   "takeover",
+	Short: "Check the hosts of earlier results or a host list for subdomain takeovers, without enumerating",
+	Run: func(cmd *cobra.Command, args []string) {
+		if versionFlag, _ := cmd.Flags().GetBool("version"); versionFlag {
+			ShowVersion()
+			return
+		}
+		if takeoverInputPath == "" {
+			usageError(cmd, "Please specify a result file or host list (-i)")
+			return
+		}
+		if projectPath != "" && domain != "" {
+			usageError(cmd, "Please use either -d or --project")
+			return
+		}
+		handleTakeoverCommand(cmd)
+	},
+}
+
+var monitorCmd = &cobra.Command{
+	Use:   "monitor",
+	Short: "Re-run enumeration on a schedule and alert on changes",
+	Run: func(cmd *cobra.Command, args []string) {
+		if versionFlag, _ := cmd.Flags().GetBool("version"); versionFlag {
+			ShowVersion()
+			return
+		}
+
+		if !checkTargetFlags(cmd) {
+			return
+		}
+
+		handleMonitorCommand(cmd)
+	},
+}
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [files...]",
+	Short: "Merge result files from subcollector, amass, subfinder or plain lists into one report",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Merged results are restricted to the project domains, not to -d/-l as well
+		if projectPath != "" && !checkTargetFlags(cmd) {
+			return
+		}
+		handleMergeCommand(cmd, args)
+	},
+}
+
+var updateRangesCmd = &cobra.Command{
+	Use:   "update-ranges",
+	Short: "Download current cloud provider IP ranges used to tag results",
+	Run: func(cmd *cobra.Command, args []string) {
+		handleUpdateRangesCommand()
+	},
+}
+
+var wordgenCmd = &cobra.Command{
+	Use:   "wordgen",
+	Short: "Build a target-specific wordlist from the target's website, sitemaps and scripts",
+	Run: func(cmd *cobra.Command, args []string) {
+		if domain == "" {
+			usageError(cmd, "Please specify a domain (-d)")
 			return
 		}
+		handleWordgenCommand()
+	},
+}
+
+var wordlistCmd = &cobra.Command{
+	Use:   "wordlist",
+	Short: "Merge, deduplicate, sort, filter and describe wordlists with the normalization rules of scans",
+}
+
+var wordlistMergeCmd = &cobra.Command{
+	Use:   "merge [files...]",
+	Short: "Merge wordlists into one, keeping the first occurrence of each entry",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		handleWordlistCommand(cmd, "merge", args)
+	},
+}
 
-		handlePassiveCommand()
+var wordlistDedupeCmd = &cobra.Command{
+	Use:   "dedupe [file]",
+	Short: "Remove duplicate entries of a wordlist, keeping its order",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		handleWordlistCommand(cmd, "dedupe", args)
+	},
+}
+
+var wordlistSortCmd = &cobra.Command{
+	Use:   "sort [files...]",
+	Short: "Sort wordlists into one list of distinct entries, spilling to temporary files when large",
+	Run: func(cmd *cobra.Command, args []string) {
+		handleWordlistCommand(cmd, "sort", args)
+	},
+}
+
+var wordlistFilterCmd = &cobra.Command{
+	Use:   "filter [files...]",
+	Short: "Keep the entries of wordlists matching length and charset limits, duplicates included",
+	Run: func(cmd *cobra.Command, args []string) {
+		handleWordlistCommand(cmd, "filter", args)
+	},
+}
+
+var wordlistStatsCmd = &cobra.Command{
+	Use:   "stats [files...]",
+	Short: "Count the entries, duplicates, invalid entries, lengths and character classes of wordlists",
+	Run: func(cmd *cobra.Command, args []string) {
+		handleWordlistStatsCommand(args)
+	},
+}
+
+var resolversCmd = &cobra.Command{
+	Use:   "resolvers",
+	Short: "Manage DNS resolver lists",
+}
+
+var resolversBenchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure latency, reliability and honesty of resolvers and write a ranked, cleaned list",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(resolvers) == 0 {
+			usageError(cmd, "Please specify resolvers (-r)")
+			return
+		}
+		handleResolversBenchCommand()
 	},
 }
 
@@ -55,12 +406,63 @@ var activeCmd = &cobra.Command{
 			return
 		}
 
-		if domain == "" && listPath == "" {
-			cmd.Println("[ERR] Please specify a domain (-d) or a domain list (-l)")
-			return
+		// Imported results may cover any domain, -d/-l/--project only restrict them
+		if importPath == "" || domain != "" || listPath != "" || projectPath != "" {
+			if !checkTargetFlags(cmd) {
+				return
+			}
+		}
+
+		handleActiveCommand(cmd)
+	},
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run scans requested over a gRPC API and stream their results",
+	Run: func(cmd *cobra.Command, args []string) {
+		handleServeCommand(cmd)
+	},
+}
+
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Run scan jobs taken from a Redis queue and push their results back",
+	Run: func(cmd *cobra.Command, args []string) {
+		handleWorkerCommand(cmd)
+	},
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history [domain]",
+	Short: "List past scans stored in the database",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		target := ""
+		if len(args) > 0 {
+			target = utils.CleanDomain(args[0])
 		}
+		handleHistoryCommand(target)
+	},
+}
+
+var showCmd = &cobra.Command{
+	Use:   "show <scan-id>",
+	Short: "Print the results of a stored scan and save them in any output format",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		handleShowCommand(args[0])
+	},
+}
 
-		handleActiveCommand()
+var annotateCmd = &cobra.Command{
+	Use:   "annotate [subdomain...]",
+	Short: "Tag subdomains and attach notes in the database, or list the annotations",
+	Long: `Tag subdomains (example: triaged, out-of-scope, interesting) and attach a note to them.
+Annotations are kept in the database and added to the results printed by show.
+Without flags, the annotations of the given subdomains, or of all of them, are listed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		handleAnnotateCommand(cmd, args)
 	},
 }
 
@@ -70,9 +472,62 @@ func Execute() error {
 }
 
 // init initializes CLI commands and flags
+var sourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Manage passive sources and their API keys",
+}
+
+var sourcesStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List passive sources and check the configured API keys",
+	Run: func(cmd *cobra.Command, args []string) {
+		handleSourcesStatusCommand()
+	},
+}
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Manage project files",
+}
+
+var projectImportCmd = &cobra.Command{
+	Use:   "import <scope-file>...",
+	Short: "Add the scope of bug bounty programs to a project file",
+	Long: `Reads scope exports of HackerOne, Bugcrowd or Intigriti programs, or a plain
+list of wildcards, and adds the in-scope domains and out-of-scope exclusions to
+the project file given with --project, creating it when it does not exist.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if projectPath == "" {
+			usageError(cmd, "Please specify the project file to write (--project)")
+			return
+		}
+		handleProjectImportCommand(args)
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(activeCmd)
 	rootCmd.AddCommand(passiveCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(takeoverCmd)
+	rootCmd.AddCommand(monitorCmd)
+	rootCmd.AddCommand(mergeCmd)
+	rootCmd.AddCommand(updateRangesCmd)
+	rootCmd.AddCommand(wordgenCmd)
+	rootCmd.AddCommand(wordlistCmd)
+	wordlistCmd.AddCommand(wordlistMergeCmd, wordlistDedupeCmd, wordlistSortCmd, wordlistFilterCmd, wordlistStatsCmd)
+	rootCmd.AddCommand(resolversCmd)
+	resolversCmd.AddCommand(resolversBenchCmd)
+	rootCmd.AddCommand(sourcesCmd)
+	sourcesCmd.AddCommand(sourcesStatusCmd)
+	rootCmd.AddCommand(projectCmd)
+	projectCmd.AddCommand(projectImportCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(workerCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(annotateCmd)
 
 	rootCmd.SetHelpCommand(&cobra.Command{
 		Use:    "no-help",
@@ -84,80 +539,1441 @@ func init() {
 	setupFlags()
 }
 
-// handlePassiveCommand handles execution of the passive command
-func handlePassiveCommand() {
-	var domains []string
-	var err error
+// secretFlags are recorded in scan metadata without their value
+var secretFlags = map[string]bool{"es-api-key": true, "token": true, "node-token": true}
 
-	if listPath != "" {
-		domains, err = utils.LoadDomains(listPath)
-		if err != nil {
-			utils.PrintError("Failed to load domain list!")
+// scanMetadata collects the tool version, effective flag values and config
+// fingerprint so that outputs record which options produced them
+func scanMetadata(cmd *cobra.Command, mode string) models.ScanMetadata {
+	flags := make(map[string]string)
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Name == "help" || flag.Name == "version" {
 			return
 		}
-	} else {
-		domains = []string{domain}
+		flags[flag.Name] = flagValue(flag)
+	})
+	fingerprint, wordlistSum := configFingerprint(cmd, mode)
+
+	return models.ScanMetadata{
+		Tool:    models.ToolInfo{Name: "subcollector", Version: version},
+		Mode:    mode,
+		Project: projectName,
+		Config: models.ScanConfigInfo{
+			Fingerprint:    fingerprint,
+			Wordlist:       wordlistPath,
+			WordlistSHA256: wordlistSum,
+			Resolvers:      resolvers,
+			Flags:          flags,
+		},
 	}
+}
 
-	// Configuration for passive scanning
-	config := scanner.PassiveScanConfig{
-		ShowIP:         showIP,
-		StreamResults:  streamResults,
-		OutputFile:     output,
-		JsonOutputFile: jsonOutput,
+// flagValue returns the value of a flag as it may be recorded or printed, without secrets
+func flagValue(flag *pflag.Flag) string {
+	value := flag.Value.String()
+	if secretFlags[flag.Name] && value != "" {
+		return "REDACTED"
+	}
+	// Header values are often tokens (Authorization: Bearer ...), only their names are kept
+	if specs, ok := flag.Value.(pflag.SliceValue); ok && flag.Name == "header" {
+		names := make([]string, len(specs.GetSlice()))
+		for i, spec := range specs.GetSlice() {
+			name, _, _ := strings.Cut(spec, ":")
+			names[i] = strings.TrimSpace(name) + ": REDACTED"
+		}
+		return "[" + strings.Join(names, ",") + "]"
+	}
+	// Passwords of URLs (--db, --es-url, --redis, ...) are hidden as well
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		value = u.Redacted()
 	}
+	return value
+}
 
-	// Run passive scanning for each domain
-	for _, d := range domains {
-		cleanedDomain := utils.CleanDomain(d)
-		if cleanedDomain == "" {
-			continue
+// printEffectiveConfig prints the targets and the value of every flag with where it came from
+func printEffectiveConfig(cmd *cobra.Command, domains []string) {
+	const maxShown = 10
+
+	fmt.Printf("\n» Targets: %d domains\n", len(domains))
+	for i, d := range domains {
+		if i == maxShown {
+			fmt.Printf("  ... and %d more\n", len(domains)-maxShown)
+			break
+		}
+		fmt.Printf("  %s\n", d)
+	}
+
+	width := 0
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		width = max(width, len(flag.Name))
+	})
+	fmt.Printf("\n» Effective configuration\n")
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Name == "help" || flag.Name == "version" || flag.Name == "dry-run" {
+			return
 		}
+		value := flagValue(flag)
+		if value == "" {
+			value = "-"
+		}
+		if name, ok := envFlags[flag.Name]; ok {
+			value += " (from $" + name + ")"
+		} else if !flag.Changed {
+			value += " (default)"
+		}
+		fmt.Printf("  --%-*s  %s\n", width, flag.Name, value)
+	})
+}
 
-		config.Domain = cleanedDomain
-		scanner.ExecutePassiveScan(config)
+// checkTargetFlags reports whether exactly one of -d, -l and --project gives the targets, printing the usage otherwise
+func checkTargetFlags(cmd *cobra.Command) bool {
+	if projectPath != "" && (domain != "" || listPath != "") {
+		usageError(cmd, "--project cannot be combined with -d or -l")
+		return false
 	}
+	if domain == "" && listPath == "" && projectPath == "" {
+		usageError(cmd, "Please specify a domain (-d), a domain list (-l) or a project (--project)")
+		return false
+	}
+	return true
 }
 
-// handleActiveCommand handles execution of the active command
-func handleActiveCommand() {
+// loadTargetDomains returns the cleaned target domains from -d, -l or --project
+func loadTargetDomains() ([]string, error) {
 	var domains []string
-	var err error
 
+	if projectPath != "" {
+		return loadProject()
+	}
 	if listPath != "" {
-		domains, err = utils.LoadDomains(listPath)
+		loaded, err := utils.LoadDomains(listPath)
 		if err != nil {
-			utils.PrintError("Failed to load domain list!")
-			return
+			return nil, err
 		}
+		domains = loaded
 	} else {
 		domains = []string{domain}
 	}
 
-	// Configuration for active scanning
-	config := scanner.ActiveScanConfig{
-		WordlistPath:   wordlistPath,
-		Resolvers:      resolvers,
-		RateLimit:      rateLimit,
-		Recursive:      recursive,
-		ShowIP:         showIP,
-		Depth:          depth,
-		Takeover:       takeover,
-		Proxy:          proxy,
-		NumWorkers:     numWorkers,
-		StreamResults:  streamResults,
-		OutputFile:     output,
-		JsonOutputFile: jsonOutput,
+	var cleanedDomains []string
+	for _, d := range domains {
+		if cleanedDomain := utils.CleanDomain(d); cleanedDomain != "" {
+			cleanedDomains = append(cleanedDomains, cleanedDomain)
+		}
 	}
+	return cleanedDomains, nil
+}
 
-	// Run active scanning for each domain
-	for _, d := range domains {
-		cleanedDomain := utils.CleanDomain(d)
-		if cleanedDomain == "" {
-			continue
+// loadProject returns the domains of the --project file
+// Its exclusions and tags are added to those given as flags, its name labels the reports
+func loadProject() ([]string, error) {
+	p, err := project.Load(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	excludePatterns = append(excludePatterns, p.Exclude...)
+	resultTags = append(resultTags, p.Tags...)
+	projectName = p.Name
+	fmt.Printf("» Project %s: %s\n", p.Name, strings.Join(p.Domains, ", "))
+	return p.Domains, nil
+}
+
+// buildPassiveConfig creates the passive scan configuration from flags
+func buildPassiveConfig(cmd *cobra.Command) (scanner.PassiveScanConfig, error) {
+	if err := applyRequestHeaders(); err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+	if err := utils.SetDNSLimits(dnsTimeout, dnsRetries); err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+	if err := utils.SetEDNSBufferSize(ednsBufferSize); err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+	if err := applySplitDNS(); err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+	if err := models.SetSeverityOverrides(severitySpecs); err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+	if err := utils.SetIDNDisplay(idnForm); err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+
+	exclude, err := utils.NewExcludeList(excludePatterns)
+	if err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+
+	filter, err := utils.NewResultFilter(matchPatterns, filterPatterns)
+	if err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+
+	if err := sources.LoadKeys(providersPath); err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+
+	names := sourceNames
+	if allSources {
+		if len(sourceNames) > 0 {
+			return scanner.PassiveScanConfig{}, fmt.Errorf("--all-sources cannot be combined with --sources")
+		}
+		names = []string{"all"}
+	}
+	selected, err := sources.Select(names, excludeSources)
+	if err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+
+	sourceOptions, err := sources.ParseTimeouts(sourceTimeouts)
+	if err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+	if err := sources.SetRequestTimeout(sourceRequestTimeout); err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+	if err := sources.SetRateLimits(sourceRateLimits); err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+	if sourceRetries < 0 {
+		return scanner.PassiveScanConfig{}, fmt.Errorf("invalid source retries %d, must not be negative", sourceRetries)
+	}
+	sourceOptions.Retries = sourceRetries
+	if sourceCacheDays > 0 {
+		if sourceOptions.Cache, err = sources.NewCache(sourceCacheDir, sourceCacheDays); err != nil {
+			return scanner.PassiveScanConfig{}, err
+		}
+	}
+
+	order, err := models.ParseSortOrder(sortOrder)
+	if err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+	format, err := parseLineFormat()
+	if err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+	sinks, err := newSinks()
+	if err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+	uploadTarget, err := upload.Parse(uploadLocation)
+	if err != nil {
+		return scanner.PassiveScanConfig{}, err
+	}
+
+	return scanner.PassiveScanConfig{
+		ShowIP:          showIP,
+		StreamResults:   streamResults,
+		OutputFile:      output,
+		JsonOutputFile:  jsonOutput,
+		HTMLOutputFile:  htmlOutput,
+		CSVOutputFile:   csvOutput,
+		XMLOutputFile:   xmlOutput,
+		SARIFOutputFile: sarifOutput,
+		Sink:            sinks,
+		Database:        databasePath,
+		Upload:          uploadTarget,
+		Sort:            order,
+		Format:          format,
+		Exclude:         exclude,
+		Filter:          filter,
+		Tags:            resultTags,
+		Metadata:        scanMetadata(cmd, "passive"),
+		Workspace:       workspaceDir,
+		Sources:         selected,
+		SourceOptions:   sourceOptions,
+		Verify:          verifyResults,
+		DropUnresolved:  dropUnresolved,
+		Resolvers:       resolvers,
+		NumWorkers:      numWorkers,
+		RateLimit:       rateLimit,
+		SourceReport:    sourceReport,
+	}, nil
+}
+
+// parseLineFormat compiles the --format template of text output
+// JSON output has a fixed layout, so the template needs a text output file (-o)
+func parseLineFormat() (*models.LineFormat, error) {
+	if lineFormat == "" {
+		return nil, nil
+	}
+	if output == "" || jsonOutput != "" {
+		return nil, fmt.Errorf("--format applies to text output, use it with -o and without -j")
+	}
+	return models.ParseLineFormat(lineFormat)
+}
+
+// newSinks creates the --es-url, --bus-url and --syslog sinks, nil when none was given
+func newSinks() (sink.Sinks, error) {
+	var sinks sink.Sinks
+	if esURL != "" {
+		elastic, err := sink.NewElastic(esURL, esIndex, esAPIKey)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, elastic)
+	}
+	if busURL != "" {
+		bus, err := sink.NewBus(busURL)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, bus)
+	}
+	if syslogTarget != "" {
+		syslog, err := sink.NewSyslog(syslogTarget, syslogFacility)
+		if err != nil {
+			return nil, err
 		}
+		sinks = append(sinks, syslog)
+	}
+	if outputStream != "" {
+		stream, err := sink.NewStream(outputStream, streamOutput())
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, stream)
+	}
+	return sinks, nil
+}
+
+// streamStdout is the standard output kept for --output-stream
+var streamStdout *os.File
+
+// streamOutput returns the standard output for streamed results and, the first
+// time, moves everything else printed afterwards to stderr
+func streamOutput() io.Writer {
+	if streamStdout == nil {
+		streamStdout = os.Stdout
+		os.Stdout = os.Stderr
+		color.Output = color.Error
+	}
+	return streamStdout
+}
+
+// applyRequestHeaders sets the --header and --user-agent values on all outbound HTTP requests
+func applyRequestHeaders() error {
+	header, err := utils.ParseHeaders(requestHeaderSpecs)
+	if err != nil {
+		return err
+	}
+	if userAgent != "" {
+		header.Set("User-Agent", userAgent)
+	}
+	utils.SetRequestHeaders(header)
+	return nil
+}
+
+// splitDNSShown is set once the split DNS routes are printed, monitors build two configurations
+var splitDNSShown bool
+
+// applySplitDNS routes the zones given with --split-zone and the search domains of
+// resolv.conf (--system-resolvers) to their resolver groups
+func applySplitDNS() error {
+	split, err := utils.NewSplitDNS(resolverGroups, splitZones, systemResolvers)
+	if err != nil {
+		return err
+	}
+	utils.SetSplitDNS(split)
+	if split == nil || splitDNSShown {
+		return nil
+	}
+	splitDNSShown = true
+	if len(split.Zones) == 0 {
+		fmt.Println("» Split DNS: no zone routed, name zones with --split-zone")
+	}
+	for _, route := range split.Describe() {
+		fmt.Printf("» Split DNS: %s\n", route)
+	}
+	return nil
+}
 
-		config.Domain = cleanedDomain
-		scanner.ExecuteActiveScan(config)
+// buildActiveConfig creates the active scan configuration from flags
+func buildActiveConfig(cmd *cobra.Command) (scanner.ActiveScanConfig, error) {
+	if err := applyRequestHeaders(); err != nil {
+		return scanner.ActiveScanConfig{}, err
 	}
+	if err := utils.SetDNSLimits(dnsTimeout, dnsRetries); err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+	if err := utils.SetEDNSBufferSize(ednsBufferSize); err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+	if err := applySplitDNS(); err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+	if err := models.SetSeverityOverrides(severitySpecs); err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+	if err := utils.SetIDNDisplay(idnForm); err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+
+	exclude, err := utils.NewExcludeList(excludePatterns)
+	if err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+
+	filter, err := utils.NewResultFilter(matchPatterns, filterPatterns)
+	if err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+
+	ports, err := probe.ParsePorts(portSpec)
+	if err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+
+	order, err := models.ParseSortOrder(sortOrder)
+	if err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+	format, err := parseLineFormat()
+	if err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+	sinks, err := newSinks()
+	if err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+	uploadTarget, err := upload.Parse(uploadLocation)
+	if err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+	backoff := scanner.BackoffConfig{
+		Enabled:       backoffEnabled,
+		MaxDelay:      backoffMaxDelay,
+		Factor:        backoffFactor,
+		Jitter:        backoffJitter,
+		FailThreshold: backoffThreshold,
+		By:            backoffBy,
+	}
+	if err := backoff.Validate(); err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+	if err := scanner.ValidateLimitBy(limitBy); err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+	if maxPerAuthority < 0 {
+		return scanner.ActiveScanConfig{}, fmt.Errorf("invalid --max-per-authority %d, must not be negative", maxPerAuthority)
+	}
+	if takeoverWorkers < 0 {
+		return scanner.ActiveScanConfig{}, fmt.Errorf("invalid takeover worker count %d, must not be negative", takeoverWorkers)
+	}
+	takeoverHTTP := scanner.TakeoverHTTPConfig{
+		Timeout:      takeoverTimeout,
+		Redirects:    takeoverRedirects,
+		Insecure:     takeoverInsecure,
+		DisableHTTP2: !takeoverHTTP2,
+	}
+	if err := scanner.ValidateRedirectPolicy(takeoverHTTP.Redirects); err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+	if takeoverMaxBody != "" {
+		if takeoverHTTP.MaxBody, err = utils.ParseByteSize(takeoverMaxBody); err != nil {
+			return scanner.ActiveScanConfig{}, err
+		}
+	}
+	if takeoverPrints != "" {
+		if _, err := scanner.LoadFingerprints(takeoverPrints); err != nil {
+			return scanner.ActiveScanConfig{}, err
+		}
+	}
+	// A cache file alone selects the disk cache
+	cache := models.CacheConfig{
+		Type:        cacheType,
+		Size:        cacheSize,
+		TTL:         cacheTTL,
+		NegativeTTL: cacheNegativeTTL,
+		Path:        cacheFile,
+		Import:      cacheImport,
+		Export:      cacheExport,
+	}
+	if cache.Type == "" && cache.Path != "" {
+		cache.Type = models.CacheDisk
+	}
+	if err := cache.Validate(); err != nil {
+		return scanner.ActiveScanConfig{}, fmt.Errorf("invalid cache flags: %v", err)
+	}
+	rules, err := scanner.ParseDepthRules(depthRules)
+	if err != nil {
+		return scanner.ActiveScanConfig{}, err
+	}
+	var memoryCap int64
+	if maxMemory != "" {
+		if memoryCap, err = utils.ParseByteSize(maxMemory); err != nil {
+			return scanner.ActiveScanConfig{}, err
+		}
+	}
+
+	return scanner.ActiveScanConfig{
+		WordlistPath:    wordlistPath,
+		Resolvers:       resolvers,
+		RateLimit:       rateLimit,
+		Backoff:         backoff,
+		LimitBy:         limitBy,
+		MaxPerAuthority: maxPerAuthority,
+		MaxMemory:       memoryCap,
+		Cache:           cache,
+		Recursive:       recursive,
+		ShowIP:          showIP,
+		Depth:           depth,
+		DepthRules:      rules,
+		Takeover:        takeover,
+		TakeoverWorkers: takeoverWorkers,
+		TakeoverHTTP:    takeoverHTTP,
+		Proxy:           proxy,
+		NumWorkers:      numWorkers,
+		StreamResults:   streamResults,
+		OutputFile:      output,
+		JsonOutputFile:  jsonOutput,
+		HTMLOutputFile:  htmlOutput,
+		CSVOutputFile:   csvOutput,
+		XMLOutputFile:   xmlOutput,
+		SARIFOutputFile: sarifOutput,
+		Sink:            sinks,
+		Database:        databasePath,
+		Upload:          uploadTarget,
+		Sort:            order,
+		Format:          format,
+		RecheckFile:     recheckOutput,
+		Exclude:         exclude,
+		Filter:          filter,
+		Tags:            resultTags,
+		Metadata:        scanMetadata(cmd, "active"),
+		DNSSEC:          dnssec,
+		ServiceRecords:  serviceRecords,
+		TLS:             grabTLS,
+		HTTP:            probeHTTP || detectTech || secondOrder,
+		Tech:            detectTech,
+		SecondOrder:     secondOrder,
+		Dangling:        findDangling,
+		Email:           checkEmail,
+		RDAP:            lookupRDAP,
+		Ports:           ports,
+		Group:           groupHosts,
+		ImportFile:      importPath,
+		ExportDir:       exportDir,
+		Workspace:       workspaceDir,
+		EvidenceDir:     evidenceDir,
+		TimeoutTotal:    timeoutTotal,
+		MaxQueries:      maxQueries,
+		CanaryInterval:  canaryInterval,
+		Verify:          verifyResults,
+		VerifyResolvers: verifyResolvers,
+		Quorum:          quorum,
+	}, nil
+}
+
+// handlePassiveCommand handles execution of the passive command
+func handlePassiveCommand(cmd *cobra.Command) {
+	domains, err := loadTargetDomains()
+	if err != nil {
+		printError(fmt.Sprintf("Failed to load domain list: %v", err))
+		return
+	}
+
+	// Configuration for passive scanning
+	config, err := buildPassiveConfig(cmd)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+
+	startRateReport(domains, config.Metadata.Config.Fingerprint)
+
+	// The domains of a project are reported together
+	if projectName != "" {
+		recordScan(scanner.ExecutePassiveProject(config, domains))
+		return
+	}
+
+	// Run passive scanning for each domain
+	for _, d := range domains {
+		config.Domain = d
+		recordScan(scanner.ExecutePassiveScan(config))
+	}
+}
+
+// handleActiveCommand handles execution of the active command
+func handleActiveCommand(cmd *cobra.Command) {
+	domains, err := loadTargetDomains()
+	if err != nil {
+		printError(fmt.Sprintf("Failed to load domain list: %v", err))
+		return
+	}
+
+	// Configuration for active scanning
+	config, err := buildActiveConfig(cmd)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+
+	// Show what would run, without sending anything
+	if dryRun {
+		printEffectiveConfig(cmd, domains)
+		fmt.Printf("\n» Config fingerprint: %s\n", config.Metadata.Config.Fingerprint)
+		switch {
+		case importPath != "":
+			fmt.Printf("\n» Results would be imported from %s, nothing is brute forced\n", importPath)
+			return
+		case exportDir != "":
+			fmt.Printf("\n» Candidates would be exported to %s, nothing is resolved\n", exportDir)
+			return
+		}
+		concurrent := 1
+		if len(nodeAddresses) > 0 {
+			concurrent = len(nodeAddresses) * max(nodeScans, 1)
+		} else if parallelDomains > 1 {
+			concurrent = min(parallelDomains, len(domains))
+		}
+		if err := scanner.DryRunActiveScan(config, domains, concurrent); err != nil {
+			reportScanError(err)
+		}
+		return
+	}
+
+	startRateReport(domains, config.Metadata.Config.Fingerprint)
+
+	// Work on massdns/zdns results instead of brute forcing
+	if importPath != "" {
+		recordScan(scanner.ExecuteImport(config, domains))
+		return
+	}
+	if exportDir != "" {
+		if err := scanner.ExportMassdns(config, domains); err != nil {
+			reportScanError(err)
+		}
+		return
+	}
+
+	// Hand the scan to remote nodes when some are given
+	if len(nodeAddresses) > 0 {
+		token := nodeToken
+		if token == "" {
+			token = os.Getenv("SUBCOLLECTOR_API_TOKEN")
+		}
+		nodes, err := cluster.Dial(nodeAddresses, cluster.Options{Token: token, TLS: nodeTLS, CAFile: nodeCAFile, Scans: nodeScans})
+		if err != nil {
+			printError(err.Error())
+			return
+		}
+		defer nodes.Close()
+		recordScan(scanner.ExecuteDistributedScan(config, domains, nodes, shardSize))
+		return
+	}
+
+	// Scan several domains at once when requested, the domains of a project are always reported together
+	if (parallelDomains > 1 || projectName != "") && len(domains) > 1 {
+		recordScan(scanner.ExecuteParallelActiveScan(config, domains, max(parallelDomains, 1)))
+		return
+	}
+
+	// Run active scanning for each domain
+	for _, d := range domains {
+		config.Domain = d
+		recordScan(scanner.ExecuteActiveScan(config))
+	}
+}
+
+// handleVerifyCommand handles execution of the verify command
+// -d and --project only restrict which names of the list are checked
+func handleVerifyCommand(cmd *cobra.Command) {
+	var domains []string
+	if domain != "" || projectPath != "" {
+		var err error
+		if domains, err = loadTargetDomains(); err != nil {
+			printError(fmt.Sprintf("Failed to load domain list: %v", err))
+			return
+		}
+	}
+
+	config, err := buildActiveConfig(cmd)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	config.Metadata = scanMetadata(cmd, "verify")
+	config.KeepUnresolved = keepUnresolved
+
+	recordScan(scanner.ExecuteVerify(config, verifyListPath, domains))
+}
+
+// handleTakeoverCommand handles execution of the takeover command
+// -d and --project only restrict which hosts of the input are checked
+func handleTakeoverCommand(cmd *cobra.Command) {
+	var domains []string
+	if domain != "" || projectPath != "" {
+		var err error
+		if domains, err = loadTargetDomains(); err != nil {
+			printError(fmt.Sprintf("Failed to load domain list: %v", err))
+			return
+		}
+	}
+
+	config, err := buildActiveConfig(cmd)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	config.Metadata = scanMetadata(cmd, "takeover")
+	config.RateLimit = takeoverRateLimit
+	config.Takeover = true
+
+	recordScan(scanner.ExecuteTakeoverCheck(config, takeoverInputPath, domains))
+}
+
+// handleMonitorCommand handles execution of the monitor command
+func handleMonitorCommand(cmd *cobra.Command) {
+	domains, err := loadTargetDomains()
+	if err != nil {
+		printError(fmt.Sprintf("Failed to load domain list: %v", err))
+		return
+	}
+
+	if monitorMode != monitor.ModePassive && monitorMode != monitor.ModeActive && monitorMode != monitor.ModeBoth {
+		printError("Invalid mode, use passive, active or both")
+		return
+	}
+
+	activeConfig, err := buildActiveConfig(cmd)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	passiveConfig, err := buildPassiveConfig(cmd)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	// Record changes are found by comparing the addresses and CNAME chains stored by each run
+	if trackRecords {
+		activeConfig.ShowIP, activeConfig.Group = true, true
+		passiveConfig.ShowIP = true
+	}
+
+	// Alert rules only select what is sent to webhooks, the console lists every change
+	ruleEntries, err := utils.ExpandPatterns(alertRules)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	var rules []*notify.Rule
+	for _, entry := range ruleEntries {
+		rule, err := notify.ParseRule(entry)
+		if err != nil {
+			printError(err.Error())
+			return
+		}
+		rules = append(rules, rule)
+	}
+
+	// Notifications always go to the console, webhooks are optional
+	notifiers := []notify.Notifier{notify.ConsoleNotifier{}}
+	for _, url := range webhooks {
+		var notifier notify.Notifier = notify.NewWebhookNotifier(url)
+		if len(rules) > 0 {
+			notifier = notify.RuleNotifier{Notifier: notifier, Rules: rules}
+		}
+		notifiers = append(notifiers, notifier)
+	}
+
+	// Passive monitors brute force nothing, their wordlist is not worth holding
+	watchedWordlist := wordlistPath
+	if monitorMode == monitor.ModePassive {
+		watchedWordlist = ""
+	}
+	defer startReload(watchedWordlist).Close()
+
+	config := monitor.Config{
+		Domains:      domains,
+		Interval:     monitorInterval,
+		Mode:         monitorMode,
+		Once:         monitorOnce,
+		DatabasePath: historyDatabase(),
+		Active:       activeConfig,
+		Passive:      passiveConfig,
+		Metadata:     scanMetadata(cmd, "monitor"),
+		Notifiers:    notifiers,
+	}
+
+	if err := monitor.Run(config); err != nil {
+		printError(err.Error())
+	}
+}
+
+// startReload watches the takeover fingerprints and wordlist of a long-running command
+// when --reload is set, so running scans keep going while updated files are picked up
+func startReload(wordlist string) *utils.FileWatcher {
+	if !reloadFiles {
+		return nil
+	}
+	watcher, err := scanner.WatchReloads(takeoverPrints, wordlist)
+	if err != nil {
+		fmt.Printf("× Fingerprints and wordlist are not reloaded: %v\n", err)
+		return nil
+	}
+	return watcher
+}
+
+// handleServeCommand starts the gRPC API with the scan defaults given as flags
+func handleServeCommand(cmd *cobra.Command) {
+	activeConfig, err := buildActiveConfig(cmd)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	passiveConfig, err := buildPassiveConfig(cmd)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+
+	token := serveToken
+	if token == "" {
+		token = os.Getenv("SUBCOLLECTOR_API_TOKEN")
+	}
+	defer startReload(wordlistPath).Close()
+
+	err = server.Serve(server.Options{
+		Address:  serveAddress,
+		MaxScans: serveMaxScans,
+		Token:    token,
+		CertFile: serveCertFile,
+		KeyFile:  serveKeyFile,
+		Active:   activeConfig,
+		Passive:  passiveConfig,
+	})
+	if err != nil {
+		printError(err.Error())
+	}
+}
+
+// handleWorkerCommand runs jobs from Redis with the scan defaults given as flags
+func handleWorkerCommand(cmd *cobra.Command) {
+	activeConfig, err := buildActiveConfig(cmd)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	passiveConfig, err := buildPassiveConfig(cmd)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+
+	defer startReload(wordlistPath).Close()
+
+	err = worker.Run(worker.Options{
+		RedisURL: redisURL,
+		Queue:    jobQueue,
+		Results:  jobResults,
+		Stream:   jobStream,
+		Group:    jobGroup,
+		Name:     workerName,
+		Drain:    drainQueue,
+		Active:   activeConfig,
+		Passive:  passiveConfig,
+	})
+	if err != nil {
+		printError(err.Error())
+	}
+}
+
+// defaultDatabase is the database of monitor, history, show and annotate when --db is not given
+const defaultDatabase = "subcollector.db"
+
+// historyDatabase returns the database of --db, defaultDatabase when none is given
+// Scans only store their results when --db is given, the commands reading or keeping
+// history always need one
+func historyDatabase() string {
+	if databasePath == "" {
+		return defaultDatabase
+	}
+	return databasePath
+}
+
+// handleHistoryCommand lists the scans stored in the database, of one domain when given
+func handleHistoryCommand(target string) {
+	store, err := storage.Open(historyDatabase())
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	defer store.Close()
+
+	scans, err := store.Scans(target, historyLimit)
+	if err != nil {
+		printError(fmt.Sprintf("Failed to read scan history: %v", err))
+		return
+	}
+	if len(scans) == 0 {
+		fmt.Printf("\n» No scans stored in %s\n", storage.Redact(historyDatabase()))
+		return
+	}
+
+	fmt.Printf("\n  %-6s  %-19s  %-8s  %-8s  %10s  %9s  %-12s  %s\n", "ID", "STARTED", "MODE", "DURATION", "SUBDOMAINS", "TAKEOVERS", "CONFIG", "DOMAIN")
+	for _, scan := range scans {
+		config := models.ShortFingerprint(scan.Fingerprint)
+		if config == "" {
+			config = "-"
+		}
+		fmt.Printf("  %-6d  %-19s  %-8s  %-8s  %10d  %9d  %-12s  %s\n", scan.ID, scan.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			scan.Mode, scan.FinishedAt.Sub(scan.StartedAt).Round(time.Second), scan.Subdomains, scan.Takeovers, config, scan.Domain)
+	}
+	fmt.Printf("\n» Print the results of a scan with: subcollector show <id>\n")
+}
+
+// handleShowCommand prints a stored scan and saves it in the requested formats
+func handleShowCommand(id string) {
+	scanID, err := strconv.ParseInt(strings.TrimPrefix(id, "#"), 10, 64)
+	if err != nil || scanID <= 0 {
+		printError(fmt.Sprintf("Invalid scan ID %q, see subcollector history", id))
+		return
+	}
+	filter, err := utils.NewResultFilter(matchPatterns, filterPatterns)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	if err := models.SetSeverityOverrides(severitySpecs); err != nil {
+		printError(err.Error())
+		return
+	}
+	if err := utils.SetIDNDisplay(idnForm); err != nil {
+		printError(err.Error())
+		return
+	}
+	order, err := models.ParseSortOrder(showSortOrder)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	format, err := parseLineFormat()
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+
+	_, err = scanner.ExecuteShow(scanner.ShowConfig{
+		Database:        historyDatabase(),
+		ScanID:          scanID,
+		ShowIP:          showIP,
+		OutputFile:      output,
+		JsonOutputFile:  jsonOutput,
+		HTMLOutputFile:  htmlOutput,
+		CSVOutputFile:   csvOutput,
+		XMLOutputFile:   xmlOutput,
+		SARIFOutputFile: sarifOutput,
+		Sort:            order,
+		Format:          format,
+		Filter:          filter,
+	})
+	if err != nil {
+		printError(err.Error())
+	}
+}
+
+// handleAnnotateCommand updates the tags and notes of subdomains, or lists them when no change is requested
+func handleAnnotateCommand(cmd *cobra.Command, subdomains []string) {
+	changed := cmd.Flags().Changed("tag") || cmd.Flags().Changed("untag") || cmd.Flags().Changed("note") || clearNote
+	if changed && len(subdomains) == 0 {
+		usageError(cmd, "Please specify the subdomains to annotate")
+		return
+	}
+	if clearNote && cmd.Flags().Changed("note") {
+		printError("--clear-note cannot be combined with --note")
+		return
+	}
+
+	store, err := storage.Open(historyDatabase())
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	defer store.Close()
+
+	annotations, err := store.Annotations()
+	if err != nil {
+		printError(fmt.Sprintf("Failed to read annotations: %v", err))
+		return
+	}
+
+	if changed {
+		for _, subdomain := range subdomains {
+			subdomain = strings.ToLower(strings.TrimSuffix(subdomain, "."))
+			annotation := annotations[subdomain]
+			annotation.Tags = models.RemoveTags(models.AddTags(annotation.Tags, resultTags...), removedTags...)
+			if cmd.Flags().Changed("note") {
+				annotation.Note = resultNote
+			}
+			if clearNote {
+				annotation.Note = ""
+			}
+			annotation.UpdatedAt = time.Now()
+			if err := store.Annotate(subdomain, annotation); err != nil {
+				printError(fmt.Sprintf("Failed to annotate %s: %v", subdomain, err))
+				return
+			}
+			annotations[subdomain] = annotation
+			if len(annotation.Tags) == 0 && annotation.Note == "" {
+				delete(annotations, subdomain)
+			}
+		}
+		fmt.Printf("\n» Annotated %d subdomains\n", len(subdomains))
+	}
+
+	// List the given subdomains, or every annotated one
+	names := subdomains
+	if len(names) == 0 {
+		for subdomain := range annotations {
+			names = append(names, subdomain)
+		}
+		sort.Strings(names)
+	}
+	fmt.Println()
+	listed := 0
+	for _, subdomain := range names {
+		subdomain = strings.ToLower(strings.TrimSuffix(subdomain, "."))
+		annotation, ok := annotations[subdomain]
+		if !ok {
+			continue
+		}
+		fmt.Printf("  %s  [%s]", subdomain, strings.Join(annotation.Tags, ","))
+		if annotation.Note != "" {
+			fmt.Printf(" %s", annotation.Note)
+		}
+		fmt.Printf("  (%s)\n", annotation.UpdatedAt.Local().Format("2006-01-02 15:04"))
+		listed++
+	}
+	if listed == 0 {
+		fmt.Printf("» No annotations stored in %s\n", storage.Redact(historyDatabase()))
+	}
+}
+
+// handleMergeCommand handles execution of the merge command
+func handleMergeCommand(cmd *cobra.Command, files []string) {
+	domains, err := loadTargetDomains()
+	if err != nil {
+		printError(fmt.Sprintf("Failed to load domain list: %v", err))
+		return
+	}
+
+	exclude, err := utils.NewExcludeList(excludePatterns)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	filter, err := utils.NewResultFilter(matchPatterns, filterPatterns)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	if err := utils.SetDNSLimits(dnsTimeout, dnsRetries); err != nil {
+		printError(err.Error())
+		return
+	}
+	if err := utils.SetEDNSBufferSize(ednsBufferSize); err != nil {
+		printError(err.Error())
+		return
+	}
+	if err := applySplitDNS(); err != nil {
+		printError(err.Error())
+		return
+	}
+	if err := models.SetSeverityOverrides(severitySpecs); err != nil {
+		printError(err.Error())
+		return
+	}
+	if err := utils.SetIDNDisplay(idnForm); err != nil {
+		printError(err.Error())
+		return
+	}
+	order, err := models.ParseSortOrder(sortOrder)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	format, err := parseLineFormat()
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	sinks, err := newSinks()
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	uploadTarget, err := upload.Parse(uploadLocation)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+
+	config := scanner.MergeConfig{
+		Files:           files,
+		Domains:         domains,
+		Resolve:         resolveMerged,
+		Resolvers:       resolvers,
+		NumWorkers:      numWorkers,
+		ShowIP:          showIP,
+		OutputFile:      output,
+		JsonOutputFile:  jsonOutput,
+		HTMLOutputFile:  htmlOutput,
+		CSVOutputFile:   csvOutput,
+		XMLOutputFile:   xmlOutput,
+		SARIFOutputFile: sarifOutput,
+		Sink:            sinks,
+		Database:        databasePath,
+		Upload:          uploadTarget,
+		Sort:            order,
+		Format:          format,
+		Exclude:         exclude,
+		Filter:          filter,
+		Tags:            resultTags,
+		Metadata:        scanMetadata(cmd, "merge"),
+	}
+
+	recordScan(scanner.ExecuteMerge(config))
+}
+
+// handleUpdateRangesCommand refreshes the cloud provider range data
+func handleUpdateRangesCommand() {
+	path := cloud.DefaultPath()
+	fmt.Printf("» Downloading cloud provider ranges\n")
+
+	report, err := cloud.Update(path)
+	for _, result := range report {
+		if result.Err != nil {
+			fmt.Printf("  × %-12s kept embedded ranges (%v)\n", result.Provider, result.Err)
+		} else {
+			fmt.Printf("  » %-12s %d ranges\n", result.Provider, result.Ranges)
+		}
+	}
+	if err != nil {
+		printError(fmt.Sprintf("Failed to save ranges: %v", err))
+		return
+	}
+	fmt.Printf("» Ranges saved to %s\n", path)
+}
+
+// handleWordgenCommand crawls the target and saves the words found, most frequent first
+func handleWordgenCommand() {
+	if err := applyRequestHeaders(); err != nil {
+		printError(err.Error())
+		return
+	}
+
+	target := utils.CleanDomain(domain)
+	fmt.Printf("\n» Crawling %s (up to %d documents)\n", target, maxPages)
+
+	pages := crawl.Crawl(context.Background(), target, crawl.Options{MaxPages: maxPages, Proxy: proxy})
+	scripts := 0
+	for _, page := range pages {
+		if page.Script {
+			scripts++
+		}
+	}
+	fmt.Printf("» Fetched %d documents (%d scripts)\n", len(pages), scripts)
+	if len(pages) == 0 {
+		printError(fmt.Sprintf("Nothing could be fetched from %s", target))
+		return
+	}
+
+	words := crawl.Words(pages, target)
+	if maxWords > 0 && len(words) > maxWords {
+		words = words[:maxWords]
+	}
+	fmt.Printf("» Extracted %d candidate words\n", len(words))
+	if len(words) == 0 {
+		return
+	}
+
+	path := output
+	if path == "" {
+		path = target + "-words.txt"
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(words, "\n")+"\n"), 0644); err != nil {
+		printError(fmt.Sprintf("Failed to save wordlist: %v", err))
+		return
+	}
+	fmt.Printf("» Wordlist saved to %s (use it with: subcollector active -d %s -w %s)\n", path, target, path)
+}
+
+// handleWordlistCommand streams wordlists through a merge, dedupe, sort or filter into the -o file
+// Entries are normalized like scan candidates unless --raw is given; "-" or no file reads stdin
+func handleWordlistCommand(cmd *cobra.Command, mode string, files []string) {
+	if output == "" {
+		usageError(cmd, "Please specify an output file (-o)")
+		return
+	}
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	opts := wordlist.Options{
+		Raw:       rawWords,
+		MinLength: minWordLength,
+		MaxLength: maxWordLength,
+		Charset:   wordCharset,
+		Dedupe:    mode != "filter",
+	}
+	if err := opts.Validate(); err != nil {
+		printError(err.Error())
+		return
+	}
+	for _, file := range files {
+		if file == output {
+			printError(fmt.Sprintf("%s is both an input and the output", file))
+			return
+		}
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		printError(fmt.Sprintf("Failed to create %s: %v", output, err))
+		return
+	}
+	defer file.Close()
+
+	var counts wordlist.Counts
+	if mode == "sort" {
+		counts, err = wordlist.Sort(files, file, opts, sortTempDir, wordlist.DefaultChunkSize)
+	} else {
+		counts, err = wordlist.Process(files, file, opts)
+	}
+	if err != nil {
+		printError(fmt.Sprintf("Failed to %s wordlists: %v", mode, err))
+		return
+	}
+
+	fmt.Printf("\n» Read %d entries, wrote %d to %s\n", counts.Read, counts.Written, output)
+	fmt.Printf("  %d duplicates, %d invalid, %d filtered out\n", counts.Duplicates, counts.Invalid, counts.Filtered)
+}
+
+// handleWordlistStatsCommand prints statistics of wordlists, read from stdin when no file is given
+func handleWordlistStatsCommand(files []string) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	stats, err := wordlist.Collect(files)
+	if err != nil {
+		printError(fmt.Sprintf("Failed to read wordlists: %v", err))
+		return
+	}
+
+	fmt.Printf("\n» %s\n\n", strings.Join(files, ", "))
+	fmt.Printf("  %-12s %d\n", "lines", stats.Lines)
+	fmt.Printf("  %-12s %d\n", "distinct", stats.Distinct)
+	fmt.Printf("  %-12s %d\n", "duplicates", stats.Duplicates)
+	fmt.Printf("  %-12s %d\n", "invalid", stats.Invalid)
+	fmt.Printf("  %-12s %d (case, scheme, path or punycode)\n", "normalized", stats.Changed)
+	if stats.Distinct == 0 {
+		return
+	}
+	fmt.Printf("  %-12s %d-%d, %.1f on average\n", "length", stats.MinLength, stats.MaxLength, stats.AvgLength)
+
+	fmt.Printf("\n  %-12s %10s\n", "LENGTH", "ENTRIES")
+	low := 1
+	for i, count := range stats.Lengths {
+		label := fmt.Sprintf("%d+", low)
+		if i < len(wordlist.LengthBuckets) {
+			label = fmt.Sprintf("%d-%d", low, wordlist.LengthBuckets[i])
+			low = wordlist.LengthBuckets[i] + 1
+		}
+		fmt.Printf("  %-12s %10d\n", label, count)
+	}
+
+	fmt.Printf("\n  %-12s %10s\n", "CHARACTERS", "ENTRIES")
+	for _, class := range []struct {
+		name  string
+		count int64
+	}{
+		{"letters", stats.Alpha}, {"digits", stats.Digit}, {"alnum", stats.Alnum},
+		{"hyphenated", stats.Hyphen}, {"dotted", stats.Dotted}, {"service", stats.Service},
+	} {
+		fmt.Printf("  %-12s %10d\n", class.name, class.count)
+	}
+}
+
+// handleResolversBenchCommand benchmarks resolvers and saves the healthy ones ranked best first
+func handleResolversBenchCommand() {
+	list := resolvers
+	source := ""
+	if len(resolvers) == 1 && utils.IsResolverFile(resolvers[0]) {
+		if loaded, err := utils.LoadResolvers(resolvers[0]); err == nil {
+			list = loaded
+			source = resolvers[0]
+		} else if net.ParseIP(resolvers[0]) == nil {
+			printError(fmt.Sprintf("Failed to load resolvers: %v", err))
+			return
+		}
+	}
+
+	fmt.Printf("\n» Benchmarking %d resolvers\n\n", len(list))
+	results := dnsresolvers.Bench(list, dnsresolvers.BenchOptions{
+		Rounds:         benchRounds,
+		Timeout:        benchTimeout,
+		Workers:        benchWorkers,
+		MinReliability: minReliability,
+	})
+
+	for i, result := range results {
+		status := "ok"
+		if len(result.Lies) > 0 {
+			status = strings.Join(result.Lies, ",")
+		} else if !result.Healthy(minReliability) {
+			status = "unreliable"
+		}
+		fmt.Printf("  %3d  %-24s %3d/%-3d %8s  %s\n",
+			i+1, result.Resolver, result.Answered, result.Sent, result.Latency.Round(time.Millisecond), status)
+	}
+
+	cleaned := dnsresolvers.CleanedList(results, minReliability)
+	fmt.Printf("\n» %d of %d resolvers kept\n", len(cleaned), len(results))
+	if len(cleaned) == 0 {
+		return
+	}
+
+	path := output
+	if path == "" {
+		path = dnsresolvers.CleanedPath(source)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(cleaned, "\n")+"\n"), 0644); err != nil {
+		printError(fmt.Sprintf("Failed to save resolvers: %v", err))
+		return
+	}
+	fmt.Printf("» Ranked resolvers saved to %s\n", path)
+}
+
+// handleProjectImportCommand adds the scope of bug bounty programs to the --project file
+// Assets naming no host are listed so they can be reviewed by hand
+func handleProjectImportCommand(paths []string) {
+	p := &project.Project{Name: scopeProjectName}
+	if _, err := os.Stat(projectPath); err == nil {
+		loaded, err := project.Load(projectPath)
+		if err != nil {
+			printError(err.Error())
+			return
+		}
+		p = loaded
+		if scopeProjectName != "" {
+			p.Name = scopeProjectName
+		}
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(projectPath), filepath.Ext(projectPath))
+	}
+	domains, excludes := len(p.Domains), len(p.Exclude)
+
+	fmt.Println()
+	var skipped []string
+	for _, path := range paths {
+		scope, err := project.ReadScope(path, scopePlatform)
+		if err != nil {
+			printError(err.Error())
+			return
+		}
+		fmt.Printf("» %s (%s): %d in scope, %d out of scope, %d other assets\n", path, scope.Platform, len(scope.In), len(scope.Out), len(scope.Ignored))
+		skipped = append(skipped, p.ImportScope(scope)...)
+	}
+
+	for _, asset := range skipped {
+		fmt.Printf("× Skipped %s, it names no host\n", asset)
+	}
+	if len(p.Domains) == 0 {
+		printError("No in-scope domain found, the project was not written")
+		return
+	}
+	if err := p.Save(projectPath); err != nil {
+		printError(err.Error())
+		return
+	}
+	fmt.Printf("\n» Project %s saved to %s: %d domains (%+d), %d exclusions (%+d)\n",
+		p.Name, projectPath, len(p.Domains), len(p.Domains)-domains, len(p.Exclude), len(p.Exclude)-excludes)
+}
+
+// handleSourcesStatusCommand lists passive sources with their configured keys
+// and checks every key against its provider
+func handleSourcesStatusCommand() {
+	if err := sources.LoadKeys(providersPath); err != nil {
+		printError(err.Error())
+		return
+	}
+	if err := sources.SetRateLimits(sourceRateLimits); err != nil {
+		printError(err.Error())
+		return
+	}
+
+	path := providersPath
+	if path == "" {
+		path = sources.DefaultKeysPath()
+	}
+	fmt.Printf("\n» Provider keys: %s\n\n", path)
+
+	names := sources.Names()
+	if len(sourceNames) > 0 {
+		selected, err := sources.Select(sourceNames, nil)
+		if err != nil {
+			printError(err.Error())
+			return
+		}
+		names = nil
+		for _, source := range selected {
+			names = append(names, source.Name())
+		}
+	}
+
+	// Sources are checked concurrently, the keys of one source one after another
+	statuses := make([][]sources.KeyStatus, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		source, _ := sources.Get(name)
+		if sources.KeysFor(name).Len() == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, source sources.Source) {
+			defer wg.Done()
+			statuses[i] = sources.CheckKeys(source, keyCheckTimeout)
+		}(i, source)
+	}
+	wg.Wait()
+
+	usable := 0
+	for i, name := range names {
+		source, _ := sources.Get(name)
+
+		traits := ""
+		if sources.IsDefault(source) {
+			traits = "default"
+		}
+		keys := "no key needed"
+		if sources.NeedsKey(source) {
+			keys = "no keys configured"
+		}
+		if count := sources.KeysFor(name).Len(); count == 1 {
+			keys = "1 key"
+		} else if count > 1 {
+			keys = fmt.Sprintf("%d keys", count)
+		}
+		if limit, ok := sources.RateLimitOf(name); ok {
+			keys += ", " + limit.String()
+		}
+		if sources.Usable(source) {
+			usable++
+		}
+		fmt.Printf("  %-18s %-8s %s\n", name, traits, keys)
+
+		for _, status := range statuses[i] {
+			line := fmt.Sprintf("      %-12s %s", sources.MaskKey(status.Key), status.State)
+			if status.Err != nil {
+				line += fmt.Sprintf(" (%v)", status.Err)
+			}
+			fmt.Println(line)
+		}
+	}
+
+	fmt.Printf("\n» %d of %d sources usable\n", usable, len(names))
 }
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime/trace"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/telemetry"
+	"github.com/fkr00t/subcollector/internal/utils"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// telemetryFlushTimeout bounds the export of the spans left when the process exits
+const telemetryFlushTimeout = 5 * time.Second
+
+// applyLogFile sets the log format asked for with --log-json and starts copying
+// log messages to --log-file, rotated as --log-max-size and --log-max-age ask
+// The file is closed by the exit hooks
+func applyLogFile(cmd *cobra.Command) error {
+	logJSON, _ := cmd.Flags().GetBool("log-json")
+	utils.SetLogJSON(logJSON)
+
+	logFile, _ := cmd.Flags().GetString("log-file")
+	if logFile == "" {
+		return nil
+	}
+	maxSizeFlag, _ := cmd.Flags().GetString("log-max-size")
+	maxAge, _ := cmd.Flags().GetDuration("log-max-age")
+	maxBackups, _ := cmd.Flags().GetInt("log-max-backups")
+
+	var maxSize int64
+	if maxSizeFlag != "" {
+		var err error
+		if maxSize, err = utils.ParseByteSize(maxSizeFlag); err != nil {
+			return fmt.Errorf("invalid --log-max-size: %v", err)
+		}
+	}
+	if err := utils.SetLogFile(logFile, maxSize, maxAge, maxBackups); err != nil {
+		return err
+	}
+	utils.OnExit(func() { utils.CloseLogger() })
+	return nil
+}
+
+// applyVerbosity sets the log level and the progress display asked for with
+// --quiet, --verbose or --debug; without them only warnings and errors are logged
+func applyVerbosity(cmd *cobra.Command) error {
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	debug, _ := cmd.Flags().GetBool("debug")
+
+	set := 0
+	for _, on := range []bool{quiet, verbose, debug} {
+		if on {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("--quiet, --verbose and --debug cannot be combined")
+	}
+
+	switch {
+	case quiet:
+		utils.SetLogLevel(utils.LevelError)
+		utils.SetQuietProgress(true)
+	case verbose:
+		utils.SetLogLevel(utils.LevelInfo)
+	case debug:
+		utils.SetLogLevel(utils.LevelDebug)
+	default:
+		utils.SetLogLevel(utils.LevelWarning)
+	}
+	utils.Info("Starting Subcollector %s", version)
+	return nil
+}
+
+// startDebug starts the pprof server and the execution trace asked for with --pprof and --trace
+// The trace is stopped by the exit hooks, so interrupted scans still leave a readable file
+func startDebug(cmd *cobra.Command) error {
+	pprofAddress, _ := cmd.Flags().GetString("pprof")
+	traceFile, _ := cmd.Flags().GetString("trace")
+
+	if pprofAddress != "" {
+		if err := startPprof(pprofAddress); err != nil {
+			return err
+		}
+	}
+	if traceFile != "" {
+		if err := startTrace(traceFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startPprof serves the net/http/pprof handlers on address in the background
+// Only the profiling handlers are served, on their own mux
+func startPprof(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen for pprof on %s: %v", address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	fmt.Printf("» pprof listening on http://%s/debug/pprof/\n", listener.Addr())
+	go http.Serve(listener, mux)
+	return nil
+}
+
+// startTrace writes the runtime execution trace to path until the process exits
+func startTrace(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create trace file: %v", err)
+	}
+	if err := trace.Start(file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to start trace: %v", err)
+	}
+
+	fmt.Printf("» Writing execution trace to %s\n", path)
+	utils.OnExit(func() {
+		trace.Stop()
+		file.Close()
+	})
+	return nil
+}
+
+// startTelemetry exports the spans of the run to the collector given with
+// --otlp-endpoint, under a root span named after the command
+// The exit hooks end the root span and flush the spans still buffered
+func startTelemetry(cmd *cobra.Command) error {
+	endpoint, _ := cmd.Flags().GetString("otlp-endpoint")
+	if endpoint == "" {
+		return nil
+	}
+	if err := telemetry.Start(endpoint, version); err != nil {
+		return err
+	}
+
+	utils.OnExit(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), telemetryFlushTimeout)
+		defer cancel()
+		if err := telemetry.Shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "× Failed to export spans: %v\n", err)
+		}
+	})
+	span := telemetry.StartRoot(cmd.CommandPath(), attribute.String("version", version))
+	utils.OnExit(func() { span.End() })
+	utils.Info("Exporting spans to %s", endpoint)
+	return nil
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/scanner"
+	"github.com/fkr00t/subcollector/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of the process, so that CI jobs and Kubernetes can act on the outcome of a run
+const (
+	ExitOK          = 0   // Every scan finished, nothing flagged
+	ExitError       = 1   // Invalid flags or configuration, or a scan could not run
+	ExitTakeover    = 2   // Takeover candidates were found
+	ExitDangling    = 3   // Dangling DNS records were found, but no takeover candidate
+	ExitWordlist    = 4   // The wordlist could not be found, read or downloaded
+	ExitResolvers   = 5   // A resolver file could not be loaded
+	ExitInterrupted = 130 // Stopped by SIGINT or SIGTERM
+)
+
+// exitSeverity orders exit codes: findings outrank errors, since one domain failing
+// does not make the takeover found on another less actionable, and errors with a
+// known cause outrank ExitError
+var exitSeverity = map[int]int{ExitOK: 0, ExitError: 1, ExitWordlist: 2, ExitResolvers: 2, ExitDangling: 3, ExitTakeover: 4}
+
+// scanErrors are the errors scans stop with that have a known fix, in the order they are matched
+var scanErrors = []struct {
+	err  error
+	code int
+	hint string
+}{
+	{scanner.ErrWordlistNotFound, ExitWordlist, "Check the path given to --wordlist, or leave it out to download the default list"},
+	{scanner.ErrWordlistRead, ExitWordlist, "Check the wordlist file is readable by the current user"},
+	{scanner.ErrWordlistFetch, ExitWordlist, "Check the network connection, or give a local list with --wordlist"},
+	{scanner.ErrResolverLoad, ExitResolvers, "Check the file given to --resolvers or --verify-resolvers, one resolver (IP, IP:port or URL) per line"},
+}
+
+var exitCode = ExitOK
+
+// ExitCode returns the exit code of the command run by Execute
+func ExitCode() int {
+	return exitCode
+}
+
+// setExitCode records an outcome, keeping the most severe one of the run
+func setExitCode(code int) {
+	if exitSeverity[code] > exitSeverity[exitCode] {
+		exitCode = code
+	}
+}
+
+// printError reports an error that stops the command
+func printError(message string) {
+	utils.PrintError(message)
+	setExitCode(ExitError)
+}
+
+// usageError reports missing arguments of a command
+func usageError(cmd *cobra.Command, message string) {
+	cmd.Println("[ERR] " + message)
+	setExitCode(ExitError)
+}
+
+// reportScanError prints an error a scan stopped with, with the fix when its cause is known
+// Other errors were already printed by the scan, only the exit code is recorded
+func reportScanError(err error) {
+	for _, known := range scanErrors {
+		if errors.Is(err, known.err) {
+			utils.PrintError(fmt.Sprintf("%v\n   %s", err, known.hint))
+			setExitCode(known.code)
+			return
+		}
+	}
+	setExitCode(ExitError)
+}
+
+// recordScan records the outcome of a scan from its results
+func recordScan(results []models.SubdomainResult, err error) {
+	if err != nil {
+		reportScanError(err)
+	}
+	counts := models.CountResults(results)
+	if counts.Takeovers > 0 {
+		setExitCode(ExitTakeover)
+	}
+	if counts.Dangling > 0 {
+		setExitCode(ExitDangling)
+	}
+}
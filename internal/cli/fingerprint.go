@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// fingerprintIgnored are flags left out of the config fingerprint: targets,
+// where results are written or sent, logging and diagnostics change nothing in
+// what a scan finds. The wordlist, resolvers and takeover fingerprints are hashed by content instead
+var fingerprintIgnored = map[string]bool{
+	"help": true, "version": true, "dry-run": true,
+	"domain": true, "list": true, "project": true,
+	"wordlist": true, "resolvers": true, "verify-resolvers": true,
+	"output": true, "json-output": true, "html-output": true, "csv-output": true, "xml-output": true,
+	"sarif-output": true, "output-stream": true, "format": true, "sort": true, "idn": true, "tag": true,
+	"recheck-output": true, "rate-report": true, "source-report": true, "evidence-dir": true, "workspace": true,
+	"export-massdns": true, "cache-export": true, "source-cache-dir": true, "upload": true, "db": true,
+	"es-url": true, "es-index": true, "es-api-key": true, "bus-url": true, "syslog": true, "syslog-facility": true,
+	"nodes": true, "node-token": true, "node-tls": true, "node-ca": true, "node-scans": true, "shard-size": true,
+	"takeover-fingerprints": true, "fingerprints": true, "reload": true,
+	"quiet": true, "verbose": true, "debug": true, "pprof": true, "trace": true, "otlp-endpoint": true,
+	"log-file": true, "log-max-size": true, "log-max-age": true, "log-max-backups": true, "log-json": true,
+}
+
+// configFingerprint hashes the settings that decide what a scan finds: the
+// tool version, the mode, the wordlist content, the resolver set and every
+// other flag. Outputs with the same fingerprint differ only through their target
+// Also returns the checksum of the wordlist, empty when the default list is used
+func configFingerprint(cmd *cobra.Command, mode string) (fingerprint, wordlistSum string) {
+	lines := []string{"tool=" + version, "mode=" + mode}
+
+	if cmd.Flags().Lookup("wordlist") != nil {
+		wordlist := "default"
+		if wordlistPath != "" {
+			sum, err := utils.FileSHA256(wordlistPath)
+			if err != nil {
+				// The scan fails on the same error, the path is all there is to record
+				sum = "unreadable:" + wordlistPath
+			}
+			wordlistSum, wordlist = sum, sum
+		}
+		lines = append(lines, "wordlist="+wordlist)
+	}
+	// Fingerprint files are hashed by content as well, under whichever flag name
+	for _, name := range []string{"takeover-fingerprints", "fingerprints"} {
+		if cmd.Flags().Lookup(name) != nil && takeoverPrints != "" {
+			sum, err := utils.FileSHA256(takeoverPrints)
+			if err != nil {
+				sum = "unreadable:" + takeoverPrints
+			}
+			lines = append(lines, "fingerprints="+sum)
+		}
+	}
+	if cmd.Flags().Lookup("resolvers") != nil {
+		lines = append(lines, "resolvers="+strings.Join(resolverSet(resolvers), ","))
+	}
+	if cmd.Flags().Lookup("verify-resolvers") != nil {
+		lines = append(lines, "verify-resolvers="+strings.Join(resolverSet(verifyResolvers), ","))
+	}
+
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if !fingerprintIgnored[flag.Name] && !secretFlags[flag.Name] {
+			lines = append(lines, "--"+flag.Name+"="+flagValue(flag))
+		}
+	})
+
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:]), wordlistSum
+}
+
+// resolverSet returns the resolvers given on the command line or in a resolver
+// file in a canonical form, sorted and without duplicates, so that the order
+// and spelling of the list do not change the fingerprint
+func resolverSet(entries []string) []string {
+	if len(entries) == 1 && utils.IsResolverFile(entries[0]) {
+		if loaded, err := utils.LoadResolvers(entries[0]); err == nil {
+			entries = loaded
+		}
+	}
+
+	seen := make(map[string]bool, len(entries))
+	set := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if resolver, err := utils.ParseResolver(entry); err == nil {
+			entry = resolver.String()
+		}
+		if !seen[entry] {
+			seen[entry] = true
+			set = append(set, entry)
+		}
+	}
+	sort.Strings(set)
+	return set
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// startRateReport records the rate of every DNS query sent to the domains when
+// --rate-report is set; the report is printed and saved when the process exits,
+// so interrupted scans leave one too. The report carries the config fingerprint
+// of the scan, so it can be matched with its results
+func startRateReport(domains []string, fingerprint string) {
+	if rateReport == "" {
+		return
+	}
+	recorder := utils.StartQueryRecorder(domains)
+	utils.OnExit(func() {
+		utils.StopQueryRecorder()
+		report := recorder.Report()
+		report.Fingerprint = fingerprint
+		printRateReport(report)
+		if err := report.Save(rateReport); err != nil {
+			fmt.Printf("× Failed to save the rate report: %v\n", err)
+			return
+		}
+		fmt.Printf("» Rate report saved to %s\n", rateReport)
+	})
+}
+
+// printRateReport prints the queries, peak and average rate of the scan, per
+// target and per resolver
+func printRateReport(report utils.QueryRateReport) {
+	fmt.Printf("\n» DNS query rates: %s\n", formatRates(report.Total))
+	for _, rates := range report.Targets {
+		fmt.Printf("  %-30s %s\n", rates.Name, formatRates(rates))
+	}
+	for _, rates := range report.Resolvers {
+		fmt.Printf("  via %-26s %s\n", rates.Name, formatRates(rates))
+	}
+}
+
+// formatRates describes the queries of a target or resolver on one line
+func formatRates(rates utils.QueryRates) string {
+	line := fmt.Sprintf("%d queries, average %.1f/s, peak %d/s", rates.Queries, rates.AverageQPS, rates.PeakQPS)
+	if rates.PeakQPS > 0 {
+		line += " at " + rates.PeakAt.Format("15:04:05")
+	}
+	return line
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// outputFileFlags name files written by commands, their directory must exist and be writable
+var outputFileFlags = []string{"output", "json-output", "html-output", "csv-output", "xml-output", "sarif-output", "recheck-output", "cache-export"}
+
+// outputDirFlags name directories created by commands when missing
+var outputDirFlags = []string{"workspace", "evidence-dir", "source-cache-dir", "temp-dir"}
+
+// validateFlags checks the flags of cmd before it starts, so a typo fails at once
+// rather than hours into a scan, or worse, turns a check off without a word
+// Only the flags cmd defines are checked, values from variables included
+func validateFlags(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+
+	if value, ok := stringFlag(flags, "proxy"); ok && value != "" {
+		if _, err := utils.ParseProxy(value); err != nil {
+			return fmt.Errorf("--proxy: %v", err)
+		}
+	}
+	for _, name := range []string{"resolvers", "verify-resolvers"} {
+		if entries, err := flags.GetStringSlice(name); err == nil {
+			if err := validateResolvers(entries); err != nil {
+				return fmt.Errorf("--%s: %v", name, err)
+			}
+		}
+	}
+
+	if value, err := flags.GetInt("workers"); err == nil && value <= 0 {
+		return fmt.Errorf("--workers: invalid worker count %d, must be at least 1", value)
+	}
+	if value, err := flags.GetInt("depth"); err == nil && value < -1 {
+		return fmt.Errorf("--depth: invalid recursion depth %d, must be -1 (unlimited) or more", value)
+	}
+	if value, err := flags.GetInt64("max-queries"); err == nil && value < 0 {
+		return fmt.Errorf("--max-queries: invalid query budget %d, must not be negative", value)
+	}
+	if value, err := flags.GetInt("rate-limit"); err == nil && value < 0 {
+		return fmt.Errorf("--rate-limit: invalid rate limit %d, must not be negative", value)
+	}
+	// Streamed results are written as they are found, they cannot be sorted afterwards
+	if order, ok := stringFlag(flags, "sort"); ok && strings.EqualFold(strings.TrimSpace(order), models.SortByName) {
+		if stream, err := flags.GetBool("stream"); err == nil && stream {
+			return fmt.Errorf("--sort: results streamed with --stream are written in discovery order, drop --stream to sort them by name")
+		}
+	}
+
+	for _, name := range outputFileFlags {
+		if path, ok := stringFlag(flags, name); ok && path != "" {
+			if err := checkWritable(filepath.Dir(path), false); err != nil {
+				return fmt.Errorf("--%s: cannot write %s: %v", name, path, err)
+			}
+		}
+	}
+	for _, name := range outputDirFlags {
+		if path, ok := stringFlag(flags, name); ok && path != "" {
+			if err := checkWritable(path, true); err != nil {
+				return fmt.Errorf("--%s: cannot write to %s: %v", name, path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// stringFlag returns the value of a string flag, false when cmd does not define it
+func stringFlag(flags *pflag.FlagSet, name string) (string, bool) {
+	value, err := flags.GetString(name)
+	return value, err == nil
+}
+
+// validateResolvers checks resolver entries are addresses or a resolver file that can be read
+// Entries without a protocol must be IP addresses: anything else is a file name that does not exist
+func validateResolvers(entries []string) error {
+	if len(entries) == 1 && utils.IsResolverFile(entries[0]) {
+		_, err := utils.LoadResolvers(entries[0])
+		return err
+	}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "://") {
+			if _, err := utils.ParseResolver(entry); err != nil {
+				return err
+			}
+			continue
+		}
+		if resolver, err := utils.ParseResolver(entry); err != nil || net.ParseIP(resolver.Host) == nil {
+			if len(entries) == 1 {
+				return fmt.Errorf("%q is neither an IP address nor an existing resolver file", entry)
+			}
+			return fmt.Errorf("%q is not an IP address, name DNS over TLS or HTTPS resolvers with tls:// or https://", entry)
+		}
+	}
+	return nil
+}
+
+// checkWritable checks files can be created in dir
+// Missing directories are fine when create is set, as long as their closest existing parent is writable
+func checkWritable(dir string, create bool) error {
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		if !create {
+			return fmt.Errorf("directory %s does not exist", dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return err
+		}
+		dir = parent
+	}
+
+	probe, err := os.CreateTemp(dir, ".subcollector-*")
+	if err != nil {
+		return fmt.Errorf("directory %s is not writable", dir)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
@@ -0,0 +1,140 @@
+package cloud
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// embeddedRanges is the snapshot shipped with the binary
+//
+//go:embed ranges.txt
+var embeddedRanges string
+
+// Match describes the provider an address belongs to
+type Match struct {
+	Provider string
+	Region   string
+}
+
+// entry is a single provider range
+type entry struct {
+	prefix netip.Prefix
+	match  Match
+}
+
+// Ranges holds provider address ranges, most specific first
+type Ranges struct {
+	entries []entry
+}
+
+var (
+	defaultRanges *Ranges
+	defaultOnce   sync.Once
+)
+
+// DefaultPath returns where refreshed range data is stored
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "subcollector", "cloud-ranges.txt")
+}
+
+// Default returns the ranges refreshed by update-ranges if present, the embedded snapshot otherwise
+func Default() *Ranges {
+	defaultOnce.Do(func() {
+		if file, err := os.Open(DefaultPath()); err == nil {
+			defer file.Close()
+			if ranges, err := Parse(file); err == nil && len(ranges.entries) > 0 {
+				defaultRanges = ranges
+				return
+			}
+		}
+		defaultRanges, _ = Parse(strings.NewReader(embeddedRanges))
+	})
+	return defaultRanges
+}
+
+// Parse reads range data in the provider,region,cidr format
+// Empty lines and lines starting with # are ignored
+func Parse(reader io.Reader) (*Ranges, error) {
+	ranges := &Ranges{}
+	scanner := bufio.NewScanner(reader)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, ",")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected provider,region,cidr", line)
+		}
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(fields[2]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		ranges.entries = append(ranges.entries, entry{
+			prefix: prefix.Masked(),
+			match:  Match{Provider: strings.TrimSpace(fields[0]), Region: strings.TrimSpace(fields[1])},
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Longest prefix first so the most specific range (usually carrying a region) wins
+	sort.SliceStable(ranges.entries, func(i, j int) bool {
+		return ranges.entries[i].prefix.Bits() > ranges.entries[j].prefix.Bits()
+	})
+	return ranges, nil
+}
+
+// Len returns the number of ranges
+func (r *Ranges) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.entries)
+}
+
+// Lookup returns the provider of an address
+func (r *Ranges) Lookup(ip string) (Match, bool) {
+	if r == nil {
+		return Match{}, false
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return Match{}, false
+	}
+	addr = addr.Unmap()
+
+	for _, e := range r.entries {
+		if e.prefix.Contains(addr) {
+			return e.match, true
+		}
+	}
+	return Match{}, false
+}
+
+// LookupAll returns the first provider matching any of the addresses
+func (r *Ranges) LookupAll(ips []string) (Match, bool) {
+	for _, ip := range ips {
+		if match, ok := r.Lookup(ip); ok {
+			return match, true
+		}
+	}
+	return Match{}, false
+}
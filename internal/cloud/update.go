@@ -0,0 +1,263 @@
+package cloud
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// source is a published range list of one provider
+type source struct {
+	provider string
+	urls     []string
+	parse    func(provider string, body []byte) ([]string, error) // Returns provider,region,cidr lines
+}
+
+// sources lists the providers whose ranges can be refreshed
+// Providers without a public feed (Azure, Akamai) keep the embedded snapshot
+var sources = []source{
+	{"AWS", []string{"https://ip-ranges.amazonaws.com/ip-ranges.json"}, parseAWS},
+	{"GCP", []string{"https://www.gstatic.com/ipranges/cloud.json"}, parseGoogle},
+	{"Google", []string{"https://www.gstatic.com/ipranges/goog.json"}, parseGoogle},
+	{"Cloudflare", []string{"https://www.cloudflare.com/ips-v4", "https://www.cloudflare.com/ips-v6"}, parsePlain},
+	{"Fastly", []string{"https://api.fastly.com/public-ip-list"}, parseFastly},
+	{"DigitalOcean", []string{"https://digitalocean.com/geo/google.csv"}, parseDigitalOcean},
+	{"Oracle", []string{"https://docs.oracle.com/en-us/iaas/tools/public_ip_ranges.json"}, parseOracle},
+}
+
+// UpdateResult reports the outcome of refreshing one provider
+type UpdateResult struct {
+	Provider string
+	Ranges   int
+	Err      error // Set when the embedded snapshot was kept for this provider
+}
+
+// Update downloads the current ranges of every provider and writes them to path
+// Providers that cannot be downloaded keep their embedded ranges
+func Update(path string) ([]UpdateResult, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	fresh := make(map[string][]string)
+	var report []UpdateResult
+	for _, src := range sources {
+		var lines []string
+		var err error
+		for _, url := range src.urls {
+			var body []byte
+			body, err = download(client, url)
+			if err != nil {
+				break
+			}
+			var parsed []string
+			parsed, err = src.parse(src.provider, body)
+			if err != nil {
+				break
+			}
+			lines = append(lines, parsed...)
+		}
+
+		if err == nil && len(lines) > 0 {
+			fresh[src.provider] = lines
+		}
+		report = append(report, UpdateResult{Provider: src.provider, Ranges: len(lines), Err: err})
+	}
+
+	// A file without fresh data would only shadow newer embedded snapshots
+	if len(fresh) == 0 {
+		return report, errors.New("no provider ranges could be downloaded")
+	}
+
+	// Keep embedded ranges of providers that were not refreshed
+	var kept []string
+	for _, line := range strings.Split(embeddedRanges, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		provider := strings.SplitN(line, ",", 2)[0]
+		if _, ok := fresh[provider]; !ok {
+			kept = append(kept, line)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return report, err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return report, err
+	}
+	defer file.Close()
+
+	fmt.Fprintf(file, "# Cloud ranges refreshed %s\n# Format: provider,region,cidr\n", time.Now().UTC().Format(time.RFC3339))
+	for _, src := range sources {
+		for _, line := range fresh[src.provider] {
+			fmt.Fprintln(file, line)
+		}
+	}
+	for _, line := range kept {
+		fmt.Fprintln(file, line)
+	}
+
+	return report, nil
+}
+
+// download fetches a URL and returns its body
+func download(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// line formats a range entry
+func line(provider, region, cidr string) string {
+	return provider + "," + region + "," + strings.TrimSpace(cidr)
+}
+
+// parseAWS parses ip-ranges.json
+func parseAWS(provider string, body []byte) ([]string, error) {
+	var data struct {
+		Prefixes []struct {
+			IPPrefix string `json:"ip_prefix"`
+			Region   string `json:"region"`
+		} `json:"prefixes"`
+		IPv6Prefixes []struct {
+			IPv6Prefix string `json:"ipv6_prefix"`
+			Region     string `json:"region"`
+		} `json:"ipv6_prefixes"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+
+	// The same prefix is listed once per service, keep one entry
+	seen := make(map[string]bool)
+	var lines []string
+	add := func(cidr, region string) {
+		if !seen[cidr] {
+			seen[cidr] = true
+			lines = append(lines, line(provider, region, cidr))
+		}
+	}
+	for _, p := range data.Prefixes {
+		add(p.IPPrefix, p.Region)
+	}
+	for _, p := range data.IPv6Prefixes {
+		add(p.IPv6Prefix, p.Region)
+	}
+	return lines, nil
+}
+
+// parseGoogle parses cloud.json and goog.json
+func parseGoogle(provider string, body []byte) ([]string, error) {
+	var data struct {
+		Prefixes []struct {
+			IPv4Prefix string `json:"ipv4Prefix"`
+			IPv6Prefix string `json:"ipv6Prefix"`
+			Scope      string `json:"scope"`
+		} `json:"prefixes"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, p := range data.Prefixes {
+		cidr := p.IPv4Prefix
+		if cidr == "" {
+			cidr = p.IPv6Prefix
+		}
+		if cidr != "" {
+			lines = append(lines, line(provider, p.Scope, cidr))
+		}
+	}
+	return lines, nil
+}
+
+// parsePlain parses one CIDR per line
+func parsePlain(provider string, body []byte) ([]string, error) {
+	var lines []string
+	for _, cidr := range strings.Split(string(body), "\n") {
+		if cidr = strings.TrimSpace(cidr); cidr != "" {
+			lines = append(lines, line(provider, "", cidr))
+		}
+	}
+	return lines, nil
+}
+
+// parseFastly parses the public-ip-list API response
+func parseFastly(provider string, body []byte) ([]string, error) {
+	var data struct {
+		Addresses     []string `json:"addresses"`
+		IPv6Addresses []string `json:"ipv6_addresses"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, cidr := range append(data.Addresses, data.IPv6Addresses...) {
+		lines = append(lines, line(provider, "", cidr))
+	}
+	return lines, nil
+}
+
+// parseDigitalOcean parses the geofeed CSV (cidr,country,region,city,postal)
+func parseDigitalOcean(provider string, body []byte) ([]string, error) {
+	reader := csv.NewReader(strings.NewReader(string(body)))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, record := range records {
+		if len(record) == 0 || record[0] == "" {
+			continue
+		}
+		region := ""
+		if len(record) > 2 {
+			region = record[2]
+		}
+		lines = append(lines, line(provider, strings.ReplaceAll(region, ",", " "), record[0]))
+	}
+	return lines, nil
+}
+
+// parseOracle parses public_ip_ranges.json
+func parseOracle(provider string, body []byte) ([]string, error) {
+	var data struct {
+		Regions []struct {
+			Region string `json:"region"`
+			CIDRs  []struct {
+				CIDR string `json:"cidr"`
+			} `json:"cidrs"`
+		} `json:"regions"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, region := range data.Regions {
+		for _, cidr := range region.CIDRs {
+			lines = append(lines, line(provider, region.Region, cidr.CIDR))
+		}
+	}
+	return lines, nil
+}
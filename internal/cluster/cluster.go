@@ -0,0 +1,254 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/fkr00t/subcollector/api/subcollector/v1"
+	"github.com/fkr00t/subcollector/internal/models"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultShardSize is the number of wordlist entries handed to a node at once
+const DefaultShardSize = 5000
+
+const (
+	maxAttempts     = 3               // Nodes a shard is tried on before it is given up
+	maxNodeFailures = 3               // Failed shards in a row after which a node is dropped
+	retryDelay      = 2 * time.Second // Pause of a node after a failed shard
+)
+
+// Shard is one unit of work of a distributed scan: a slice of the wordlist under one domain
+type Shard struct {
+	Domain string
+	Words  []string
+	Err    error // Last error, set on shards that could not be scanned
+
+	attempts int
+}
+
+// Split cuts the wordlist into shards of at most size words for every domain
+func Split(domains, words []string, size int) []Shard {
+	if size <= 0 {
+		size = DefaultShardSize
+	}
+	var shards []Shard
+	for _, domain := range domains {
+		for start := 0; start < len(words); start += size {
+			end := min(start+size, len(words))
+			shards = append(shards, Shard{Domain: domain, Words: words[start:end]})
+		}
+	}
+	return shards
+}
+
+// Cluster is the set of nodes a coordinator distributes shards to
+type Cluster struct {
+	nodes []*Node
+	scans int
+
+	// Log receives node failures while shards run, they are printed when nil
+	Log func(message string)
+
+	// Dispatch, when set, is called before a shard is sent to a node, again when it is
+	// reassigned; a shard it refuses is not sent and fails with ErrNotDispatched
+	Dispatch func(shard Shard) bool
+}
+
+// ErrNotDispatched is the error of shards refused by Cluster.Dispatch
+var ErrNotDispatched = errors.New("shard not dispatched")
+
+// Dial prepares connections to the nodes at addresses (host:port)
+func Dial(addresses []string, opts Options) (*Cluster, error) {
+	if opts.Scans <= 0 {
+		opts.Scans = 1
+	}
+
+	c := &Cluster{scans: opts.Scans}
+	seen := make(map[string]bool)
+	for _, address := range addresses {
+		address = strings.TrimSpace(address)
+		if address == "" || seen[address] {
+			continue
+		}
+		seen[address] = true
+
+		node, err := dialNode(address, opts)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.nodes = append(c.nodes, node)
+	}
+	if len(c.nodes) == 0 {
+		return nil, errors.New("no node given")
+	}
+	return c, nil
+}
+
+// Nodes returns the nodes of the cluster
+func (c *Cluster) Nodes() []*Node {
+	return c.nodes
+}
+
+// Close closes the connections to every node
+func (c *Cluster) Close() {
+	for _, node := range c.nodes {
+		node.Close()
+	}
+}
+
+// nodeHealth counts the failures in a row of one node
+type nodeHealth struct {
+	mu       sync.Mutex
+	failures int
+	dropped  bool
+}
+
+// record counts the outcome of a shard and reports whether the node has to be dropped
+func (h *nodeHealth) record(err error) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		h.failures = 0
+		return false
+	}
+	h.failures++
+	if h.failures >= maxNodeFailures && !h.dropped {
+		h.dropped = true
+		return true
+	}
+	return false
+}
+
+func (h *nodeHealth) isDropped() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.dropped
+}
+
+// Run scans the shards on the nodes with the settings of req, calling result for every
+// result streamed and done for every shard scanned completely; both are called concurrently
+// A shard failing on a node is given to another node, so results of the partial attempt
+// may be reported twice. Nodes failing several shards in a row are dropped.
+// Returns the shards that could not be scanned
+func (c *Cluster) Run(ctx context.Context, req *pb.ScanRequest, shards []Shard, result func(models.SubdomainResult), done func(Shard)) []Shard {
+	if len(shards) == 0 {
+		return nil
+	}
+
+	// Shards wait in the queue, failed ones go back to it; it is closed once every shard is settled
+	queue := make(chan Shard, len(shards))
+	for _, shard := range shards {
+		queue <- shard
+	}
+	var mu sync.Mutex
+	remaining := len(shards)
+	var failed []Shard
+	settle := func(shard Shard, ok bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !ok {
+			failed = append(failed, shard)
+		}
+		if remaining--; remaining == 0 {
+			close(queue)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, node := range c.nodes {
+		health := &nodeHealth{}
+		for i := 0; i < c.scans; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.work(ctx, node, health, req, queue, result, done, settle)
+			}()
+		}
+	}
+	wg.Wait()
+
+	// Every node was dropped, the shards left in the queue cannot be scanned
+	for {
+		select {
+		case shard, ok := <-queue:
+			if !ok {
+				return failed
+			}
+			if shard.Err == nil {
+				shard.Err = errors.New("no node left")
+			}
+			failed = append(failed, shard)
+		default:
+			return failed
+		}
+	}
+}
+
+// work runs shards from the queue on one node until the queue is closed or the node is dropped
+func (c *Cluster) work(ctx context.Context, node *Node, health *nodeHealth, req *pb.ScanRequest, queue chan Shard,
+	result func(models.SubdomainResult), done func(Shard), settle func(Shard, bool)) {
+	for shard := range queue {
+		if health.isDropped() {
+			queue <- shard
+			return
+		}
+		if ctx.Err() != nil {
+			shard.Err = ctx.Err()
+			settle(shard, false)
+			continue
+		}
+		if c.Dispatch != nil && !c.Dispatch(shard) {
+			shard.Err = ErrNotDispatched
+			settle(shard, false)
+			continue
+		}
+
+		shardReq := proto.Clone(req).(*pb.ScanRequest)
+		shardReq.Domain = shard.Domain
+		shardReq.Words = shard.Words
+		err := node.Scan(ctx, shardReq, result)
+		drop := health.record(err)
+		if err == nil {
+			done(shard)
+			settle(shard, true)
+			continue
+		}
+
+		shard.attempts++
+		shard.Err = fmt.Errorf("%s: %v", node.Address, err)
+		if ctx.Err() != nil {
+			// Shards cut short by the end of the scan report why it ended
+			shard.Err = ctx.Err()
+			settle(shard, false)
+		} else if shard.attempts >= maxAttempts {
+			settle(shard, false)
+		} else {
+			c.log(fmt.Sprintf("× Shard of %s failed on %s, reassigning: %v", shard.Domain, node.Address, err))
+			queue <- shard
+		}
+		if drop {
+			c.log(fmt.Sprintf("× Node %s dropped after %d failed shards in a row", node.Address, maxNodeFailures))
+			return
+		}
+
+		select {
+		case <-time.After(retryDelay):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// log reports a node failure
+func (c *Cluster) log(message string) {
+	if c.Log != nil {
+		c.Log(message)
+		return
+	}
+	fmt.Println(message)
+}
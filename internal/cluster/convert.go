@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	pb "github.com/fkr00t/subcollector/api/subcollector/v1"
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// fromProto converts a result message streamed by a node back to a result
+func fromProto(msg *pb.SubdomainResult) models.SubdomainResult {
+	result := models.SubdomainResult{
+		Subdomain:    msg.GetSubdomain(),
+		IPs:          msg.GetIps(),
+		TTL:          msg.GetTtl(),
+		ResponseTime: msg.GetResponseMs(),
+		Takeover:     msg.GetTakeover(),
+		DNSSEC:       msg.GetDnssec(),
+		Source:       msg.GetSource(),
+		CNAME:        msg.GetCname(),
+		Provider:     msg.GetProvider(),
+		Region:       msg.GetRegion(),
+		Evidence:     msg.GetEvidence(),
+		Unverified:   msg.GetUnverified(),
+		Resolution:   msg.GetResolution(),
+	}
+	for _, port := range msg.GetPorts() {
+		result.Ports = append(result.Ports, int(port))
+	}
+
+	if cert := msg.GetTls(); cert != nil {
+		result.TLS = &models.CertInfo{
+			Subject:   cert.GetSubject(),
+			Issuer:    cert.GetIssuer(),
+			SANs:      cert.GetSans(),
+			NotBefore: cert.GetNotBefore().AsTime(),
+			NotAfter:  cert.GetNotAfter().AsTime(),
+		}
+	}
+	if consensus := msg.GetConsensus(); consensus != nil {
+		result.Consensus = &models.Consensus{
+			Confirmed:     int(consensus.GetConfirmed()),
+			Resolvers:     int(consensus.GetResolvers()),
+			Disagreements: consensus.GetDisagreements(),
+		}
+	}
+	if http := msg.GetHttp(); http != nil {
+		result.HTTP = &models.HTTPInfo{
+			URL:         http.GetUrl(),
+			StatusCode:  int(http.GetStatusCode()),
+			Title:       http.GetTitle(),
+			Server:      http.GetServer(),
+			FaviconURL:  http.GetFaviconUrl(),
+			FaviconHash: http.GetFaviconMmh3(),
+		}
+		for _, tech := range http.GetTechnologies() {
+			result.HTTP.Technologies = append(result.HTTP.Technologies, models.Technology{Name: tech.GetName(), Category: tech.GetCategory(), Version: tech.GetVersion()})
+		}
+	}
+	for _, record := range msg.GetDangling() {
+		result.Dangling = append(result.Dangling, models.DanglingRecord{Type: record.GetType(), Severity: record.GetSeverity(), Target: record.GetTarget(), Detail: record.GetDetail()})
+	}
+	if email := msg.GetEmail(); email != nil {
+		result.Email = &models.EmailPosture{
+			Domain:      email.GetDomain(),
+			MX:          email.GetMx(),
+			SPF:         email.GetSpf(),
+			SPFIncludes: email.GetSpfIncludes(),
+			DMARC:       email.GetDmarc(),
+			DKIM:        email.GetDkimSelectors(),
+		}
+		for _, issue := range email.GetIssues() {
+			result.Email.Issues = append(result.Email.Issues, models.EmailIssue{Issue: issue.GetIssue(), Severity: issue.GetSeverity(), Detail: issue.GetDetail()})
+		}
+	}
+	return result
+}
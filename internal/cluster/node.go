@@ -0,0 +1,111 @@
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	pb "github.com/fkr00t/subcollector/api/subcollector/v1"
+	"github.com/fkr00t/subcollector/internal/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+)
+
+// keepaliveTime is how long a stream may stay quiet before the node is pinged;
+// a node that does not answer the ping within keepaliveTimeout is considered gone
+const (
+	keepaliveTime    = 30 * time.Second
+	keepaliveTimeout = 10 * time.Second
+)
+
+// Options configures the connections to the nodes
+type Options struct {
+	Token  string // Bearer token sent to every node, none when empty
+	TLS    bool   // Connect over TLS
+	CAFile string // CA certificate verifying the nodes, the system roots when empty (implies TLS)
+	Scans  int    // Shards run at once on each node
+}
+
+// Node is a subcollector server (subcollector serve) running shards of a distributed scan
+type Node struct {
+	Address string
+
+	conn   *grpc.ClientConn
+	client pb.ScanServiceClient
+	token  string
+}
+
+// dialNode prepares the connection to a node, which is only opened by the first scan
+func dialNode(address string, opts Options) (*Node, error) {
+	creds := insecure.NewCredentials()
+	if opts.TLS || opts.CAFile != "" {
+		config := &tls.Config{MinVersion: tls.VersionTLS12}
+		if opts.CAFile != "" {
+			pem, err := os.ReadFile(opts.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA certificate: %v", err)
+			}
+			config.RootCAs = x509.NewCertPool()
+			if !config.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificate found in %s", opts.CAFile)
+			}
+		}
+		creds = credentials.NewTLS(config)
+	}
+
+	conn, err := grpc.NewClient(address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{Time: keepaliveTime, Timeout: keepaliveTimeout}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node address %q: %v", address, err)
+	}
+	return &Node{Address: address, conn: conn, client: pb.NewScanServiceClient(conn), token: opts.Token}, nil
+}
+
+// Scan runs one scan on the node and calls result for every result it streams
+// Returns an error when the call fails or the node reports that the scan failed
+func (n *Node) Scan(ctx context.Context, req *pb.ScanRequest, result func(models.SubdomainResult)) error {
+	if n.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+n.token)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := n.client.Scan(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return errors.New("stream ended before the scan finished")
+		}
+		if err != nil {
+			return err
+		}
+
+		switch event := event.GetEvent().(type) {
+		case *pb.ScanEvent_Result:
+			result(fromProto(event.Result))
+		case *pb.ScanEvent_Finished:
+			if event.Finished.GetError() != "" {
+				return errors.New(event.Finished.GetError())
+			}
+			return nil
+		}
+	}
+}
+
+// Close closes the connection to the node
+func (n *Node) Close() error {
+	return n.conn.Close()
+}
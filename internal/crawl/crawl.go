@@ -0,0 +1,261 @@
+package crawl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+const (
+	// DefaultMaxPages bounds how many documents are fetched per target
+	DefaultMaxPages = 50
+
+	// maxBodySize caps how much of a document is read
+	maxBodySize = 5 << 20
+
+	// userAgent identifies subcollector to the crawled site
+	userAgent = "subcollector (+https://github.com/fkr00t/subcollector)"
+)
+
+var (
+	linkPattern    = regexp.MustCompile(`(?i)(?:href|src)\s*=\s*["']([^"'#\s]+)`)
+	scriptPattern  = regexp.MustCompile(`(?i)<script[^>]+src\s*=\s*["']([^"'#\s]+)`)
+	sitemapPattern = regexp.MustCompile(`(?i)<loc>\s*([^<\s]+)\s*</loc>`)
+
+	// skippedExtensions are documents without useful text
+	skippedExtensions = map[string]bool{
+		".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".ico": true, ".svg": true,
+		".woff": true, ".woff2": true, ".ttf": true, ".eot": true, ".otf": true,
+		".pdf": true, ".zip": true, ".gz": true, ".mp4": true, ".mp3": true, ".webm": true, ".css": true,
+	}
+)
+
+// Page is one fetched document of the target
+type Page struct {
+	URL    string
+	Body   []byte
+	Script bool // JavaScript file rather than a page
+}
+
+// Options control how much of the target is crawled
+type Options struct {
+	MaxPages int          // Documents fetched at most, DefaultMaxPages when zero
+	Proxy    string       // Optional HTTP proxy URL used when Client is nil
+	Client   *http.Client // HTTP client, a client with a 10s timeout when nil
+}
+
+// Crawl fetches the target's main site, robots.txt, sitemaps and JavaScript files
+// Only hosts of the target domain are followed; robots.txt, sitemaps and scripts
+// are fetched before further pages because they carry the most names
+func Crawl(ctx context.Context, domain string, opts Options) []Page {
+	if opts.MaxPages <= 0 {
+		opts.MaxPages = DefaultMaxPages
+	}
+	if opts.Client == nil {
+		var transport http.RoundTripper
+		if proxyURL, err := utils.ParseProxy(opts.Proxy); opts.Proxy != "" && err == nil {
+			transport = &http.Transport{Proxy: http.ProxyURL(proxyURL)}
+		}
+		opts.Client = &http.Client{Transport: utils.WrapTransport(transport), Timeout: 10 * time.Second}
+	}
+
+	c := &crawler{domain: strings.ToLower(domain), opts: opts, seen: make(map[string]bool)}
+	c.push("https://"+domain+"/robots.txt", true)
+	c.push("https://"+domain+"/sitemap.xml", true)
+	c.push("https://"+domain+"/", false)
+	c.push("https://www."+domain+"/", false)
+
+	var pages []Page
+	for len(pages) < opts.MaxPages && ctx.Err() == nil {
+		target, ok := c.pop()
+		if !ok {
+			break
+		}
+
+		body, err := c.fetch(ctx, target)
+		if err != nil {
+			// Sites without TLS are retried over plain HTTP
+			if target == "https://"+domain+"/" {
+				c.push("http://"+domain+"/robots.txt", true)
+				c.push("http://"+domain+"/sitemap.xml", true)
+				c.push("http://"+domain+"/", false)
+			}
+			continue
+		}
+
+		page := Page{URL: target, Body: body, Script: isScript(target)}
+		pages = append(pages, page)
+		c.follow(page)
+	}
+
+	return pages
+}
+
+// crawler keeps the queues and visited URLs of one crawl
+type crawler struct {
+	domain   string
+	opts     Options
+	seen     map[string]bool
+	priority []string // robots.txt, sitemaps and scripts
+	pages    []string
+}
+
+// push queues an in-scope URL that was not seen yet
+func (c *crawler) push(target string, priority bool) {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if host != c.domain && !strings.HasSuffix(host, "."+c.domain) {
+		return
+	}
+	if skippedExtensions[strings.ToLower(path.Ext(parsed.Path))] {
+		return
+	}
+
+	parsed.Fragment = ""
+	target = parsed.String()
+	if c.seen[target] {
+		return
+	}
+	c.seen[target] = true
+
+	if priority {
+		c.priority = append(c.priority, target)
+	} else {
+		c.pages = append(c.pages, target)
+	}
+}
+
+// pop returns the next URL to fetch
+func (c *crawler) pop() (string, bool) {
+	if len(c.priority) > 0 {
+		target := c.priority[0]
+		c.priority = c.priority[1:]
+		return target, true
+	}
+	if len(c.pages) > 0 {
+		target := c.pages[0]
+		c.pages = c.pages[1:]
+		return target, true
+	}
+	return "", false
+}
+
+// fetch downloads a text document
+func (c *crawler) fetch(ctx context.Context, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", target, resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !isText(contentType) {
+		return nil, fmt.Errorf("%s is %s", target, contentType)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+}
+
+// follow queues the URLs a document links to
+func (c *crawler) follow(page Page) {
+	base, err := url.Parse(page.URL)
+	if err != nil || page.Script {
+		return
+	}
+
+	switch {
+	case path.Base(base.Path) == "robots.txt":
+		lines := bufio.NewScanner(bytes.NewReader(page.Body))
+		for lines.Scan() {
+			key, value, found := strings.Cut(strings.TrimSpace(lines.Text()), ":")
+			if found && strings.EqualFold(key, "sitemap") {
+				c.push(strings.TrimSpace(value), true)
+			}
+		}
+	case isSitemap(base.Path, page.Body):
+		for _, match := range sitemapPattern.FindAllSubmatch(page.Body, -1) {
+			target := string(match[1])
+			c.push(target, strings.HasSuffix(strings.ToLower(target), ".xml"))
+		}
+	default:
+		for _, match := range scriptPattern.FindAllSubmatch(page.Body, -1) {
+			if ref, err := base.Parse(string(match[1])); err == nil {
+				c.push(ref.String(), true)
+			}
+		}
+		for _, match := range linkPattern.FindAllSubmatch(page.Body, -1) {
+			if ref, err := base.Parse(string(match[1])); err == nil {
+				c.push(ref.String(), isScript(ref.Path))
+			}
+		}
+	}
+}
+
+// escapedSlashes rewrites slashes escaped in scripts and URLs, which would
+// otherwise stick to the hostname that follows them (\u002Fapi.example.com)
+var escapedSlashes = strings.NewReplacer(`\u002F`, "/", `\u002f`, "/", `\x2F`, "/", `\x2f`, "/", "%2F", "/", "%2f", "/", `\/`, "/")
+
+// Hosts returns the distinct in-scope hostnames mentioned in the crawled documents
+func Hosts(pages []Page, domain string) []string {
+	var hosts []string
+	seen := make(map[string]bool)
+	for _, page := range pages {
+		body := []byte(escapedSlashes.Replace(string(page.Body)))
+		for _, host := range utils.ExtractHosts(body, domain) {
+			if !seen[host] {
+				seen[host] = true
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	return hosts
+}
+
+// isScript reports whether a URL or path names a JavaScript file
+func isScript(target string) bool {
+	if parsed, err := url.Parse(target); err == nil {
+		target = parsed.Path
+	}
+	ext := strings.ToLower(path.Ext(target))
+	return ext == ".js" || ext == ".mjs"
+}
+
+// isSitemap reports whether a document is a sitemap or sitemap index
+func isSitemap(urlPath string, body []byte) bool {
+	if strings.HasSuffix(strings.ToLower(urlPath), ".xml") {
+		return bytes.Contains(body, []byte("<loc>")) || bytes.Contains(body, []byte("<LOC>"))
+	}
+	return false
+}
+
+// isText reports whether a content type carries text worth reading
+func isText(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, kind := range []string{"text/", "javascript", "json", "xml"} {
+		if strings.Contains(contentType, kind) {
+			return true
+		}
+	}
+	return false
+}
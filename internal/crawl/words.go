@@ -0,0 +1,153 @@
+package crawl
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	minWordLength = 3
+	maxWordLength = 30
+
+	// hostWeight ranks labels of real in-scope hostnames above words from text
+	hostWeight = 10
+)
+
+var tokenPattern = regexp.MustCompile(`[A-Za-z][A-Za-z0-9-]*`)
+
+// stopwords are common English, HTML, CSS and JavaScript words that are
+// unlikely subdomain labels
+var stopwords = toSet(`
+about above after again against all also and any are around back because been before being below
+between both but can cannot could did does doing down during each even every few for from further
+get got had has have having here how into its just like made make many may more most much must
+near need new next not now off once one only other our out over own per same see should since some
+still such than that the their them then there these they this those through too under until upon
+use used using very via was way well were what when where which while who whom why will with within
+without would yes yet you your
+
+html head body div span class style href src alt title meta link rel type content charset name
+width height script noscript iframe img input button form label option select textarea table tbody
+thead tfoot tr td th nav header footer section article aside main svg path fill stroke viewbox xmlns
+http https www com net org index utf text javascript json xml css px em rem auto none block inline
+flex grid left right top bottom center middle hidden visible display position absolute relative
+fixed color background border margin padding font size weight bold normal italic solid transparent
+inherit important hover focus active disabled checked selected opacity transform transition
+animation rgba hsl var calc min max
+
+function return const let true false null undefined new this typeof instanceof void delete
+throw try catch finally break continue switch case default else while for do if in of class
+extends super import export from async await yield static get set prototype constructor
+window document object array string number boolean symbol promise error console log length push
+pop shift slice splice map filter reduce foreach keys values entries assign create define
+exports require module call apply bind then resolve reject arguments event target value data
+element node parent child children attribute attributes listener handler callback options config
+props state render component default
+
+robots disallow allow user-agent agent sitemap urlset loc lastmod changefreq priority txt png jpg
+jpeg gif svg ico pdf
+`)
+
+// Words extracts candidate subdomain labels from crawled documents, most frequent first
+// Labels of hostnames of domain count most, then URL path segments, then words
+// from page text, script identifiers (split at camelCase humps) and strings
+func Words(pages []Page, domain string) []string {
+	// Labels of the target domain itself are everywhere but useless
+	root := strings.Split(strings.ToLower(domain), ".")
+	skip := toSet(strings.Join(root, " "))
+
+	counts := make(map[string]int)
+	add := func(word string, weight int) {
+		word = strings.Trim(strings.ToLower(word), "-")
+		if !skip[word] && len(word) >= minWordLength && isCandidate(word) {
+			counts[word] += weight
+		}
+	}
+
+	// Labels of real hostnames are kept even when short or common, like ci or www
+	for _, host := range Hosts(pages, domain) {
+		labels := strings.Split(host, ".")
+		for _, label := range labels[:len(labels)-len(root)] {
+			if label != "" {
+				counts[label] += hostWeight
+			}
+		}
+	}
+
+	for _, page := range pages {
+		if parsed, err := url.Parse(page.URL); err == nil {
+			for _, segment := range strings.FieldsFunc(parsed.Path, isSeparator) {
+				add(segment, 1)
+			}
+		}
+
+		for _, token := range tokenPattern.FindAll(page.Body, -1) {
+			for _, word := range splitCamel(string(token)) {
+				add(word, 1)
+			}
+		}
+	}
+
+	words := make([]string, 0, len(counts))
+	for word := range counts {
+		words = append(words, word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	return words
+}
+
+// isCandidate reports whether a word could be a subdomain label worth trying
+func isCandidate(word string) bool {
+	if word == "" || len(word) > maxWordLength || stopwords[word] {
+		return false
+	}
+	if !unicode.IsLetter(rune(word[0])) {
+		return false
+	}
+	for _, r := range word {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// splitCamel splits identifiers like getUserProfile into their parts
+// Hyphenated and lowercase tokens are returned whole
+func splitCamel(token string) []string {
+	if strings.ToLower(token) == token || strings.ToUpper(token) == token || strings.Contains(token, "-") {
+		return []string{token}
+	}
+
+	var parts []string
+	start := 0
+	for i := 1; i < len(token); i++ {
+		if unicode.IsUpper(rune(token[i])) && !unicode.IsUpper(rune(token[i-1])) {
+			parts = append(parts, token[start:i])
+			start = i
+		}
+	}
+	return append(parts, token[start:])
+}
+
+// isSeparator splits URL paths into segments
+func isSeparator(r rune) bool {
+	return r == '/' || r == '.' || r == '_' || r == '?' || r == '&' || r == '='
+}
+
+// toSet builds a lookup set from whitespace separated words
+func toSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
@@ -0,0 +1,330 @@
+package email
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/utils"
+	"github.com/miekg/dns"
+)
+
+const (
+	// maxSPFLookups is the number of DNS-querying SPF terms allowed by RFC 7208
+	maxSPFLookups = 10
+
+	// minDKIMKeyBits is the smallest RSA key size still considered safe
+	minDKIMKeyBits = 1024
+)
+
+// DKIMSelectors are selectors used by common mail providers and software
+// Selectors cannot be listed through DNS, so only these are tried
+var DKIMSelectors = []string{
+	"default", "dkim", "mail", "email", "smtp", "mx", "key1", "key2", "k1", "k2", "k3",
+	"s1", "s2", "selector1", "selector2", "google", "20230601", "20221208", "20210112",
+	"mandrill", "mailjet", "zoho", "zmail", "protonmail", "protonmail2", "protonmail3",
+	"fm1", "fm2", "fm3", "pm", "everlytickey1", "everlytickey2", "sendinblue", "mailchimp",
+}
+
+// severityRank orders issues, most severe first
+var severityRank = map[string]int{
+	models.SeverityHigh:   0,
+	models.SeverityMedium: 1,
+	models.SeverityLow:    2,
+}
+
+// Analyze collects the MX, SPF, DMARC and DKIM records of domain and flags weak
+// or missing policies
+// root is the scanned root domain: SPF includes outside of it are third parties,
+// and subdomains inherit its DMARC policy so a missing record is only flagged there
+// Returns nil for a subdomain that publishes no mail records at all
+func Analyze(domain, root, resolver string) *models.EmailPosture {
+	posture := &models.EmailPosture{Domain: domain, MX: queryMX(domain, resolver)}
+	isRoot := domain == root
+
+	var spfRecords, dmarcRecords []string
+	for _, txt := range queryTXT(domain, resolver) {
+		if isSPF(txt) {
+			spfRecords = append(spfRecords, txt)
+		}
+	}
+	for _, txt := range queryTXT("_dmarc."+domain, resolver) {
+		if strings.HasPrefix(strings.ToLower(txt), "v=dmarc1") {
+			dmarcRecords = append(dmarcRecords, txt)
+		}
+	}
+
+	if !isRoot && len(posture.MX) == 0 && len(spfRecords) == 0 && len(dmarcRecords) == 0 {
+		return nil
+	}
+
+	// A domain that sends no mail publishes "v=spf1 -all" and has no MX
+	sendsMail := len(posture.MX) > 0
+	if len(spfRecords) > 0 && strings.Join(strings.Fields(strings.ToLower(spfRecords[0])), " ") != "v=spf1 -all" {
+		sendsMail = true
+	}
+
+	checkSPF(posture, spfRecords, root, isRoot)
+	checkDMARC(posture, dmarcRecords, isRoot, sendsMail)
+	if isRoot || sendsMail {
+		checkDKIM(posture, resolver, sendsMail)
+	}
+
+	sort.SliceStable(posture.Issues, func(i, j int) bool {
+		return severityRank[posture.Issues[i].Severity] < severityRank[posture.Issues[j].Severity]
+	})
+	return posture
+}
+
+// MailHosts returns the mail exchangers of domain, by preference, and the domains
+// referenced by include: and redirect= in its SPF policy, without analyzing them
+func MailHosts(domain, resolver string) (mx, includes []string) {
+	for _, txt := range queryTXT(domain, resolver) {
+		if isSPF(txt) {
+			includes = spfIncludes(txt)
+			break
+		}
+	}
+	return queryMX(domain, resolver), includes
+}
+
+// spfIncludes returns the domains referenced by include: and redirect= in an SPF record
+func spfIncludes(record string) []string {
+	var includes []string
+	for _, term := range strings.Fields(record)[1:] {
+		mechanism := strings.TrimLeft(strings.ToLower(term), "+-~?")
+		if value, ok := strings.CutPrefix(mechanism, "include:"); ok {
+			includes = append(includes, value)
+		} else if value, ok := strings.CutPrefix(mechanism, "redirect="); ok {
+			includes = append(includes, value)
+		}
+	}
+	return includes
+}
+
+// checkSPF records the SPF policy and flags missing, duplicate or permissive records
+func checkSPF(posture *models.EmailPosture, records []string, root string, isRoot bool) {
+	switch {
+	case len(records) == 0:
+		if len(posture.MX) > 0 {
+			flag(posture, "spf-missing", models.SeverityMedium, "")
+		} else if isRoot {
+			// Domains that send no mail should still say so with "v=spf1 -all"
+			flag(posture, "spf-missing", models.SeverityLow, "")
+		}
+		return
+	case len(records) > 1:
+		// Several SPF records make every evaluation a permanent error
+		flag(posture, "spf-multiple", models.SeverityHigh, fmt.Sprintf("%d records", len(records)))
+	}
+
+	posture.SPF = records[0]
+	lookups := 0
+	qualifier := "" // Qualifier of the all mechanism, empty when absent
+	redirect := false
+	for _, term := range strings.Fields(records[0])[1:] {
+		lower := strings.ToLower(term)
+		mechanism := strings.TrimLeft(lower, "+-~?")
+		name, value, _ := strings.Cut(mechanism, ":")
+
+		if strings.HasPrefix(mechanism, "redirect=") {
+			name, value = "redirect", strings.TrimPrefix(mechanism, "redirect=")
+			redirect = true
+		}
+		if i := strings.IndexByte(name, '/'); i >= 0 {
+			name = name[:i]
+		}
+
+		switch name {
+		case "include", "redirect":
+			lookups++
+			posture.SPFIncludes = append(posture.SPFIncludes, value)
+			if value != root && !utils.IsSubdomainOf(value, root) {
+				flag(posture, "spf-third-party", models.SeverityLow, value)
+			}
+		case "a", "mx", "exists":
+			lookups++
+		case "ptr":
+			lookups++
+			flag(posture, "spf-ptr", models.SeverityLow, term)
+		case "all":
+			qualifier = strings.TrimSuffix(lower, "all")
+			if qualifier == "" {
+				qualifier = "+"
+			}
+		}
+	}
+
+	switch {
+	case qualifier == "" && !redirect:
+		flag(posture, "spf-no-all", models.SeverityMedium, "")
+	case qualifier == "+":
+		flag(posture, "spf-pass-all", models.SeverityHigh, "+all")
+	case qualifier == "?":
+		flag(posture, "spf-neutral-all", models.SeverityMedium, "?all")
+	case qualifier == "~":
+		flag(posture, "spf-softfail-all", models.SeverityLow, "~all")
+	}
+
+	if lookups > maxSPFLookups {
+		flag(posture, "spf-too-many-lookups", models.SeverityMedium, fmt.Sprintf("%d lookups", lookups))
+	}
+}
+
+// checkDMARC records the DMARC policy and flags missing or non-enforcing ones
+func checkDMARC(posture *models.EmailPosture, records []string, isRoot, sendsMail bool) {
+	switch {
+	case len(records) == 0:
+		if !isRoot {
+			// The root domain's policy applies
+			return
+		}
+		severity := models.SeverityMedium
+		if sendsMail {
+			severity = models.SeverityHigh
+		}
+		flag(posture, "dmarc-missing", severity, "")
+		return
+	case len(records) > 1:
+		// Receivers ignore DMARC entirely when several records are published
+		flag(posture, "dmarc-multiple", models.SeverityHigh, fmt.Sprintf("%d records", len(records)))
+	}
+
+	posture.DMARC = records[0]
+	tags := parseTags(records[0])
+
+	switch policy := DMARCPolicy(records[0]); policy {
+	case "reject", "quarantine":
+	case "none":
+		flag(posture, "dmarc-policy-none", models.SeverityMedium, "p=none")
+	default:
+		flag(posture, "dmarc-invalid", models.SeverityHigh, "p="+policy)
+	}
+
+	if isRoot && tags["sp"] == "none" && tags["p"] != "none" {
+		flag(posture, "dmarc-subdomain-none", models.SeverityMedium, "sp=none")
+	}
+	if pct, err := strconv.Atoi(tags["pct"]); err == nil && pct < 100 {
+		flag(posture, "dmarc-partial", models.SeverityLow, fmt.Sprintf("pct=%d", pct))
+	}
+	if tags["rua"] == "" {
+		flag(posture, "dmarc-no-reports", models.SeverityLow, "")
+	}
+}
+
+// checkDKIM tries the common selectors and flags weak keys
+// A missing key is only flagged for domains sending mail, selectors may be unusual
+func checkDKIM(posture *models.EmailPosture, resolver string, sendsMail bool) {
+	for _, selector := range DKIMSelectors {
+		for _, txt := range queryTXT(selector+"._domainkey."+posture.Domain, resolver) {
+			tags := parseTags(txt)
+			key, ok := tags["p"]
+			if !ok || key == "" {
+				// An empty key is a revoked selector
+				continue
+			}
+			posture.DKIM = append(posture.DKIM, selector)
+
+			if bits := rsaKeyBits(key); bits > 0 && bits < minDKIMKeyBits {
+				flag(posture, "dkim-weak-key", models.SeverityMedium, fmt.Sprintf("%s: %d-bit RSA", selector, bits))
+			}
+			break
+		}
+	}
+
+	if len(posture.DKIM) == 0 && sendsMail {
+		flag(posture, "dkim-not-found", models.SeverityLow, "no common selector")
+	}
+}
+
+// DMARCPolicy returns the p= policy of a DMARC record
+func DMARCPolicy(record string) string {
+	return parseTags(record)["p"]
+}
+
+// flag records an issue on a posture
+func flag(posture *models.EmailPosture, issue, severity, detail string) {
+	posture.Issues = append(posture.Issues, models.EmailIssue{Issue: issue, Severity: severity, Detail: detail})
+}
+
+// queryMX returns the mail exchangers of a domain, by preference
+// A null MX (RFC 7505) declaring that the domain accepts no mail is left out
+func queryMX(domain, resolver string) []string {
+	records, err := utils.QueryRecords(domain, dns.TypeMX, resolver)
+	if err != nil {
+		return nil
+	}
+
+	var mx []*dns.MX
+	for _, rr := range records {
+		if record, ok := rr.(*dns.MX); ok && record.Mx != "." {
+			mx = append(mx, record)
+		}
+	}
+	sort.SliceStable(mx, func(i, j int) bool { return mx[i].Preference < mx[j].Preference })
+
+	hosts := make([]string, len(mx))
+	for i, record := range mx {
+		hosts[i] = strings.ToLower(strings.TrimSuffix(record.Mx, "."))
+	}
+	return hosts
+}
+
+// queryTXT returns the TXT records of a name, each one's strings joined
+func queryTXT(name, resolver string) []string {
+	records, err := utils.QueryRecords(name, dns.TypeTXT, resolver)
+	if err != nil {
+		return nil
+	}
+
+	var texts []string
+	for _, rr := range records {
+		if txt, ok := rr.(*dns.TXT); ok {
+			texts = append(texts, strings.Join(txt.Txt, ""))
+		}
+	}
+	return texts
+}
+
+// isSPF reports whether a TXT record is an SPF policy
+func isSPF(txt string) bool {
+	lower := strings.ToLower(txt)
+	return lower == "v=spf1" || strings.HasPrefix(lower, "v=spf1 ")
+}
+
+// parseTags splits a tag=value; list as used by DMARC and DKIM records
+// Tag names are lowercased, values are kept with whitespace removed
+func parseTags(record string) map[string]string {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(record, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		tags[strings.ToLower(strings.TrimSpace(name))] = strings.Join(strings.Fields(value), "")
+	}
+	return tags
+}
+
+// rsaKeyBits returns the size of a base64 RSA public key, 0 for other or invalid keys
+func rsaKeyBits(encoded string) int {
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0
+	}
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		if rsaKey, ok := key.(*rsa.PublicKey); ok {
+			return rsaKey.N.BitLen()
+		}
+		return 0
+	}
+	if key, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return key.N.BitLen()
+	}
+	return 0
+}
@@ -0,0 +1,122 @@
+package interop
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// Result file formats recognized by LoadResultFile
+const (
+	FormatSubcollector = "subcollector" // subcollector JSON output
+	FormatAmass        = "amass"        // amass JSON lines
+	FormatSubfinder    = "subfinder"    // subfinder JSON lines (-oJ)
+	FormatList         = "list"         // one host per line (assetfinder, subfinder -o, ...)
+)
+
+// LoadResultFile reads a result file of another tool (or of subcollector itself)
+// and returns its results with Source set to the detected tool
+func LoadResultFile(path string) ([]models.SubdomainResult, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// subcollector output is a single JSON document, recognized by its subdomains key
+	// since files written before schema_version existed lack that field
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("{")) && json.Valid(trimmed) {
+		var keys map[string]json.RawMessage
+		if json.Unmarshal(trimmed, &keys) == nil {
+			if _, ok := keys["subdomains"]; ok {
+				var document models.OutputJSON
+				if err := json.Unmarshal(trimmed, &document); err != nil {
+					return nil, "", fmt.Errorf("%s: %v", filepath.Base(path), err)
+				}
+				for i := range document.Subdomains {
+					if document.Subdomains[i].Source == "" {
+						document.Subdomains[i].Source = FormatSubcollector
+					}
+				}
+				return document.Subdomains, FormatSubcollector, nil
+			}
+		}
+		// A single pretty-printed object of another tool is read as one line
+		var compact bytes.Buffer
+		if json.Compact(&compact, trimmed) == nil {
+			data = compact.Bytes()
+		}
+	}
+
+	var results []models.SubdomainResult
+	format := FormatList
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(text, "{") {
+			// Plain lists may carry extra columns (host,ip or "host ip")
+			fields := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+			if len(fields) > 0 {
+				results = append(results, models.SubdomainResult{Subdomain: normalizeName(fields[0]), Source: FormatList})
+			}
+			continue
+		}
+
+		result, lineFormat, err := parseToolLine(text)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s line %d: %v", filepath.Base(path), line, err)
+		}
+		if result.Subdomain != "" {
+			format = lineFormat
+			results = append(results, result)
+		}
+	}
+
+	return results, format, scanner.Err()
+}
+
+// parseToolLine parses one amass or subfinder JSON line
+func parseToolLine(text string) (models.SubdomainResult, string, error) {
+	var line struct {
+		// amass
+		Name      string `json:"name"`
+		Addresses []struct {
+			IP string `json:"ip"`
+		} `json:"addresses"`
+		// subfinder
+		Host   string `json:"host"`
+		IP     string `json:"ip"`
+		Source string `json:"source"`
+	}
+	if err := json.Unmarshal([]byte(text), &line); err != nil {
+		return models.SubdomainResult{}, "", err
+	}
+
+	if line.Host != "" {
+		result := models.SubdomainResult{Subdomain: normalizeName(line.Host), Source: FormatSubfinder}
+		if line.IP != "" {
+			result.IPs = []string{line.IP}
+		}
+		if line.Source != "" {
+			result.Source += ":" + strings.ToLower(line.Source)
+		}
+		return result, FormatSubfinder, nil
+	}
+
+	result := models.SubdomainResult{Subdomain: normalizeName(line.Name), Source: FormatAmass}
+	for _, address := range line.Addresses {
+		result.IPs = appendUnique(result.IPs, address.IP)
+	}
+	return result, FormatAmass, nil
+}
@@ -0,0 +1,209 @@
+package interop
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// Record is a single DNS answer read from resolver tool output
+type Record struct {
+	Name string
+	Type string
+	Data string
+}
+
+// LoadResolverOutput reads massdns or zdns output and converts it to results
+// Supported formats are massdns simple text (-o S), massdns JSON (-o J) and zdns JSON lines
+func LoadResolverOutput(path string) ([]models.SubdomainResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := ParseResolverOutput(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return RecordsToResults(records), nil
+}
+
+// ParseResolverOutput parses massdns/zdns output, detecting the format from each line
+func ParseResolverOutput(reader io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "{") {
+			parsed, err := parseJSONLine(text)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			records = append(records, parsed...)
+			continue
+		}
+
+		// massdns simple format: "name. TYPE data"
+		fields := strings.Fields(text)
+		if len(fields) < 3 {
+			continue
+		}
+		records = append(records, Record{
+			Name: normalizeName(fields[0]),
+			Type: strings.ToUpper(fields[1]),
+			Data: strings.Join(fields[2:], " "),
+		})
+	}
+
+	return records, scanner.Err()
+}
+
+// resolverAnswer is an answer in massdns or zdns JSON output
+type resolverAnswer struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Data   string `json:"data"`   // massdns
+	Answer string `json:"answer"` // zdns
+}
+
+// resolverAnswers holds the answer section in massdns or zdns JSON output
+type resolverAnswers struct {
+	Answers []resolverAnswer `json:"answers"`
+}
+
+// parseJSONLine parses one massdns (-o J) or zdns output line
+func parseJSONLine(text string) ([]Record, error) {
+	var line struct {
+		Name    string          `json:"name"`
+		Status  string          `json:"status"`
+		Data    resolverAnswers `json:"data"`
+		Results map[string]struct {
+			Status string          `json:"status"`
+			Data   resolverAnswers `json:"data"`
+		} `json:"results"` // zdns with several modules per line
+	}
+	if err := json.Unmarshal([]byte(text), &line); err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	add := func(status string, answers []resolverAnswer) {
+		if status != "" && status != "NOERROR" {
+			return
+		}
+		for _, answer := range answers {
+			data := answer.Data
+			if data == "" {
+				data = answer.Answer
+			}
+			name := answer.Name
+			if name == "" {
+				name = line.Name
+			}
+			records = append(records, Record{Name: normalizeName(name), Type: strings.ToUpper(answer.Type), Data: data})
+		}
+	}
+
+	add(line.Status, line.Data.Answers)
+	for _, result := range line.Results {
+		add(result.Status, result.Data.Answers)
+	}
+	return records, nil
+}
+
+// RecordsToResults groups records by name into results
+// Names with an address or CNAME answer become results; other record types are ignored
+func RecordsToResults(records []Record) []models.SubdomainResult {
+	byName := make(map[string]*models.SubdomainResult)
+	var names []string
+
+	get := func(name string) *models.SubdomainResult {
+		result, ok := byName[name]
+		if !ok {
+			result = &models.SubdomainResult{Subdomain: name}
+			byName[name] = result
+			names = append(names, name)
+		}
+		return result
+	}
+
+	// CNAMEs are indexed by owner to rebuild chains starting at each name
+	cnames := make(map[string]string)
+	for _, record := range records {
+		switch record.Type {
+		case "A", "AAAA":
+			result := get(record.Name)
+			result.IPs = appendUnique(result.IPs, record.Data)
+		case "CNAME":
+			get(record.Name)
+			cnames[record.Name] = normalizeName(record.Data)
+		}
+	}
+
+	// Answers also carry the records of CNAME targets (CDN hosts and the like), which are
+	// only kept when they belong to the same root domain as a name that was queried
+	targets := make(map[string]bool)
+	for _, target := range cnames {
+		targets[target] = true
+	}
+	roots := make(map[string]bool)
+	for _, name := range names {
+		if !targets[name] {
+			roots[utils.ExtractRootDomain(name)] = true
+		}
+	}
+
+	sort.Strings(names)
+	results := make([]models.SubdomainResult, 0, len(names))
+	for _, name := range names {
+		if targets[name] && !roots[utils.ExtractRootDomain(name)] {
+			continue
+		}
+		result := byName[name]
+		for target, hops := cnames[name], 0; target != "" && hops < 16; target, hops = cnames[target], hops+1 {
+			result.CNAME = append(result.CNAME, target)
+			// Addresses of the final target belong to the name as well
+			if resolved, ok := byName[target]; ok {
+				for _, ip := range resolved.IPs {
+					result.IPs = appendUnique(result.IPs, ip)
+				}
+			}
+		}
+		results = append(results, *result)
+	}
+	return results
+}
+
+// normalizeName lowercases a DNS name and strips the trailing dot
+// Internationalized names are converted to punycode like the names subcollector finds
+func normalizeName(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	if ascii, err := utils.ToASCII(name); err == nil {
+		return ascii
+	}
+	return name
+}
+
+// appendUnique appends a value unless it is already present
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
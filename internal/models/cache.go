@@ -1,7 +1,15 @@
 package models
 
 import (
+	"bufio"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -11,12 +19,129 @@ type DNSResult struct {
 	IPs   []string // Associated IP addresses if the subdomain is found
 }
 
+// Cache types selectable with CacheConfig
+const (
+	CacheMemory = "memory" // Every answer of the scan, kept in memory
+	CacheLRU    = "lru"    // The most recently used answers, each reused until its TTL runs out
+	CacheDisk   = "disk"   // Answers kept in a file and reused by later scans until their TTL runs out
+)
+
+// Cache defaults
+const (
+	DefaultCacheSize        = 10000            // Entries kept by the LRU cache
+	DefaultCacheTTL         = 30 * time.Minute // Time an existing name is reused by the LRU and disk caches
+	DefaultCacheNegativeTTL = 5 * time.Minute  // Time a name that does not exist is reused, short so new records show up
+)
+
+// Cache stores DNS answers shared by the workers of a scan
+type Cache interface {
+	Load(subdomain string) (DNSResult, bool)
+	Store(subdomain string, result DNSResult)
+	Range(fn func(subdomain string, result DNSResult) bool) // Calls fn for each live answer until it returns false
+	Stats() CacheStats
+	Close() error // Writes out what is still buffered, the cache is not used afterwards
+}
+
+// CacheStats counts how a cache was used
+type CacheStats struct {
+	Hits      int64 // Lookups answered by the cache
+	Misses    int64 // Lookups the cache had no answer for, expired answers included
+	Evictions int64 // Answers dropped to make room or because their TTL ran out
+}
+
+// CacheCounters counts the use of a cache, safe for concurrent use
+// Caches embed it to provide Stats
+type CacheCounters struct {
+	hits, misses, evictions atomic.Int64
+}
+
+// Count records a lookup as a hit or a miss and returns hit
+func (c *CacheCounters) Count(hit bool) bool {
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return hit
+}
+
+// Evict records dropped answers
+func (c *CacheCounters) Evict(n int) {
+	c.evictions.Add(int64(n))
+}
+
+// Stats returns the counts so far
+func (c *CacheCounters) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Evictions: c.evictions.Load()}
+}
+
+// CacheConfig selects and sizes the cache of a scan
+type CacheConfig struct {
+	Type        string        // One of the Cache constants, CacheMemory when empty
+	Size        int           // Entries kept by CacheLRU (DefaultCacheSize when 0)
+	TTL         time.Duration // Time an existing name is reused by CacheLRU and CacheDisk (DefaultCacheTTL when 0)
+	NegativeTTL time.Duration // Time a name that does not exist is reused (DefaultCacheNegativeTTL when 0)
+	Path        string        // File of CacheDisk
+	Import      string        // JSON file written by ExportCache whose answers seed the cache
+	Export      string        // JSON file receiving the answers of the cache at the end of the scan
+}
+
+// Validate checks the cache type and its settings
+func (c CacheConfig) Validate() error {
+	switch c.Type {
+	case "", CacheMemory, CacheLRU:
+	case CacheDisk:
+		if c.Path == "" {
+			return fmt.Errorf("the disk cache needs a file")
+		}
+	default:
+		return fmt.Errorf("unknown cache %q, use memory, lru or disk", c.Type)
+	}
+	if c.Size < 0 {
+		return fmt.Errorf("invalid cache size %d, must not be negative", c.Size)
+	}
+	if c.TTL < 0 {
+		return fmt.Errorf("invalid cache TTL %s, must not be negative", c.TTL)
+	}
+	if c.NegativeTTL < 0 {
+		return fmt.Errorf("invalid negative cache TTL %s, must not be negative", c.NegativeTTL)
+	}
+	return nil
+}
+
+// NewCache creates the cache described by config
+func NewCache(config CacheConfig) (Cache, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	size, ttl, negativeTTL := config.Size, config.TTL, config.NegativeTTL
+	if size == 0 {
+		size = DefaultCacheSize
+	}
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	if negativeTTL == 0 {
+		negativeTTL = DefaultCacheNegativeTTL
+	}
+
+	switch config.Type {
+	case CacheLRU:
+		return NewDNSCacheWithLRU(size, ttl, negativeTTL), nil
+	case CacheDisk:
+		return NewDiskDNSCache(config.Path, ttl, negativeTTL)
+	default:
+		return NewDNSCache(), nil
+	}
+}
+
 //
 // Basic DNS Cache Implementation
 //
 
 // DNSCache provides a thread-safe cache for DNS results using sync.Map
 type DNSCache struct {
+	CacheCounters
 	cache *sync.Map
 }
 
@@ -35,89 +160,123 @@ func (c *DNSCache) Store(subdomain string, result DNSResult) {
 // Load retrieves a DNS result from the cache
 func (c *DNSCache) Load(subdomain string) (DNSResult, bool) {
 	val, ok := c.cache.Load(subdomain)
-	if !ok {
+	if !c.Count(ok) {
 		return DNSResult{}, false
 	}
 	return val.(DNSResult), true
 }
 
+// Range calls fn for each cached result until it returns false
+func (c *DNSCache) Range(fn func(subdomain string, result DNSResult) bool) {
+	c.cache.Range(func(key, value interface{}) bool {
+		return fn(key.(string), value.(DNSResult))
+	})
+}
+
+// Close does nothing, the cache only lives in memory
+func (c *DNSCache) Close() error {
+	return nil
+}
+
 //
 // Advanced LRU Cache Implementation with TTL
 //
 
-// CacheEntry represents an individual cache entry with TTL
-type CacheEntry struct {
-	Data      interface{}
-	ExpiresAt time.Time
+// lruEntry is an entry of LRUCache with its expiry
+type lruEntry struct {
+	key       string
+	data      interface{}
+	expiresAt time.Time
 }
 
 // LRUCache is a thread-safe implementation of an LRU cache with TTL
 type LRUCache struct {
-	cache       map[string]CacheEntry
-	mutex       *sync.RWMutex
-	capacity    int
-	accessOrder []string
-	ttl         time.Duration
+	mutex     sync.Mutex
+	capacity  int
+	ttl       time.Duration
+	items     map[string]*list.Element
+	order     *list.List // Most recently used entries first
+	evictions int64      // Entries dropped to make room or because they expired
 }
 
 // NewLRUCache creates a new instance of LRUCache with a specified capacity and TTL
 func NewLRUCache(capacity int, ttl time.Duration) *LRUCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
 	return &LRUCache{
-		cache:       make(map[string]CacheEntry, capacity),
-		mutex:       &sync.RWMutex{},
-		capacity:    capacity,
-		accessOrder: make([]string, 0, capacity),
-		ttl:         ttl,
+		capacity: capacity,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element, capacity),
+		order:    list.New(),
 	}
 }
 
-// Set stores a value in the cache
+// Set stores a value in the cache, evicting the least recently used entry when full
 func (c *LRUCache) Set(key string, value interface{}) {
+	c.SetWithTTL(key, value, c.ttl)
+}
+
+// SetWithTTL stores a value in the cache that expires after ttl instead of the cache TTL
+func (c *LRUCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	// If the key exists, update the value and move it to the end of access order
-	if _, exists := c.cache[key]; exists {
-		c.removeFromAccessOrder(key)
-	} else if len(c.cache) >= c.capacity {
-		// If the cache is full, evict the LRU item
-		c.evictLRU()
+	expiresAt := time.Now().Add(ttl)
+	if element, exists := c.items[key]; exists {
+		entry := element.Value.(*lruEntry)
+		entry.data, entry.expiresAt = value, expiresAt
+		c.order.MoveToFront(element)
+		return
 	}
 
-	// Add or update entry
-	c.cache[key] = CacheEntry{
-		Data:      value,
-		ExpiresAt: time.Now().Add(c.ttl),
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+		c.evictions++
 	}
-	c.accessOrder = append(c.accessOrder, key)
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, data: value, expiresAt: expiresAt})
 }
 
 // Get retrieves a value from the cache, returning nil if not found or expired
 func (c *LRUCache) Get(key string) (interface{}, bool) {
-	c.mutex.RLock()
-	entry, exists := c.cache[key]
-	c.mutex.RUnlock()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
+	element, exists := c.items[key]
 	if !exists {
 		return nil, false
 	}
-
-	// Check if the entry is expired
-	if time.Now().After(entry.ExpiresAt) {
-		c.mutex.Lock()
-		delete(c.cache, key)
-		c.removeFromAccessOrder(key)
-		c.mutex.Unlock()
+	entry := element.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.items, key)
+		c.evictions++
 		return nil, false
 	}
+	c.order.MoveToFront(element)
+	return entry.data, true
+}
 
-	// Update access order
+// Range calls fn for each entry that has not expired, most recently used first,
+// until it returns false. fn runs on a copy, so it may use the cache
+func (c *LRUCache) Range(fn func(key string, value interface{}) bool) {
 	c.mutex.Lock()
-	c.removeFromAccessOrder(key)
-	c.accessOrder = append(c.accessOrder, key)
+	now := time.Now()
+	var entries []lruEntry
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		if entry := element.Value.(*lruEntry); !now.After(entry.expiresAt) {
+			entries = append(entries, *entry)
+		}
+	}
 	c.mutex.Unlock()
 
-	return entry.Data, true
+	for _, entry := range entries {
+		if !fn(entry.key, entry.data) {
+			return
+		}
+	}
 }
 
 // Cleanup removes expired cache entries
@@ -126,74 +285,299 @@ func (c *LRUCache) Cleanup() {
 	defer c.mutex.Unlock()
 
 	now := time.Now()
-	for key, entry := range c.cache {
-		if now.After(entry.ExpiresAt) {
-			delete(c.cache, key)
-			c.removeFromAccessOrder(key)
+	for key, element := range c.items {
+		if now.After(element.Value.(*lruEntry).expiresAt) {
+			c.order.Remove(element)
+			delete(c.items, key)
+			c.evictions++
 		}
 	}
 }
 
 // GetSize returns the number of entries in the cache
 func (c *LRUCache) GetSize() int {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
-	return len(c.cache)
-}
-
-// removeFromAccessOrder removes a key from the accessOrder list
-func (c *LRUCache) removeFromAccessOrder(key string) {
-	for i, k := range c.accessOrder {
-		if k == key {
-			c.accessOrder = append(c.accessOrder[:i], c.accessOrder[i+1:]...)
-			break
-		}
-	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.items)
 }
 
-// evictLRU removes the least recently used item
-func (c *LRUCache) evictLRU() {
-	if len(c.accessOrder) == 0 {
-		return
-	}
-
-	lruKey := c.accessOrder[0]
-	delete(c.cache, lruKey)
-	c.accessOrder = c.accessOrder[1:]
+// Evictions returns the number of entries dropped to make room or because they expired
+func (c *LRUCache) Evictions() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.evictions
 }
 
 // DNSCacheWithLRU implements an LRU-based DNS cache
+// Expired entries are dropped when they are looked up or evicted
 type DNSCacheWithLRU struct {
-	cache *LRUCache
+	counters    CacheCounters
+	cache       *LRUCache
+	negativeTTL time.Duration
 }
 
-// NewDNSCacheWithLRU creates an LRU-based DNS cache
-func NewDNSCacheWithLRU(capacity int, ttl time.Duration) *DNSCacheWithLRU {
+// NewDNSCacheWithLRU creates an LRU-based DNS cache keeping existing names for ttl
+// and names that do not exist for negativeTTL
+func NewDNSCacheWithLRU(capacity int, ttl, negativeTTL time.Duration) *DNSCacheWithLRU {
 	return &DNSCacheWithLRU{
-		cache: NewLRUCache(capacity, ttl),
+		cache:       NewLRUCache(capacity, ttl),
+		negativeTTL: negativeTTL,
 	}
 }
 
 // Store saves the DNS result in the cache
 func (c *DNSCacheWithLRU) Store(subdomain string, result DNSResult) {
+	if !result.Found {
+		c.cache.SetWithTTL(subdomain, result, c.negativeTTL)
+		return
+	}
 	c.cache.Set(subdomain, result)
 }
 
 // Load retrieves a DNS result from the cache
 func (c *DNSCacheWithLRU) Load(subdomain string) (DNSResult, bool) {
 	val, ok := c.cache.Get(subdomain)
-	if !ok {
+	if !c.counters.Count(ok) {
 		return DNSResult{}, false
 	}
 	return val.(DNSResult), true
 }
 
-// StartCleanup starts automatic cache cleanup
-func (c *DNSCacheWithLRU) StartCleanup(interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	go func() {
-		for range ticker.C {
-			c.cache.Cleanup()
+// Range calls fn for each cached result that has not expired until it returns false
+func (c *DNSCacheWithLRU) Range(fn func(subdomain string, result DNSResult) bool) {
+	c.cache.Range(func(key string, value interface{}) bool {
+		return fn(key, value.(DNSResult))
+	})
+}
+
+// Stats returns the hits and misses of the cache and the evictions of its LRU cache
+func (c *DNSCacheWithLRU) Stats() CacheStats {
+	stats := c.counters.Stats()
+	stats.Evictions = c.cache.Evictions()
+	return stats
+}
+
+// Close does nothing, the cache only lives in memory
+func (c *DNSCacheWithLRU) Close() error {
+	return nil
+}
+
+//
+// Persistent DNS Cache Implementation
+//
+
+// diskEntry is a line of the disk cache file
+type diskEntry struct {
+	Name      string    `json:"name"`
+	Found     bool      `json:"found"`
+	IPs       []string  `json:"ips,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DiskDNSCache keeps DNS results in a JSON lines file so later scans reuse them
+// until their TTL runs out. Entries are also held in memory for lookups
+type DiskDNSCache struct {
+	CacheCounters
+	entries     sync.Map // Name -> *diskEntry, a pointer so expired entries can be deleted with CompareAndDelete
+	ttl         time.Duration
+	negativeTTL time.Duration
+	mu          sync.Mutex
+	file        *os.File
+	writer      *bufio.Writer
+	err         error
+}
+
+// NewDiskDNSCache opens or creates the cache file at path, keeping existing names
+// for ttl and names that do not exist for negativeTTL
+// Expired and unreadable lines are dropped and the file is rewritten with the rest;
+// entries written with longer TTLs are shortened to the current ones
+func NewDiskDNSCache(path string, ttl, negativeTTL time.Duration) (*DiskDNSCache, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache file: %v", err)
+	}
+	c := &DiskDNSCache{ttl: ttl, negativeTTL: negativeTTL, file: file}
+
+	// Later lines of a name replace earlier ones
+	now := time.Now()
+	var order []string
+	latest := make(map[string]diskEntry)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry diskEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil || entry.Name == "" {
+			continue
+		}
+		if _, seen := latest[entry.Name]; !seen {
+			order = append(order, entry.Name)
+		}
+		latest[entry.Name] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read cache file: %v", err)
+	}
+
+	if err := file.Truncate(0); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to rewrite cache file: %v", err)
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to rewrite cache file: %v", err)
+	}
+	c.writer = bufio.NewWriter(file)
+	for _, name := range order {
+		entry := latest[name]
+		if limit := now.Add(c.ttlOf(entry.Found)); entry.ExpiresAt.After(limit) {
+			entry.ExpiresAt = limit
 		}
-	}()
+		if entry.ExpiresAt.After(now) {
+			c.entries.Store(name, &entry)
+			c.write(entry)
+		}
+	}
+	// The file was truncated, so the kept entries must not wait in the buffer until Close
+	if err := c.writer.Flush(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to rewrite cache file: %v", err)
+	}
+	return c, nil
+}
+
+// ttlOf returns the TTL of an existing name or of a name that does not exist
+func (c *DiskDNSCache) ttlOf(found bool) time.Duration {
+	if found {
+		return c.ttl
+	}
+	return c.negativeTTL
+}
+
+// write appends an entry to the file, keeping the first error
+func (c *DiskDNSCache) write(entry diskEntry) {
+	line, err := json.Marshal(entry)
+	if err == nil {
+		line = append(line, '\n')
+		_, err = c.writer.Write(line)
+	}
+	if err != nil && c.err == nil {
+		c.err = err
+	}
+}
+
+// Store saves the DNS result in the cache and appends it to the file
+func (c *DiskDNSCache) Store(subdomain string, result DNSResult) {
+	entry := diskEntry{Name: subdomain, Found: result.Found, IPs: result.IPs, ExpiresAt: time.Now().Add(c.ttlOf(result.Found))}
+	c.entries.Store(subdomain, &entry)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.writer != nil {
+		c.write(entry)
+	}
+}
+
+// Load retrieves a DNS result from the cache, unless it expired
+func (c *DiskDNSCache) Load(subdomain string) (DNSResult, bool) {
+	val, ok := c.entries.Load(subdomain)
+	if !ok {
+		c.Count(false)
+		return DNSResult{}, false
+	}
+	entry := val.(*diskEntry)
+	if time.Now().After(entry.ExpiresAt) {
+		if c.entries.CompareAndDelete(subdomain, val) {
+			c.Evict(1)
+		}
+		c.Count(false)
+		return DNSResult{}, false
+	}
+	c.Count(true)
+	return DNSResult{Found: entry.Found, IPs: entry.IPs}, true
+}
+
+// Range calls fn for each cached result that has not expired until it returns false
+func (c *DiskDNSCache) Range(fn func(subdomain string, result DNSResult) bool) {
+	now := time.Now()
+	c.entries.Range(func(key, value interface{}) bool {
+		entry := value.(*diskEntry)
+		if now.After(entry.ExpiresAt) {
+			return true
+		}
+		return fn(entry.Name, DNSResult{Found: entry.Found, IPs: entry.IPs})
+	})
+}
+
+// Close writes out the buffered entries and closes the file
+// Returns the first write error of the cache
+func (c *DiskDNSCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.writer == nil {
+		return c.err
+	}
+	if err := c.writer.Flush(); err != nil && c.err == nil {
+		c.err = err
+	}
+	if err := c.file.Close(); err != nil && c.err == nil {
+		c.err = err
+	}
+	c.file, c.writer = nil, nil
+	return c.err
+}
+
+//
+// Cache Export and Import
+//
+
+// CacheExportEntry is an answer of a cache export file
+type CacheExportEntry struct {
+	Name  string   `json:"name"`
+	Found bool     `json:"found"`
+	IPs   []string `json:"ips,omitempty"`
+}
+
+// ExportCache writes the live answers of a cache to a JSON file, sorted by name,
+// so later stages of a pipeline can reuse them. Returns the number of answers written
+func ExportCache(cache Cache, path string) (int, error) {
+	entries := []CacheExportEntry{}
+	cache.Range(func(subdomain string, result DNSResult) bool {
+		entries = append(entries, CacheExportEntry{Name: subdomain, Found: result.Found, IPs: result.IPs})
+		return true
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write cache export: %v", err)
+	}
+	return len(entries), nil
+}
+
+// ImportCache stores the answers of a file written by ExportCache in a cache
+// Returns the number of answers stored
+func ImportCache(cache Cache, path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache import: %v", err)
+	}
+	var entries []CacheExportEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, fmt.Errorf("invalid cache import %s: %v", path, err)
+	}
+
+	stored := 0
+	for _, entry := range entries {
+		name := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(entry.Name), "."))
+		if name == "" {
+			continue
+		}
+		cache.Store(name, DNSResult{Found: entry.Found, IPs: entry.IPs})
+		stored++
+	}
+	return stored, nil
 }
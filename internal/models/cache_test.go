@@ -0,0 +1,246 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheConfigValidate(t *testing.T) {
+	tests := []struct {
+		config CacheConfig
+		valid  bool
+	}{
+		{CacheConfig{}, true},
+		{CacheConfig{Type: CacheMemory}, true},
+		{CacheConfig{Type: CacheLRU, Size: 100, TTL: time.Minute}, true},
+		{CacheConfig{Type: CacheDisk, Path: "cache.jsonl"}, true},
+		{CacheConfig{Type: CacheDisk}, false},
+		{CacheConfig{Type: "redis"}, false},
+		{CacheConfig{Type: CacheLRU, Size: -1}, false},
+		{CacheConfig{Type: CacheLRU, TTL: -time.Second}, false},
+		{CacheConfig{Type: CacheLRU, NegativeTTL: -time.Second}, false},
+	}
+	for _, test := range tests {
+		if err := test.config.Validate(); (err == nil) != test.valid {
+			t.Errorf("Validate(%+v) = %v, want valid %v", test.config, err, test.valid)
+		}
+	}
+}
+
+func TestNewCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.jsonl")
+	tests := []struct {
+		config CacheConfig
+		want   string
+	}{
+		{CacheConfig{}, "*models.DNSCache"},
+		{CacheConfig{Type: CacheLRU}, "*models.DNSCacheWithLRU"},
+		{CacheConfig{Type: CacheDisk, Path: path}, "*models.DiskDNSCache"},
+	}
+	for _, test := range tests {
+		cache, err := NewCache(test.config)
+		if err != nil {
+			t.Errorf("NewCache(%+v) failed: %v", test.config, err)
+			continue
+		}
+		if got := fmt.Sprintf("%T", cache); got != test.want {
+			t.Errorf("NewCache(%+v) = %s, want %s", test.config, got, test.want)
+		}
+		cache.Close()
+	}
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	cache := NewLRUCache(2, time.Hour)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("a") // a is now the most recently used
+	cache.Set("c", 3)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := cache.Get(key); ok != want {
+			t.Errorf("Get(%q) found %v, want %v", key, ok, want)
+		}
+	}
+	if size, evictions := cache.GetSize(), cache.Evictions(); size != 2 || evictions != 1 {
+		t.Errorf("size %d and %d evictions, want 2 and 1", size, evictions)
+	}
+}
+
+func TestLRUCacheExpiry(t *testing.T) {
+	cache := NewLRUCache(10, time.Hour)
+	cache.Set("live", 1)
+	cache.SetWithTTL("expired", 2, -time.Second)
+	cache.SetWithTTL("stale", 3, -time.Second)
+
+	var keys []string
+	cache.Range(func(key string, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if strings.Join(keys, ",") != "live" {
+		t.Errorf("Range visited %v, want [live]", keys)
+	}
+	if _, ok := cache.Get("expired"); ok {
+		t.Error("Get returned an expired entry")
+	}
+	cache.Cleanup()
+	if size, evictions := cache.GetSize(), cache.Evictions(); size != 1 || evictions != 2 {
+		t.Errorf("size %d and %d evictions after Cleanup, want 1 and 2", size, evictions)
+	}
+}
+
+func TestDNSCacheWithLRUStats(t *testing.T) {
+	cache := NewDNSCacheWithLRU(1, time.Hour, time.Hour)
+	cache.Store("www.example.com", DNSResult{Found: true, IPs: []string{"192.0.2.1"}})
+	if result, ok := cache.Load("www.example.com"); !ok || result.IPs[0] != "192.0.2.1" {
+		t.Fatalf("Load = %+v, %v", result, ok)
+	}
+	cache.Store("api.example.com", DNSResult{Found: true})
+	if _, ok := cache.Load("www.example.com"); ok {
+		t.Fatal("Load returned an evicted entry")
+	}
+	if stats := cache.Stats(); stats != (CacheStats{Hits: 1, Misses: 1, Evictions: 1}) {
+		t.Errorf("Stats = %+v, want 1 hit, 1 miss and 1 eviction", stats)
+	}
+}
+
+func TestDiskDNSCacheReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.jsonl")
+	cache, err := NewDiskDNSCache(path, time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("NewDiskDNSCache failed: %v", err)
+	}
+	cache.Store("www.example.com", DNSResult{Found: true, IPs: []string{"192.0.2.1"}})
+	cache.Store("www.example.com", DNSResult{Found: true, IPs: []string{"192.0.2.2"}})
+	cache.Store("nx.example.com", DNSResult{Found: false})
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Unreadable and expired lines are dropped on the next open
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString("not json\n")
+	file.WriteString(`{"name":"old.example.com","found":true,"expires_at":"2000-01-01T00:00:00Z"}` + "\n")
+	file.Close()
+
+	cache, err = NewDiskDNSCache(path, time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("reopening the cache failed: %v", err)
+	}
+	defer cache.Close()
+	if result, ok := cache.Load("www.example.com"); !ok || !result.Found || len(result.IPs) != 1 || result.IPs[0] != "192.0.2.2" {
+		t.Errorf("Load(www) = %+v, %v, want the last stored answer", result, ok)
+	}
+	if result, ok := cache.Load("nx.example.com"); !ok || result.Found {
+		t.Errorf("Load(nx) = %+v, %v, want a cached NXDOMAIN", result, ok)
+	}
+	if _, ok := cache.Load("old.example.com"); ok {
+		t.Error("Load returned an expired entry")
+	}
+
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("cache file has %d lines after reopening, want 2:\n%s", lines, data)
+	}
+}
+
+func TestExportImportCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.json")
+	source := NewDNSCache()
+	source.Store("www.example.com", DNSResult{Found: true, IPs: []string{"192.0.2.1"}})
+	source.Store("nx.example.com", DNSResult{Found: false})
+	if n, err := ExportCache(source, path); err != nil || n != 2 {
+		t.Fatalf("ExportCache = %d, %v, want 2", n, err)
+	}
+
+	target := NewDNSCacheWithLRU(10, time.Hour, time.Hour)
+	if n, err := ImportCache(target, path); err != nil || n != 2 {
+		t.Fatalf("ImportCache = %d, %v, want 2", n, err)
+	}
+	if result, ok := target.Load("www.example.com"); !ok || result.IPs[0] != "192.0.2.1" {
+		t.Errorf("Load(www) = %+v, %v after import", result, ok)
+	}
+	if result, ok := target.Load("nx.example.com"); !ok || result.Found {
+		t.Errorf("Load(nx) = %+v, %v after import", result, ok)
+	}
+}
+
+func TestDiskDNSCacheExpiredLoad(t *testing.T) {
+	cache, err := NewDiskDNSCache(filepath.Join(t.TempDir(), "cache.jsonl"), 10*time.Millisecond, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewDiskDNSCache failed: %v", err)
+	}
+	defer cache.Close()
+	cache.Store("www.example.com", DNSResult{Found: true, IPs: []string{"192.0.2.1"}})
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := cache.Load("www.example.com"); ok {
+		t.Fatal("Load returned an expired entry")
+	}
+	if stats := cache.Stats(); stats != (CacheStats{Misses: 1, Evictions: 1}) {
+		t.Errorf("Stats = %+v, want 1 miss and 1 eviction", stats)
+	}
+}
+
+func TestDNSCacheWithLRUNegativeTTL(t *testing.T) {
+	cache := NewDNSCacheWithLRU(10, time.Hour, 10*time.Millisecond)
+	cache.Store("www.example.com", DNSResult{Found: true})
+	cache.Store("new.example.com", DNSResult{Found: false})
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := cache.Load("www.example.com"); !ok {
+		t.Error("an existing name expired with the negative TTL")
+	}
+	if _, ok := cache.Load("new.example.com"); ok {
+		t.Error("a name that did not exist outlived the negative TTL")
+	}
+}
+
+func TestDiskDNSCacheNegativeTTL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.jsonl")
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	os.WriteFile(path, []byte(fmt.Sprintf(
+		"{\"name\":\"www.example.com\",\"found\":true,\"expires_at\":%q}\n{\"name\":\"new.example.com\",\"found\":false,\"expires_at\":%q}\n",
+		expires, expires)), 0644)
+
+	// Entries written with a longer negative TTL are shortened to the current one
+	cache, err := NewDiskDNSCache(path, time.Hour, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewDiskDNSCache failed: %v", err)
+	}
+	defer cache.Close()
+	cache.Store("gone.example.com", DNSResult{Found: false})
+	time.Sleep(20 * time.Millisecond)
+
+	tests := []struct {
+		name  string
+		found bool
+	}{
+		{"www.example.com", true},
+		{"new.example.com", false},
+		{"gone.example.com", false},
+	}
+	for _, test := range tests {
+		if _, ok := cache.Load(test.name); ok != test.found {
+			t.Errorf("Load(%q) found %v, want %v", test.name, ok, test.found)
+		}
+	}
+	if stats := cache.Stats(); stats.Evictions != 2 {
+		t.Errorf("%d evictions, want the 2 expired names", stats.Evictions)
+	}
+}
+
+func TestNewCacheNegativeTTLDefault(t *testing.T) {
+	cache, err := NewCache(CacheConfig{Type: CacheLRU})
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+	if ttl := cache.(*DNSCacheWithLRU).negativeTTL; ttl != DefaultCacheNegativeTTL {
+		t.Errorf("negative TTL = %s, want %s", ttl, DefaultCacheNegativeTTL)
+	}
+}
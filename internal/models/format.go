@@ -0,0 +1,83 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// LineFormat renders one line of text output per result from a Go template
+// such as '{{.Subdomain}},{{join .IPs ";"}},{{.Takeover}}'
+// Fields are those of SubdomainResult; a nil LineFormat renders the subdomain alone
+type LineFormat struct {
+	tmpl *template.Template
+}
+
+// lineFuncs are the functions available to line templates
+var lineFuncs = template.FuncMap{
+	"join":    joinValues,
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"unicode": utils.ToUnicode,
+}
+
+// ParseLineFormat compiles a line template, an empty spec returns nil
+// The template is tried on an empty result and on one with every optional section
+// set, so unknown fields and sections used without {{with}} are rejected upfront
+func ParseLineFormat(spec string) (*LineFormat, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.New("format").Funcs(lineFuncs).Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %v", err)
+	}
+
+	format := &LineFormat{tmpl: tmpl}
+	samples := []SubdomainResult{
+		{},
+		{TLS: &CertInfo{}, Consensus: &Consensus{}, HTTP: &HTTPInfo{}, Email: &EmailPosture{}},
+	}
+	for _, sample := range samples {
+		_, err := format.Render(sample)
+		if err != nil && strings.Contains(err.Error(), "nil pointer") {
+			return nil, fmt.Errorf("invalid format: %v (wrap optional sections such as .TLS or .HTTP in {{with}})", err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid format: %v", err)
+		}
+	}
+	return format, nil
+}
+
+// Render returns the line of a result, without trailing newline
+func (f *LineFormat) Render(result SubdomainResult) (string, error) {
+	if f == nil {
+		return result.Subdomain, nil
+	}
+
+	var buf bytes.Buffer
+	if err := f.tmpl.Execute(&buf, result); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(buf.String(), "\r\n"), nil
+}
+
+// joinValues joins the elements of any slice, such as IPs or Ports, with sep
+func joinValues(values interface{}, sep string) string {
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Sprint(values)
+	}
+
+	parts := make([]string, v.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(parts, sep)
+}
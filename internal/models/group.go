@@ -0,0 +1,99 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Group kinds
+const (
+	GroupByIP       = "ip"       // Hosts resolving to the same address
+	GroupByCNAME    = "cname"    // Hosts whose CNAME chain ends at the same target
+	GroupByProvider = "provider" // Hosts served by the same detected provider
+	GroupByFavicon  = "favicon"  // Hosts serving the same favicon, usually the same application
+	GroupByTitle    = "title"    // Hosts serving pages with the same title
+	GroupByTech     = "tech"     // Hosts running the same detected technology
+)
+
+// HostGroup lists the subdomains sharing one piece of infrastructure
+type HostGroup struct {
+	Kind       string   `json:"kind"` // ip, cname, provider, favicon, title or tech
+	Key        string   `json:"key"`  // Address, CNAME target, provider name, favicon hash, page title or technology
+	Count      int      `json:"count"`
+	Subdomains []string `json:"subdomains"`
+}
+
+// HostGrouper builds host groups incrementally so streamed results can be grouped too
+type HostGrouper struct {
+	groups map[string]*HostGroup
+}
+
+// NewHostGrouper creates an empty HostGrouper
+func NewHostGrouper() *HostGrouper {
+	return &HostGrouper{groups: make(map[string]*HostGroup)}
+}
+
+// Add records a result in every group it belongs to
+func (g *HostGrouper) Add(result SubdomainResult) {
+	for _, ip := range result.IPs {
+		g.add(GroupByIP, ip, result.Subdomain)
+	}
+	if len(result.CNAME) > 0 {
+		g.add(GroupByCNAME, result.CNAME[len(result.CNAME)-1], result.Subdomain)
+	}
+	if result.Provider != "" {
+		g.add(GroupByProvider, result.Provider, result.Subdomain)
+	}
+	if result.HTTP != nil && result.HTTP.FaviconHash != 0 {
+		g.add(GroupByFavicon, strconv.Itoa(int(result.HTTP.FaviconHash)), result.Subdomain)
+	}
+	if result.HTTP != nil && result.HTTP.Title != "" {
+		g.add(GroupByTitle, result.HTTP.Title, result.Subdomain)
+	}
+	if result.HTTP != nil {
+		for _, tech := range result.HTTP.Technologies {
+			g.add(GroupByTech, tech.Name, result.Subdomain)
+		}
+	}
+}
+
+// add appends a subdomain to the group identified by kind and key
+func (g *HostGrouper) add(kind, key, subdomain string) {
+	id := kind + "|" + key
+	group, ok := g.groups[id]
+	if !ok {
+		group = &HostGroup{Kind: kind, Key: key}
+		g.groups[id] = group
+	}
+	group.Subdomains = append(group.Subdomains, subdomain)
+	group.Count++
+}
+
+// Groups returns the groups, largest first
+func (g *HostGrouper) Groups() []HostGroup {
+	groups := make([]HostGroup, 0, len(g.groups))
+	for _, group := range g.groups {
+		sort.Strings(group.Subdomains)
+		groups = append(groups, *group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		if groups[i].Kind != groups[j].Kind {
+			return groups[i].Kind < groups[j].Kind
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+// GroupResults groups results by IP address, CNAME target, provider, favicon, page title and technology
+func GroupResults(results []SubdomainResult) []HostGroup {
+	grouper := NewHostGrouper()
+	for _, result := range results {
+		grouper.Add(result)
+	}
+	return grouper.Groups()
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// MergeResults combines results from several scans or tools, keeping one entry per subdomain
+// IP addresses are combined, other fields are taken from the first set that carries them,
+// and the sources of every set are listed in Source
+func MergeResults(sets ...[]SubdomainResult) []SubdomainResult {
+	var merged []SubdomainResult
+	index := make(map[string]int)
+
+	for _, set := range sets {
+		for _, result := range set {
+			result.Subdomain = strings.ToLower(strings.TrimSuffix(result.Subdomain, "."))
+			i, exists := index[result.Subdomain]
+			if !exists {
+				index[result.Subdomain] = len(merged)
+				merged = append(merged, result)
+				continue
+			}
+
+			existing := &merged[i]
+			for _, ip := range result.IPs {
+				if !containsString(existing.IPs, ip) {
+					existing.IPs = append(existing.IPs, ip)
+				}
+			}
+			if existing.Takeover == "" {
+				existing.Takeover = result.Takeover
+			}
+			if existing.DNSSEC == "" {
+				existing.DNSSEC = result.DNSSEC
+			}
+			if existing.TLS == nil {
+				existing.TLS = result.TLS
+			}
+			if existing.HTTP == nil {
+				existing.HTTP = result.HTTP
+			}
+			if len(existing.Dangling) == 0 {
+				existing.Dangling = result.Dangling
+			}
+			if existing.Resolution == "" {
+				existing.Resolution = result.Resolution
+			}
+			if len(existing.Ports) == 0 {
+				existing.Ports = result.Ports
+			}
+			if len(existing.CNAME) == 0 {
+				existing.CNAME = result.CNAME
+			}
+			if existing.Provider == "" {
+				existing.Provider = result.Provider
+				existing.Region = result.Region
+			}
+			if len(existing.Networks) == 0 {
+				existing.Networks = result.Networks
+			}
+			existing.Source = mergeSources(existing.Source, result.Source)
+			existing.Tags = AddTags(existing.Tags, result.Tags...)
+			if existing.Note == "" {
+				existing.Note = result.Note
+			}
+		}
+	}
+
+	return merged
+}
+
+// mergeSources combines two comma separated source lists
+func mergeSources(a, b string) string {
+	if b == "" || a == b {
+		return a
+	}
+	if a == "" {
+		return b
+	}
+
+	var sources []string
+	for _, source := range strings.Split(a+","+b, ",") {
+		if source != "" && !containsString(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+	sort.Strings(sources)
+	return strings.Join(sources, ",")
+}
+
+// containsString reports whether a slice contains a value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -1,14 +1,237 @@
 package models
 
+import "time"
+
+// OutputSchemaVersion identifies the layout of OutputJSON
+// It only changes when existing fields are renamed, removed or change meaning
+const OutputSchemaVersion = "1.0"
+
 // SubdomainResult represents the result of discovering a subdomain with its associated data
 type SubdomainResult struct {
-	Subdomain string   `json:"subdomain"`          // The discovered subdomain
-	IPs       []string `json:"ips,omitempty"`      // Associated IP addresses for the subdomain
-	Takeover  string   `json:"takeover,omitempty"` // Potential takeover vulnerability
+	Subdomain    string     `json:"subdomain"`             // The discovered subdomain
+	IPs          []string   `json:"ips,omitempty"`         // Associated IP addresses for the subdomain
+	TTL          uint32     `json:"ttl,omitempty"`         // Lowest TTL of the address records, in seconds
+	ResponseTime float64    `json:"response_ms,omitempty"` // Time the resolver took to answer, in milliseconds
+	Takeover     string     `json:"takeover,omitempty"`    // Potential takeover vulnerability
+	DNSSEC       string     `json:"dnssec,omitempty"`      // DNSSEC validation status (secure, insecure, bogus, indeterminate)
+	Source       string     `json:"source,omitempty"`      // How the subdomain was found when not by brute force (example: srv:_sip._tcp.example.com), comma separated when several
+	TLS          *CertInfo  `json:"tls,omitempty"`         // Certificate served on port 443
+	Ports        []int      `json:"ports,omitempty"`       // Open TCP ports found on the resolved addresses
+	CNAME        []string   `json:"cname,omitempty"`       // CNAME chain, in resolution order
+	Provider     string     `json:"provider,omitempty"`    // Hosting/CDN provider detected from the CNAME chain or resolved IPs
+	Region       string     `json:"region,omitempty"`      // Cloud region of the resolved IPs, when published by the provider
+	Evidence     string     `json:"evidence,omitempty"`    // File holding the HTTP exchange and CNAME chain behind a takeover finding
+	Unverified   bool       `json:"unverified,omitempty"`  // Answered by a resolver later caught lying and not confirmed by another one
+	Consensus    *Consensus `json:"consensus,omitempty"`   // Outcome of the consensus pass, only kept when some resolvers disagreed
+	HTTP         *HTTPInfo  `json:"http,omitempty"`        // Fingerprint of the web server answering on the host
+
+	Dangling   []DanglingRecord `json:"dangling,omitempty"`   // Records pointing at resources that no longer exist
+	Email      *EmailPosture    `json:"email,omitempty"`      // Mail records and policy weaknesses, only set for hosts publishing mail records
+	Networks   []NetworkInfo    `json:"networks,omitempty"`   // Registered networks of the resolved IPs, looked up over RDAP
+	Resolution string           `json:"resolution,omitempty"` // Whether a passive finding still resolves, only set when verified
+	Tags       []string         `json:"tags,omitempty"`       // Labels given with --tag or subcollector annotate (example: triaged, out-of-scope)
+	Note       string           `json:"note,omitempty"`       // Free text attached with subcollector annotate
+	Severity   string           `json:"severity,omitempty"`   // Severity of the most severe finding, info for a plain subdomain (see RateResults)
+}
+
+// Resolution states of verified passive findings
+const (
+	ResolutionResolvable   = "resolvable"   // Resolved to at least one address
+	ResolutionUnresolvable = "unresolvable" // Authoritative answer that the name does not exist
+	ResolutionUnknown      = "unknown"      // No resolver gave an authoritative answer
+)
+
+// Dangling record types
+const (
+	DanglingCNAME    = "cname-nxdomain"    // CNAME whose target does not exist
+	DanglingCloudIP  = "cloud-ip"          // Address in a cloud provider range with no service answering
+	DanglingNS       = "ns-unregistered"   // Delegation to a nameserver in an unregistered domain
+	DanglingLameNS   = "ns-lame"           // Delegation to a nameserver not answering for the zone
+	DanglingResource = "resource-dangling" // Page resource served from a host anyone can claim (second-order takeover)
+	DanglingMX       = "mx-dangling"       // Mail exchanger anyone can claim, receiving the mail of the domain
+	DanglingSPF      = "spf-dangling"      // SPF include anyone can claim, authorizing mail sent as the domain
+)
+
+// Severities of results, dangling records and email issues
+const (
+	SeverityCritical = "critical" // Exploitable by anyone, for example by registering a domain
+	SeverityHigh     = "high"     // Likely exploitable
+	SeverityMedium   = "medium"   // Exploitable under some conditions
+	SeverityLow      = "low"      // Hardening advice rather than an exploitable flaw
+	SeverityInfo     = "info"     // Nothing exploitable, for example a plain subdomain
+)
+
+// DanglingRecord is a DNS record left pointing at a resource that no longer exists
+// Unlike Takeover, which matches error pages of known services, it is found from DNS
+// answers and connection attempts alone
+type DanglingRecord struct {
+	Type     string `json:"type"`             // cname-nxdomain, cloud-ip, ns-unregistered or resource-dangling
+	Severity string `json:"severity"`         // critical, high or medium
+	Target   string `json:"target"`           // CNAME target, address, nameserver or resource host
+	Detail   string `json:"detail,omitempty"` // Unregistered domain, provider or service owning the target
+}
+
+// Consensus records how trusted resolvers answered for a result during verification
+type Consensus struct {
+	Confirmed     int      `json:"confirmed"`     // Resolvers that resolved the name
+	Resolvers     int      `json:"resolvers"`     // Resolvers asked
+	Disagreements []string `json:"disagreements"` // Resolvers that did not resolve it, with their answer (example: 9.9.9.9: NXDOMAIN)
+}
+
+// CertInfo holds the details of a TLS certificate served by a host
+type CertInfo struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	SANs      []string  `json:"sans,omitempty"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// NetworkInfo holds the registration of the network an address belongs to, from RDAP
+type NetworkInfo struct {
+	IP      string `json:"ip"`                // Resolved address
+	Range   string `json:"range,omitempty"`   // CIDR blocks, or first and last address, of the network
+	Handle  string `json:"handle,omitempty"`  // Registry handle of the network (example: NET-8-8-8-0-2)
+	Name    string `json:"netname,omitempty"` // Network name (example: GOGL)
+	Org     string `json:"org,omitempty"`     // Registrant organization
+	Country string `json:"country,omitempty"` // ISO 3166 country code of the registration
+}
+
+// HTTPInfo holds the fingerprint of the front page served by a host
+// Identical favicon hashes and titles across hosts usually mean the same application
+type HTTPInfo struct {
+	URL         string `json:"url"`                    // Final URL after redirects
+	StatusCode  int    `json:"status_code"`            // Status of the final response
+	Title       string `json:"title,omitempty"`        // Page title
+	Server      string `json:"server,omitempty"`       // Server response header
+	FaviconURL  string `json:"favicon_url,omitempty"`  // Icon that was hashed
+	FaviconHash int32  `json:"favicon_mmh3,omitempty"` // Shodan-compatible favicon hash (http.favicon.hash)
+
+	Technologies []Technology `json:"technologies,omitempty"` // Software detected from headers, cookies and page content
+	Resources    []string     `json:"resources,omitempty"`    // Other hosts serving scripts, images, stylesheets and frames of the page
+}
+
+// Technology is a piece of software detected on a web server
+type Technology struct {
+	Name     string `json:"name"`              // Product name (example: WordPress)
+	Category string `json:"category"`          // server, cdn, language, framework, library, cms or app
+	Version  string `json:"version,omitempty"` // Version when the response reveals it
+}
+
+// String returns the name with the version, if known
+func (t Technology) String() string {
+	if t.Version == "" {
+		return t.Name
+	}
+	return t.Name + " " + t.Version
+}
+
+// EmailPosture holds the mail records of a domain and the weaknesses found in them
+type EmailPosture struct {
+	Domain      string       `json:"domain"`
+	MX          []string     `json:"mx,omitempty"`             // Mail exchangers, by preference
+	SPF         string       `json:"spf,omitempty"`            // SPF record
+	SPFIncludes []string     `json:"spf_includes,omitempty"`   // Domains referenced by include: and redirect=
+	DMARC       string       `json:"dmarc,omitempty"`          // DMARC record published at _dmarc
+	DKIM        []string     `json:"dkim_selectors,omitempty"` // Common DKIM selectors found with a key
+	Issues      []EmailIssue `json:"issues,omitempty"`         // Weak or missing policies, most severe first
+}
+
+// EmailIssue is a weakness of the mail policy of a domain
+type EmailIssue struct {
+	Issue    string `json:"issue"`            // Short identifier (example: dmarc-missing)
+	Severity string `json:"severity"`         // high, medium or low
+	Detail   string `json:"detail,omitempty"` // Offending record part or third-party domain
+}
+
+// ToolInfo identifies the program that produced an output file
+type ToolInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ScanConfigInfo records the options a scan was run with
+type ScanConfigInfo struct {
+	Fingerprint    string            `json:"fingerprint,omitempty"`     // SHA-256 of the settings deciding what the scan finds, equal for scans run alike
+	Wordlist       string            `json:"wordlist,omitempty"`        // Wordlist path (empty when the default list was downloaded)
+	WordlistSHA256 string            `json:"wordlist_sha256,omitempty"` // Checksum of the wordlist file
+	Resolvers      []string          `json:"resolvers,omitempty"`       // Resolvers or resolver file given on the command line
+	Flags          map[string]string `json:"flags,omitempty"`           // Every command flag with its effective value
+}
+
+// ShortFingerprint returns the start of a config fingerprint, enough to tell configurations apart on screen
+func ShortFingerprint(fingerprint string) string {
+	if len(fingerprint) > 12 {
+		return fingerprint[:12]
+	}
+	return fingerprint
+}
+
+// ScanMetadata describes how and when a scan was run
+type ScanMetadata struct {
+	Tool       ToolInfo       `json:"tool"`
+	Mode       string         `json:"mode"`              // "active" or "passive"
+	Project    string         `json:"project,omitempty"` // Project the scanned domains belong to (--project)
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Config     ScanConfigInfo `json:"config"`
+	Coverage   *ScanCoverage  `json:"coverage,omitempty"` // Only set for time-boxed scans
+	Email      []EmailPosture `json:"email,omitempty"`    // Mail posture of the scanned root domains, only set with --email
+	Sources    []SourceReport `json:"sources,omitempty"`  // What each passive source contributed, only set by passive scans
+}
+
+// SourceReport records what a passive source contributed to a scan and what it cost
+type SourceReport struct {
+	Source    string  `json:"source"`
+	Hosts     int     `json:"hosts"`                // Distinct in-scope hostnames reported
+	Unique    int     `json:"unique"`               // Hostnames no other source reported
+	Found     int     `json:"found"`                // Hostnames reported, including duplicates and other domains
+	Errors    int     `json:"errors"`               // Failures reported by the source
+	Seconds   float64 `json:"seconds"`              // Time until the source finished or was stopped
+	TimedOut  bool    `json:"timed_out,omitempty"`  // Stopped by its time limit
+	Retries   int     `json:"retries,omitempty"`    // Runs repeated after the provider rate limited the source
+	Cached    bool    `json:"cached,omitempty"`     // Answered from the source cache
+	LastError string  `json:"last_error,omitempty"` // Most recent failure
+}
+
+// ScanCoverage records how much of its planned work a time-boxed scan completed
+type ScanCoverage struct {
+	Checked  int64 `json:"checked"`  // Candidates checked before the budget ran out
+	Planned  int64 `json:"planned"`  // Candidates the scan would have checked without a budget
+	Complete bool  `json:"complete"` // False when the time budget stopped the scan early
+}
+
+// ResultCounts summarizes the results contained in an output file
+type ResultCounts struct {
+	Subdomains int `json:"subdomains"`
+	WithIPs    int `json:"with_ips"`
+	Takeovers  int `json:"takeovers"`
+	Dangling   int `json:"dangling"` // Subdomains with at least one dangling record
+}
+
+// CountResults computes ResultCounts for a result set
+func CountResults(results []SubdomainResult) ResultCounts {
+	counts := ResultCounts{Subdomains: len(results)}
+	for _, result := range results {
+		if len(result.IPs) > 0 {
+			counts.WithIPs++
+		}
+		if result.Takeover != "" {
+			counts.Takeovers++
+		}
+		if len(result.Dangling) > 0 {
+			counts.Dangling++
+		}
+	}
+	return counts
 }
 
 // OutputJSON represents the complete output structure for JSON serialization
 type OutputJSON struct {
-	Domain     string            `json:"domain"`     // The main scanned domain
-	Subdomains []SubdomainResult `json:"subdomains"` // List of discovered subdomains
+	SchemaVersion string            `json:"schema_version"` // Version of this layout, see OutputSchemaVersion
+	Domain        string            `json:"domain"`         // The main scanned domain
+	ScanMetadata                    // Tool, mode, timestamps and configuration
+	Counts        ResultCounts      `json:"counts"`           // Result counts
+	Subdomains    []SubdomainResult `json:"subdomains"`       // List of discovered subdomains
+	Groups        []HostGroup       `json:"groups,omitempty"` // Subdomains grouped by shared infrastructure
 }
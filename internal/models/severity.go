@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of findings whose severity can be overridden, besides the dangling record types
+const (
+	FindingSubdomain = "subdomain" // Subdomain without any finding
+	FindingTakeover  = "takeover"  // Takeover candidate matched by a service fingerprint
+)
+
+// severityRank orders severities from the least to the most severe
+var severityRank = map[string]int{SeverityInfo: 0, SeverityLow: 1, SeverityMedium: 2, SeverityHigh: 3, SeverityCritical: 4}
+
+// severityOverrides replaces the default severity of kinds of findings, see SetSeverityOverrides
+var severityOverrides = map[string]string{}
+
+// SetSeverityOverrides parses kind=severity pairs (example: takeover=critical, cloud-ip=low)
+// Kinds are subdomain, takeover, cname-nxdomain, cloud-ip, ns-unregistered, ns-lame, resource-dangling, mx-dangling and spf-dangling
+func SetSeverityOverrides(specs []string) error {
+	overrides := map[string]string{}
+	for _, spec := range specs {
+		kind, severity, ok := strings.Cut(spec, "=")
+		kind, severity = strings.ToLower(strings.TrimSpace(kind)), strings.ToLower(strings.TrimSpace(severity))
+		if !ok {
+			return fmt.Errorf("invalid severity override %q, expected kind=severity", spec)
+		}
+		switch kind {
+		case FindingSubdomain, FindingTakeover, DanglingCNAME, DanglingCloudIP, DanglingNS, DanglingLameNS, DanglingResource, DanglingMX, DanglingSPF:
+		default:
+			return fmt.Errorf("unknown finding kind %q, use %s, %s, %s, %s, %s, %s, %s, %s or %s", kind,
+				FindingSubdomain, FindingTakeover, DanglingCNAME, DanglingCloudIP, DanglingNS, DanglingLameNS, DanglingResource, DanglingMX, DanglingSPF)
+		}
+		if !IsSeverity(severity) {
+			return fmt.Errorf("invalid severity %q, use info, low, medium, high or critical", severity)
+		}
+		overrides[kind] = severity
+	}
+	severityOverrides = overrides
+	return nil
+}
+
+// IsSeverity reports whether a value is one of the severities
+func IsSeverity(severity string) bool {
+	_, ok := severityRank[severity]
+	return ok
+}
+
+// FindingSeverity returns the severity of a kind of finding, its override or the given default
+func FindingSeverity(kind, severity string) string {
+	if override, ok := severityOverrides[kind]; ok {
+		return override
+	}
+	return severity
+}
+
+// MaxSeverity returns the most severe of two severities
+func MaxSeverity(a, b string) string {
+	if severityRank[b] > severityRank[a] {
+		return b
+	}
+	return a
+}
+
+// SeverityAtLeast reports whether severity is as severe as minimum or more
+func SeverityAtLeast(severity, minimum string) bool {
+	return severityRank[severity] >= severityRank[minimum]
+}
+
+// RateResults sets the severity of every result and of its dangling records, applying the overrides
+// A result is as severe as its most severe finding: high for a takeover candidate,
+// the severity of its dangling records, info for a plain subdomain
+func RateResults(results []SubdomainResult) {
+	for i := range results {
+		results[i].Severity = rateResult(&results[i])
+	}
+}
+
+// RateResult returns a copy of a result with its severity set, see RateResults
+func RateResult(result SubdomainResult) SubdomainResult {
+	result.Dangling = append([]DanglingRecord(nil), result.Dangling...)
+	result.Severity = rateResult(&result)
+	return result
+}
+
+func rateResult(result *SubdomainResult) string {
+	severity := FindingSeverity(FindingSubdomain, SeverityInfo)
+	if result.Takeover != "" {
+		severity = MaxSeverity(severity, FindingSeverity(FindingTakeover, SeverityHigh))
+	}
+	for i := range result.Dangling {
+		record := &result.Dangling[i]
+		record.Severity = FindingSeverity(record.Type, record.Severity)
+		severity = MaxSeverity(severity, record.Severity)
+	}
+	return severity
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Orders of saved results
+const (
+	SortByDiscovery = "discovery" // Order in which subdomains were found, varies with worker scheduling
+	SortByName      = "name"      // Alphabetical order, identical between runs with the same findings
+)
+
+// ParseSortOrder validates a --sort value, an empty value keeps the discovery order
+func ParseSortOrder(order string) (string, error) {
+	switch strings.ToLower(order) {
+	case "", SortByDiscovery:
+		return SortByDiscovery, nil
+	case SortByName:
+		return SortByName, nil
+	}
+	return "", fmt.Errorf("invalid sort order %q, use %s or %s", order, SortByName, SortByDiscovery)
+}
+
+// SortResults orders results in place
+// With SortByName, subdomains are sorted alphabetically and the values collected
+// in any order, such as IP addresses, are sorted too so files are byte-for-byte stable
+func SortResults(results []SubdomainResult, order string) {
+	if order != SortByName {
+		return
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Subdomain < results[j].Subdomain
+	})
+	for i := range results {
+		sort.Strings(results[i].IPs)
+		sort.Ints(results[i].Ports)
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+import "slices"
+
+// AddTags returns tags followed by the ones of add it lacks, empty tags are skipped
+func AddTags(tags []string, add ...string) []string {
+	for _, tag := range add {
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// RemoveTags returns tags without the ones of remove
+func RemoveTags(tags []string, remove ...string) []string {
+	var kept []string
+	for _, tag := range tags {
+		if !slices.Contains(remove, tag) {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+// TagResults adds tags to every result
+func TagResults(results []SubdomainResult, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	for i := range results {
+		results[i].Tags = AddTags(results[i].Tags, tags...)
+	}
+}
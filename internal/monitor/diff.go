@@ -0,0 +1,110 @@
+package monitor
+
+import (
+	"net"
+	"slices"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/notify"
+)
+
+// Diff compares two result sets of the same domain and returns change events
+// for subdomains that appeared, disappeared, changed their A/AAAA/CNAME answers or gained a takeover finding
+func Diff(domain string, previous, current []models.SubdomainResult, detectedAt time.Time) []notify.Event {
+	var events []notify.Event
+
+	previousMap := make(map[string]models.SubdomainResult, len(previous))
+	for _, result := range previous {
+		previousMap[result.Subdomain] = result
+	}
+
+	currentMap := make(map[string]bool, len(current))
+	for _, result := range current {
+		currentMap[result.Subdomain] = true
+
+		old, existed := previousMap[result.Subdomain]
+		if !existed {
+			events = append(events, newEvent(notify.EventSubdomainAdded, domain, result, detectedAt))
+		} else {
+			events = append(events, recordChanges(domain, old, result, detectedAt)...)
+		}
+
+		// Report takeover candidates that were not already known
+		if result.Takeover != "" && (!existed || old.Takeover != result.Takeover) {
+			events = append(events, newEvent(notify.EventTakeoverDetected, domain, result, detectedAt))
+		}
+	}
+
+	for _, result := range previous {
+		if !currentMap[result.Subdomain] {
+			events = append(events, newEvent(notify.EventSubdomainRemoved, domain, result, detectedAt))
+		}
+	}
+
+	return events
+}
+
+// newEvent builds an event from a result
+func newEvent(eventType notify.EventType, domain string, result models.SubdomainResult, detectedAt time.Time) notify.Event {
+	return notify.Event{
+		Type:       eventType,
+		Domain:     domain,
+		Subdomain:  result.Subdomain,
+		IPs:        result.IPs,
+		Takeover:   result.Takeover,
+		Severity:   result.Severity,
+		Evidence:   result.Evidence,
+		DetectedAt: detectedAt,
+	}
+}
+
+// recordChanges compares the answers of a subdomain found by both runs
+// Answers are only compared when both runs stored addresses (--track-records), a run without them is no change
+// CNAME chains are compared too: a flip to a third party is when takeovers appear
+func recordChanges(domain string, old, current models.SubdomainResult, detectedAt time.Time) []notify.Event {
+	if len(old.IPs) == 0 || len(current.IPs) == 0 {
+		return nil
+	}
+
+	var events []notify.Event
+	change := func(record string, previous, now []string) {
+		event := newEvent(notify.EventRecordChanged, domain, current, detectedAt)
+		event.Record, event.Previous, event.Current = record, previous, now
+		if record == "CNAME" && current.Provider != old.Provider {
+			event.Provider = current.Provider
+		}
+		events = append(events, event)
+	}
+
+	oldV4, oldV6 := splitAddresses(old.IPs)
+	currentV4, currentV6 := splitAddresses(current.IPs)
+	if !slices.Equal(oldV4, currentV4) {
+		change("A", oldV4, currentV4)
+	}
+	if !slices.Equal(oldV6, currentV6) {
+		change("AAAA", oldV6, currentV6)
+	}
+	if !slices.Equal(old.CNAME, current.CNAME) {
+		change("CNAME", old.CNAME, current.CNAME)
+	}
+	return events
+}
+
+// splitAddresses returns the sorted IPv4 and IPv6 addresses of a result
+func splitAddresses(ips []string) (v4, v6 []string) {
+	for _, ip := range ips {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			continue
+		}
+		if parsed.To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+	slices.Sort(v4)
+	slices.Sort(v6)
+	return v4, v6
+}
@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/notify"
+	"github.com/fkr00t/subcollector/internal/scanner"
+	"github.com/fkr00t/subcollector/internal/storage"
+)
+
+// Enumeration modes supported by the monitor
+const (
+	ModePassive = "passive"
+	ModeActive  = "active"
+	ModeBoth    = "both"
+)
+
+// Config holds the configuration for monitoring mode
+type Config struct {
+	Domains      []string
+	Interval     time.Duration
+	Mode         string                    // passive, active or both
+	Once         bool                      // Run a single cycle and exit
+	DatabasePath string                    // SQLite file or PostgreSQL URL
+	Active       scanner.ActiveScanConfig  // Template for active scans
+	Passive      scanner.PassiveScanConfig // Template for passive scans
+	Metadata     models.ScanMetadata
+	Notifiers    []notify.Notifier
+}
+
+// Run re-runs enumeration for every domain on a schedule, stores each run
+// and sends notifications for changes compared to the previous run
+func Run(config Config) error {
+	store, err := storage.Open(config.DatabasePath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	fmt.Printf("\n» Monitoring %d domains every %s (mode: %s, db: %s)\n", len(config.Domains), config.Interval, config.Mode, storage.Redact(config.DatabasePath))
+
+	for {
+		for _, domain := range config.Domains {
+			if err := runCycle(store, domain, config); err != nil {
+				fmt.Printf("× Monitor run failed for %s: %v\n", domain, err)
+			}
+		}
+
+		if config.Once {
+			return nil
+		}
+
+		fmt.Printf("\n» Next run at %s\n", time.Now().Add(config.Interval).Format("2006-01-02 15:04:05"))
+		time.Sleep(config.Interval)
+	}
+}
+
+// runCycle enumerates one domain, stores the results and notifies changes
+func runCycle(store storage.Store, domain string, config Config) error {
+	metadata := config.Metadata
+	metadata.StartedAt = time.Now()
+
+	results, err := enumerate(domain, config)
+	if err != nil {
+		return err
+	}
+
+	metadata.FinishedAt = time.Now()
+
+	previousID, previous, err := store.LatestScan(domain)
+	if err != nil {
+		return fmt.Errorf("failed to load previous scan: %v", err)
+	}
+
+	if _, err := store.SaveScan(domain, metadata, results); err != nil {
+		return err
+	}
+
+	// The first run only records a baseline
+	if previousID == 0 {
+		fmt.Printf("» Baseline stored for %s: %d subdomains\n", domain, len(results))
+		return nil
+	}
+
+	events := Diff(domain, previous, results, metadata.FinishedAt)
+	if len(events) == 0 {
+		fmt.Printf("» No changes for %s\n", domain)
+		return nil
+	}
+
+	fmt.Printf("» %d changes for %s\n", len(events), domain)
+	for _, notifier := range config.Notifiers {
+		if err := notifier.Notify(events); err != nil {
+			fmt.Printf("× Failed to send notification: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// enumerate runs the configured scan types for a domain
+// A failed scan aborts the cycle so that it is not mistaken for removed subdomains
+func enumerate(domain string, config Config) ([]models.SubdomainResult, error) {
+	var passiveResults, activeResults []models.SubdomainResult
+	var err error
+
+	if config.Mode == ModePassive || config.Mode == ModeBoth {
+		passiveConfig := config.Passive
+		passiveConfig.Domain = domain
+		if passiveResults, err = scanner.ExecutePassiveScan(passiveConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Mode == ModeActive || config.Mode == ModeBoth {
+		activeConfig := config.Active
+		activeConfig.Domain = domain
+		if activeResults, err = scanner.ExecuteActiveScan(activeConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	// Findings of both scans count towards the severity of a subdomain
+	results := models.MergeResults(passiveResults, activeResults)
+	models.RateResults(results)
+	return results, nil
+}
@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+var (
+	green  = color.New(color.FgGreen).SprintFunc()
+	yellow = color.New(color.FgYellow).SprintFunc()
+	red    = color.New(color.FgRed).SprintFunc()
+)
+
+// EventType identifies the kind of change a notification reports
+type EventType string
+
+const (
+	EventSubdomainAdded   EventType = "subdomain_added"
+	EventSubdomainRemoved EventType = "subdomain_removed"
+	EventTakeoverDetected EventType = "takeover_detected"
+	EventRecordChanged    EventType = "record_changed"
+)
+
+// Event describes a single change detected between two scans
+type Event struct {
+	Type       EventType `json:"type"`
+	Domain     string    `json:"domain"`
+	Subdomain  string    `json:"subdomain"`
+	IPs        []string  `json:"ips,omitempty"`
+	Takeover   string    `json:"takeover,omitempty"`
+	Severity   string    `json:"severity,omitempty"` // Severity of the subdomain: info, low, medium, high or critical
+	Evidence   string    `json:"evidence,omitempty"` // Evidence file of a takeover finding
+	Record     string    `json:"record,omitempty"`   // Record type of a record change: A, AAAA or CNAME
+	Previous   []string  `json:"previous,omitempty"` // Answers of the previous run, for record changes
+	Current    []string  `json:"current,omitempty"`  // Answers of this run, for record changes
+	Provider   string    `json:"provider,omitempty"` // Provider the CNAME chain now points to, for CNAME changes
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// Message returns a short human readable description of the event
+// Internationalized subdomains are written in the form chosen with utils.SetIDNDisplay
+func (e Event) Message() string {
+	e.Subdomain = utils.DisplayDomain(e.Subdomain)
+	switch e.Type {
+	case EventSubdomainAdded:
+		return fmt.Sprintf("New subdomain: %s", e.Subdomain)
+	case EventSubdomainRemoved:
+		return fmt.Sprintf("Subdomain disappeared: %s", e.Subdomain)
+	case EventTakeoverDetected:
+		return fmt.Sprintf("Possible takeover on %s: %s", e.Subdomain, e.Takeover)
+	case EventRecordChanged:
+		message := fmt.Sprintf("%s record of %s changed: %s → %s", e.Record, e.Subdomain, answers(e.Previous), answers(e.Current))
+		if e.Provider != "" {
+			message += " (" + e.Provider + ")"
+		}
+		return message
+	default:
+		return fmt.Sprintf("%s: %s", e.Type, e.Subdomain)
+	}
+}
+
+// answers formats the answers of a record change
+func answers(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ", ")
+}
+
+// Notifier delivers change events somewhere
+type Notifier interface {
+	Notify(events []Event) error
+}
+
+// ConsoleNotifier prints events to the terminal
+type ConsoleNotifier struct{}
+
+// Notify prints each event on its own line
+func (ConsoleNotifier) Notify(events []Event) error {
+	for _, event := range events {
+		switch event.Type {
+		case EventSubdomainAdded:
+			fmt.Printf(" %s  %s\n", green("+"), event.Message())
+		case EventSubdomainRemoved:
+			fmt.Printf(" %s  %s\n", yellow("-"), event.Message())
+		case EventRecordChanged:
+			fmt.Printf(" %s  %s\n", yellow("~"), event.Message())
+		default:
+			fmt.Printf(" %s  %s\n", red("!"), event.Message())
+		}
+	}
+	return nil
+}
+
+// WebhookNotifier posts events as JSON to a URL
+// The payload has a "text" field so Slack/Mattermost incoming webhooks can display it
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier creates a WebhookNotifier for the given URL
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// webhookPayload is the JSON document sent to webhooks
+type webhookPayload struct {
+	Text   string  `json:"text"`
+	Events []Event `json:"events"`
+}
+
+// Notify sends all events in a single request
+func (w *WebhookNotifier) Notify(events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	payload := webhookPayload{Events: events}
+	for i, event := range events {
+		if i > 0 {
+			payload.Text += "\n"
+		}
+		payload.Text += fmt.Sprintf("[%s] %s", event.Domain, event.Message())
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.Client.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package notify
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// Rule selects the events worth an alert
+// A rule is made of conditions joined by && (all must hold) and || (one group must hold):
+//
+//	type == subdomain_added && subdomain ~ ^(dev|staging|vpn)\.
+//	takeover != '' || type == record_changed && record == CNAME
+//
+// Fields are type, domain, subdomain, ip, takeover, severity, record, previous, current and provider;
+// operators are == and != (exact), ~ and !~ (regular expression), and >= for severity. Values may be quoted.
+// List fields (ip, previous, current) hold when any answer matches, != and !~ when none does.
+type Rule struct {
+	expr   string
+	groups [][]condition
+}
+
+// condition compares one field of an event
+type condition struct {
+	field string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+// ruleFields are the fields conditions may test
+var ruleFields = map[string]bool{
+	"type": true, "domain": true, "subdomain": true, "ip": true, "takeover": true, "severity": true,
+	"record": true, "previous": true, "current": true, "provider": true,
+}
+
+var conditionPattern = regexp.MustCompile(`^\s*([a-z]+)\s*(==|!=|!~|~|>=)\s*(.*?)\s*$`)
+
+// ParseRule parses an alert rule
+func ParseRule(expr string) (*Rule, error) {
+	rule := &Rule{expr: strings.TrimSpace(expr)}
+	for _, group := range strings.Split(expr, "||") {
+		var conditions []condition
+		for _, text := range strings.Split(group, "&&") {
+			c, err := parseCondition(text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid alert rule %q: %v", rule.expr, err)
+			}
+			conditions = append(conditions, c)
+		}
+		rule.groups = append(rule.groups, conditions)
+	}
+	return rule, nil
+}
+
+// parseCondition parses "field op value"
+func parseCondition(text string) (condition, error) {
+	match := conditionPattern.FindStringSubmatch(text)
+	if match == nil {
+		return condition{}, fmt.Errorf("expected field, operator and value in %q", strings.TrimSpace(text))
+	}
+	c := condition{field: match[1], op: match[2], value: unquote(match[3])}
+	if !ruleFields[c.field] {
+		return condition{}, fmt.Errorf("unknown field %q", c.field)
+	}
+	if c.op == ">=" {
+		if c.field != "severity" {
+			return condition{}, fmt.Errorf(">= only applies to severity")
+		}
+		if !models.IsSeverity(c.value) {
+			return condition{}, fmt.Errorf("invalid severity %q, use info, low, medium, high or critical", c.value)
+		}
+	}
+	if c.op == "~" || c.op == "!~" {
+		re, err := regexp.Compile(c.value)
+		if err != nil {
+			return condition{}, fmt.Errorf("invalid regular expression %q: %v", c.value, err)
+		}
+		c.re = re
+	}
+	return c, nil
+}
+
+// unquote strips matching single or double quotes around a value
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// String returns the rule as it was written
+func (r *Rule) String() string {
+	return r.expr
+}
+
+// Match reports whether an event satisfies the rule
+func (r *Rule) Match(event Event) bool {
+	for _, group := range r.groups {
+		matched := true
+		for _, c := range group {
+			if !c.match(event) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// match tests the condition against the values of its field
+func (c condition) match(event Event) bool {
+	values := c.values(event)
+	negated := c.op == "!=" || c.op == "!~"
+	for _, value := range values {
+		var found bool
+		if c.op == ">=" {
+			found = models.SeverityAtLeast(value, c.value)
+		} else if c.re != nil {
+			found = c.re.MatchString(value)
+		} else {
+			found = value == c.value
+		}
+		if found {
+			return !negated
+		}
+	}
+	return negated
+}
+
+// values returns the values of the field, an empty list field holds a single empty value
+func (c condition) values(event Event) []string {
+	var list []string
+	switch c.field {
+	case "type":
+		return []string{string(event.Type)}
+	case "domain":
+		return []string{event.Domain}
+	case "subdomain":
+		return []string{event.Subdomain}
+	case "takeover":
+		return []string{event.Takeover}
+	case "severity":
+		return []string{event.Severity}
+	case "record":
+		return []string{event.Record}
+	case "provider":
+		return []string{event.Provider}
+	case "ip":
+		list = event.IPs
+	case "previous":
+		list = event.Previous
+	case "current":
+		list = event.Current
+	}
+	if len(list) == 0 {
+		return []string{""}
+	}
+	return list
+}
+
+// Filter returns the events matching at least one rule, every event when there are no rules
+func Filter(events []Event, rules []*Rule) []Event {
+	if len(rules) == 0 {
+		return events
+	}
+	var matched []Event
+	for _, event := range events {
+		for _, rule := range rules {
+			if rule.Match(event) {
+				matched = append(matched, event)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// RuleNotifier passes the events matching its rules on to another notifier
+type RuleNotifier struct {
+	Notifier Notifier
+	Rules    []*Rule
+}
+
+// Notify forwards the matching events, nothing when none matches
+func (n RuleNotifier) Notify(events []Event) error {
+	events = Filter(events, n.Rules)
+	if len(events) == 0 {
+		return nil
+	}
+	return n.Notifier.Notify(events)
+}
@@ -0,0 +1,129 @@
+package output
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// csvHeader lists the columns of CSV output, multi-valued fields are separated by ';'
+var csvHeader = []string{
+	"subdomain", "ips", "ttl", "response_ms", "cname", "provider", "region", "ports",
+	"takeover", "evidence", "dnssec", "tls_issuer", "tls_not_after", "source", "unverified",
+	"http_status", "http_title", "http_server", "favicon_mmh3", "technologies", "dangling",
+	"mx", "spf", "dmarc", "email_issues", "resolution", "tags", "note", "severity",
+	"netname", "org", "country", "config_fingerprint",
+}
+
+// SaveCSV writes results as CSV with one row per subdomain
+// Every row repeats the config fingerprint of the scan, kept when rows are combined
+func SaveCSV(outputFile string, results []models.SubdomainResult, metadata models.ScanMetadata) error {
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if err := writer.Write(append(csvRow(result), metadata.Config.Fingerprint)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvRow formats a result in the column order of csvHeader, up to the scan columns
+func csvRow(result models.SubdomainResult) []string {
+	var ttl, responseTime string
+	if result.TTL > 0 {
+		ttl = strconv.FormatUint(uint64(result.TTL), 10)
+	}
+	if result.ResponseTime > 0 {
+		responseTime = strconv.FormatFloat(result.ResponseTime, 'f', 1, 64)
+	}
+
+	ports := make([]string, len(result.Ports))
+	for i, port := range result.Ports {
+		ports[i] = strconv.Itoa(port)
+	}
+
+	var issuer, notAfter string
+	if result.TLS != nil {
+		issuer = result.TLS.Issuer
+		notAfter = result.TLS.NotAfter.Format(time.RFC3339)
+	}
+
+	unverified := ""
+	if result.Unverified {
+		unverified = "true"
+	}
+
+	var status, title, server, favicon, techs string
+	if result.HTTP != nil {
+		status = strconv.Itoa(result.HTTP.StatusCode)
+		techs = joinTechnologies(result.HTTP.Technologies, ";")
+		title = result.HTTP.Title
+		server = result.HTTP.Server
+		if result.HTTP.FaviconHash != 0 {
+			favicon = strconv.Itoa(int(result.HTTP.FaviconHash))
+		}
+	}
+
+	var mx, spf, dmarc, emailIssues string
+	if result.Email != nil {
+		mx = strings.Join(result.Email.MX, ";")
+		spf = result.Email.SPF
+		dmarc = result.Email.DMARC
+		issues := make([]string, len(result.Email.Issues))
+		for i, issue := range result.Email.Issues {
+			issues[i] = issue.Issue + " (" + issue.Severity + ")"
+		}
+		emailIssues = strings.Join(issues, ";")
+	}
+
+	netname, org, country := joinNetworks(result.Networks, ";")
+
+	return []string{
+		result.Subdomain,
+		strings.Join(result.IPs, ";"),
+		ttl,
+		responseTime,
+		strings.Join(result.CNAME, ";"),
+		result.Provider,
+		result.Region,
+		strings.Join(ports, ";"),
+		result.Takeover,
+		result.Evidence,
+		result.DNSSEC,
+		issuer,
+		notAfter,
+		result.Source,
+		unverified,
+		status,
+		title,
+		server,
+		favicon,
+		techs,
+		joinDangling(result.Dangling, ";"),
+		mx,
+		spf,
+		dmarc,
+		emailIssues,
+		result.Resolution,
+		strings.Join(result.Tags, ";"),
+		result.Note,
+		result.Severity,
+		netname,
+		org,
+		country,
+	}
+}
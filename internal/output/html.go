@@ -0,0 +1,137 @@
+package output
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// reportData is the data rendered by the HTML report template
+type reportData struct {
+	models.OutputJSON
+	Shared []models.HostGroup // Groups with more than one subdomain
+	Unique int                // Hosts not sharing any infrastructure with another one
+}
+
+// reportFuncs are helpers available in the HTML report template
+var reportFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"short": models.ShortFingerprint,
+	"time": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format("2006-01-02 15:04:05 MST")
+	},
+	"date": func(t time.Time) string { return t.Format("2006-01-02") },
+	"host": utils.DisplayDomain,
+	"hosts": func(hosts []string) []string {
+		names := make([]string, len(hosts))
+		for i, host := range hosts {
+			names[i] = utils.DisplayDomain(host)
+		}
+		return names
+	},
+	"ports": func(ports []int) string {
+		values := make([]string, len(ports))
+		for i, port := range ports {
+			values[i] = fmt.Sprint(port)
+		}
+		return strings.Join(values, ", ")
+	},
+}
+
+// reportTemplate is a self-contained HTML page, no external assets are loaded
+var reportTemplate = template.Must(template.New("report").Funcs(reportFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Subcollector report - {{host .Domain}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0; }
+.meta { color: #666; margin-top: .3em; }
+.counts span { display: inline-block; margin-right: 2em; font-size: 1.2em; }
+table { border-collapse: collapse; width: 100%; margin: 1em 0 2em; font-size: .9em; }
+th, td { border: 1px solid #ddd; padding: .4em .6em; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; }
+tr:nth-child(even) td { background: #fafafa; }
+.kind { font-family: monospace; color: #555; }
+.takeover { color: #b00; font-weight: bold; }
+details summary { cursor: pointer; }
+</style>
+</head>
+<body>
+<h1>{{host .Domain}}</h1>
+<p class="meta">{{.Tool.Name}} {{.Tool.Version}} &middot; {{.Mode}} scan &middot; started {{time .StartedAt}} &middot; finished {{time .FinishedAt}}{{with .Config.Fingerprint}} &middot; config <code title="{{.}}">{{short .}}</code>{{end}}</p>
+<p class="counts"><span>{{.Counts.Subdomains}} subdomains</span><span>{{.Counts.WithIPs}} with IPs</span><span>{{.Counts.Takeovers}} possible takeovers</span><span>{{.Counts.Dangling}} with dangling records</span></p>
+
+<h2>Shared infrastructure</h2>
+{{if .Shared}}<p>{{len .Shared}} groups of hosts share an address, CNAME target, provider, favicon, page title or technology; {{.Unique}} hosts do not share anything.</p>
+<table>
+<tr><th>Kind</th><th>Key</th><th>Hosts</th><th>Subdomains</th></tr>
+{{range .Shared}}<tr><td class="kind">{{.Kind}}</td><td>{{.Key}}</td><td>{{.Count}}</td><td><details><summary>show</summary>{{join (hosts .Subdomains) ", "}}</details></td></tr>
+{{end}}</table>
+{{else}}<p>No shared infrastructure found (grouping needs IP addresses, CNAME data or web fingerprints, see <code>--group</code> and <code>--http</code>).</p>
+{{end}}
+{{if .Email}}<h2>Email posture</h2>
+<table>
+<tr><th>Domain</th><th>MX</th><th>SPF</th><th>DMARC</th><th>DKIM selectors</th><th>Issues</th></tr>
+{{range .Email}}<tr><td>{{.Domain}}</td><td>{{join .MX ", "}}</td><td>{{.SPF}}</td><td>{{.DMARC}}</td><td>{{join .DKIM ", "}}</td><td>{{range .Issues}}<span class="kind">{{.Severity}}</span> {{.Issue}}{{with .Detail}} ({{.}}){{end}}<br>{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Subdomains</h2>
+<table>
+<tr><th>Subdomain</th><th>IPs</th><th>CNAME</th><th>Provider</th><th>Ports</th><th>TLS</th><th>Details</th></tr>
+{{range .Subdomains}}<tr>
+<td title="{{.Subdomain}}">{{host .Subdomain}}</td>
+<td>{{join .IPs ", "}}</td>
+<td>{{join .CNAME " → "}}</td>
+<td>{{.Provider}}</td>
+<td>{{ports .Ports}}</td>
+<td>{{with .TLS}}{{.Issuer}}, expires {{date .NotAfter}}{{end}}</td>
+<td>{{with .Severity}}{{if ne . "info"}}<span class="kind">{{.}}</span> {{end}}{{end}}{{if .Takeover}}<span class="takeover">Possible takeover: {{.Takeover}}</span> {{with .Evidence}}evidence: {{.}} {{end}}{{end}}{{range .Dangling}}<span class="takeover">Dangling {{.Type}} ({{.Severity}}): {{.Target}}</span> {{with .Detail}}{{.}} {{end}}{{end}}{{if .Unverified}}unverified {{end}}{{with .Resolution}}{{.}} {{end}}{{if .DNSSEC}}dnssec: {{.DNSSEC}} {{end}}{{with .HTTP}}http: {{.StatusCode}} {{with .Title}}&ldquo;{{.}}&rdquo; {{end}}{{with .Server}}server: {{.}} {{end}}{{with .FaviconHash}}favicon: {{.}} {{end}}{{with .Technologies}}tech: {{range $i, $t := .}}{{if $i}}, {{end}}{{$t}}{{end}} {{end}}{{end}}{{range .Networks}}net: {{.IP}} {{with .Name}}{{.}} {{end}}{{with .Org}}{{.}} {{end}}{{with .Country}}{{.}} {{end}}{{end}}{{with .Email}}email: {{len .MX}} mx{{range .Issues}}, {{.Severity}} {{.Issue}}{{end}} {{end}}{{if .Source}}source: {{.Source}} {{end}}{{with .Tags}}tags: {{join . ", "}} {{end}}{{with .Note}}note: {{.}}{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// SaveHTMLReport writes a standalone HTML report with results and host groups
+func SaveHTMLReport(outputFile, domain string, results []models.SubdomainResult, metadata models.ScanMetadata) error {
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data := reportData{
+		OutputJSON: models.OutputJSON{
+			SchemaVersion: models.OutputSchemaVersion,
+			Domain:        domain,
+			ScanMetadata:  metadata,
+			Counts:        models.CountResults(results),
+			Subdomains:    results,
+			Groups:        models.GroupResults(results),
+		},
+	}
+
+	// Hosts appearing in a shared group are not unique infrastructure
+	shared := make(map[string]bool)
+	for _, group := range data.Groups {
+		if group.Count > 1 {
+			data.Shared = append(data.Shared, group)
+			for _, subdomain := range group.Subdomains {
+				shared[subdomain] = true
+			}
+		}
+	}
+	data.Unique = len(results) - len(shared)
+
+	return reportTemplate.Execute(file, data)
+}
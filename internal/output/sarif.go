@@ -0,0 +1,245 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// SARIF 2.1.0 identifiers
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolURI      = "https://github.com/fkr00t/subcollector"
+)
+
+// Rule IDs of SARIF findings
+const (
+	ruleTakeover = "subdomain-takeover"
+	ruleDangling = "dangling-" // Followed by the dangling record type, example: dangling-cname-nxdomain
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool         `json:"tool"`
+	Results    []sarifResult     `json:"results"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string          `json:"id"`
+	Name                 string          `json:"name"`
+	ShortDescription     sarifMessage    `json:"shortDescription"`
+	FullDescription      sarifMessage    `json:"fullDescription"`
+	DefaultConfiguration sarifRuleConfig `json:"defaultConfiguration"`
+	Properties           sarifProperties `json:"properties"`
+}
+
+type sarifRuleConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifProperties struct {
+	Tags             []string `json:"tags,omitempty"`
+	SecuritySeverity string   `json:"security-severity,omitempty"` // Read by GitHub code scanning to rank alerts
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          sarifProperties   `json:"properties"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// sarifRules describes every rule a finding can be reported under
+var sarifRules = []sarifRule{
+	{
+		ID:                   ruleTakeover,
+		Name:                 "SubdomainTakeover",
+		ShortDescription:     sarifMessage{"Subdomain takeover"},
+		FullDescription:      sarifMessage{"The subdomain points at a third-party service that answers with the error page of an unclaimed resource; anyone can claim it and serve content on the subdomain."},
+		DefaultConfiguration: sarifRuleConfig{"error"},
+		Properties:           sarifProperties{Tags: []string{"security", "dns"}, SecuritySeverity: "8.0"},
+	},
+	{
+		ID:                   ruleDangling + models.DanglingCNAME,
+		Name:                 "DanglingCNAME",
+		ShortDescription:     sarifMessage{"CNAME to a name that does not exist"},
+		FullDescription:      sarifMessage{"The subdomain has a CNAME whose target does not exist; registering the target domain or claiming the resource takes over the subdomain."},
+		DefaultConfiguration: sarifRuleConfig{"error"},
+		Properties:           sarifProperties{Tags: []string{"security", "dns"}, SecuritySeverity: "8.0"},
+	},
+	{
+		ID:                   ruleDangling + models.DanglingCloudIP,
+		Name:                 "DanglingCloudIP",
+		ShortDescription:     sarifMessage{"Address in a cloud range with no service answering"},
+		FullDescription:      sarifMessage{"The subdomain resolves to a cloud provider address where nothing answers; the address may have been released and can be allocated by another customer."},
+		DefaultConfiguration: sarifRuleConfig{"warning"},
+		Properties:           sarifProperties{Tags: []string{"security", "dns"}, SecuritySeverity: "5.0"},
+	},
+	{
+		ID:                   ruleDangling + models.DanglingNS,
+		Name:                 "UnregisteredNameserver",
+		ShortDescription:     sarifMessage{"Delegation to a nameserver in an unregistered domain"},
+		FullDescription:      sarifMessage{"The subdomain is delegated to a nameserver whose domain is not registered; registering it gives control over every record of the zone."},
+		DefaultConfiguration: sarifRuleConfig{"error"},
+		Properties:           sarifProperties{Tags: []string{"security", "dns"}, SecuritySeverity: "9.5"},
+	},
+	{
+		ID:                   ruleDangling + models.DanglingLameNS,
+		Name:                 "LameDelegation",
+		ShortDescription:     sarifMessage{"Delegation to a nameserver not answering for the zone"},
+		FullDescription:      sarifMessage{"The subdomain is delegated to a nameserver that does not exist, does not answer or does not serve the zone; whoever creates the zone on the DNS provider hosting that nameserver controls every record of it."},
+		DefaultConfiguration: sarifRuleConfig{"error"},
+		Properties:           sarifProperties{Tags: []string{"security", "dns"}, SecuritySeverity: "8.0"},
+	},
+	{
+		ID:                   ruleDangling + models.DanglingResource,
+		Name:                 "DanglingResource",
+		ShortDescription:     sarifMessage{"Page resource served from a host anyone can claim"},
+		FullDescription:      sarifMessage{"A page of the subdomain loads a script, image, stylesheet or frame from another host that is unregistered, dangling or serves the error page of an unclaimed resource; whoever claims it serves content inside the page (second-order takeover)."},
+		DefaultConfiguration: sarifRuleConfig{"error"},
+		Properties:           sarifProperties{Tags: []string{"security", "web"}, SecuritySeverity: "8.0"},
+	},
+	{
+		ID:                   ruleDangling + models.DanglingMX,
+		Name:                 "DanglingMailExchanger",
+		ShortDescription:     sarifMessage{"Mail exchanger anyone can claim"},
+		FullDescription:      sarifMessage{"An MX record of the subdomain points to a host that is unregistered, dangling or missing on a takeover-prone service; whoever claims it receives the mail sent to the subdomain, password resets included."},
+		DefaultConfiguration: sarifRuleConfig{"error"},
+		Properties:           sarifProperties{Tags: []string{"security", "dns", "email"}, SecuritySeverity: "8.0"},
+	},
+	{
+		ID:                   ruleDangling + models.DanglingSPF,
+		Name:                 "DanglingSPFInclude",
+		ShortDescription:     sarifMessage{"SPF include anyone can claim"},
+		FullDescription:      sarifMessage{"The SPF policy of the subdomain includes a domain that is unregistered, dangling or missing on a takeover-prone service; whoever claims it can authorize their own servers to send mail as the subdomain."},
+		DefaultConfiguration: sarifRuleConfig{"error"},
+		Properties:           sarifProperties{Tags: []string{"security", "dns", "email"}, SecuritySeverity: "7.0"},
+	},
+}
+
+// SaveSARIF writes takeover and dangling DNS findings as a SARIF 2.1.0 log
+// for code scanning dashboards; subdomains without findings are left out
+func SaveSARIF(outputFile string, results []models.SubdomainResult, metadata models.ScanMetadata) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           metadata.Tool.Name,
+			Version:        metadata.Tool.Version,
+			InformationURI: toolURI,
+			Rules:          sarifRules,
+		}},
+		Results: []sarifResult{},
+	}
+	if run.Tool.Driver.Name == "" {
+		run.Tool.Driver.Name = "subcollector"
+	}
+	if metadata.Config.Fingerprint != "" {
+		run.Properties = map[string]string{"configFingerprint": metadata.Config.Fingerprint}
+	}
+
+	for _, result := range results {
+		if result.Takeover != "" {
+			message := fmt.Sprintf("%s can be taken over through %s", result.Subdomain, result.Takeover)
+			if result.Evidence != "" {
+				message += fmt.Sprintf(" (evidence: %s)", result.Evidence)
+			}
+			run.Results = append(run.Results, sarifFinding(result.Subdomain, ruleTakeover, models.FindingSeverity(models.FindingTakeover, models.SeverityHigh), message, result.Takeover))
+		}
+		for _, record := range result.Dangling {
+			message := fmt.Sprintf("%s has a dangling %s record pointing at %s", result.Subdomain, record.Type, record.Target)
+			if record.Detail != "" {
+				message += fmt.Sprintf(" (%s)", record.Detail)
+			}
+			run.Results = append(run.Results, sarifFinding(result.Subdomain, ruleDangling+record.Type, record.Severity, message, record.Target))
+		}
+	}
+
+	data, err := json.MarshalIndent(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputFile, data, 0644)
+}
+
+// sarifFinding builds a SARIF result located at a subdomain
+// The fingerprint lets dashboards track a finding across scans and close it once fixed
+func sarifFinding(subdomain, ruleID, severity, message, target string) sarifResult {
+	return sarifResult{
+		RuleID:  ruleID,
+		Level:   sarifLevel(severity),
+		Message: sarifMessage{message},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: subdomain}},
+			LogicalLocations: []sarifLogicalLocation{{Name: subdomain, Kind: "resource"}},
+		}},
+		PartialFingerprints: map[string]string{"finding/v1": ruleID + "|" + subdomain + "|" + target},
+		Properties:          sarifProperties{Tags: []string{"security", severity}, SecuritySeverity: securitySeverity(severity)},
+	}
+}
+
+// sarifLevel maps a finding severity to a SARIF level
+func sarifLevel(severity string) string {
+	switch severity {
+	case models.SeverityCritical, models.SeverityHigh:
+		return "error"
+	case models.SeverityMedium:
+		return "warning"
+	}
+	return "note"
+}
+
+// securitySeverity maps a finding severity to the CVSS-like score GitHub uses for alert severity
+func securitySeverity(severity string) string {
+	switch severity {
+	case models.SeverityCritical:
+		return "9.5"
+	case models.SeverityHigh:
+		return "8.0"
+	case models.SeverityMedium:
+		return "5.0"
+	}
+	return "3.0"
+}
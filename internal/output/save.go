@@ -4,14 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/fkr00t/subcollector/internal/models"
 )
 
 // SaveResults saves scan results to a file
 // Supports text and JSON formats
+// JSON output embeds the scan metadata so files are self-describing
+// Text output writes one line per result, rendered by format (nil for the subdomain alone)
 // Returns an error if an issue occurs
-func SaveResults(output, jsonOutput, domain string, results []models.SubdomainResult) error {
+func SaveResults(output, jsonOutput, domain string, results []models.SubdomainResult, metadata models.ScanMetadata, format *models.LineFormat) error {
 	outputFile := output
 	if jsonOutput != "" {
 		outputFile = jsonOutput
@@ -29,8 +32,15 @@ func SaveResults(output, jsonOutput, domain string, results []models.SubdomainRe
 
 	if jsonOutput != "" {
 		outputData := models.OutputJSON{
-			Domain:     domain,
-			Subdomains: results,
+			SchemaVersion: models.OutputSchemaVersion,
+			Domain:        domain,
+			ScanMetadata:  metadata,
+			Counts:        models.CountResults(results),
+			Subdomains:    results,
+			Groups:        models.GroupResults(results),
+		}
+		if outputData.Subdomains == nil {
+			outputData.Subdomains = []models.SubdomainResult{}
 		}
 		jsonData, err := json.MarshalIndent(outputData, "", "    ")
 		if err != nil {
@@ -44,8 +54,12 @@ func SaveResults(output, jsonOutput, domain string, results []models.SubdomainRe
 		fmt.Printf("[INF] Results saved to %s (JSON format)\n", outputFile)
 	} else {
 		for _, result := range results {
-			_, err := file.WriteString(fmt.Sprintf("%s\n", result.Subdomain))
+			line, err := format.Render(result)
 			if err != nil {
+				fmt.Printf("[ERR] Failed to format %s: %v\n", result.Subdomain, err)
+				return err
+			}
+			if _, err := file.WriteString(line + "\n"); err != nil {
 				return err
 			}
 		}
@@ -57,7 +71,8 @@ func SaveResults(output, jsonOutput, domain string, results []models.SubdomainRe
 
 // BatchSaveResultsJSON saves results in batches to avoid storing all results in memory
 // This function processes the result channel and writes directly to a JSON file
-func BatchSaveResultsJSON(outputFile, domain string, resultsChan <-chan models.SubdomainResult, doneChan chan<- bool) {
+// The layout matches OutputJSON; finish time and counts are written after the results
+func BatchSaveResultsJSON(outputFile, domain string, metadata models.ScanMetadata, resultsChan <-chan models.SubdomainResult, doneChan chan<- bool) {
 	file, err := os.Create(outputFile)
 	if err != nil {
 		fmt.Println("[ERR] Failed to create output file!")
@@ -66,9 +81,29 @@ func BatchSaveResultsJSON(outputFile, domain string, resultsChan <-chan models.S
 	}
 	defer file.Close()
 
+	// Write the header fields known before the first result
+	header := []struct {
+		key   string
+		value interface{}
+	}{
+		{"schema_version", models.OutputSchemaVersion},
+		{"domain", domain},
+		{"tool", metadata.Tool},
+		{"mode", metadata.Mode},
+		{"started_at", metadata.StartedAt},
+		{"config", metadata.Config},
+	}
+	file.WriteString("{\n")
+	for _, field := range header {
+		value, _ := json.Marshal(field.value)
+		file.WriteString(fmt.Sprintf("  %q: %s,\n", field.key, value))
+	}
+
 	// Initialize JSON array
-	file.WriteString(fmt.Sprintf("{\n  \"domain\": \"%s\",\n  \"subdomains\": [\n", domain))
+	file.WriteString("  \"subdomains\": [\n")
 
+	var counts models.ResultCounts
+	grouper := models.NewHostGrouper()
 	first := true
 	for result := range resultsChan {
 		jsonData, err := json.Marshal(result)
@@ -83,17 +118,37 @@ func BatchSaveResultsJSON(outputFile, domain string, resultsChan <-chan models.S
 		}
 
 		file.WriteString("    " + string(jsonData))
+
+		counts.Subdomains++
+		if len(result.IPs) > 0 {
+			counts.WithIPs++
+		}
+		if result.Takeover != "" {
+			counts.Takeovers++
+		}
+		if len(result.Dangling) > 0 {
+			counts.Dangling++
+		}
+		grouper.Add(result)
 	}
 
-	// Close JSON array and object
-	file.WriteString("\n  ]\n}")
+	// Close JSON array, then write the trailing fields and close the object
+	finishedAt, _ := json.Marshal(time.Now())
+	countsData, _ := json.Marshal(counts)
+	file.WriteString(fmt.Sprintf("\n  ],\n  \"finished_at\": %s,\n  \"counts\": %s", finishedAt, countsData))
+	if groups := grouper.Groups(); len(groups) > 0 {
+		groupsData, _ := json.Marshal(groups)
+		file.WriteString(fmt.Sprintf(",\n  \"groups\": %s", groupsData))
+	}
+	file.WriteString("\n}")
 
 	doneChan <- true
 }
 
 // BatchSaveResultsText saves results in batches to avoid storing all results in memory
 // This function processes the result channel and writes directly to a text file
-func BatchSaveResultsText(outputFile string, resultsChan <-chan models.SubdomainResult, doneChan chan<- bool) {
+// Lines are rendered by format, results that fail to render are skipped
+func BatchSaveResultsText(outputFile string, format *models.LineFormat, resultsChan <-chan models.SubdomainResult, doneChan chan<- bool) {
 	file, err := os.Create(outputFile)
 	if err != nil {
 		fmt.Println("[ERR] Failed to create output file!")
@@ -102,10 +157,31 @@ func BatchSaveResultsText(outputFile string, resultsChan <-chan models.Subdomain
 	}
 	defer file.Close()
 
-	// Simple text format
 	for result := range resultsChan {
-		file.WriteString(fmt.Sprintf("%s\n", result.Subdomain))
+		line, err := format.Render(result)
+		if err != nil {
+			fmt.Printf("[ERR] Failed to format %s: %v\n", result.Subdomain, err)
+			continue
+		}
+		file.WriteString(line + "\n")
 	}
 
 	doneChan <- true
 }
+
+// SaveLines writes one entry per line to a plain text file
+func SaveLines(outputFile string, lines []string) error {
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for _, line := range lines {
+		if _, err := file.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
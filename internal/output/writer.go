@@ -2,11 +2,14 @@ package output
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/cheggaaa/pb/v3"
 	"github.com/fatih/color"
 	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/utils"
 )
 
 var (
@@ -22,7 +25,8 @@ type ResultWriter struct {
 	mutex         *sync.Mutex
 	results       []models.SubdomainResult
 	showIP        bool
-	foundTakeover bool // Tracks if a takeover is detected
+	foundTakeover bool                // Tracks if a takeover is detected
+	filter        *utils.ResultFilter // Optional match/filter rules for display
 }
 
 // NewResultWriter creates a new instance of ResultWriter
@@ -36,11 +40,23 @@ func NewResultWriter(bar *pb.ProgressBar, showIP bool) *ResultWriter {
 	}
 }
 
+// SetFilter sets the match/filter rules applied to displayed results
+func (rw *ResultWriter) SetFilter(filter *utils.ResultFilter) {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+	rw.filter = filter
+}
+
 // WriteResult writes a new result while keeping the progress bar intact
 func (rw *ResultWriter) WriteResult(result models.SubdomainResult) {
 	rw.mutex.Lock()
 	defer rw.mutex.Unlock()
 
+	// Skip results hidden by match/filter rules
+	if !rw.filter.Allows(result.Subdomain) {
+		return
+	}
+
 	// Store result
 	rw.results = append(rw.results, result)
 
@@ -62,6 +78,17 @@ func (rw *ResultWriter) WriteResult(result models.SubdomainResult) {
 	fmt.Print(barString)
 }
 
+// WriteLine prints an informational line while keeping the progress bar intact
+func (rw *ResultWriter) WriteLine(line string) {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+
+	barString := rw.bar.String()
+	fmt.Print("\r\033[K")
+	fmt.Println(line)
+	fmt.Print(barString)
+}
+
 // GetResults returns all stored results
 func (rw *ResultWriter) GetResults() []models.SubdomainResult {
 	rw.mutex.Lock()
@@ -69,23 +96,188 @@ func (rw *ResultWriter) GetResults() []models.SubdomainResult {
 	return rw.results
 }
 
+// FilterResults returns the results allowed by the match/filter rules
+func FilterResults(results []models.SubdomainResult, filter *utils.ResultFilter) []models.SubdomainResult {
+	if filter == nil {
+		return results
+	}
+
+	var filtered []models.SubdomainResult
+	for _, result := range results {
+		if filter.Allows(result.Subdomain) {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
 // DisplayResult formats and prints a single subdomain result
 func DisplayResult(result models.SubdomainResult, showIP bool) {
-	subdomain := cyan(result.Subdomain)
+	// Results are displayed as they are found, before the scanner rates the whole set
+	if result.Severity == "" {
+		result = models.RateResult(result)
+	}
+	subdomain := cyan(utils.DisplayDomain(result.Subdomain))
 
+	var line string
 	if result.Takeover != "" {
 		// Prioritize displaying takeover alerts with a clear flag
 		if showIP && len(result.IPs) > 0 {
-			fmt.Printf(" !  %s (%s) | %s\n", subdomain, result.IPs[0], red("Possible Takeover: "+result.Takeover))
+			line = fmt.Sprintf(" !  %s (%s) | %s", subdomain, result.IPs[0], red("Possible Takeover: "+result.Takeover))
 		} else {
-			fmt.Printf(" !  %s | %s\n", subdomain, red("Possible Takeover: "+result.Takeover))
+			line = fmt.Sprintf(" !  %s | %s", subdomain, red("Possible Takeover: "+result.Takeover))
+		}
+	} else if len(result.Dangling) > 0 {
+		// Dangling records are flagged like takeovers, with their type and severity
+		if showIP && len(result.IPs) > 0 {
+			line = fmt.Sprintf(" !  %s (%s) | %s", subdomain, result.IPs[0], red("Dangling: "+joinDangling(result.Dangling, "; ")))
+		} else {
+			line = fmt.Sprintf(" !  %s | %s", subdomain, red("Dangling: "+joinDangling(result.Dangling, "; ")))
 		}
 	} else {
 		// Normal display for subdomains without takeover warnings
 		if showIP && len(result.IPs) > 0 {
-			fmt.Printf(" +  %s → %s\n", subdomain, result.IPs[0])
+			line = fmt.Sprintf(" +  %s → %s", subdomain, result.IPs[0])
+		} else {
+			line = fmt.Sprintf(" +  %s", subdomain)
+		}
+	}
+
+	// Append optional enrichment tags
+	if result.DNSSEC != "" {
+		line += " " + yellow("[dnssec:"+result.DNSSEC+"]")
+	}
+	if result.Provider != "" {
+		provider := result.Provider
+		if result.Region != "" {
+			provider += "/" + result.Region
+		}
+		line += " " + yellow("["+provider+"]")
+	}
+	if len(result.Networks) > 0 {
+		line += " " + yellow("[net:"+networkTag(result.Networks)+"]")
+	}
+	if len(result.Ports) > 0 {
+		ports := make([]string, len(result.Ports))
+		for i, port := range result.Ports {
+			ports[i] = strconv.Itoa(port)
+		}
+		line += " " + yellow("[ports:"+strings.Join(ports, ",")+"]")
+	}
+	if result.Source != "" {
+		line += " " + yellow("["+result.Source+"]")
+	}
+	if result.HTTP != nil {
+		tag := fmt.Sprintf("http:%d", result.HTTP.StatusCode)
+		if title := result.HTTP.Title; title != "" {
+			if len(title) > 40 {
+				title = title[:40] + "…"
+			}
+			tag += fmt.Sprintf(" %q", title)
+		}
+		line += " " + yellow("["+tag+"]")
+		if len(result.HTTP.Technologies) > 0 {
+			line += " " + yellow("[tech:"+joinTechnologies(result.HTTP.Technologies, ",")+"]")
+		}
+	}
+	if result.Email != nil {
+		tag := "email"
+		if len(result.Email.Issues) > 0 {
+			// Issues are sorted, the first one is the most severe
+			tag += fmt.Sprintf(":%d issues, %s", len(result.Email.Issues), result.Email.Issues[0].Severity)
+		}
+		line += " " + yellow("["+tag+"]")
+	}
+	if result.Takeover != "" && len(result.Dangling) > 0 {
+		line += " " + red("[dangling: "+joinDangling(result.Dangling, "; ")+"]")
+	}
+	if result.Unverified {
+		line += " " + yellow("[unverified]")
+	}
+	if result.Resolution != "" && result.Resolution != models.ResolutionResolvable {
+		line += " " + yellow("["+result.Resolution+"]")
+	}
+	if result.Evidence != "" {
+		line += " " + yellow("[evidence:"+result.Evidence+"]")
+	}
+	if severity := result.Severity; severity != models.SeverityInfo {
+		if models.SeverityAtLeast(severity, models.SeverityHigh) {
+			line += " " + red("["+severity+"]")
 		} else {
-			fmt.Printf(" +  %s\n", subdomain)
+			line += " " + yellow("["+severity+"]")
+		}
+	}
+	if len(result.Tags) > 0 {
+		line += " " + yellow("[tags:"+strings.Join(result.Tags, ",")+"]")
+	}
+	if result.Note != "" {
+		line += " " + yellow(fmt.Sprintf("[note: %s]", result.Note))
+	}
+
+	fmt.Println(line)
+}
+
+// joinTechnologies formats detected technologies with their versions
+func joinTechnologies(techs []models.Technology, sep string) string {
+	names := make([]string, len(techs))
+	for i, tech := range techs {
+		names[i] = tech.String()
+	}
+	return strings.Join(names, sep)
+}
+
+// joinNetworks returns the distinct network names, organizations and countries of networks
+func joinNetworks(networks []models.NetworkInfo, sep string) (names, orgs, countries string) {
+	var nameList, orgList, countryList []string
+	for _, network := range networks {
+		nameList = appendDistinct(nameList, network.Name)
+		orgList = appendDistinct(orgList, network.Org)
+		countryList = appendDistinct(countryList, network.Country)
+	}
+	return strings.Join(nameList, sep), strings.Join(orgList, sep), strings.Join(countryList, sep)
+}
+
+// networkTag formats the first network of a result as "netname, org, country",
+// counting the other networks the addresses belong to
+func networkTag(networks []models.NetworkInfo) string {
+	first := networks[0]
+	var parts []string
+	for _, part := range []string{first.Name, first.Org, first.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	tag := strings.Join(parts, ", ")
+	others := 0
+	for _, network := range networks[1:] {
+		if network.Handle != first.Handle || network.Name != first.Name {
+			others++
 		}
 	}
+	if others > 0 {
+		tag += fmt.Sprintf(" +%d", others)
+	}
+	return tag
+}
+
+// appendDistinct appends value to list unless it is empty or already listed
+func appendDistinct(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// joinDangling formats dangling records as "type (severity) target"
+func joinDangling(records []models.DanglingRecord, sep string) string {
+	parts := make([]string, len(records))
+	for i, record := range records {
+		parts[i] = fmt.Sprintf("%s (%s) %s", record.Type, record.Severity, record.Target)
+	}
+	return strings.Join(parts, sep)
 }
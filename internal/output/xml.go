@@ -0,0 +1,185 @@
+package output
+
+import (
+	"encoding/xml"
+	"os"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// xmlReport is the root element of XML output, carrying the same data as OutputJSON
+// Scalar values are attributes and lists repeated child elements, as most XML consumers expect
+type xmlReport struct {
+	XMLName       xml.Name       `xml:"subcollector"`
+	SchemaVersion string         `xml:"schema_version,attr"`
+	Domain        string         `xml:"domain,attr"`
+	Tool          string         `xml:"tool,attr"`
+	Version       string         `xml:"version,attr"`
+	Mode          string         `xml:"mode,attr"`
+	StartedAt     time.Time      `xml:"started_at,attr"`
+	FinishedAt    time.Time      `xml:"finished_at,attr"`
+	Fingerprint   string         `xml:"config_fingerprint,attr,omitempty"`
+	Counts        xmlCounts      `xml:"counts"`
+	Subdomains    []xmlSubdomain `xml:"subdomains>subdomain"`
+}
+
+type xmlCounts struct {
+	Subdomains int `xml:"subdomains,attr"`
+	WithIPs    int `xml:"with_ips,attr"`
+	Takeovers  int `xml:"takeovers,attr"`
+	Dangling   int `xml:"dangling,attr"`
+}
+
+type xmlSubdomain struct {
+	Name         string        `xml:"name,attr"`
+	TTL          uint32        `xml:"ttl,attr,omitempty"`
+	ResponseTime float64       `xml:"response_ms,attr,omitempty"`
+	DNSSEC       string        `xml:"dnssec,attr,omitempty"`
+	Provider     string        `xml:"provider,attr,omitempty"`
+	Region       string        `xml:"region,attr,omitempty"`
+	Source       string        `xml:"source,attr,omitempty"`
+	Resolution   string        `xml:"resolution,attr,omitempty"`
+	Severity     string        `xml:"severity,attr,omitempty"`
+	Unverified   bool          `xml:"unverified,attr,omitempty"`
+	Tags         []string      `xml:"tag"`
+	Note         string        `xml:"note,omitempty"`
+	IPs          []string      `xml:"ip"`
+	CNAME        []string      `xml:"cname"`
+	Ports        []int         `xml:"port"`
+	Takeover     *xmlTakeover  `xml:"takeover"`
+	TLS          *xmlTLS       `xml:"tls"`
+	HTTP         *xmlHTTP      `xml:"http"`
+	Dangling     []xmlDangling `xml:"dangling"`
+	Email        *xmlEmail     `xml:"email"`
+	Networks     []xmlNetwork  `xml:"network"`
+}
+
+type xmlTakeover struct {
+	Service  string `xml:"service,attr"`
+	Evidence string `xml:"evidence,attr,omitempty"`
+}
+
+type xmlTLS struct {
+	Subject   string    `xml:"subject,attr"`
+	Issuer    string    `xml:"issuer,attr"`
+	NotBefore time.Time `xml:"not_before,attr"`
+	NotAfter  time.Time `xml:"not_after,attr"`
+	SANs      []string  `xml:"san"`
+}
+
+type xmlHTTP struct {
+	URL          string          `xml:"url,attr"`
+	StatusCode   int             `xml:"status_code,attr"`
+	Title        string          `xml:"title,attr,omitempty"`
+	Server       string          `xml:"server,attr,omitempty"`
+	FaviconHash  int32           `xml:"favicon_mmh3,attr,omitempty"`
+	Technologies []xmlTechnology `xml:"technology"`
+}
+
+type xmlTechnology struct {
+	Name     string `xml:"name,attr"`
+	Category string `xml:"category,attr"`
+	Version  string `xml:"version,attr,omitempty"`
+}
+
+type xmlDangling struct {
+	Type     string `xml:"type,attr"`
+	Severity string `xml:"severity,attr"`
+	Target   string `xml:"target,attr"`
+	Detail   string `xml:"detail,attr,omitempty"`
+}
+
+type xmlNetwork struct {
+	IP      string `xml:"ip,attr"`
+	Range   string `xml:"range,attr,omitempty"`
+	Handle  string `xml:"handle,attr,omitempty"`
+	Name    string `xml:"netname,attr,omitempty"`
+	Org     string `xml:"org,attr,omitempty"`
+	Country string `xml:"country,attr,omitempty"`
+}
+
+type xmlEmail struct {
+	SPF    string          `xml:"spf,attr,omitempty"`
+	DMARC  string          `xml:"dmarc,attr,omitempty"`
+	MX     []string        `xml:"mx"`
+	Issues []xmlEmailIssue `xml:"issue"`
+}
+
+type xmlEmailIssue struct {
+	Issue    string `xml:"name,attr"`
+	Severity string `xml:"severity,attr"`
+	Detail   string `xml:"detail,attr,omitempty"`
+}
+
+// SaveXML writes results as an XML document for tools that do not read JSON
+func SaveXML(outputFile, domain string, results []models.SubdomainResult, metadata models.ScanMetadata) error {
+	counts := models.CountResults(results)
+	report := xmlReport{
+		SchemaVersion: models.OutputSchemaVersion,
+		Domain:        domain,
+		Tool:          metadata.Tool.Name,
+		Version:       metadata.Tool.Version,
+		Mode:          metadata.Mode,
+		StartedAt:     metadata.StartedAt,
+		FinishedAt:    metadata.FinishedAt,
+		Fingerprint:   metadata.Config.Fingerprint,
+		Counts:        xmlCounts{counts.Subdomains, counts.WithIPs, counts.Takeovers, counts.Dangling},
+	}
+	for _, result := range results {
+		report.Subdomains = append(report.Subdomains, xmlResult(result))
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputFile, append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
+
+// xmlResult converts a result to its XML element
+func xmlResult(result models.SubdomainResult) xmlSubdomain {
+	element := xmlSubdomain{
+		Name:         result.Subdomain,
+		TTL:          result.TTL,
+		ResponseTime: result.ResponseTime,
+		DNSSEC:       result.DNSSEC,
+		Provider:     result.Provider,
+		Region:       result.Region,
+		Source:       result.Source,
+		Resolution:   result.Resolution,
+		Severity:     result.Severity,
+		Unverified:   result.Unverified,
+		Tags:         result.Tags,
+		Note:         result.Note,
+		IPs:          result.IPs,
+		CNAME:        result.CNAME,
+		Ports:        result.Ports,
+	}
+
+	if result.Takeover != "" {
+		element.Takeover = &xmlTakeover{Service: result.Takeover, Evidence: result.Evidence}
+	}
+	if cert := result.TLS; cert != nil {
+		element.TLS = &xmlTLS{Subject: cert.Subject, Issuer: cert.Issuer, NotBefore: cert.NotBefore, NotAfter: cert.NotAfter, SANs: cert.SANs}
+	}
+	if http := result.HTTP; http != nil {
+		element.HTTP = &xmlHTTP{URL: http.URL, StatusCode: http.StatusCode, Title: http.Title, Server: http.Server, FaviconHash: http.FaviconHash}
+		for _, tech := range http.Technologies {
+			element.HTTP.Technologies = append(element.HTTP.Technologies, xmlTechnology{tech.Name, tech.Category, tech.Version})
+		}
+	}
+	for _, record := range result.Dangling {
+		element.Dangling = append(element.Dangling, xmlDangling{record.Type, record.Severity, record.Target, record.Detail})
+	}
+	if posture := result.Email; posture != nil {
+		element.Email = &xmlEmail{SPF: posture.SPF, DMARC: posture.DMARC, MX: posture.MX}
+		for _, issue := range posture.Issues {
+			element.Email.Issues = append(element.Email.Issues, xmlEmailIssue{issue.Issue, issue.Severity, issue.Detail})
+		}
+	}
+	for _, network := range result.Networks {
+		element.Networks = append(element.Networks, xmlNetwork{network.IP, network.Range, network.Handle, network.Name, network.Org, network.Country})
+	}
+	return element
+}
@@ -0,0 +1,151 @@
+package probe
+
+import (
+	"crypto/tls"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+const (
+	// DefaultHTTPTimeout bounds each request of an HTTP probe
+	DefaultHTTPTimeout = 10 * time.Second
+
+	// maxProbeBody caps how much of a page or icon is read
+	maxProbeBody = 1 << 20
+
+	// maxTitleLength caps recorded page titles
+	maxTitleLength = 200
+
+	// maxResourceHosts caps the hosts of page resources recorded per page
+	maxResourceHosts = 50
+)
+
+var (
+	titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	iconPattern  = regexp.MustCompile(`(?is)<link[^>]+rel\s*=\s*["'][^"']*icon[^"']*["'][^>]*>`)
+	hrefPattern  = regexp.MustCompile(`(?is)href\s*=\s*["']([^"']+)["']`)
+
+	// resourcePattern matches the URL of a script, image, stylesheet, frame or embedded object
+	resourcePattern = regexp.MustCompile(`(?is)<(?:script|img|link|iframe|frame|source|embed|object)\b[^>]*?\s(?:src|href|data)\s*=\s*["']([^"']+)["']`)
+)
+
+// NewHTTPClient creates the client used for HTTP probes
+// Certificates are not verified so hosts with broken TLS are still fingerprinted
+func NewHTTPClient(proxy string) *http.Client {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	if proxyURL, err := utils.ParseProxy(proxy); proxy != "" && err == nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return &http.Client{Transport: utils.WrapTransport(transport), Timeout: DefaultHTTPTimeout}
+}
+
+// ProbeHTTP fetches the front page of host over HTTPS, falling back to HTTP
+// Returns the final URL, status, title, Server header, favicon hash, the detected
+// technologies when tech is set and the hosts of page resources when resources is
+// set, or nil when neither scheme answers
+func ProbeHTTP(client *http.Client, host string, tech, resources bool) *models.HTTPInfo {
+	for _, scheme := range []string{"https", "http"} {
+		resp, err := client.Get(scheme + "://" + host + "/")
+		if err != nil {
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+		resp.Body.Close()
+
+		info := &models.HTTPInfo{
+			URL:        resp.Request.URL.String(),
+			StatusCode: resp.StatusCode,
+			Title:      pageTitle(body),
+			Server:     resp.Header.Get("Server"),
+		}
+		info.FaviconURL, info.FaviconHash = favicon(client, resp.Request.URL, body)
+		if tech {
+			info.Technologies = DetectTechnologies(resp.Header, body)
+		}
+		if resources {
+			info.Resources = ResourceHosts(resp.Request.URL, body)
+		}
+		return info
+	}
+	return nil
+}
+
+// pageTitle returns the whitespace-collapsed title of an HTML page
+func pageTitle(body []byte) string {
+	match := titlePattern.FindSubmatch(body)
+	if match == nil {
+		return ""
+	}
+	title := strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+	if len(title) > maxTitleLength {
+		title = title[:maxTitleLength]
+	}
+	return title
+}
+
+// ResourceHosts returns the hosts other than the page's own that serve its scripts,
+// images, stylesheets, frames and embedded objects, sorted
+// Relative URLs are resolved against page, URLs other than HTTP(S) are ignored
+func ResourceHosts(page *url.URL, body []byte) []string {
+	seen := map[string]bool{strings.ToLower(page.Hostname()): true}
+	var hosts []string
+	for _, match := range resourcePattern.FindAllSubmatch(body, -1) {
+		resource, err := page.Parse(strings.TrimSpace(html.UnescapeString(string(match[1]))))
+		if err != nil || (resource.Scheme != "http" && resource.Scheme != "https") {
+			continue
+		}
+		host := strings.ToLower(strings.TrimSuffix(resource.Hostname(), "."))
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		hosts = append(hosts, host)
+		if len(hosts) == maxResourceHosts {
+			break
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// favicon fetches the icon declared by the page, or /favicon.ico, and hashes it
+func favicon(client *http.Client, page *url.URL, body []byte) (string, int32) {
+	iconURL, _ := page.Parse("/favicon.ico")
+	if link := iconPattern.Find(body); link != nil {
+		if href := hrefPattern.FindSubmatch(link); href != nil {
+			if declared, err := page.Parse(html.UnescapeString(string(href[1]))); err == nil {
+				iconURL = declared
+			}
+		}
+	}
+	if iconURL.Scheme != "http" && iconURL.Scheme != "https" {
+		return "", 0
+	}
+
+	resp, err := client.Get(iconURL.String())
+	if err != nil {
+		return "", 0
+	}
+	defer resp.Body.Close()
+
+	icon, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	if err != nil || resp.StatusCode != http.StatusOK || len(icon) == 0 {
+		return "", 0
+	}
+	// Soft 404 pages are not icons
+	if strings.Contains(strings.ToLower(resp.Header.Get("Content-Type")), "text/html") {
+		return "", 0
+	}
+	return iconURL.String(), FaviconHash(icon)
+}
@@ -0,0 +1,71 @@
+package probe
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math/bits"
+)
+
+// FaviconHash returns the favicon hash used by Shodan (http.favicon.hash)
+// It is the signed 32-bit MurmurHash3 of the base64 encoded icon, wrapped
+// every 76 characters with a trailing newline like Python's base64.encodebytes
+func FaviconHash(icon []byte) int32 {
+	encoded := base64.StdEncoding.EncodeToString(icon)
+
+	wrapped := make([]byte, 0, len(encoded)+len(encoded)/76+1)
+	for len(encoded) > 76 {
+		wrapped = append(wrapped, encoded[:76]...)
+		wrapped = append(wrapped, '\n')
+		encoded = encoded[76:]
+	}
+	wrapped = append(wrapped, encoded...)
+	wrapped = append(wrapped, '\n')
+
+	return int32(murmur3(wrapped, 0))
+}
+
+// murmur3 computes the x86 32-bit MurmurHash3 of data
+func murmur3(data []byte, seed uint32) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+
+	h := seed
+	blocks := len(data) / 4
+	for i := 0; i < blocks; i++ {
+		k := binary.LittleEndian.Uint32(data[i*4:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	var k uint32
+	tail := data[blocks*4:]
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,107 @@
+package probe
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPortTimeout bounds a single TCP connect attempt
+const DefaultPortTimeout = time.Second
+
+// portScanConcurrency is the number of simultaneous connects per address
+const portScanConcurrency = 50
+
+// TopPorts lists the most common open TCP ports, most frequent first (nmap top 100)
+var TopPorts = []int{
+	80, 23, 443, 21, 22, 25, 3389, 110, 445, 139, 143, 53, 135, 3306, 8080, 1723, 111, 995, 993, 5900,
+	1025, 587, 8888, 199, 1720, 465, 548, 113, 81, 6001, 10000, 514, 5060, 179, 1026, 2000, 8443, 8000, 32768, 554,
+	26, 1433, 49152, 2001, 515, 8008, 49154, 1027, 5666, 646, 5000, 5631, 631, 49153, 8081, 2049, 88, 79, 5800, 106,
+	2121, 1110, 49155, 6000, 513, 990, 5357, 427, 49156, 543, 544, 5101, 144, 7, 389, 8009, 3128, 444, 9999, 5009,
+	7070, 5190, 3000, 5432, 1900, 3986, 13, 1029, 9, 5051, 6646, 49157, 1028, 873, 1755, 2717, 4899, 9100, 119, 37,
+}
+
+// ParsePorts parses a port specification
+// Accepts "top-N" (the N most common ports, N up to 100) or a list of ports and ranges such as "80,443,8000-8100"
+func ParsePorts(spec string) ([]int, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(spec, "top-") {
+		n, err := strconv.Atoi(strings.TrimPrefix(spec, "top-"))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid port specification %q", spec)
+		}
+		if n > len(TopPorts) {
+			n = len(TopPorts)
+		}
+		return append([]int(nil), TopPorts[:n]...), nil
+	}
+
+	seen := make(map[int]bool)
+	var ports []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		low, high := part, part
+		if i := strings.Index(part, "-"); i >= 0 {
+			low, high = part[:i], part[i+1:]
+		}
+
+		start, err1 := strconv.Atoi(low)
+		end, err2 := strconv.Atoi(high)
+		if err1 != nil || err2 != nil || start < 1 || end > 65535 || start > end {
+			return nil, fmt.Errorf("invalid port or range %q", part)
+		}
+
+		for port := start; port <= end; port++ {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+
+	return ports, nil
+}
+
+// ScanPorts runs a TCP connect scan of the given ports against an address
+// Returns the open ports in ascending order
+func ScanPorts(ip string, ports []int, timeout time.Duration) []int {
+	var open []int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, portScanConcurrency)
+
+	for _, port := range ports {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(port int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), timeout)
+			if err != nil {
+				return
+			}
+			conn.Close()
+
+			mu.Lock()
+			open = append(open, port)
+			mu.Unlock()
+		}(port)
+	}
+
+	wg.Wait()
+	sort.Ints(open)
+	return open
+}
@@ -0,0 +1,180 @@
+package probe
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// Technology categories
+const (
+	CategoryServer    = "server"
+	CategoryCDN       = "cdn"
+	CategoryLanguage  = "language"
+	CategoryFramework = "framework"
+	CategoryCMS       = "cms"
+	CategoryApp       = "app"
+	CategoryLibrary   = "library"
+)
+
+// techMatcher looks for a technology in one part of a response
+// The first capture group of pattern, when present and non-empty, is the version
+type techMatcher struct {
+	header  string // Header to match, "" for the body
+	cookie  bool   // Match cookie names set by the response instead
+	pattern *regexp.Regexp
+}
+
+// techRule identifies one technology by any of its matchers
+type techRule struct {
+	name     string
+	category string
+	matchers []techMatcher
+}
+
+// header matches a response header value
+func header(name, pattern string) techMatcher {
+	return techMatcher{header: http.CanonicalHeaderKey(name), pattern: regexp.MustCompile("(?i)" + pattern)}
+}
+
+// body matches the page content
+func body(pattern string) techMatcher {
+	return techMatcher{pattern: regexp.MustCompile("(?i)" + pattern)}
+}
+
+// cookie matches the name of a cookie set by the response
+func cookie(pattern string) techMatcher {
+	return techMatcher{cookie: true, pattern: regexp.MustCompile("(?i)^" + pattern + "$")}
+}
+
+// techRules are the fingerprints checked on every probed page
+var techRules = []techRule{
+	// Web servers
+	{"Nginx", CategoryServer, []techMatcher{header("Server", `^nginx(?:/([\d.]+))?`)}},
+	{"OpenResty", CategoryServer, []techMatcher{header("Server", `^openresty(?:/([\d.]+))?`)}},
+	{"Apache HTTP Server", CategoryServer, []techMatcher{header("Server", `^apache(?:/([\d.]+))?`)}},
+	{"Microsoft IIS", CategoryServer, []techMatcher{header("Server", `^microsoft-iis(?:/([\d.]+))?`)}},
+	{"LiteSpeed", CategoryServer, []techMatcher{header("Server", `^litespeed`)}},
+	{"Caddy", CategoryServer, []techMatcher{header("Server", `^caddy`)}},
+	{"Envoy", CategoryServer, []techMatcher{header("Server", `^envoy`), header("X-Envoy-Upstream-Service-Time", `.`)}},
+	{"Jetty", CategoryServer, []techMatcher{header("Server", `jetty(?:\(([\d.]+))?`)}},
+	{"Apache Tomcat", CategoryServer, []techMatcher{body(`Apache Tomcat(?:/([\d.]+))?`)}},
+	{"Gunicorn", CategoryServer, []techMatcher{header("Server", `^gunicorn(?:/([\d.]+))?`)}},
+	{"Kestrel", CategoryServer, []techMatcher{header("Server", `^kestrel`)}},
+
+	// CDNs and hosting
+	{"Cloudflare", CategoryCDN, []techMatcher{header("Server", `^cloudflare`), header("Cf-Ray", `.`)}},
+	{"Akamai", CategoryCDN, []techMatcher{header("Server", `akamai`), header("X-Akamai-Transformed", `.`)}},
+	{"Fastly", CategoryCDN, []techMatcher{header("X-Served-By", `cache-`), header("Fastly-Debug-Digest", `.`)}},
+	{"Amazon CloudFront", CategoryCDN, []techMatcher{header("X-Amz-Cf-Id", `.`), header("Via", `cloudfront`)}},
+	{"Amazon S3", CategoryCDN, []techMatcher{header("Server", `^amazons3`)}},
+	{"Vercel", CategoryCDN, []techMatcher{header("Server", `^vercel`), header("X-Vercel-Id", `.`)}},
+	{"Netlify", CategoryCDN, []techMatcher{header("Server", `^netlify`), header("X-Nf-Request-Id", `.`)}},
+	{"GitHub Pages", CategoryCDN, []techMatcher{header("Server", `^github\.com`)}},
+	{"Heroku", CategoryCDN, []techMatcher{header("Via", `vegur`)}},
+
+	// Languages
+	{"PHP", CategoryLanguage, []techMatcher{header("X-Powered-By", `php(?:/([\d.]+))?`), cookie(`PHPSESSID`)}},
+	{"ASP.NET", CategoryLanguage, []techMatcher{header("X-AspNet-Version", `([\d.]+)`), header("X-Powered-By", `asp\.net`), cookie(`ASP\.NET_SessionId`)}},
+	{"Java", CategoryLanguage, []techMatcher{cookie(`JSESSIONID`)}},
+
+	// Frameworks
+	{"Express", CategoryFramework, []techMatcher{header("X-Powered-By", `^express`)}},
+	{"Next.js", CategoryFramework, []techMatcher{header("X-Powered-By", `next\.js`), body(`__NEXT_DATA__|/_next/static/`)}},
+	{"Nuxt.js", CategoryFramework, []techMatcher{body(`__NUXT__|/_nuxt/`)}},
+	{"Angular", CategoryFramework, []techMatcher{body(`ng-version="([\d.]+)"`)}},
+	{"React", CategoryFramework, []techMatcher{body(`data-reactroot|react-dom(?:\.production)?(?:\.min)?\.js`)}},
+	{"Vue.js", CategoryFramework, []techMatcher{body(`data-v-[0-9a-f]{8}|vue(?:\.runtime)?(?:\.min)?\.js`)}},
+	{"Django", CategoryFramework, []techMatcher{body(`csrfmiddlewaretoken`), cookie(`django_language`)}},
+	{"Laravel", CategoryFramework, []techMatcher{cookie(`laravel_session`)}},
+	{"Ruby on Rails", CategoryFramework, []techMatcher{header("X-Powered-By", `phusion passenger`), body(`<meta name="csrf-param" content="authenticity_token"`)}},
+	{"Spring", CategoryFramework, []techMatcher{header("X-Application-Context", `.`), body(`Whitelabel Error Page`)}},
+	{"jQuery", CategoryLibrary, []techMatcher{body(`jquery[.-]([\d.]+\d)(?:\.min)?\.js`), body(`/jquery(?:\.min)?\.js`)}},
+	{"Swagger UI", CategoryLibrary, []techMatcher{body(`swagger-ui`)}},
+
+	// Content management and commerce
+	{"WordPress", CategoryCMS, []techMatcher{body(`content="WordPress ?([\d.]*)"`), body(`/wp-(?:content|includes)/`)}},
+	{"Drupal", CategoryCMS, []techMatcher{header("X-Generator", `drupal ?(\d*)`), header("X-Drupal-Cache", `.`), body(`Drupal\.settings`)}},
+	{"Joomla", CategoryCMS, []techMatcher{body(`content="Joomla!? ?([\d.]*)`)}},
+	{"Ghost", CategoryCMS, []techMatcher{body(`content="Ghost ?([\d.]*)"`)}},
+	{"Shopify", CategoryCMS, []techMatcher{header("X-Shopid", `.`), body(`cdn\.shopify\.com`)}},
+	{"Wix", CategoryCMS, []techMatcher{header("X-Wix-Request-Id", `.`)}},
+	{"Squarespace", CategoryCMS, []techMatcher{body(`static\.squarespace\.com`)}},
+	{"Magento", CategoryCMS, []techMatcher{body(`Mage\.Cookies|/static/version\d+/frontend/`)}},
+
+	// Applications worth a closer look
+	{"Jenkins", CategoryApp, []techMatcher{header("X-Jenkins", `([\d.]+)`)}},
+	{"GitLab", CategoryApp, []techMatcher{cookie(`_gitlab_session`), body(`gon\.gitlab_url|content="GitLab"`)}},
+	{"Grafana", CategoryApp, []techMatcher{body(`grafanaBootData|<title>Grafana</title>`)}},
+	{"Kibana", CategoryApp, []techMatcher{header("Kbn-Version", `([\d.]+)`), header("Kbn-Name", `.`)}},
+	{"Jira", CategoryApp, []techMatcher{header("X-Arequestid", `.`), body(`<meta name="application-name" content="JIRA"`)}},
+	{"Confluence", CategoryApp, []techMatcher{header("X-Confluence-Request-Time", `.`)}},
+	{"SonarQube", CategoryApp, []techMatcher{body(`<title>SonarQube</title>`)}},
+	{"Keycloak", CategoryApp, []techMatcher{body(`/auth/resources/[^/]+/login/keycloak|kc-form-login`)}},
+	{"phpMyAdmin", CategoryApp, []techMatcher{body(`<title>phpMyAdmin|pma_navigation`)}},
+	{"Outlook Web App", CategoryApp, []techMatcher{header("X-Owa-Version", `([\d.]+)`), body(`/owa/auth/`)}},
+	{"Citrix Gateway", CategoryApp, []techMatcher{cookie(`NSC_[A-Za-z0-9_]+`), body(`/vpn/resources/|Citrix Gateway`)}},
+	{"FortiGate", CategoryApp, []techMatcher{body(`/remote/login|fgt_lang`)}},
+	{"Pulse Secure", CategoryApp, []techMatcher{body(`/dana-na/`)}},
+}
+
+// DetectTechnologies returns the technologies a response reveals, sorted by category and name
+func DetectTechnologies(headers http.Header, page []byte) []models.Technology {
+	var cookies []string
+	for _, raw := range headers.Values("Set-Cookie") {
+		name, _, _ := strings.Cut(raw, "=")
+		cookies = append(cookies, strings.TrimSpace(name))
+	}
+
+	var found []models.Technology
+	for _, rule := range techRules {
+		if version, ok := rule.match(headers, cookies, page); ok {
+			found = append(found, models.Technology{Name: rule.name, Category: rule.category, Version: version})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Category != found[j].Category {
+			return found[i].Category < found[j].Category
+		}
+		return found[i].Name < found[j].Name
+	})
+	return found
+}
+
+// match reports whether any matcher of the rule fires, with the first version found
+func (r techRule) match(headers http.Header, cookies []string, page []byte) (string, bool) {
+	matched := false
+	for _, m := range r.matchers {
+		var groups [][]byte
+		switch {
+		case m.cookie:
+			for _, name := range cookies {
+				if m.pattern.MatchString(name) {
+					groups = [][]byte{[]byte(name)}
+					break
+				}
+			}
+		case m.header != "":
+			for _, value := range headers.Values(m.header) {
+				if groups = m.pattern.FindSubmatch([]byte(value)); groups != nil {
+					break
+				}
+			}
+		default:
+			groups = m.pattern.FindSubmatch(page)
+		}
+
+		if groups == nil {
+			continue
+		}
+		matched = true
+		if len(groups) > 1 && len(groups[1]) > 0 {
+			return string(groups[1]), true
+		}
+	}
+	return "", matched
+}
@@ -0,0 +1,50 @@
+package probe
+
+import (
+	"crypto/tls"
+	"crypto/x509/pkix"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// DefaultTLSTimeout bounds the TCP connect plus TLS handshake
+const DefaultTLSTimeout = 5 * time.Second
+
+// GrabCertificate connects to host:port over TLS and returns the details of the leaf certificate
+// Certificates are read without verification so expired or self-signed ones are recorded too
+func GrabCertificate(host string, port int, timeout time.Duration) (*models.CertInfo, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, strconv.Itoa(port)), &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, nil
+	}
+	leaf := certs[0]
+
+	return &models.CertInfo{
+		Subject:   nameOf(leaf.Subject),
+		Issuer:    nameOf(leaf.Issuer),
+		SANs:      leaf.DNSNames,
+		NotBefore: leaf.NotBefore,
+		NotAfter:  leaf.NotAfter,
+	}, nil
+}
+
+// nameOf returns the common name of a certificate name, or the full name when it has none
+func nameOf(name pkix.Name) string {
+	if name.CommonName != "" {
+		return name.CommonName
+	}
+	return name.String()
+}
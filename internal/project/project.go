@@ -0,0 +1,76 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Project is the scope of an organization: the root domains it owns and the hosts left out
+//
+//	name: acme
+//	domains:
+//	  - acme.com
+//	  - acme.io
+//	  - acme-cdn.net
+//	exclude:
+//	  - "*.corp.acme.com"
+//	  - re:^dev[0-9]+\.
+//	tags: [acme]
+type Project struct {
+	Name    string   `yaml:"name"`              // Name of the project in reports, the file name when empty
+	Domains []string `yaml:"domains"`           // Root domains scanned together
+	Exclude []string `yaml:"exclude,omitempty"` // Exclusion patterns, same syntax as --exclude
+	Tags    []string `yaml:"tags,omitempty"`    // Labels added to every result, same as --tag
+}
+
+// Load reads a project file and normalizes its domains
+// Domains are cleaned and deduplicated, unknown keys are rejected so typos do not widen the scope silently
+func Load(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project: %v", err)
+	}
+
+	var p Project
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid project file %s: %v", path, err)
+	}
+
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	var domains []string
+	for _, d := range p.Domains {
+		if cleaned := strings.ToLower(utils.CleanDomain(d)); cleaned != "" && !slices.Contains(domains, cleaned) {
+			domains = append(domains, cleaned)
+		}
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("project %s lists no domains", path)
+	}
+	p.Domains = domains
+	return &p, nil
+}
+
+// Save writes the project file, replacing it when it exists
+func (p *Project) Save(path string) error {
+	var data bytes.Buffer
+	encoder := yaml.NewEncoder(&data)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(p); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write project: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,448 @@
+package project
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// Scope formats read by ReadScope
+const (
+	PlatformHackerOne = "hackerone" // HackerOne scope CSV, structured scopes API or bounty-targets-data JSON
+	PlatformBugcrowd  = "bugcrowd"  // Bugcrowd target groups API or bounty-targets-data JSON
+	PlatformIntigriti = "intigriti" // Intigriti program domains API or bounty-targets-data JSON
+	PlatformWildcard  = "wildcard"  // One asset per line, out-of-scope ones prefixed with ! or -
+)
+
+// Platforms lists the accepted scope formats
+var Platforms = []string{PlatformHackerOne, PlatformBugcrowd, PlatformIntigriti, PlatformWildcard}
+
+// nonHostTypes are asset types of the platforms that never name a host
+// App store identifiers look like domains (com.acme.app) and are skipped by type
+var nonHostTypes = map[string]bool{
+	"apple_store_app_id": true, "google_play_app_id": true, "windows_app_store_app_id": true,
+	"other_apk": true, "other_ipa": true, "testflight": true, "source_code": true,
+	"downloadable_executables": true, "hardware": true, "smart_contract": true, "ai_model": true,
+	"cidr": true, "ip_address": true, "iprange": true, "network": true,
+	"android": true, "ios": true, "mobile": true, "iot": true, "device": true, "executable": true,
+}
+
+// Scope is what a bug bounty program allows testing, as read from an export of its scope page
+type Scope struct {
+	Platform string
+	In       []string // In-scope assets as listed (example: *.acme.com, https://app.acme.com)
+	Out      []string // Out-of-scope assets
+	Ignored  []string // Assets of types that are not hosts (apps, IP ranges, ...)
+}
+
+// add records an asset unless its type is not a host
+func (s *Scope) add(identifier, kind string, inScope bool) {
+	identifier = strings.TrimSpace(identifier)
+	switch {
+	case identifier == "":
+	case nonHostTypes[strings.ToLower(strings.TrimSpace(kind))]:
+		s.Ignored = append(s.Ignored, identifier)
+	case inScope:
+		s.In = append(s.In, identifier)
+	default:
+		s.Out = append(s.Out, identifier)
+	}
+}
+
+// ReadScope reads the scope export of a bug bounty program
+// An empty platform is detected from the content of the file
+func ReadScope(path, platform string) (Scope, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scope{}, fmt.Errorf("failed to read scope: %v", err)
+	}
+	if platform == "" {
+		platform = DetectPlatform(data)
+	}
+
+	scope := Scope{Platform: platform}
+	switch platform {
+	case PlatformHackerOne:
+		err = scope.readHackerOne(data)
+	case PlatformBugcrowd:
+		err = scope.readBugcrowd(data)
+	case PlatformIntigriti:
+		err = scope.readIntigriti(data)
+	case PlatformWildcard:
+		scope.readWildcard(data)
+	default:
+		return Scope{}, fmt.Errorf("unknown scope platform %q, use %s", platform, strings.Join(Platforms, ", "))
+	}
+	if err != nil {
+		return Scope{}, fmt.Errorf("invalid %s scope %s: %v", platform, path, err)
+	}
+	return scope, nil
+}
+
+// DetectPlatform guesses the platform a scope export comes from
+// Anything that is neither a known CSV nor JSON is read as a wildcard list
+func DetectPlatform(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		header, _, _ := bytes.Cut(trimmed, []byte("\n"))
+		if bytes.Contains(header, []byte("identifier")) && bytes.Contains(header, []byte("asset_type")) {
+			return PlatformHackerOne
+		}
+		return PlatformWildcard
+	}
+
+	switch {
+	case bytes.Contains(data, []byte(`"asset_identifier"`)):
+		return PlatformHackerOne
+	case bytes.Contains(data, []byte(`"endpoint"`)):
+		return PlatformIntigriti
+	case bytes.Contains(data, []byte(`"target_groups"`)), bytes.Contains(data, []byte(`"target"`)):
+		return PlatformBugcrowd
+	}
+	return PlatformWildcard
+}
+
+// bountyTargets is the targets object of a program in bounty-targets-data
+// (github.com/arkadiyt/bounty-targets-data), shared by the three platforms
+type bountyTargets[T any] struct {
+	InScope    []T `json:"in_scope"`
+	OutOfScope []T `json:"out_of_scope"`
+}
+
+// decodePrograms decodes a single program object or an array of them
+func decodePrograms[T any](data []byte) ([]T, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var programs []T
+		err := json.Unmarshal(trimmed, &programs)
+		return programs, err
+	}
+	var program T
+	err := json.Unmarshal(data, &program)
+	return []T{program}, err
+}
+
+// hackerOneAsset is a structured scope of a HackerOne program
+type hackerOneAsset struct {
+	Identifier string `json:"asset_identifier"`
+	Type       string `json:"asset_type"`
+	Eligible   *bool  `json:"eligible_for_submission"` // Assets not eligible for submission are out of scope
+}
+
+// readHackerOne reads the scope CSV of a program page, the structured scopes
+// API (data[].attributes) or a bounty-targets-data program
+func (s *Scope) readHackerOne(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] != '{' && trimmed[0] != '[' {
+		return s.readHackerOneCSV(data)
+	}
+
+	programs, err := decodePrograms[struct {
+		Data []struct {
+			Attributes hackerOneAsset `json:"attributes"`
+		} `json:"data"`
+		Targets bountyTargets[hackerOneAsset] `json:"targets"`
+	}](data)
+	if err != nil {
+		return err
+	}
+	for _, program := range programs {
+		for _, item := range program.Data {
+			s.add(item.Attributes.Identifier, item.Attributes.Type, item.Attributes.Eligible == nil || *item.Attributes.Eligible)
+		}
+		for _, asset := range program.Targets.InScope {
+			s.add(asset.Identifier, asset.Type, asset.Eligible == nil || *asset.Eligible)
+		}
+		for _, asset := range program.Targets.OutOfScope {
+			s.add(asset.Identifier, asset.Type, false)
+		}
+	}
+	return nil
+}
+
+// readHackerOneCSV reads the CSV downloaded from the scope table of a program
+func (s *Scope) readHackerOneCSV(data []byte) error {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+	column := make(map[string]int, len(header))
+	for i, name := range header {
+		column[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	identifier, ok := column["identifier"]
+	if !ok {
+		return fmt.Errorf("no identifier column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := column[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if identifier >= len(record) {
+			continue
+		}
+		eligible := field(record, "eligible_for_submission")
+		s.add(record[identifier], field(record, "asset_type"), eligible == "" || strings.EqualFold(eligible, "true"))
+	}
+}
+
+// bugcrowdTarget is a target of a Bugcrowd program
+// bounty-targets-data names the host in target, the API in name or uri
+type bugcrowdTarget struct {
+	Target   string `json:"target"`
+	URI      string `json:"uri"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Category string `json:"category"`
+}
+
+// addTo records a Bugcrowd target, the first field naming it wins
+func (t bugcrowdTarget) addTo(s *Scope, inScope bool) {
+	kind := t.Type
+	if kind == "" {
+		kind = t.Category
+	}
+	for _, identifier := range []string{t.Target, t.URI, t.Name} {
+		if identifier != "" {
+			s.add(identifier, kind, inScope)
+			return
+		}
+	}
+}
+
+// readBugcrowd reads the target groups API of a program or a bounty-targets-data program
+func (s *Scope) readBugcrowd(data []byte) error {
+	programs, err := decodePrograms[struct {
+		TargetGroups []struct {
+			InScope bool             `json:"in_scope"`
+			Targets []bugcrowdTarget `json:"targets"`
+		} `json:"target_groups"`
+		Targets bountyTargets[bugcrowdTarget] `json:"targets"`
+	}](data)
+	if err != nil {
+		return err
+	}
+	for _, program := range programs {
+		for _, group := range program.TargetGroups {
+			for _, target := range group.Targets {
+				target.addTo(s, group.InScope)
+			}
+		}
+		for _, target := range program.Targets.InScope {
+			target.addTo(s, true)
+		}
+		for _, target := range program.Targets.OutOfScope {
+			target.addTo(s, false)
+		}
+	}
+	return nil
+}
+
+// intigritiDomain is a domain of an Intigriti program
+// The API wraps type and tier in {"value": ...} objects, bounty-targets-data does not
+type intigritiDomain struct {
+	Endpoint string          `json:"endpoint"`
+	Type     json.RawMessage `json:"type"`
+	Tier     json.RawMessage `json:"tier"`
+}
+
+// readIntigriti reads the program domains API (domains or domains.content)
+// or a bounty-targets-data program
+func (s *Scope) readIntigriti(data []byte) error {
+	programs, err := decodePrograms[struct {
+		Domains json.RawMessage                `json:"domains"`
+		Content []intigritiDomain              `json:"content"`
+		Targets bountyTargets[intigritiDomain] `json:"targets"`
+	}](data)
+	if err != nil {
+		return err
+	}
+	for _, program := range programs {
+		domains := program.Content
+		if len(program.Domains) > 0 {
+			var list []intigritiDomain
+			var page struct {
+				Content []intigritiDomain `json:"content"`
+			}
+			if json.Unmarshal(program.Domains, &list) == nil {
+				domains = append(domains, list...)
+			} else if err := json.Unmarshal(program.Domains, &page); err == nil {
+				domains = append(domains, page.Content...)
+			} else {
+				return err
+			}
+		}
+		for _, domain := range domains {
+			s.add(domain.Endpoint, enumValue(domain.Type), !strings.EqualFold(enumValue(domain.Tier), "out of scope"))
+		}
+		for _, domain := range program.Targets.InScope {
+			s.add(domain.Endpoint, enumValue(domain.Type), true)
+		}
+		for _, domain := range program.Targets.OutOfScope {
+			s.add(domain.Endpoint, enumValue(domain.Type), false)
+		}
+	}
+	return nil
+}
+
+// enumValue reads an Intigriti enum, either a plain string or a {"value": ...} object
+func enumValue(raw json.RawMessage) string {
+	var value string
+	if json.Unmarshal(raw, &value) == nil {
+		return value
+	}
+	var wrapped struct {
+		Value string `json:"value"`
+	}
+	json.Unmarshal(raw, &wrapped)
+	return wrapped.Value
+}
+
+// readWildcard reads one asset per line, # starting comments
+// Lines starting with ! or - are out of scope
+func (s *Scope) readWildcard(data []byte) {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "!"); ok {
+			s.add(rest, "", false)
+		} else if rest, ok := strings.CutPrefix(line, "-"); ok {
+			s.add(rest, "", false)
+		} else {
+			s.add(line, "", true)
+		}
+	}
+}
+
+// ImportScope adds a bug bounty scope to the project: wildcard and host assets in
+// scope become root domains, assets out of scope under them become exclusions
+// Root domains under another root domain are dropped, the enumeration of the
+// parent finds them. Returns the assets that name no host, such as example.*
+// or IP addresses, which have to be reviewed by hand
+func (p *Project) ImportScope(scope Scope) []string {
+	var skipped []string
+	for _, asset := range scope.In {
+		host, _, ok := scopeHost(asset)
+		if !ok {
+			skipped = append(skipped, asset)
+			continue
+		}
+		if !slices.Contains(p.Domains, host) {
+			p.Domains = append(p.Domains, host)
+		}
+	}
+
+	var domains []string
+	for _, domain := range p.Domains {
+		nested := false
+		for _, other := range p.Domains {
+			if utils.IsSubdomainOf(domain, other) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			domains = append(domains, domain)
+		}
+	}
+	p.Domains = domains
+
+	for _, asset := range scope.Out {
+		host, wildcard, ok := scopeHost(asset)
+		if !ok {
+			skipped = append(skipped, "!"+asset)
+			continue
+		}
+		if !p.covers(host) {
+			continue
+		}
+		pattern := host
+		if wildcard {
+			pattern = "*." + host
+		}
+		if !slices.Contains(p.Exclude, pattern) {
+			p.Exclude = append(p.Exclude, pattern)
+		}
+	}
+	return skipped
+}
+
+// covers reports whether a host is one of the project domains or under one
+func (p *Project) covers(host string) bool {
+	for _, domain := range p.Domains {
+		if host == domain || utils.IsSubdomainOf(host, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeHost extracts the host of a scope asset: a host, a URL or a *.wildcard
+// Returns whether the asset was a wildcard, and false for assets naming no host
+// (IP addresses, wildcards in the middle of a name, descriptions)
+func scopeHost(asset string) (string, bool, bool) {
+	host := strings.ToLower(strings.TrimSpace(asset))
+	if _, rest, ok := strings.Cut(host, "://"); ok {
+		host = rest
+	}
+	if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	if h, port, ok := strings.Cut(host, ":"); ok && port != "" && !strings.Contains(port, ":") {
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	wildcard := false
+	if rest, ok := strings.CutPrefix(host, "*."); ok {
+		host, wildcard = rest, true
+	}
+	if !isASCII(host) {
+		ascii, err := utils.ToASCII(host)
+		if err != nil {
+			return "", false, false
+		}
+		host = ascii
+	}
+
+	if !strings.Contains(host, ".") || net.ParseIP(host) != nil {
+		return "", false, false
+	}
+	for _, r := range host {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '.' || r == '_') {
+			return "", false, false
+		}
+	}
+	return host, wildcard, true
+}
+
+// isASCII reports whether s only holds ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
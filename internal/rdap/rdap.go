@@ -0,0 +1,304 @@
+package rdap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+const (
+	// DefaultTimeout bounds each RDAP request
+	DefaultTimeout = 10 * time.Second
+
+	// maxConcurrent caps the requests sent at once, registries throttle bulk lookups
+	maxConcurrent = 4
+
+	// maxResponse caps how much of an RDAP answer is read
+	maxResponse = 1 << 20
+
+	// failureTTL is how long an address whose lookup failed is not asked again
+	failureTTL = time.Hour
+)
+
+// bootstrapURLs are the IANA registries naming the RDAP service of each address block
+var bootstrapURLs = []string{
+	"https://data.iana.org/rdap/ipv4.json",
+	"https://data.iana.org/rdap/ipv6.json",
+}
+
+// service is an address block and the RDAP servers of the registry holding it
+type service struct {
+	prefix netip.Prefix
+	urls   []string
+}
+
+// network is a registered network already looked up
+type network struct {
+	start, end netip.Addr
+	info       models.NetworkInfo
+}
+
+// Client looks up the networks holding IP addresses over RDAP
+// Answers are cached by network, so the addresses of a network already seen
+// cost no request, and failed addresses are not asked again for failureTTL
+type Client struct {
+	http  *http.Client
+	slots chan struct{}
+
+	bootMu   sync.Mutex
+	services []service // Nil until the registries were read
+	bootErr  error
+	bootAt   time.Time
+
+	mu       sync.Mutex
+	networks []network
+	failed   map[netip.Addr]failure
+	pending  map[netip.Addr]chan struct{}
+}
+
+// failure is a failed lookup of an address
+type failure struct {
+	err error
+	at  time.Time
+}
+
+var (
+	clients   = make(map[string]*Client)
+	clientsMu sync.Mutex
+)
+
+// ClientFor returns the client sending requests through proxy, shared by every scan
+// of the process so the networks looked up by one scan serve the next ones
+func ClientFor(proxy string) *Client {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if client, ok := clients[proxy]; ok {
+		return client
+	}
+	client := NewClient(proxy)
+	clients[proxy] = client
+	return client
+}
+
+// NewClient creates a client with a cache of its own
+func NewClient(proxy string) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyURL, err := utils.ParseProxy(proxy); proxy != "" && err == nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return &Client{
+		http:    &http.Client{Transport: utils.WrapServiceTransport(transport), Timeout: DefaultTimeout},
+		slots:   make(chan struct{}, maxConcurrent),
+		failed:  make(map[netip.Addr]failure),
+		pending: make(map[netip.Addr]chan struct{}),
+	}
+}
+
+// LookupAll returns the networks of the public addresses among ips, in their order
+// Addresses whose lookup fails are left out
+func (c *Client) LookupAll(ctx context.Context, ips []string) []models.NetworkInfo {
+	var networks []models.NetworkInfo
+	for _, ip := range ips {
+		if info, err := c.Lookup(ctx, ip); err == nil && info != nil {
+			networks = append(networks, *info)
+		}
+	}
+	return networks
+}
+
+// Lookup returns the network holding ip, nil for private and reserved addresses
+func (c *Client) Lookup(ctx context.Context, ip string) (*models.NetworkInfo, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q", ip)
+	}
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return nil, nil
+	}
+
+	for {
+		c.mu.Lock()
+		if info, ok := c.cached(addr); ok {
+			c.mu.Unlock()
+			return withIP(info, addr), nil
+		}
+		if failed, ok := c.failed[addr]; ok && time.Since(failed.at) < failureTTL {
+			c.mu.Unlock()
+			return nil, failed.err
+		}
+		// A lookup of the same address already running is waited for
+		if wait, ok := c.pending[addr]; ok {
+			c.mu.Unlock()
+			select {
+			case <-wait:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		done := make(chan struct{})
+		c.pending[addr] = done
+		c.mu.Unlock()
+
+		info, err := c.fetch(ctx, addr)
+
+		c.mu.Lock()
+		delete(c.pending, addr)
+		// A lookup cut short by its caller says nothing about the registry and is not remembered
+		if err != nil && ctx.Err() == nil {
+			c.failed[addr] = failure{err: err, at: time.Now()}
+		} else if err == nil {
+			delete(c.failed, addr)
+		}
+		c.mu.Unlock()
+		close(done)
+
+		if err != nil {
+			return nil, err
+		}
+		return withIP(info, addr), nil
+	}
+}
+
+// cached returns the network holding addr among those already looked up, c.mu held
+func (c *Client) cached(addr netip.Addr) (models.NetworkInfo, bool) {
+	for _, network := range c.networks {
+		if network.start.BitLen() == addr.BitLen() && network.start.Compare(addr) <= 0 && addr.Compare(network.end) <= 0 {
+			return network.info, true
+		}
+	}
+	return models.NetworkInfo{}, false
+}
+
+// withIP returns a copy of the network info for addr
+func withIP(info models.NetworkInfo, addr netip.Addr) *models.NetworkInfo {
+	info.IP = addr.String()
+	return &info
+}
+
+// fetch asks the registry holding addr for its network and caches it
+func (c *Client) fetch(ctx context.Context, addr netip.Addr) (models.NetworkInfo, error) {
+	select {
+	case c.slots <- struct{}{}:
+		defer func() { <-c.slots }()
+	case <-ctx.Done():
+		return models.NetworkInfo{}, ctx.Err()
+	}
+
+	// Networks looked up while this request waited for a slot may hold addr
+	c.mu.Lock()
+	info, ok := c.cached(addr)
+	c.mu.Unlock()
+	if ok {
+		return info, nil
+	}
+
+	base, err := c.server(addr)
+	if err != nil {
+		return models.NetworkInfo{}, err
+	}
+	var answer ipNetwork
+	if err := c.get(ctx, base+"ip/"+addr.String(), &answer); err != nil {
+		return models.NetworkInfo{}, err
+	}
+
+	network := answer.network(addr)
+	c.mu.Lock()
+	c.networks = append(c.networks, network)
+	c.mu.Unlock()
+	return network.info, nil
+}
+
+// server returns the base URL of the RDAP service of the registry holding addr
+// The registries are read once, or again failureTTL after they could not be
+func (c *Client) server(addr netip.Addr) (string, error) {
+	c.bootMu.Lock()
+	if c.services == nil && (c.bootErr == nil || time.Since(c.bootAt) >= failureTTL) {
+		c.services, c.bootErr = c.bootstrap(context.Background())
+		c.bootAt = time.Now()
+	}
+	services, err := c.services, c.bootErr
+	c.bootMu.Unlock()
+	if err != nil {
+		return "", err
+	}
+
+	// The most specific block wins, registries transfer parts of their blocks
+	var best *service
+	for i, svc := range services {
+		if svc.prefix.Contains(addr) && (best == nil || svc.prefix.Bits() > best.prefix.Bits()) {
+			best = &services[i]
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("no RDAP service registered for %s", addr)
+	}
+	// HTTPS is preferred when a registry lists both schemes
+	base := best.urls[0]
+	for _, url := range best.urls {
+		if strings.HasPrefix(url, "https://") {
+			base = url
+			break
+		}
+	}
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return base, nil
+}
+
+// bootstrap reads the IANA registries of IPv4 and IPv6 RDAP services
+func (c *Client) bootstrap(ctx context.Context) ([]service, error) {
+	var services []service
+	for _, url := range bootstrapURLs {
+		var registry struct {
+			Services [][][]string `json:"services"`
+		}
+		if err := c.get(ctx, url, &registry); err != nil {
+			return nil, fmt.Errorf("failed to load the RDAP bootstrap registry: %v", err)
+		}
+		for _, entry := range registry.Services {
+			if len(entry) != 2 || len(entry[1]) == 0 {
+				continue
+			}
+			for _, block := range entry[0] {
+				if prefix, err := netip.ParsePrefix(block); err == nil {
+					services = append(services, service{prefix: prefix, urls: entry[1]})
+				}
+			}
+		}
+	}
+	return services, nil
+}
+
+// get fetches url and decodes its JSON answer into v
+func (c *Client) get(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/rdap+json, application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", req.URL.Host, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponse)).Decode(v); err != nil {
+		return fmt.Errorf("invalid answer from %s: %v", req.URL.Host, err)
+	}
+	return nil
+}
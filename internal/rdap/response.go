@@ -0,0 +1,111 @@
+package rdap
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// ipNetwork is the part of an RDAP IP network object (RFC 9083) kept in results
+type ipNetwork struct {
+	Handle       string   `json:"handle"`
+	StartAddress string   `json:"startAddress"`
+	EndAddress   string   `json:"endAddress"`
+	Name         string   `json:"name"`
+	Country      string   `json:"country"`
+	Entities     []entity `json:"entities"`
+	CIDRs        []struct {
+		V4Prefix string `json:"v4prefix"`
+		V6Prefix string `json:"v6prefix"`
+		Length   int    `json:"length"`
+	} `json:"cidr0_cidrs"`
+}
+
+// entity is a contact of a network, its vCard holds the organization name
+type entity struct {
+	Roles      []string      `json:"roles"`
+	VCardArray []interface{} `json:"vcardArray"`
+}
+
+// network converts the answer for addr to a cached network
+// Answers without a usable range only cover addr
+func (n ipNetwork) network(addr netip.Addr) network {
+	info := models.NetworkInfo{
+		Handle:  n.Handle,
+		Name:    n.Name,
+		Org:     n.org(),
+		Country: strings.ToUpper(n.Country),
+	}
+
+	start, errStart := netip.ParseAddr(n.StartAddress)
+	end, errEnd := netip.ParseAddr(n.EndAddress)
+	if errStart != nil || errEnd != nil || start.BitLen() != addr.BitLen() || start.Compare(addr) > 0 || addr.Compare(end) > 0 {
+		start, end = addr, addr
+	}
+	info.Range = n.cidrs()
+	if info.Range == "" && start != end {
+		info.Range = start.String() + " - " + end.String()
+	}
+	return network{start: start, end: end, info: info}
+}
+
+// cidrs returns the CIDR blocks of the network, when the registry publishes them
+func (n ipNetwork) cidrs() string {
+	var blocks []string
+	for _, cidr := range n.CIDRs {
+		prefix := cidr.V4Prefix
+		if prefix == "" {
+			prefix = cidr.V6Prefix
+		}
+		if prefix != "" {
+			blocks = append(blocks, prefix+"/"+strconv.Itoa(cidr.Length))
+		}
+	}
+	return strings.Join(blocks, ", ")
+}
+
+// org returns the name of the registrant of the network
+// ARIN and RIPE list the organization as registrant, APNIC and LACNIC at times only
+// as administrative contact, which is used when no registrant is named
+func (n ipNetwork) org() string {
+	var fallback string
+	for _, contact := range n.Entities {
+		name := contact.name()
+		if name == "" {
+			continue
+		}
+		for _, role := range contact.Roles {
+			switch role {
+			case "registrant":
+				return name
+			case "administrative":
+				if fallback == "" {
+					fallback = name
+				}
+			}
+		}
+	}
+	return fallback
+}
+
+// name returns the fn property of the vCard of the entity
+// vcardArray is ["vcard", [[name, params, type, value], ...]]
+func (e entity) name() string {
+	if len(e.VCardArray) != 2 {
+		return ""
+	}
+	properties, _ := e.VCardArray[1].([]interface{})
+	for _, property := range properties {
+		fields, _ := property.([]interface{})
+		if len(fields) < 4 {
+			continue
+		}
+		if key, _ := fields[0].(string); key == "fn" {
+			value, _ := fields[3].(string)
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
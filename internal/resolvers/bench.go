@@ -0,0 +1,264 @@
+package resolvers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+	"github.com/miekg/dns"
+)
+
+// Defaults of a benchmark
+const (
+	DefaultRounds         = 3
+	DefaultTimeout        = 2 * time.Second
+	DefaultMinReliability = 0.8
+)
+
+// KnownAnswers are names whose addresses have been stable for years
+// A resolver answering them with anything else is rewriting answers
+var KnownAnswers = map[string][]string{
+	"dns.google":      {"8.8.8.8", "8.8.4.4"},
+	"one.one.one.one": {"1.1.1.1", "1.0.0.1"},
+	"dns.quad9.net":   {"9.9.9.9", "149.112.112.112"},
+}
+
+// nxdomainParents are zones without wildcard records, random names under them must not exist
+var nxdomainParents = []string{"com", "example.com"}
+
+// Lie reasons reported for resolvers that cannot be trusted
+const (
+	LieNXDomainHijack = "nxdomain-hijack" // Answers names that do not exist
+	LieWrongAnswer    = "wrong-answer"    // Answers known names with other addresses
+)
+
+// BenchOptions configures a resolver benchmark
+type BenchOptions struct {
+	Rounds         int           // Times each known name is queried
+	Timeout        time.Duration // Timeout of a single query
+	Workers        int           // Resolvers benchmarked concurrently
+	MinReliability float64       // Share of answered queries a resolver needs to be kept
+}
+
+// BenchResult holds the measurements of a single resolver
+type BenchResult struct {
+	Resolver string
+	Sent     int           // Queries of known names sent
+	Answered int           // Queries answered in time with NOERROR
+	Latency  time.Duration // Median round trip time of answered queries
+	Lies     []string      // Reasons the resolver cannot be trusted
+}
+
+// Reliability returns the share of queries the resolver answered
+func (r BenchResult) Reliability() float64 {
+	if r.Sent == 0 {
+		return 0
+	}
+	return float64(r.Answered) / float64(r.Sent)
+}
+
+// Healthy reports whether the resolver answered honestly and often enough
+func (r BenchResult) Healthy(minReliability float64) bool {
+	return len(r.Lies) == 0 && r.Answered > 0 && r.Reliability() >= minReliability
+}
+
+// Bench measures every resolver and returns the results ranked best first:
+// healthy resolvers before the others, then by reliability and latency
+func Bench(resolvers []string, opts BenchOptions) []BenchResult {
+	if opts.Rounds <= 0 {
+		opts.Rounds = DefaultRounds
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 10
+	}
+
+	results := make([]BenchResult, len(resolvers))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				results[index] = benchResolver(resolvers[index], opts)
+			}
+		}()
+	}
+	for i := range resolvers {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Healthy(opts.MinReliability) != b.Healthy(opts.MinReliability) {
+			return a.Healthy(opts.MinReliability)
+		}
+		if a.Reliability() != b.Reliability() {
+			return a.Reliability() > b.Reliability()
+		}
+		return a.Latency < b.Latency
+	})
+	return results
+}
+
+// benchResolver queries known and non-existent names through a resolver
+func benchResolver(resolver string, opts BenchOptions) BenchResult {
+	result := BenchResult{Resolver: resolver}
+
+	names := make([]string, 0, len(KnownAnswers))
+	for name := range KnownAnswers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var latencies []time.Duration
+	wrong := false
+	for round := 0; round < opts.Rounds; round++ {
+		for _, name := range names {
+			result.Sent++
+			resp, rtt, err := query(resolver, name, opts.Timeout)
+			if err != nil || resp.Rcode != dns.RcodeSuccess {
+				continue
+			}
+			result.Answered++
+			latencies = append(latencies, rtt)
+			if !matchesKnown(resp, KnownAnswers[name]) {
+				wrong = true
+			}
+		}
+	}
+
+	if wrong {
+		result.Lies = append(result.Lies, LieWrongAnswer)
+	}
+	if hijacksNXDomain(resolver, opts.Timeout) {
+		result.Lies = append(result.Lies, LieNXDomainHijack)
+	}
+
+	result.Latency = median(latencies)
+	return result
+}
+
+// CheckHonesty sends canary queries through a resolver: names with known answers
+// and names that cannot exist. Returns the lies detected, none when the resolver is
+// honest or did not answer
+func CheckHonesty(resolver string, timeout time.Duration) []string {
+	var lies []string
+	for name, expected := range KnownAnswers {
+		resp, _, err := query(resolver, name, timeout)
+		if err == nil && resp.Rcode == dns.RcodeSuccess && !matchesKnown(resp, expected) {
+			lies = append(lies, LieWrongAnswer)
+			break
+		}
+	}
+	if hijacksNXDomain(resolver, timeout) {
+		lies = append(lies, LieNXDomainHijack)
+	}
+	return lies
+}
+
+// hijacksNXDomain reports whether a resolver answers random names that cannot exist
+func hijacksNXDomain(resolver string, timeout time.Duration) bool {
+	for _, parent := range nxdomainParents {
+		resp, _, err := query(resolver, randomLabel()+"."+parent, timeout)
+		if err == nil && len(addresses(resp)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// query sends an A query with recursion desired over the protocol of the resolver
+func query(resolver, name string, timeout time.Duration) (*dns.Msg, time.Duration, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), dns.TypeA)
+	msg.RecursionDesired = true
+	return utils.ExchangeTimeout(msg, resolver, timeout)
+}
+
+// matchesKnown reports whether every address of an answer is one of the expected ones
+func matchesKnown(resp *dns.Msg, expected []string) bool {
+	answered := addresses(resp)
+	if len(answered) == 0 {
+		return false
+	}
+	for _, ip := range answered {
+		known := false
+		for _, want := range expected {
+			if ip == want {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return false
+		}
+	}
+	return true
+}
+
+// addresses returns the A records of an answer
+func addresses(resp *dns.Msg) []string {
+	var ips []string
+	for _, rr := range resp.Answer {
+		if a, ok := rr.(*dns.A); ok {
+			ips = append(ips, a.A.String())
+		}
+	}
+	return ips
+}
+
+// randomLabel returns a label that no zone is expected to contain
+func randomLabel() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "sc-" + hex.EncodeToString(buf)
+}
+
+// median returns the median of durations, or 0 when there are none
+func median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+// CleanedList returns the healthy resolvers of ranked results, best first
+// Addresses on the default port are written without it, like resolver lists usually are
+func CleanedList(results []BenchResult, minReliability float64) []string {
+	var cleaned []string
+	for _, result := range results {
+		if !result.Healthy(minReliability) {
+			continue
+		}
+		resolver := result.Resolver
+		if host, port, err := net.SplitHostPort(resolver); err == nil && port == "53" {
+			resolver = host
+		}
+		cleaned = append(cleaned, resolver)
+	}
+	return cleaned
+}
+
+// CleanedPath returns the default path of the cleaned list written next to a resolver file
+func CleanedPath(source string) string {
+	if source == "" {
+		return "resolvers.clean.txt"
+	}
+	if dot := strings.LastIndex(source, "."); dot > strings.LastIndexAny(source, `/\`) && dot > 0 {
+		return source[:dot] + ".clean" + source[dot:]
+	}
+	return source + ".clean"
+}
@@ -3,8 +3,6 @@ package scanner
 import (
 	"context"
 	"fmt"
-	"net/http"
-	"net/url"
 	"os"
 	"os/signal"
 	"strings"
@@ -14,28 +12,109 @@ import (
 
 	"github.com/fkr00t/subcollector/internal/models"
 	"github.com/fkr00t/subcollector/internal/output"
+	"github.com/fkr00t/subcollector/internal/sink"
+	"github.com/fkr00t/subcollector/internal/storage"
+	"github.com/fkr00t/subcollector/internal/telemetry"
+	"github.com/fkr00t/subcollector/internal/upload"
 	"github.com/fkr00t/subcollector/internal/utils"
+	"github.com/fkr00t/subcollector/internal/workspace"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // ActiveScanConfig holds the configuration for active scanning
 type ActiveScanConfig struct {
-	Domain         string
-	WordlistPath   string
-	Resolvers      []string
-	RateLimit      int
-	Recursive      bool
-	ShowIP         bool
-	Depth          int
-	Takeover       bool
-	Proxy          string
-	NumWorkers     int
-	StreamResults  bool
-	OutputFile     string
-	JsonOutputFile string
+	Domain          string
+	WordlistPath    string
+	Resolvers       []string
+	RateLimit       int
+	Recursive       bool
+	ShowIP          bool
+	Depth           int
+	Takeover        bool
+	Proxy           string
+	NumWorkers      int
+	StreamResults   bool
+	OutputFile      string
+	JsonOutputFile  string
+	HTMLOutputFile  string              // Optional standalone HTML report
+	CSVOutputFile   string              // Optional CSV output with one row per subdomain
+	XMLOutputFile   string              // Optional XML output with the same data as JSON
+	SARIFOutputFile string              // Optional SARIF log of takeover and dangling DNS findings
+	Sink            sink.Sinks          // Optional destinations receiving results as they are confirmed
+	Tags            []string            // Labels added to every result (--tag)
+	Database        string              // Optional SQLite file or PostgreSQL URL storing the results
+	Upload          *upload.Target      // Optional bucket location receiving the output files
+	Sort            string              // Order of saved results, models.SortByName or models.SortByDiscovery
+	Format          *models.LineFormat  // Line format of the text output, nil for one subdomain per line
+	RecheckFile     string              // Optional file listing candidates without an authoritative answer
+	Exclude         *utils.ExcludeList  // Hosts never queried nor reported
+	Filter          *utils.ResultFilter // Match/filter rules applied before display and saving
+	Metadata        models.ScanMetadata // Tool and configuration details embedded in JSON output
+	DNSSEC          bool                // Record the DNSSEC validation status of each result
+	ServiceRecords  bool                // Query well-known SRV/TXT names for leaked hostnames
+	TLS             bool                // Grab certificates on port 443 and feed in-scope SANs back into the scan
+	Ports           []int               // TCP ports checked on resolved addresses (empty disables port scanning)
+	Group           bool                // Record IPs, CNAME chains and providers to group hosts by shared infrastructure
+	ImportFile      string              // massdns/zdns output to enrich instead of brute forcing
+	ExportDir       string              // Directory receiving massdns input files instead of scanning
+	Workspace       string              // Base directory of per-run artifact directories
+	EvidenceDir     string              // Directory receiving takeover evidence (defaults to the workspace)
+	TimeoutTotal    time.Duration       // Time budget of the whole scan, results found so far are kept (0 for none)
+	MaxQueries      int64               // Lookups the whole scan may send, results found so far are kept (0 for no cap)
+	Context         context.Context     // Ends the scan early when done, such as when an API client is gone; results found so far are kept (nil for none)
+	DepthRules      []DepthRule         // Recursion depth under the hosts matching a pattern, overriding Depth
+	CanaryInterval  time.Duration       // Time between canary checks dropping lying resolvers (0 disables them)
+	Verify          bool                // Re-resolve results through trusted resolvers and keep the confirmed ones
+	VerifyResolvers []string            // Trusted resolvers of the consensus pass (default DefaultVerifyResolvers)
+	Quorum          int                 // Trusted resolvers that must confirm a result (0 for a majority)
+	HTTP            bool                // Probe web servers and record status, title, Server header and favicon hash
+	Tech            bool                // Detect the technologies of probed web servers (implies HTTP)
+	SecondOrder     bool                // Check the hosts serving page resources of probed web servers for takeovers (implies HTTP)
+	Dangling        bool                // Report CNAMEs, cloud addresses and delegations left dangling
+	Email           bool                // Analyze MX, SPF, DKIM and DMARC records of the root domain and subdomains
+	RDAP            bool                // Look up the registered networks of resolved IPs over RDAP
+	Backoff         BackoffConfig       // Adaptive slowdown of limit keys whose lookups keep failing
+	MaxMemory       int64               // Heap size at which lookup answers and results are spilled to disk (0 for no cap)
+	Cache           models.CacheConfig  // DNS cache of the scan (memory when the type is empty)
+	LimitBy         string              // What rate limits, backoff and concurrency caps are charged to (LimitByRoot, LimitByAuthority or LimitByResolver)
+	MaxPerAuthority int                 // Lookups running at once per limit key (0 for no cap)
+	KeepUnresolved  bool                // Also report the names of a verified list that do not resolve, with their Resolution
+	TakeoverWorkers int                 // Takeover checks running at once, apart from the DNS workers (0 for DefaultTakeoverWorkers)
+	TakeoverHTTP    TakeoverHTTPConfig  // Timeout, redirect policy, TLS and body cap of takeover fingerprinting requests
+
+	workspace *workspace.Workspace // Artifact directory of the current run
+	deadline  time.Time            // End of the time budget, zero when not time-boxed
+	queries   *queryBudget         // Lookups left to the scan, nil when not capped
+	trace     context.Context      // Span of the scan, parent of the spans of its stages
 }
 
 // ExecuteActiveScan runs an active scan with the provided configuration
-func ExecuteActiveScan(config ActiveScanConfig) {
+// Returns the results that passed the match/filter rules
+func ExecuteActiveScan(config ActiveScanConfig) ([]models.SubdomainResult, error) {
+	config.Metadata.StartedAt = time.Now()
+	config.startBudget()
+
+	// The stages of the scan are traced under one span
+	ctx, span := telemetry.Span(context.Background(), "active scan", attribute.String("domain", config.Domain))
+	defer span.End()
+	config.trace = ctx
+
+	// A resolver file that cannot be read would leave the scan on the system resolver
+	if err := checkResolvers(config.Resolvers, config.VerifyResolvers); err != nil {
+		telemetry.Fail(span, err)
+		return nil, err
+	}
+
+	// Collect logs and artifacts of this run when a workspace is used
+	ws, err := openWorkspace(&config, config.Domain)
+	if err != nil {
+		return nil, err
+	}
+	defer ws.Close()
+
+	config.Sink.Begin(config.Domain, config.Metadata)
+	defer config.Sink.Close()
+
 	// Display a minimalist scan header
 	fmt.Printf("\n» Scanning %s\n", config.Domain)
 
@@ -54,6 +133,9 @@ func ExecuteActiveScan(config ActiveScanConfig) {
 		} else {
 			activeFlags = append(activeFlags, "recursive")
 		}
+		if len(config.DepthRules) > 0 {
+			activeFlags = append(activeFlags, fmt.Sprintf("depth-rules:%d", len(config.DepthRules)))
+		}
 	}
 	if config.Proxy != "" {
 		activeFlags = append(activeFlags, "proxy")
@@ -64,9 +146,60 @@ func ExecuteActiveScan(config ActiveScanConfig) {
 	if config.JsonOutputFile != "" {
 		activeFlags = append(activeFlags, fmt.Sprintf("json:%s", config.JsonOutputFile))
 	}
+	if config.HTMLOutputFile != "" {
+		activeFlags = append(activeFlags, fmt.Sprintf("html:%s", config.HTMLOutputFile))
+	}
+	if config.CSVOutputFile != "" {
+		activeFlags = append(activeFlags, fmt.Sprintf("csv:%s", config.CSVOutputFile))
+	}
+	if config.XMLOutputFile != "" {
+		activeFlags = append(activeFlags, fmt.Sprintf("xml:%s", config.XMLOutputFile))
+	}
+	if config.SARIFOutputFile != "" {
+		activeFlags = append(activeFlags, fmt.Sprintf("sarif:%s", config.SARIFOutputFile))
+	}
+	if config.Upload != nil {
+		activeFlags = append(activeFlags, fmt.Sprintf("upload:%s", config.Upload))
+	}
 	if config.WordlistPath != "" {
 		activeFlags = append(activeFlags, fmt.Sprintf("wordlist:%s", config.WordlistPath))
 	}
+	if ws != nil {
+		activeFlags = append(activeFlags, fmt.Sprintf("workspace:%s", ws.Dir))
+	}
+	if config.ServiceRecords {
+		activeFlags = append(activeFlags, "srv")
+	}
+	if config.TLS {
+		activeFlags = append(activeFlags, "tls")
+	}
+	if len(config.Ports) > 0 {
+		activeFlags = append(activeFlags, fmt.Sprintf("ports:%d", len(config.Ports)))
+	}
+	if config.Group {
+		activeFlags = append(activeFlags, "group")
+	}
+	if config.HTTP {
+		activeFlags = append(activeFlags, "http")
+	}
+	if config.Tech {
+		activeFlags = append(activeFlags, "tech")
+	}
+	if config.Dangling {
+		activeFlags = append(activeFlags, "dangling")
+	}
+	if config.Email {
+		activeFlags = append(activeFlags, "email")
+	}
+	if config.RDAP {
+		activeFlags = append(activeFlags, "rdap")
+	}
+	if config.TimeoutTotal > 0 {
+		activeFlags = append(activeFlags, fmt.Sprintf("timeout:%s", config.TimeoutTotal))
+	}
+	if config.MaxQueries > 0 {
+		activeFlags = append(activeFlags, fmt.Sprintf("max-queries:%d", config.MaxQueries))
+	}
 
 	// Display the flags used, if any
 	if len(activeFlags) > 0 {
@@ -75,16 +208,18 @@ func ExecuteActiveScan(config ActiveScanConfig) {
 
 	fmt.Println()
 
+	// Track lookup outcomes for the summary
+	stats := NewLookupStats()
+
 	// Define threshold for switching to streaming approach
 	const streamingThreshold = 10000 // 10k entries
 
 	// Check wordlist size
 	var wordlistSize int
-	var err error
 
 	// Get wordlist size
 	if config.WordlistPath == "" {
-		wordlistSize = 114441
+		wordlistSize = defaultWordlistSize
 	} else {
 		wordlistSize, err = utils.CountLinesInFile(config.WordlistPath)
 		if err != nil {
@@ -96,62 +231,350 @@ func ExecuteActiveScan(config ActiveScanConfig) {
 	if wordlistSize > streamingThreshold {
 		// Add result processor
 		streamingConfig := StreamingActiveScanConfig{
-			Domain:       config.Domain,
-			WordlistPath: config.WordlistPath,
-			Resolvers:    config.Resolvers,
-			BackoffConfig: BackoffConfig{
-				Enabled:       true,
-				BaseDelay:     time.Duration(config.RateLimit) * time.Millisecond,
-				MaxDelay:      10 * time.Second,
-				Factor:        2.0,
-				Jitter:        0.3,
-				FailThreshold: 3,
-			},
-			Recursive:  config.Recursive,
-			ShowIP:     config.ShowIP,
-			Depth:      config.Depth,
-			Takeover:   config.Takeover,
-			Proxy:      config.Proxy,
-			NumWorkers: config.NumWorkers,
+			Domain:          config.Domain,
+			WordlistPath:    config.WordlistPath,
+			Resolvers:       config.Resolvers,
+			BackoffConfig:   config.Backoff,
+			LimitBy:         config.LimitBy,
+			MaxPerAuthority: config.MaxPerAuthority,
+			MaxMemory:       config.MaxMemory,
+			Cache:           config.Cache,
+			Recursive:       config.Recursive,
+			ShowIP:          config.ShowIP,
+			Depth:           config.Depth,
+			DepthRules:      config.DepthRules,
+			MaxQueries:      config.MaxQueries,
+			ctx:             config.Context,
+			Takeover:        config.Takeover,
+			TakeoverWorkers: config.TakeoverWorkers,
+			TakeoverHTTP:    config.TakeoverHTTP,
+			Proxy:           config.Proxy,
+			NumWorkers:      config.NumWorkers,
+			Exclude:         config.Exclude,
+			Filter:          config.Filter,
+			DNSSEC:          config.DNSSEC,
+			ServiceRecords:  config.ServiceRecords,
+			TLS:             config.TLS,
+			Ports:           config.Ports,
+			Group:           config.Group,
+			EvidenceDir:     config.EvidenceDir,
+			CanaryInterval:  config.CanaryInterval,
+			Verify:          config.Verify,
+			VerifyResolvers: config.VerifyResolvers,
+			Quorum:          config.Quorum,
+			HTTP:            config.HTTP,
+			Tech:            config.Tech,
+			SecondOrder:     config.SecondOrder,
+			Dangling:        config.Dangling,
+			Email:           config.Email,
+			RDAP:            config.RDAP,
+			Sink:            config.Sink,
+			workspace:       config.workspace,
+			deadline:        config.deadline,
+			queries:         config.queries,
+			trace:           config.trace,
 		}
 
+		// The rate limit is carried as the first delay of the backoff
+		streamingConfig.BackoffConfig.BaseDelay = time.Duration(config.RateLimit) * time.Millisecond
 		streamingConfig.ResultProcessor = func(result models.SubdomainResult) {
-			output.DisplayResult(result, config.ShowIP)
+			if config.Filter.Allows(result.Subdomain) {
+				output.DisplayResult(result, config.ShowIP)
+			}
 		}
 
 		// Run streaming scan
-		results := streamingActiveScan(streamingConfig)
+		results, err := streamingActiveScan(streamingConfig, stats)
+		if err != nil {
+			telemetry.Fail(span, err)
+			return nil, err
+		}
+		results = reportFilteredResults(results, config.Filter)
+		span.SetAttributes(attribute.Int("found", len(results)))
 
 		// Brief summary
 		fmt.Printf("\n» Found %d subdomains\n", len(results))
+		reportLookupStats(stats, config.RecheckFile)
+		reportCoverage(&config, stats)
+		if config.Group || config.HTTP || config.Tech {
+			reportHostGroups(results)
+		}
+		reportEmailPosture(&config, []string{config.Domain}, results)
 
 		// Save results if requested
-		if !config.StreamResults && (config.OutputFile != "" || config.JsonOutputFile != "") {
-			output.SaveResults(config.OutputFile, config.JsonOutputFile, config.Domain, results)
-			fmt.Printf("» Results saved\n")
-		}
-	} else {
-		// Section for subdomains
-		results := activeScan(config)
+		saveActiveResults(config, config.Domain, results)
+
+		return results, nil
+	}
+
+	// Section for subdomains
+	results, err := activeScan(config, stats)
+	if err != nil {
+		telemetry.Fail(span, err)
+		return nil, err
+	}
+	results = reportFilteredResults(results, config.Filter)
+	span.SetAttributes(attribute.Int("found", len(results)))
+
+	// Brief summary
+	fmt.Printf("\n» Found %d subdomains\n", len(results))
+	reportLookupStats(stats, config.RecheckFile)
+	reportCoverage(&config, stats)
+	if config.Group || config.HTTP || config.Tech {
+		reportHostGroups(results)
+	}
+	reportEmailPosture(&config, []string{config.Domain}, results)
+
+	// Save results if requested
+	saveActiveResults(config, config.Domain, results)
 
-		if results == nil {
-			fmt.Println("× Scan failed")
+	return results, nil
+}
+
+// reportFilteredResults applies match/filter rules to the final results
+// and reports how many were hidden
+func reportFilteredResults(results []models.SubdomainResult, filter *utils.ResultFilter) []models.SubdomainResult {
+	filtered := output.FilterResults(results, filter)
+	if hidden := len(results) - len(filtered); hidden > 0 {
+		fmt.Printf("\n» %d subdomains hidden by match/filter rules\n", hidden)
+	}
+	return filtered
+}
+
+// reportLookupStats prints lookup error counts and optionally writes
+// the candidates that never got an authoritative answer to a file
+func reportLookupStats(stats *LookupStats, recheckFile string) {
+	fmt.Printf("» Lookups: %s\n", stats.Summary())
+	if cache := stats.CacheSummary(); cache != "" {
+		fmt.Printf("» DNS cache: %s\n", cache)
+	}
+
+	unresolved := stats.Unresolved()
+	if len(unresolved) == 0 {
+		return
+	}
+
+	fmt.Printf("» %d candidates never got an authoritative answer\n", len(unresolved))
+	if recheckFile != "" {
+		if err := output.SaveLines(recheckFile, unresolved); err != nil {
+			fmt.Printf("× Failed to save re-check list: %v\n", err)
 			return
 		}
+		fmt.Printf("» Re-check list saved to %s\n", recheckFile)
+	}
+}
 
-		// Brief summary
-		fmt.Printf("\n» Found %d subdomains\n", len(results))
+// reportHostGroups prints the largest groups of hosts sharing infrastructure
+func reportHostGroups(results []models.SubdomainResult) {
+	const maxGroups = 10
 
-		// Save results if requested
-		if !config.StreamResults && (config.OutputFile != "" || config.JsonOutputFile != "") {
-			output.SaveResults(config.OutputFile, config.JsonOutputFile, config.Domain, results)
-			fmt.Printf("» Results saved\n")
+	shown := 0
+	for _, group := range models.GroupResults(results) {
+		if group.Count < 2 || shown == maxGroups {
+			break
+		}
+		if shown == 0 {
+			fmt.Println("» Shared infrastructure:")
+		}
+		fmt.Printf("    %-8s %-40s %d hosts\n", group.Kind, group.Key, group.Count)
+		shown++
+	}
+}
+
+// saveToDatabase stores results in the database when one was given, as one scan per domain
+// Results are attributed to the longest target domain containing them; without targets,
+// as after merging arbitrary files, to their root domain
+func saveToDatabase(dsn string, domains []string, results []models.SubdomainResult, metadata models.ScanMetadata) {
+	if dsn == "" {
+		return
+	}
+	store, err := storage.Open(dsn)
+	if err != nil {
+		fmt.Printf("× %v\n", err)
+		return
+	}
+	defer store.Close()
+
+	opts := LookupOptions{Scope: domains}
+	byDomain := make(map[string][]models.SubdomainResult)
+	var order []string
+	for _, result := range results {
+		domain := opts.zoneOf(result.Subdomain)
+		if domain == "" {
+			domain = utils.ExtractRootDomain(result.Subdomain)
+		}
+		if _, ok := byDomain[domain]; !ok {
+			order = append(order, domain)
 		}
+		byDomain[domain] = append(byDomain[domain], result)
+	}
+	// Target domains without results are stored too, their scan is empty rather than missing
+	for _, domain := range domains {
+		if _, ok := byDomain[domain]; !ok {
+			byDomain[domain] = nil
+			order = append(order, domain)
+		}
+	}
+
+	for _, domain := range order {
+		scanID, err := store.SaveScan(domain, metadata, byDomain[domain])
+		if err != nil {
+			fmt.Printf("× Failed to store results of %s: %v\n", domain, err)
+			continue
+		}
+		fmt.Printf("» %d results of %s stored in %s (scan #%d)\n", len(byDomain[domain]), domain, storage.Redact(dsn), scanID)
+	}
+}
+
+// uploadOutputs copies the output files of a finished scan to the upload target when one was given
+func uploadOutputs(target *upload.Target, domains []string, metadata models.ScanMetadata, files ...string) {
+	if target == nil {
+		return
+	}
+	domain := "multi"
+	if metadata.Project != "" {
+		domain = metadata.Project
+	} else if len(domains) == 1 {
+		domain = domains[0]
+	}
+	uploaded, err := target.Upload(files, domain, metadata.Mode, metadata.StartedAt)
+	if err != nil {
+		fmt.Printf("× %v\n", err)
+	}
+	if uploaded > 0 {
+		fmt.Printf("» Uploaded %d files to %s://%s\n", uploaded, target.Scheme, target.Bucket)
+	}
+}
+
+// workspaceOutputs lists the result files of a workspace, nil without one
+func workspaceOutputs(ws *workspace.Workspace) []string {
+	if ws == nil {
+		return nil
+	}
+	return []string{
+		ws.Path(workspace.ResultsText), ws.Path(workspace.ResultsJSON), ws.Path(workspace.ResultsCSV),
+		ws.Path(workspace.ResultsXML), ws.Path(workspace.FindingsSARIF), ws.Path(workspace.ReportHTML),
+	}
+}
+
+// publish sends a confirmed result to the sink, unless match/filter rules hide it
+func (c ActiveScanConfig) publish(result models.SubdomainResult) {
+	if c.Filter.Allows(result.Subdomain) {
+		result.Tags = models.AddTags(result.Tags, c.Tags...)
+		result = models.RateResult(result)
+		c.Sink.Add(result)
+	}
+}
+
+// saveActiveResults writes the text/JSON output and the HTML report when requested
+func saveActiveResults(config ActiveScanConfig, domain string, results []models.SubdomainResult) {
+	_, span := telemetry.Span(config.trace, "save results", attribute.Int("results", len(results)))
+	defer span.End()
+
+	config.Metadata.FinishedAt = time.Now()
+	models.TagResults(results, config.Tags)
+	models.RateResults(results)
+	models.SortResults(results, config.Sort)
+	if !config.StreamResults && (config.OutputFile != "" || config.JsonOutputFile != "") {
+		output.SaveResults(config.OutputFile, config.JsonOutputFile, domain, results, config.Metadata, config.Format)
+		fmt.Printf("» Results saved\n")
+	}
+	saveHTMLReport(config.HTMLOutputFile, domain, results, config.Metadata)
+	saveCSV(config.CSVOutputFile, results, config.Metadata)
+	saveXML(config.XMLOutputFile, domain, results, config.Metadata)
+	saveSARIF(config.SARIFOutputFile, results, config.Metadata)
+	saveToDatabase(config.Database, []string{domain}, results, config.Metadata)
+
+	saveWorkspace(config.workspace, domain, results, config.Metadata)
+
+	files := append([]string{config.OutputFile, config.JsonOutputFile, config.HTMLOutputFile, config.CSVOutputFile,
+		config.XMLOutputFile, config.SARIFOutputFile}, workspaceOutputs(config.workspace)...)
+	uploadOutputs(config.Upload, strings.Split(domain, ","), config.Metadata, files...)
+}
+
+// openWorkspace creates the workspace of a run and starts capturing its output
+// The re-check list and takeover evidence default to the workspace when no location was given
+func openWorkspace(config *ActiveScanConfig, target string) (*workspace.Workspace, error) {
+	ws, err := workspace.Create(config.Workspace, target, config.Metadata.StartedAt)
+	if err != nil {
+		fmt.Printf("× %v\n", err)
+		return nil, err
+	}
+	if err := ws.CaptureOutput(); err != nil {
+		fmt.Printf("× Failed to capture output: %v\n", err)
+	}
+	if ws != nil && config.RecheckFile == "" {
+		config.RecheckFile = ws.Path(workspace.UnresolvedFile)
 	}
+	if ws != nil && config.EvidenceDir == "" {
+		config.EvidenceDir = ws.Path(workspace.EvidenceDir)
+	}
+	config.workspace = ws
+	return ws, nil
+}
+
+// saveWorkspace writes the final artifacts of a run to its workspace
+func saveWorkspace(ws *workspace.Workspace, domain string, results []models.SubdomainResult, metadata models.ScanMetadata) {
+	if ws == nil {
+		return
+	}
+	if err := ws.SaveResults(domain, results, metadata); err != nil {
+		fmt.Printf("× Failed to save workspace artifacts: %v\n", err)
+		return
+	}
+	fmt.Printf("» Artifacts saved to %s\n", ws.Dir)
+}
+
+// saveHTMLReport writes the HTML report when a path is configured
+func saveHTMLReport(path, domain string, results []models.SubdomainResult, metadata models.ScanMetadata) {
+	if path == "" {
+		return
+	}
+	if err := output.SaveHTMLReport(path, domain, results, metadata); err != nil {
+		fmt.Printf("× Failed to save HTML report: %v\n", err)
+		return
+	}
+	fmt.Printf("» HTML report saved to %s\n", path)
+}
+
+// saveCSV writes the CSV output when a file was given
+func saveCSV(path string, results []models.SubdomainResult, metadata models.ScanMetadata) {
+	if path == "" {
+		return
+	}
+	if err := output.SaveCSV(path, results, metadata); err != nil {
+		fmt.Printf("× Failed to save CSV output: %v\n", err)
+		return
+	}
+	fmt.Printf("» CSV output saved to %s\n", path)
+}
+
+// saveXML writes the XML output when a file was given
+func saveXML(path, domain string, results []models.SubdomainResult, metadata models.ScanMetadata) {
+	if path == "" {
+		return
+	}
+	if err := output.SaveXML(path, domain, results, metadata); err != nil {
+		fmt.Printf("× Failed to save XML output: %v\n", err)
+		return
+	}
+	fmt.Printf("» XML output saved to %s\n", path)
+}
+
+// saveSARIF writes the SARIF log of takeover and dangling DNS findings when a file was given
+func saveSARIF(path string, results []models.SubdomainResult, metadata models.ScanMetadata) {
+	if path == "" {
+		return
+	}
+	if err := output.SaveSARIF(path, results, metadata); err != nil {
+		fmt.Printf("× Failed to save SARIF output: %v\n", err)
+		return
+	}
+	fmt.Printf("» SARIF output saved to %s\n", path)
 }
 
 // Helper function to save results from streaming scan
-func streamingActiveScan(config StreamingActiveScanConfig) []models.SubdomainResult {
+func streamingActiveScan(config StreamingActiveScanConfig, stats *LookupStats) ([]models.SubdomainResult, error) {
 	// This is a wrapper for the StreamingActiveScan function from memory_efficient.go
 	var collectedResults []models.SubdomainResult
 	var resultsMutex sync.Mutex
@@ -172,21 +595,57 @@ func streamingActiveScan(config StreamingActiveScanConfig) []models.SubdomainRes
 
 	// Simulate using active scan
 	tempConfig := ActiveScanConfig{
-		Domain:        config.Domain,
-		WordlistPath:  config.WordlistPath,
-		Resolvers:     config.Resolvers,
-		RateLimit:     int(config.BackoffConfig.BaseDelay / time.Millisecond),
-		Recursive:     config.Recursive,
-		ShowIP:        config.ShowIP,
-		Depth:         config.Depth,
-		Takeover:      config.Takeover,
-		Proxy:         config.Proxy,
-		NumWorkers:    config.NumWorkers,
-		StreamResults: false,
+		Domain:          config.Domain,
+		WordlistPath:    config.WordlistPath,
+		Resolvers:       config.Resolvers,
+		RateLimit:       int(config.BackoffConfig.BaseDelay / time.Millisecond),
+		Backoff:         config.BackoffConfig,
+		LimitBy:         config.LimitBy,
+		MaxPerAuthority: config.MaxPerAuthority,
+		MaxMemory:       config.MaxMemory,
+		Cache:           config.Cache,
+		Recursive:       config.Recursive,
+		ShowIP:          config.ShowIP,
+		Depth:           config.Depth,
+		DepthRules:      config.DepthRules,
+		MaxQueries:      config.MaxQueries,
+		Context:         config.ctx,
+		Takeover:        config.Takeover,
+		TakeoverWorkers: config.TakeoverWorkers,
+		TakeoverHTTP:    config.TakeoverHTTP,
+		Proxy:           config.Proxy,
+		NumWorkers:      config.NumWorkers,
+		StreamResults:   false,
+		Exclude:         config.Exclude,
+		Filter:          config.Filter,
+		DNSSEC:          config.DNSSEC,
+		ServiceRecords:  config.ServiceRecords,
+		TLS:             config.TLS,
+		Ports:           config.Ports,
+		Group:           config.Group,
+		EvidenceDir:     config.EvidenceDir,
+		CanaryInterval:  config.CanaryInterval,
+		Verify:          config.Verify,
+		VerifyResolvers: config.VerifyResolvers,
+		Quorum:          config.Quorum,
+		HTTP:            config.HTTP,
+		Tech:            config.Tech,
+		SecondOrder:     config.SecondOrder,
+		Dangling:        config.Dangling,
+		Email:           config.Email,
+		RDAP:            config.RDAP,
+		Sink:            config.Sink,
+		workspace:       config.workspace,
+		deadline:        config.deadline,
+		queries:         config.queries,
+		trace:           config.trace,
 	}
 
 	// Call activeScan function temporarily until StreamingActiveScan is implemented
-	temporaryResults := activeScan(tempConfig)
+	temporaryResults, err := activeScan(tempConfig, stats)
+	if err != nil {
+		return nil, err
+	}
 
 	// Simulate calling the result processor
 	for _, result := range temporaryResults {
@@ -195,78 +654,95 @@ func streamingActiveScan(config StreamingActiveScanConfig) []models.SubdomainRes
 		}
 	}
 
-	return collectedResults
+	return collectedResults, nil
 }
 
 // activeScan performs active subdomain enumeration using a wordlist
 // Tries to find subdomains by adding words from the wordlist to the domain
-func activeScan(config ActiveScanConfig) []models.SubdomainResult {
-	var wordlist []string
-	var err error
-
+// Fails with ErrWordlistNotFound, ErrResolverLoad and the like when the scan cannot start
+func activeScan(config ActiveScanConfig, stats *LookupStats) ([]models.SubdomainResult, error) {
 	// Load or download wordlist
-	if config.WordlistPath == "" {
-		defaultWordlistURL := "https://raw.githubusercontent.com/danielmiessler/SecLists/refs/heads/master/Discovery/DNS/subdomains-top1million-110000.txt"
-		fmt.Println("» Downloading wordlist...")
-		wordlist, err = utils.FetchWordlistFromURL(defaultWordlistURL)
-		if err != nil {
-			fmt.Println("× Failed to fetch wordlist")
-			return nil
-		}
-	} else {
-		wordlist, err = utils.LoadWordlist(config.WordlistPath)
-		if err != nil {
-			fmt.Println("× Wordlist file not found")
-			return nil
-		}
+	_, loadSpan := telemetry.Span(config.trace, "load wordlist", attribute.String("path", config.WordlistPath))
+	wordlist, err := loadWordlist(config.WordlistPath)
+	loadSpan.SetAttributes(attribute.Int("words", len(wordlist)))
+	telemetry.Fail(loadSpan, err)
+	loadSpan.End()
+	if err != nil {
+		return nil, err
 	}
 
 	// Process resolvers
-	var finalResolvers []string
-	finalResolvers = processResolvers(config.Resolvers)
+	finalResolvers := processResolvers(config.Resolvers)
 
 	// Set up HTTP client for takeover checks
-	client := setupHTTPClient(config.Takeover, config.Proxy)
+	client := setupTakeoverClient(config.Takeover, config.Proxy, config.TakeoverHTTP)
+
+	// Lookup answers and results move to disk when the heap nears --max-memory
+	guard := startMemoryGuard(config.MaxMemory)
+	defer guard.Stop()
+	spool := newResultSpool(guard)
+	cache, err := newScanCache(config.Cache, guard)
+	if err != nil {
+		fmt.Printf("× %v\n", err)
+		return nil, err
+	}
+	defer closeCache(cache, config.Cache, stats)
+	opts := newLookupOptions(finalResolvers, cache, client, config, stats)
 
-	var results []models.SubdomainResult
-	cache := models.NewDNSCache()
-	level := 1
-	toScan := []string{config.Domain}
+	// Discovered subdomains are expanded by priority rather than strictly level by level
+	queue := newRecursionQueue(config.Domain)
+	queue.Push(config.Domain, 1)
 
 	// Channel for streaming results if enabled
 	var streamChan chan models.SubdomainResult
 	if config.StreamResults {
-		streamChan = setupStreamChannel(config.ShowIP)
+		streamChan = setupStreamChannel(config.ShowIP, config.Filter)
 	} else {
 		streamChan = nil
 	}
 
-	// For each recursive level
-	for len(toScan) > 0 && (config.Depth == -1 || level <= config.Depth) {
-		if level > 1 || config.Recursive {
-			fmt.Printf("\n» Level %d: %d domains\n", level, len(toScan))
+	// For each queued parent, the root domain first
+	for {
+		// Parents left when the budget runs out count as planned but unchecked
+		if config.budgetSpent() {
+			stats.addPlanned(queue.Len() * len(wordlist))
+			break
+		}
+		parent, ok := queue.Pop()
+		if !ok {
+			break
+		}
+		if config.Recursive {
+			fmt.Printf("\n» Level %d: %s (%d queued)\n", parent.Level, parent.Name, queue.Len())
 		}
 
+		stats.addPlanned(len(wordlist))
+		log := scannerLog.With("domain", config.Domain).With("level", parent.Level)
+		log.Info("Brute-forcing %d names under %s", len(wordlist), parent.Name)
+		levelCtx, levelSpan := telemetry.Span(config.trace, "scan level",
+			attribute.String("parent", parent.Name), attribute.Int("level", parent.Level))
 		levelResults := scanLevel(
-			toScan,
+			levelCtx,
+			[]string{parent.Name},
 			wordlist,
-			finalResolvers,
-			cache,
-			client,
+			opts,
 			config,
 			streamChan,
 		)
-
-		// Process results of this level for the next level if recursive
-		results = append(results, levelResults...)
-		if config.Recursive && (config.Depth == -1 || level < config.Depth) {
-			toScan = []string{}
-			for _, res := range levelResults {
-				toScan = append(toScan, res.Subdomain)
+		levelSpan.SetAttributes(attribute.Int("found", len(levelResults)))
+		levelSpan.End()
+		log.Info("Finished %s: %d found", parent.Name, len(levelResults))
+
+		// Queue the findings as parents of the next level if recursive
+		spool.Append(levelResults...)
+		if err := config.workspace.Checkpoint(levelResults); err != nil {
+			fmt.Printf("× Failed to write checkpoint: %v\n", err)
+		}
+		if config.Recursive {
+			names := deeperParents(resultNames(levelResults), parent.Level, config.Depth, config.DepthRules)
+			for _, name := range opts.recursionParents(names) {
+				queue.Push(name, parent.Level+1)
 			}
-			level++
-		} else {
-			toScan = []string{}
 		}
 	}
 
@@ -274,15 +750,94 @@ func activeScan(config ActiveScanConfig) []models.SubdomainResult {
 		close(streamChan)
 	}
 
-	return results
+	results, err := spool.Results()
+	if err != nil {
+		fmt.Printf("× Failed to read spilled results: %v\n", err)
+	}
+
+	// Candidates lost to transient failures get a second chance
+	retried := retryPass(opts, config)
+	config.workspace.Checkpoint(retried)
+	results = append(results, retried...)
+
+	// Results answered by a resolver dropped mid-scan are checked again
+	results = revalidateSuspects(results, opts)
+
+	// Service records often point at hosts the wordlist never reaches
+	if config.ServiceRecords && config.budgetSpent() {
+		fmt.Println("» Scan budget exhausted, skipping service records")
+	} else if config.ServiceRecords {
+		serviceResults := serviceRecordPass(config.Domain, results, opts, config)
+		config.workspace.Checkpoint(serviceResults)
+		results = append(results, serviceResults...)
+	}
+
+	return verifyConsensus(results, config), nil
+}
+
+// defaultWordlistURL is used when no wordlist file is provided
+const defaultWordlistURL = "https://raw.githubusercontent.com/danielmiessler/SecLists/refs/heads/master/Discovery/DNS/subdomains-top1million-110000.txt"
+
+// loadWordlist loads the wordlist from a file, or downloads the default one
+// Entries are normalized, invalid and duplicate ones are left out; failures are
+// wrapped in ErrWordlistNotFound, ErrWordlistRead or ErrWordlistFetch
+// Wordlists watched by WatchReloads are served from memory
+func loadWordlist(path string) ([]string, error) {
+	if watched, ok := watchedWordlists.Load(path); ok && path != "" {
+		return watched.([]string), nil
+	}
+	return readWordlist(path)
+}
+
+// readWordlist reads the wordlist at path, or downloads the default one when empty
+func readWordlist(path string) ([]string, error) {
+	var words []string
+	var err error
+	if path == "" {
+		fmt.Println("» Downloading wordlist...")
+		words, err = utils.FetchWordlistFromURL(defaultWordlistURL)
+	} else {
+		words, err = utils.LoadWordlist(path)
+	}
+	if err != nil {
+		return nil, wordlistError(path, err)
+	}
+
+	wordlist, invalid := sanitizeWordlist(words)
+	if invalid > 0 {
+		fmt.Printf("» Skipped %d invalid wordlist entries\n", invalid)
+	}
+	return wordlist, nil
+}
+
+// sanitizeWordlist normalizes wordlist entries with utils.NormalizeWord
+// Returns the distinct valid words and the number of invalid entries
+func sanitizeWordlist(words []string) ([]string, int) {
+	wordlist := make([]string, 0, len(words))
+	seen := make(map[string]bool, len(words))
+	invalid := 0
+	for _, word := range words {
+		normalized := utils.NormalizeWord(word)
+		if normalized == "" {
+			invalid++
+			continue
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			wordlist = append(wordlist, normalized)
+		}
+	}
+	return wordlist, invalid
 }
 
 // processResolvers processes the given resolvers
+// Scans check resolver files with checkResolvers first, so a file failing here changed mid-run
 func processResolvers(resolvers []string) []string {
 	var finalResolvers []string
 	if len(resolvers) == 1 && utils.IsResolverFile(resolvers[0]) {
 		fileResolvers, err := utils.LoadResolvers(resolvers[0])
 		if err != nil {
+			fmt.Printf("× Failed to load resolvers: %v\n", err)
 			return nil
 		}
 		finalResolvers = fileResolvers
@@ -294,55 +849,60 @@ func processResolvers(resolvers []string) []string {
 	return finalResolvers
 }
 
-// setupHTTPClient sets up an HTTP client for takeover checks
-func setupHTTPClient(takeover bool, proxy string) *http.Client {
+// checkResolvers loads the resolver files among lists before a scan starts
+// A file that cannot be read fails with ErrResolverLoad instead of the scan
+// silently falling back to the system resolver
+func checkResolvers(lists ...[]string) error {
+	for _, resolvers := range lists {
+		if len(resolvers) == 1 && utils.IsResolverFile(resolvers[0]) {
+			if _, err := utils.LoadResolvers(resolvers[0]); err != nil {
+				return fmt.Errorf("%w: %v", ErrResolverLoad, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setupTakeoverClient sets up the HTTP client of takeover checks
+// Returns nil, disabling them, when takeover is unset or the client cannot be created
+func setupTakeoverClient(takeover bool, proxy string, config TakeoverHTTPConfig) *TakeoverClient {
 	if !takeover {
 		return nil
 	}
 
-	if proxy != "" {
-		proxyURL, err := url.Parse(proxy)
-		if err != nil {
-			return nil
-		}
-		transport := &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
-		}
-		return &http.Client{Transport: transport, Timeout: 5 * time.Second}
+	client, err := NewTakeoverClient(proxy, config)
+	if err != nil {
+		fmt.Printf("× Takeover checks disabled: %v\n", err)
+		return nil
 	}
-
-	return &http.Client{Timeout: 5 * time.Second}
+	return client
 }
 
 // setupStreamChannel sets up a channel for streaming results
-func setupStreamChannel(showIP bool) chan models.SubdomainResult {
+func setupStreamChannel(showIP bool, filter *utils.ResultFilter) chan models.SubdomainResult {
 	streamChan := make(chan models.SubdomainResult, 100)
 
 	// Set up goroutine to process streaming results
 	go func() {
 		for result := range streamChan {
-			output.DisplayResult(result, showIP)
+			if filter.Allows(result.Subdomain) {
+				output.DisplayResult(result, showIP)
+			}
 		}
 	}()
 
 	return streamChan
 }
 
-// scanLevel performs scanning for one recursion level
+// scanLevel performs scanning for one recursion level, traced under the span of trace
 func scanLevel(
+	trace context.Context,
 	toScan []string,
 	wordlist []string,
-	resolvers []string,
-	cache *models.DNSCache,
-	client *http.Client,
+	opts LookupOptions,
 	config ActiveScanConfig,
 	streamChan chan models.SubdomainResult,
 ) []models.SubdomainResult {
-	var levelResults []models.SubdomainResult
-	var wg sync.WaitGroup
-	subdomainChan := make(chan string, 100)
-	resultChan := make(chan models.SubdomainResult, 100)
-
 	// Display total tasks to be performed
 	totalTasks := len(toScan) * len(wordlist)
 	fmt.Printf("» Checking %d subdomains\n", totalTasks)
@@ -353,11 +913,13 @@ func scanLevel(
 	// Setup result writer for real-time display
 	var resultWriter *output.ResultWriter
 	resultWriter = output.NewResultWriter(bar, config.ShowIP)
+	resultWriter.SetFilter(config.Filter)
 
 	// Handle interrupt signal for clean exit
+	// The context also ends when the time budget of the scan runs out
 	interruptChan := make(chan os.Signal, 1)
 	signal.Notify(interruptChan, os.Interrupt, syscall.SIGTERM)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := budgetContext(config.Context, config.deadline, config.queries)
 
 	go func() {
 		select {
@@ -366,7 +928,7 @@ func scanLevel(
 			// Complete progress bar elegantly
 			bar.Finish()
 			fmt.Println("\nBye!")
-			os.Exit(0)
+			utils.Exit(0)
 		case <-ctx.Done():
 			return
 		}
@@ -381,54 +943,29 @@ func scanLevel(
 	// Start progress bar
 	bar.Start()
 
-	// Create worker pool
-	for i := 0; i < config.NumWorkers; i++ {
-		wg.Add(1)
-		go Worker(
-			subdomainChan,
-			resultChan,
-			resolvers,
-			cache,
-			client,
-			bar,
-			resultWriter,
-			&wg,
-			config.ShowIP,
-			config.RateLimit,
-			streamChan,
-		)
-	}
-
-	// Feed subdomains to workers
-	go func() {
-		for _, target := range toScan {
-			for _, word := range wordlist {
-				select {
-				case <-ctx.Done():
-					return // Exit if interrupted
-				default:
-					subdomain := word + "." + target
-					subdomainChan <- subdomain
-				}
+	engine := &Engine{
+		Options:       opts,
+		Workers:       config.NumWorkers,
+		Pause:         time.Duration(config.RateLimit) * time.Millisecond,
+		Exclude:       config.Exclude,
+		CountCoverage: true,
+		Output: func(result models.SubdomainResult) {
+			// Write results in real-time
+			result.Tags = models.AddTags(result.Tags, config.Tags...)
+			result = models.RateResult(result)
+			resultWriter.WriteResult(result)
+			config.publish(result)
+			if streamChan != nil {
+				streamChan <- result
 			}
-		}
-		close(subdomainChan)
-	}()
-
-	// Collect results
-	go func() {
-		wg.Wait()
-		close(resultChan)
-		if streamChan != nil && !config.Recursive {
-			close(streamChan)
-		}
-	}()
-
-	// Process and save results for this level
-	for result := range resultChan {
-		levelResults = append(levelResults, result)
+		},
+		Progress: func() { bar.Increment() },
 	}
+	levelResults := engine.Run(telemetry.Join(ctx, trace), WordlistProducer(toScan, wordlist))
 
+	if streamChan != nil && !config.Recursive {
+		close(streamChan)
+	}
 	bar.Finish()
 
 	return levelResults
@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// adaptiveBackoff slows down the limit keys (root domains, authoritative servers or
+// resolvers, see LookupOptions.limitKey) whose lookups keep failing
+// Only failures without an authoritative answer (timeouts, SERVFAIL, refused) count:
+// NXDOMAIN is the answer to most candidates of a brute-force scan
+type adaptiveBackoff struct {
+	delays    *utils.ExponentialBackoff
+	threshold int
+	by        string // What the delays are keyed by, the limit key when empty
+}
+
+// newAdaptiveBackoff creates the backoff of a scan, nil when it is disabled
+func newAdaptiveBackoff(config BackoffConfig) *adaptiveBackoff {
+	if !config.Enabled {
+		return nil
+	}
+	base := config.BaseDelay
+	if base <= 0 {
+		base = defaultBackoffBaseDelay
+	}
+	return &adaptiveBackoff{
+		delays:    utils.NewExponentialBackoff(base, config.MaxDelay, config.Factor, config.Jitter),
+		threshold: max(config.FailThreshold, 1),
+		by:        config.By,
+	}
+}
+
+// wait pauses before a lookup charged to key while the key is backed off
+func (b *adaptiveBackoff) wait(key string) {
+	if b == nil {
+		return
+	}
+	if b.delays.IsRateLimited(key, b.threshold) {
+		time.Sleep(b.delays.NextDelay(key))
+	}
+}
+
+// record counts the outcome of a lookup charged to key, answers ease its backoff
+func (b *adaptiveBackoff) record(key string, status utils.LookupStatus) {
+	if b == nil {
+		return
+	}
+	b.delays.AdaptiveDelay(key, status.IsAuthoritative())
+}
@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// startBudget sets the deadline of a time-boxed scan from its start time
+// and the query budget of a scan capped by MaxQueries
+func (c *ActiveScanConfig) startBudget() {
+	if c.TimeoutTotal > 0 {
+		c.deadline = c.Metadata.StartedAt.Add(c.TimeoutTotal)
+	}
+	c.queries = newQueryBudget(c.MaxQueries)
+}
+
+// budgetSpent reports whether the time budget or the query budget of the scan is exhausted,
+// or the scan was cancelled through its Context
+func (c *ActiveScanConfig) budgetSpent() bool {
+	return budgetExceeded(c.deadline) || c.queries.exhausted() || (c.Context != nil && c.Context.Err() != nil)
+}
+
+// queryBudget caps the lookups of a scan across its levels, branches and passes
+// A nil budget never runs out
+type queryBudget struct {
+	max  int64
+	used atomic.Int64
+	done chan struct{} // Closed once the budget is spent
+	once sync.Once
+}
+
+// newQueryBudget returns a budget of limit lookups, nil when limit is not positive
+func newQueryBudget(limit int64) *queryBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &queryBudget{max: limit, done: make(chan struct{})}
+}
+
+// spend charges one lookup to the budget
+// Returns false once the budget is spent, the lookup must then not be sent
+func (b *queryBudget) spend() bool {
+	return b.charge(1)
+}
+
+// charge counts n lookups made elsewhere, such as by the nodes of a distributed scan
+// Returns false when they exceed the budget
+func (b *queryBudget) charge(n int) bool {
+	if b == nil {
+		return true
+	}
+	used := b.used.Add(int64(n))
+	if used >= b.max {
+		b.once.Do(func() { close(b.done) })
+	}
+	return used <= b.max
+}
+
+// exhausted reports whether every lookup of the budget was spent
+func (b *queryBudget) exhausted() bool {
+	return b != nil && b.used.Load() >= b.max
+}
+
+// budgetExceeded reports whether the time budget ending at deadline is exhausted
+// A zero deadline means the scan is not time-boxed
+func budgetExceeded(deadline time.Time) bool {
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
+
+// budgetContext returns a context cancelled with parent, when the deadline passes,
+// if there is one, or when the query budget is spent
+// A nil parent stands for context.Background()
+func budgetContext(parent context.Context, deadline time.Time, queries *queryBudget) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if deadline.IsZero() {
+		ctx, cancel = context.WithCancel(parent)
+	} else {
+		ctx, cancel = context.WithDeadline(parent, deadline)
+	}
+	if queries != nil {
+		go func() {
+			select {
+			case <-queries.done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return ctx, cancel
+}
+
+// reportCoverage prints how many planned candidates a time-boxed or query-capped
+// scan checked and records it in the scan metadata
+func reportCoverage(config *ActiveScanConfig, stats *LookupStats) {
+	if config.TimeoutTotal <= 0 && config.MaxQueries <= 0 {
+		return
+	}
+
+	checked, planned := stats.Coverage()
+	coverage := &models.ScanCoverage{
+		Checked:  checked,
+		Planned:  planned,
+		Complete: checked >= planned,
+	}
+	config.Metadata.Coverage = coverage
+
+	if coverage.Complete {
+		return
+	}
+	percent := 0.0
+	if planned > 0 {
+		percent = float64(checked) * 100 / float64(planned)
+	}
+	if config.queries.exhausted() {
+		fmt.Printf("» Query budget of %d lookups exhausted: checked %d of %d candidates (%.1f%%)\n",
+			config.MaxQueries, checked, planned, percent)
+		return
+	}
+	fmt.Printf("» Time budget of %s exhausted: checked %d of %d candidates (%.1f%%)\n",
+		config.TimeoutTotal, checked, planned, percent)
+}
@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	dnsresolvers "github.com/fkr00t/subcollector/internal/resolvers"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// DefaultCanaryInterval is the time between canary checks of the resolvers during a scan
+const DefaultCanaryInterval = 2 * time.Minute
+
+// resolverGuard drops resolvers caught lying by periodic canary queries
+// and remembers which resolver answered each found host so results
+// obtained through a dropped resolver can be re-validated
+type resolverGuard struct {
+	resolvers []string
+	interval  time.Duration
+
+	mu         sync.RWMutex
+	banned     map[string][]string // Lies detected per dropped resolver
+	active     []string            // Resolvers not dropped, in configured order
+	answeredBy map[string]string   // Resolver that answered each found host
+	lastCheck  time.Time
+	checking   int32
+}
+
+// newResolverGuard creates a guard and runs the first canary check in the background
+func newResolverGuard(resolvers []string, interval time.Duration) *resolverGuard {
+	g := &resolverGuard{
+		resolvers:  resolvers,
+		interval:   interval,
+		banned:     make(map[string][]string),
+		active:     resolvers,
+		answeredBy: make(map[string]string),
+	}
+	g.maybeCheck()
+	return g
+}
+
+// Active returns the resolvers still trusted, starting a canary check when one is due
+func (g *resolverGuard) Active() []string {
+	g.maybeCheck()
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.active
+}
+
+// recordAnswer remembers the resolver a found host was resolved through
+func (g *resolverGuard) recordAnswer(host, resolver string) {
+	if resolver == "" {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.answeredBy[host] = resolver
+}
+
+// suspect reports whether a host was resolved through a dropped resolver
+func (g *resolverGuard) suspect(host string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	resolver, ok := g.answeredBy[host]
+	if !ok {
+		return false
+	}
+	_, banned := g.banned[resolver]
+	return banned
+}
+
+// maybeCheck starts a canary check when the interval elapsed and none is running
+func (g *resolverGuard) maybeCheck() {
+	g.mu.RLock()
+	due := time.Since(g.lastCheck) >= g.interval
+	g.mu.RUnlock()
+	if !due || !atomic.CompareAndSwapInt32(&g.checking, 0, 1) {
+		return
+	}
+
+	g.mu.Lock()
+	g.lastCheck = time.Now()
+	candidates := append([]string(nil), g.active...)
+	g.mu.Unlock()
+
+	go func() {
+		defer atomic.StoreInt32(&g.checking, 0)
+		g.check(candidates)
+	}()
+}
+
+// check sends canary queries through every resolver and drops the liars
+func (g *resolverGuard) check(candidates []string) {
+	const workers = 10
+
+	lies := make([][]string, len(candidates))
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				lies[index] = dnsresolvers.CheckHonesty(candidates[index], dnsresolvers.DefaultTimeout)
+			}
+		}()
+	}
+	for i := range candidates {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, resolver := range candidates {
+		if len(lies[i]) == 0 {
+			continue
+		}
+		g.banned[resolver] = lies[i]
+		fmt.Printf("\r\033[K× Dropped resolver %s (%s)\n", resolver, strings.Join(lies[i], ", "))
+	}
+
+	var active []string
+	for _, resolver := range g.resolvers {
+		if _, banned := g.banned[resolver]; !banned {
+			active = append(active, resolver)
+		}
+	}
+	if len(active) == 0 && len(g.resolvers) > 0 {
+		fmt.Printf("\r\033[K× Every resolver was dropped, falling back to the system resolver\n")
+	}
+	g.active = active
+}
+
+// revalidateSuspects re-resolves results answered by a resolver dropped during the scan
+// through the resolvers still trusted. Names that no longer exist are removed, names that
+// cannot be confirmed are kept and marked unverified
+func revalidateSuspects(results []models.SubdomainResult, opts LookupOptions) []models.SubdomainResult {
+	if opts.guard == nil {
+		return results
+	}
+
+	var kept []models.SubdomainResult
+	checked, dropped := 0, 0
+	for _, result := range results {
+		if !opts.guard.suspect(result.Subdomain) {
+			kept = append(kept, result)
+			continue
+		}
+
+		checked++
+		addresses, status := resolveSubdomain(result.Subdomain, opts.guard.Active(), nil)
+		switch status {
+		case utils.StatusNXDomain:
+			dropped++
+			continue
+		case utils.StatusResolved:
+			if opts.ShowIP {
+				result.IPs = addresses
+			}
+			result.Unverified = false
+		default:
+			result.Unverified = true
+		}
+		kept = append(kept, result)
+	}
+
+	if checked > 0 {
+		fmt.Printf("» Re-validated %d results from dropped resolvers, %d no longer exist\n", checked, dropped)
+	}
+	return kept
+}
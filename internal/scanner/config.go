@@ -1,19 +1,59 @@
 package scanner
 
 import (
+	"context"
+	"fmt"
 	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/sink"
+	"github.com/fkr00t/subcollector/internal/utils"
+	"github.com/fkr00t/subcollector/internal/workspace"
 	"io"
 	"time"
 )
 
+// Defaults of the adaptive backoff
+const (
+	DefaultBackoffMaxDelay  = 10 * time.Second
+	DefaultBackoffFactor    = 2.0
+	DefaultBackoffJitter    = 0.3
+	DefaultBackoffThreshold = 3
+
+	// defaultBackoffBaseDelay is the first delay when scans are not rate limited
+	defaultBackoffBaseDelay = 100 * time.Millisecond
+)
+
 // BackoffConfig configuration for the backoff algorithm
 type BackoffConfig struct {
 	Enabled       bool
-	BaseDelay     time.Duration
-	MaxDelay      time.Duration
-	Factor        float64
-	Jitter        float64
-	FailThreshold int
+	BaseDelay     time.Duration // First delay, the rate limit usually (default 100ms)
+	MaxDelay      time.Duration // Longest delay between lookups of a root domain
+	Factor        float64       // Growth of the delay per failed lookup
+	Jitter        float64       // Random share of the delay added to it, 0 to 1
+	FailThreshold int           // Failed lookups before a root domain is slowed down
+	By            string        // What the delays are keyed by (LimitByParent and the like), the limit key of the scan when empty
+}
+
+// Validate checks the knobs of an enabled backoff
+func (b BackoffConfig) Validate() error {
+	if !b.Enabled {
+		return nil
+	}
+	if b.Factor < 1 {
+		return fmt.Errorf("invalid backoff factor %g, must be at least 1", b.Factor)
+	}
+	if b.Jitter < 0 || b.Jitter > 1 {
+		return fmt.Errorf("invalid backoff jitter %g, must be between 0 and 1", b.Jitter)
+	}
+	if b.MaxDelay <= 0 {
+		return fmt.Errorf("invalid backoff max delay %s, must be positive", b.MaxDelay)
+	}
+	if b.FailThreshold < 1 {
+		return fmt.Errorf("invalid backoff threshold %d, must be at least 1", b.FailThreshold)
+	}
+	if err := ValidateLimitBy(b.By); err != nil {
+		return fmt.Errorf("invalid backoff key: %v", err)
+	}
+	return nil
 }
 
 // StreamingActiveScanConfig configuration for active scanning with streaming
@@ -24,12 +64,46 @@ type StreamingActiveScanConfig struct {
 	WordlistReader  io.Reader // Changed from interface{} to io.Reader
 	Resolvers       []string
 	BackoffConfig   BackoffConfig
+	LimitBy         string             // What the backoff and concurrency caps are charged to, see ActiveScanConfig
+	MaxPerAuthority int                // Lookups running at once per limit key (0 for no cap)
+	MaxMemory       int64              // Heap size at which lookup answers and results are spilled to disk (0 for no cap)
+	Cache           models.CacheConfig // DNS cache of the scan (LRU when the type is empty)
 	Recursive       bool
 	ShowIP          bool
 	Depth           int
+	DepthRules      []DepthRule // Recursion depth under the hosts matching a pattern, overriding Depth
+	MaxQueries      int64       // Lookups the whole scan may send, each a name asked from one resolver (0 for no cap)
 	Takeover        bool
+	TakeoverWorkers int                // Takeover checks running at once, apart from the DNS workers
+	TakeoverHTTP    TakeoverHTTPConfig // Timeout, redirect policy, TLS and body cap of takeover fingerprinting requests
 	Proxy           string
 	NumWorkers      int
 	ChunkSize       int
 	ResultProcessor func(models.SubdomainResult)
+	Stats           *LookupStats        // Optional counters for lookup outcomes
+	Exclude         *utils.ExcludeList  // Hosts never queried nor reported
+	Filter          *utils.ResultFilter // Match/filter rules applied before display
+	DNSSEC          bool                // Record the DNSSEC validation status of each result
+	ServiceRecords  bool                // Query well-known SRV/TXT names for leaked hostnames
+	TLS             bool                // Grab certificates on port 443 and record them
+	Ports           []int               // TCP ports checked on resolved addresses
+	Group           bool                // Record IPs, CNAME chains and providers for grouping
+	EvidenceDir     string              // Directory receiving takeover evidence
+	CanaryInterval  time.Duration       // Time between canary checks dropping lying resolvers
+	Verify          bool                // Keep only results confirmed by a quorum of trusted resolvers
+	VerifyResolvers []string            // Trusted resolvers of the consensus pass
+	Quorum          int                 // Trusted resolvers that must confirm a result
+	HTTP            bool                // Probe web servers and record their fingerprint
+	Tech            bool                // Detect the technologies of probed web servers
+	SecondOrder     bool                // Check the hosts serving page resources of probed web servers
+	Dangling        bool                // Report records left pointing at missing resources
+	Email           bool                // Analyze the mail records of subdomains
+	RDAP            bool                // Look up the registered networks of resolved IPs
+	Sink            sink.Sinks          // Optional destinations receiving results as they are confirmed
+
+	workspace *workspace.Workspace // Artifact directory of the current run
+	deadline  time.Time            // End of the time budget, zero when not time-boxed
+	queries   *queryBudget         // Lookups left to the scan, nil when not capped
+	ctx       context.Context      // Ends the scan early, see ActiveScanConfig.Context
+	trace     context.Context      // Span of the scan, parent of the spans of its stages
 }
@@ -0,0 +1,274 @@
+package scanner
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/cloud"
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/probe"
+	"github.com/fkr00t/subcollector/internal/utils"
+	"golang.org/x/net/publicsuffix"
+)
+
+// danglingPorts are tried on cloud addresses, an address answering none of them
+// was most likely released back to the provider
+var danglingPorts = []int{80, 443, 22, 8080, 8443}
+
+// danglingCache remembers per-scan answers shared by many subdomains
+type danglingCache struct {
+	servers    sync.Map // Zone -> authoritative server address, "" when none answered
+	addresses  sync.Map // Cloud address -> whether any service answered
+	registered sync.Map // Registrable domain -> whether it is registered
+	hosts      sync.Map // Nameserver -> address, "" when the name does not exist
+	resources  sync.Map // Host serving page resources -> *models.DanglingRecord, nil when it cannot be claimed
+	mail       sync.Map // Mail exchanger or SPF include -> models.DanglingRecord, without severity when it cannot be claimed
+}
+
+// checkUnresolved looks for dangling records behind a name that did not resolve
+// A CNAME to a missing target yields NXDOMAIN, a delegation to nameservers that
+// do not exist yields SERVFAIL or a timeout
+// records are the answers of the lookup, nil when the system resolver gave it: the CNAME
+// chain is then queried again. Further queries are paced and budgeted, see followUp
+func (o LookupOptions) checkUnresolved(subdomain string, status utils.LookupStatus, records utils.RecordSet) (models.SubdomainResult, bool) {
+	result := models.SubdomainResult{Subdomain: subdomain}
+
+	switch status {
+	case utils.StatusNXDomain:
+		var chain []string
+		if records != nil {
+			chain = records.CNAMEChain(subdomain)
+		} else if o.followUp(subdomain) {
+			chain = utils.ResolveCNAMEChain(subdomain, o.QueryResolver)
+		}
+		// The name has a CNAME and answered NXDOMAIN, so the end of the chain does not exist
+		if len(chain) > 0 && o.followUp(subdomain) {
+			result.CNAME = chain
+			result.Provider = utils.DetectProvider(chain)
+			result.Dangling = []models.DanglingRecord{o.missingTarget(chain)}
+		}
+	case utils.StatusServFail, utils.StatusTimeout:
+		if o.followUp(subdomain) {
+			result.Dangling = o.danglingDelegation(subdomain)
+		}
+	}
+
+	return result, len(result.Dangling) > 0
+}
+
+// followUp paces a query sent about name after its lookup like the lookups of candidates:
+// it is charged to the query budget and waits for the rate limit of name's limit key
+// Returns false once the budget is spent, the query must then not be sent
+func (o LookupOptions) followUp(name string) bool {
+	if !o.queries.spend() {
+		return false
+	}
+	if o.limiter != nil {
+		o.limiter.Wait(o.limitKey(name))
+	}
+	if o.pause > 0 {
+		time.Sleep(o.pause)
+	}
+	return true
+}
+
+// recordDangling checks a resolved subdomain for dangling delegations, cloud addresses and mail hosts
+func (o LookupOptions) recordDangling(result *models.SubdomainResult, addresses []string) {
+	result.Dangling = append(o.danglingDelegation(result.Subdomain), o.danglingAddresses(addresses)...)
+	result.Dangling = append(result.Dangling, o.danglingMail(result)...)
+}
+
+// danglingCNAME reports a CNAME chain ending at a target that does not exist
+// The finding is critical when the target's domain itself is unregistered
+func (o LookupOptions) danglingCNAME(chain []string) (models.DanglingRecord, bool) {
+	if len(chain) == 0 {
+		return models.DanglingRecord{}, false
+	}
+
+	target := chain[len(chain)-1]
+	if missing, err := utils.IsNXDomain(target, o.QueryResolver); err != nil || !missing {
+		return models.DanglingRecord{}, false
+	}
+	return o.missingTarget(chain), true
+}
+
+// missingTarget reports a CNAME chain ending at a target known not to exist
+func (o LookupOptions) missingTarget(chain []string) models.DanglingRecord {
+	target := chain[len(chain)-1]
+	finding := models.DanglingRecord{
+		Type:     models.DanglingCNAME,
+		Severity: models.SeverityHigh,
+		Target:   target,
+		Detail:   utils.DetectProvider(chain),
+	}
+	if domain, ok := o.unregisteredDomain(target); ok {
+		finding.Severity = models.SeverityCritical
+		finding.Detail = domain + " is not registered"
+	}
+	return finding
+}
+
+// danglingDelegation reports nameservers of a delegated subdomain that anyone could
+// take over: those whose domain is unregistered, and those that do not answer
+// authoritatively for the zone, which may be claimed on the DNS provider hosting them
+// The delegation is read from the parent zone, which is the scanned root domain
+func (o LookupOptions) danglingDelegation(subdomain string) []models.DanglingRecord {
+	zone := o.zoneOf(subdomain)
+	if zone == "" || zone == subdomain {
+		return nil
+	}
+
+	server := o.authoritativeServer(zone)
+	if server == "" {
+		return nil
+	}
+
+	nameservers, err := utils.QueryDelegation(subdomain, server)
+	if err != nil {
+		return nil
+	}
+
+	// Several nameservers usually share a domain, it is reported once
+	var findings, lame []models.DanglingRecord
+	reported := make(map[string]bool)
+	for _, ns := range nameservers {
+		if domain, ok := o.unregisteredDomain(ns); ok {
+			if !reported[domain] {
+				reported[domain] = true
+				findings = append(findings, models.DanglingRecord{
+					Type:     models.DanglingNS,
+					Severity: models.SeverityCritical,
+					Target:   ns,
+					Detail:   domain + " is not registered",
+				})
+			}
+			continue
+		}
+		if reason, ok := o.lameNameserver(subdomain, ns); ok {
+			lame = append(lame, models.DanglingRecord{
+				Type:     models.DanglingLameNS,
+				Severity: models.SeverityMedium,
+				Target:   ns,
+				Detail:   reason,
+			})
+		}
+	}
+
+	// With no nameserver answering, the zone is gone from the provider and can be created again
+	if len(lame) == len(nameservers) {
+		for i := range lame {
+			lame[i].Severity = models.SeverityHigh
+		}
+	}
+	return append(findings, lame...)
+}
+
+// lameNameserver reports why a delegated nameserver does not answer authoritatively for zone
+// A nameserver whose address cannot be looked up is given the benefit of the doubt
+func (o LookupOptions) lameNameserver(zone, ns string) (string, bool) {
+	address, ok := o.nameserverAddress(ns)
+	if !ok {
+		return "", false
+	}
+	if address == "" {
+		return "nameserver does not exist", true
+	}
+
+	authoritative, reason, err := utils.QueryAuthority(zone, address)
+	if err != nil {
+		return "no answer (" + utils.ClassifyLookupError(err).String() + ")", true
+	}
+	if !authoritative {
+		return reason, true
+	}
+	return "", false
+}
+
+// nameserverAddress returns a cached address of a nameserver, "" when the name does not exist
+// Returns false when the lookup gave no definitive answer
+func (o LookupOptions) nameserverAddress(ns string) (string, bool) {
+	if cached, ok := o.dangling.hosts.Load(ns); ok {
+		return cached.(string), true
+	}
+
+	addresses, err := utils.LookupWithResolver(ns, o.QueryResolver)
+	switch {
+	case err == nil && len(addresses) > 0:
+		o.dangling.hosts.Store(ns, addresses[0])
+		return addresses[0], true
+	case utils.ClassifyLookupError(err) == utils.StatusNXDomain:
+		o.dangling.hosts.Store(ns, "")
+		return "", true
+	}
+	return "", false
+}
+
+// danglingAddresses reports addresses in cloud provider ranges where no service answers
+func (o LookupOptions) danglingAddresses(addresses []string) []models.DanglingRecord {
+	var findings []models.DanglingRecord
+	for _, ip := range addresses {
+		match, ok := cloud.Default().Lookup(ip)
+		if !ok {
+			continue
+		}
+
+		var alive bool
+		if cached, ok := o.dangling.addresses.Load(ip); ok {
+			alive = cached.(bool)
+		} else {
+			alive = len(probe.ScanPorts(ip, danglingPorts, probe.DefaultPortTimeout)) > 0
+			o.dangling.addresses.Store(ip, alive)
+		}
+		if alive {
+			continue
+		}
+
+		detail := match.Provider
+		if match.Region != "" {
+			detail += "/" + match.Region
+		}
+		findings = append(findings, models.DanglingRecord{
+			Type:     models.DanglingCloudIP,
+			Severity: models.SeverityMedium,
+			Target:   ip,
+			Detail:   detail,
+		})
+	}
+	return findings
+}
+
+// authoritativeServer returns a cached authoritative server address of zone
+func (o LookupOptions) authoritativeServer(zone string) string {
+	if cached, ok := o.dangling.servers.Load(zone); ok {
+		return cached.(string)
+	}
+	server, _ := utils.AuthoritativeServer(zone, o.QueryResolver)
+	o.dangling.servers.Store(zone, server)
+	return server
+}
+
+// unregisteredDomain returns the registrable domain of host when nobody registered it
+// Hosts under private suffixes like herokuapp.com are skipped, their names are
+// claimed on the platform rather than at a registrar
+func (o LookupOptions) unregisteredDomain(host string) (string, bool) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if _, icann := publicsuffix.PublicSuffix(host); !icann {
+		return "", false
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return "", false
+	}
+
+	if cached, ok := o.dangling.registered.Load(domain); ok {
+		return domain, !cached.(bool)
+	}
+	missing, err := utils.IsNXDomain(domain, o.QueryResolver)
+	if err != nil {
+		// Unknown is treated as registered, a false alarm is worse than a miss here
+		return "", false
+	}
+	o.dangling.registered.Store(domain, !missing)
+	return domain, missing
+}
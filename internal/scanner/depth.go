@@ -0,0 +1,70 @@
+package scanner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DepthRule sets how deep recursion goes under a zone, overriding the depth of the scan
+// so a branch such as *.aws.example.com can be brute forced deeper, or a noisy one shallower
+type DepthRule struct {
+	Zone  string // The zone and the hosts under it match
+	Depth int    // Deepest level brute forced under them, counted like Depth (-1 for unlimited)
+}
+
+// String formats the rule as accepted by ParseDepthRules
+func (r DepthRule) String() string {
+	return fmt.Sprintf("*.%s=%d", r.Zone, r.Depth)
+}
+
+// ParseDepthRules parses pattern=depth pairs (example: *.aws.example.com=5)
+// The pattern is a zone, with or without a leading *.: the zone itself and every host under it match
+func ParseDepthRules(specs []string) ([]DepthRule, error) {
+	var rules []DepthRule
+	for _, spec := range specs {
+		pattern, value, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid depth rule %q, expected pattern=depth (example: *.aws.example.com=5)", spec)
+		}
+		zone := strings.ToLower(strings.Trim(strings.TrimPrefix(strings.TrimSpace(pattern), "*."), "."))
+		if zone == "" || strings.Contains(zone, "*") {
+			return nil, fmt.Errorf("invalid depth rule pattern %q, use a zone such as *.aws.example.com", pattern)
+		}
+		depth, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || depth < -1 {
+			return nil, fmt.Errorf("invalid depth %q in rule %q, must be -1 (unlimited) or more", value, spec)
+		}
+		rules = append(rules, DepthRule{Zone: zone, Depth: depth})
+	}
+	return rules, nil
+}
+
+// depthFor returns the recursion depth under name: the depth of the rule with the
+// longest zone matching it, depth when none does
+func depthFor(name string, depth int, rules []DepthRule) int {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	matched := ""
+	for _, rule := range rules {
+		if len(rule.Zone) <= len(matched) {
+			continue
+		}
+		if name == rule.Zone || strings.HasSuffix(name, "."+rule.Zone) {
+			matched = rule.Zone
+			depth = rule.Depth
+		}
+	}
+	return depth
+}
+
+// deeperParents returns the names found at level that are brute forced in turn,
+// those whose depth, see depthFor, goes past level
+func deeperParents(names []string, level, depth int, rules []DepthRule) []string {
+	var parents []string
+	for _, name := range names {
+		if limit := depthFor(name, depth, rules); limit == -1 || level < limit {
+			parents = append(parents, name)
+		}
+	}
+	return parents
+}
@@ -0,0 +1,199 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/fkr00t/subcollector/api/subcollector/v1"
+	"github.com/fkr00t/subcollector/internal/cluster"
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/output"
+	"github.com/fkr00t/subcollector/internal/rdap"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// ExecuteDistributedScan runs the active scan of domains on the nodes of a cluster
+// The wordlist is cut into shards of shardSize words that the nodes brute force, while
+// recursion, deduplication, match/filter rules and outputs are handled here like for a local scan
+func ExecuteDistributedScan(config ActiveScanConfig, domains []string, nodes *cluster.Cluster, shardSize int) ([]models.SubdomainResult, error) {
+	config.Metadata.StartedAt = time.Now()
+	config.startBudget()
+
+	target := reportTarget(config.Metadata, domains)
+	name := "distributed"
+	if len(domains) == 1 {
+		name = domains[0]
+	}
+	ws, err := openWorkspace(&config, name)
+	if err != nil {
+		return nil, err
+	}
+	defer ws.Close()
+
+	config.Sink.Begin(target, config.Metadata)
+	defer config.Sink.Close()
+
+	var addresses []string
+	for _, node := range nodes.Nodes() {
+		addresses = append(addresses, node.Address)
+	}
+	fmt.Printf("\n» Scanning %s on %d nodes\n", target, len(addresses))
+	fmt.Printf("  nodes: %s\n\n", strings.Join(addresses, ", "))
+
+	wordlist, err := loadWordlist(config.WordlistPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResolvers(config.Resolvers); err != nil {
+		return nil, err
+	}
+
+	// Resolver and exclusion files are read here, the nodes may not have them
+	req := &pb.ScanRequest{
+		Mode:        pb.ScanMode_SCAN_MODE_ACTIVE,
+		Takeover:    config.Takeover,
+		Tls:         config.TLS,
+		Http:        config.HTTP,
+		Tech:        config.Tech,
+		Dangling:    config.Dangling,
+		Dnssec:      config.DNSSEC,
+		Resolvers:   processResolvers(config.Resolvers),
+		Workers:     int32(config.NumWorkers),
+		RateLimitMs: int32(config.RateLimit),
+		Exclude:     config.Exclude.Patterns(),
+		Verify:      config.Verify,
+	}
+	for _, port := range config.Ports {
+		req.Ports = append(req.Ports, int32(port))
+	}
+
+	// Wildcard and delegation checks of recursion parents run here, between levels
+	guard := LookupOptions{
+		Resolvers:     req.Resolvers,
+		Cache:         models.NewDNSCache(),
+		QueryResolver: utils.SystemResolver(),
+		Scope:         domains,
+		dangling:      &danglingCache{},
+		wildcards:     &wildcardCache{},
+		queries:       config.queries,
+	}
+	if len(req.Resolvers) > 0 {
+		guard.QueryResolver = req.Resolvers[0]
+	}
+
+	stats := NewLookupStats()
+	ctx, cancel := budgetContext(config.Context, config.deadline, config.queries)
+	defer cancel()
+
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var results []models.SubdomainResult
+	var failed []cluster.Shard
+
+	// Nodes scan one level without recursing, the findings of a level are the parents of the next
+	parents := domains
+	for level := 1; len(parents) > 0; level++ {
+		if config.Recursive {
+			fmt.Printf("\n» Level %d: %d parents\n", level, len(parents))
+		}
+		if !config.deadline.IsZero() {
+			req.TimeoutSeconds = int64(time.Until(config.deadline)/time.Second) + 1
+		}
+
+		shards := cluster.Split(parents, wordlist, shardSize)
+		totalTasks := len(parents) * len(wordlist)
+		stats.addPlanned(totalTasks)
+		fmt.Printf("» Checking %d subdomains in %d shards\n", totalTasks, len(shards))
+
+		bar := utils.CreateProgressBar(totalTasks)
+		writer := output.NewResultWriter(bar, config.ShowIP)
+		writer.SetFilter(config.Filter)
+		nodes.Log = writer.WriteLine
+		// Shards are charged when they are handed out, reassigned ones again since their lookups are sent again
+		nodes.Dispatch = func(shard cluster.Shard) bool {
+			return config.queries.charge(len(shard.Words))
+		}
+		bar.Start()
+
+		var levelResults []models.SubdomainResult
+		levelFailed := nodes.Run(ctx, req, shards, func(result models.SubdomainResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			// Reassigned shards report their results again, and SANs can be found by several shards
+			if seen[result.Subdomain] {
+				return
+			}
+			seen[result.Subdomain] = true
+			if !config.ShowIP && !config.Group {
+				result.IPs = nil
+			}
+			writer.WriteResult(result)
+			config.publish(result)
+			levelResults = append(levelResults, result)
+		}, func(shard cluster.Shard) {
+			stats.addChecked(len(shard.Words))
+			bar.Add(len(shard.Words))
+		})
+		bar.Finish()
+
+		failed = append(failed, levelFailed...)
+		results = append(results, levelResults...)
+		if err := config.workspace.Checkpoint(levelResults); err != nil {
+			fmt.Printf("× Failed to write checkpoint: %v\n", err)
+		}
+
+		if config.budgetSpent() || !config.Recursive {
+			break
+		}
+		parents = guard.recursionParents(deeperParents(resultNames(levelResults), level, config.Depth, config.DepthRules))
+	}
+
+	// Shards left once the budget ran out or the scan was cancelled count as unchecked, not as failed
+	if config.budgetSpent() {
+		failed = slices.DeleteFunc(failed, func(shard cluster.Shard) bool {
+			return errors.Is(shard.Err, cluster.ErrNotDispatched) || errors.Is(shard.Err, context.Canceled) ||
+				errors.Is(shard.Err, context.DeadlineExceeded)
+		})
+	}
+	if len(failed) > 0 {
+		unchecked := 0
+		for _, shard := range failed {
+			unchecked += len(shard.Words)
+		}
+		fmt.Printf("\n× %d shards (%d candidates) could not be scanned: %v\n", len(failed), unchecked, failed[len(failed)-1].Err)
+		err = fmt.Errorf("%d shards could not be scanned: %w", len(failed), ErrScanFailed)
+	}
+
+	results = reportFilteredResults(results, config.Filter)
+
+	// Nodes are not asked for RDAP lookups, the coordinator caches networks across shards
+	// The lookups still run once the budget is spent, but not once the scan is cancelled
+	if config.RDAP {
+		client := rdap.ClientFor(config.Proxy)
+		scanCtx := config.Context
+		if scanCtx == nil {
+			scanCtx = context.Background()
+		}
+		for i := range results {
+			results[i].Networks = client.LookupAll(scanCtx, results[i].IPs)
+		}
+	}
+
+	// Brief summary
+	fmt.Printf("\n» Found %d subdomains across %d domains\n", len(results), len(domains))
+	reportDomainCounts(domains, results)
+	reportCoverage(&config, stats)
+	if config.Group || config.HTTP || config.Tech {
+		reportHostGroups(results)
+	}
+	reportEmailPosture(&config, domains, results)
+
+	saveCombinedResults(config, domains, results)
+
+	return results, err
+}
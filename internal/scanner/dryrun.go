@@ -0,0 +1,156 @@
+package scanner
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// defaultWordlistSize is the number of entries of the wordlist downloaded when none is given
+const defaultWordlistSize = 114441
+
+// maxExcludeCount bounds the candidates matched against exclusions, larger scans are not filtered
+const maxExcludeCount = 10_000_000
+
+// DryRunActiveScan prints the workload of an active scan without sending a single query or request
+// concurrent is the number of domains or shards scanned at the same time
+func DryRunActiveScan(config ActiveScanConfig, domains []string, concurrent int) error {
+	fmt.Printf("\n» Workload\n")
+
+	words, excluded := defaultWordlistSize, 0
+	if config.WordlistPath == "" {
+		fmt.Printf("  wordlist:    %d words (default list, downloaded when the scan starts)\n", words)
+	} else {
+		entries, err := utils.LoadWordlist(config.WordlistPath)
+		if err != nil {
+			return wordlistError(config.WordlistPath, err)
+		}
+		wordlist, invalid := sanitizeWordlist(entries)
+		words = len(wordlist)
+		fmt.Printf("  wordlist:    %d words from %s", words, config.WordlistPath)
+		if invalid > 0 {
+			fmt.Printf(" (%d invalid entries skipped)", invalid)
+		}
+		fmt.Println()
+
+		// Excluded candidates are never queried
+		if config.Exclude.Len() > 0 && len(domains)*words <= maxExcludeCount {
+			for _, domain := range domains {
+				for _, word := range wordlist {
+					if config.Exclude.Matches(word + "." + domain) {
+						excluded++
+					}
+				}
+			}
+		}
+	}
+
+	switch {
+	case len(config.Resolvers) == 0:
+		fmt.Printf("  resolvers:   system resolver\n")
+	case len(config.Resolvers) == 1 && utils.IsResolverFile(config.Resolvers[0]):
+		resolvers, err := utils.LoadResolvers(config.Resolvers[0])
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrResolverLoad, err)
+		}
+		fmt.Printf("  resolvers:   %d from %s\n", len(resolvers), config.Resolvers[0])
+	default:
+		fmt.Printf("  resolvers:   %s\n", strings.Join(config.Resolvers, ", "))
+	}
+
+	// Brute force candidates of each level; deeper levels depend on what the previous one finds
+	candidates := len(domains)*words - excluded
+	fmt.Printf("  level 1:     %d candidates (%d domains × %d words", candidates, len(domains), words)
+	if excluded > 0 {
+		fmt.Printf(", %d excluded", excluded)
+	}
+	fmt.Println(")")
+	if config.Recursive {
+		deepest := "no depth limit"
+		if config.Depth != -1 {
+			deepest = fmt.Sprintf("up to level %d", config.Depth)
+		}
+		if config.Depth == -1 || config.Depth > 1 || len(config.DepthRules) > 0 {
+			fmt.Printf("  level 2+:    %d candidates per subdomain found on the previous level (%s)\n", words, deepest)
+		}
+		for _, rule := range config.DepthRules {
+			if rule.Depth == -1 {
+				fmt.Printf("  depth rule:  %s, no depth limit\n", rule)
+			} else {
+				fmt.Printf("  depth rule:  %s, up to level %d\n", rule, rule.Depth)
+			}
+		}
+	}
+
+	// Every check resolves A and AAAA records; names that do not resolve get a CNAME lookup with --dangling
+	perCandidate := 2
+	if config.Dangling {
+		perCandidate++
+	}
+	queries := candidates * perCandidate
+	fmt.Printf("  queries:     about %d for level 1 (%d per candidate, before retries of timeouts)\n", queries, perCandidate)
+	fmt.Printf("  per finding: TTL lookup%s\n", findingChecks(config))
+	if config.MaxQueries > 0 {
+		fmt.Printf("  budget:      at most %d lookups over every level, failovers and follow-ups included (--max-queries)\n", config.MaxQueries)
+		if int64(candidates) > config.MaxQueries {
+			fmt.Printf("× The query budget of %d lookups ends before level 1 is done (about %.0f%% checked)\n",
+				config.MaxQueries, float64(config.MaxQueries)*100/float64(candidates))
+		}
+	}
+	if config.ServiceRecords {
+		fmt.Printf("  srv:         well-known SRV and TXT names of each domain after brute forcing\n")
+	}
+
+	// Workers pause for the rate limit after each check, so it bounds the throughput
+	if config.RateLimit <= 0 {
+		fmt.Printf("  rate:        no rate limit, bounded by resolver latency only\n")
+		return nil
+	}
+	checksPerSecond := float64(config.NumWorkers) * 1000 / float64(config.RateLimit) * float64(max(concurrent, 1))
+	fmt.Printf("  rate:        at most %.0f queries/s (%d workers, %d ms between checks, %d scans at once)\n",
+		checksPerSecond*float64(perCandidate), config.NumWorkers, config.RateLimit, max(concurrent, 1))
+
+	duration := time.Duration(math.Ceil(float64(candidates)/checksPerSecond)) * time.Second
+	fmt.Printf("  duration:    at least %s for level 1\n", duration)
+	if config.TimeoutTotal > 0 && duration > config.TimeoutTotal {
+		fmt.Printf("× The time budget of %s ends before level 1 is done (about %.0f%% checked)\n",
+			config.TimeoutTotal, float64(config.TimeoutTotal)*100/float64(duration))
+	}
+	return nil
+}
+
+// findingChecks lists the checks run on every subdomain found
+func findingChecks(config ActiveScanConfig) string {
+	var checks string
+	if config.Takeover {
+		checks += ", CNAME lookup and HTTP request for takeovers"
+	}
+	if config.DNSSEC {
+		checks += ", DNSSEC lookup"
+	}
+	if config.TLS {
+		checks += ", TLS handshake on port 443"
+	}
+	if len(config.Ports) > 0 {
+		checks += fmt.Sprintf(", %d port connections", len(config.Ports))
+	}
+	if config.HTTP {
+		checks += ", HTTP probe"
+	}
+	if config.SecondOrder {
+		checks += ", DNS lookups and HTTP requests for the hosts of page resources"
+	}
+	if config.Email {
+		checks += ", mail record lookups"
+	}
+	if config.RDAP {
+		checks += ", RDAP request for addresses in networks not seen yet"
+	}
+	if config.Verify {
+		checks += fmt.Sprintf(", confirmation by %d trusted resolvers", max(config.Quorum, 1))
+	}
+	return checks
+}
@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"fmt"
+
+	"github.com/fkr00t/subcollector/internal/email"
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// reportEmailPosture analyzes the mail records of the scanned root domains, records
+// them in the scan metadata and prints the issues found there and on subdomains
+func reportEmailPosture(config *ActiveScanConfig, domains []string, results []models.SubdomainResult) {
+	if !config.Email {
+		return
+	}
+
+	// The first resolver given, the system resolver when they come from a file
+	resolver := utils.SystemResolver()
+	if len(config.Resolvers) > 0 && !utils.IsResolverFile(config.Resolvers[0]) {
+		resolver = config.Resolvers[0]
+	}
+
+	var postures []models.EmailPosture
+	for _, domain := range domains {
+		if posture := email.Analyze(domain, domain, resolver); posture != nil {
+			config.Metadata.Email = append(config.Metadata.Email, *posture)
+			postures = append(postures, *posture)
+		}
+	}
+	for _, result := range results {
+		if result.Email != nil {
+			postures = append(postures, *result.Email)
+		}
+	}
+
+	fmt.Println("» Email posture:")
+	for _, posture := range postures {
+		dmarc := "-"
+		if posture.DMARC != "" {
+			dmarc = "p=" + email.DMARCPolicy(posture.DMARC)
+		}
+		spf := "-"
+		if posture.SPF != "" {
+			spf = "yes"
+		}
+		fmt.Printf("    %-40s mx:%d spf:%s dmarc:%s dkim:%d\n", posture.Domain, len(posture.MX), spf, dmarc, len(posture.DKIM))
+		for _, issue := range posture.Issues {
+			fmt.Printf("      %-8s %s %s\n", issue.Severity, issue.Issue, issue.Detail)
+		}
+	}
+}
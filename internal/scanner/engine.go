@@ -0,0 +1,357 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/telemetry"
+	"github.com/fkr00t/subcollector/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Candidate is a name for the engine to check and how it was found
+type Candidate struct {
+	Name   string // Fully qualified name to look up
+	Source string // How the name was found, empty for brute forced names
+}
+
+// Producer emits the candidates of one engine run, stopping once ctx is done
+// emit blocks while the workers are busy, so candidates are never held in bulk
+type Producer func(ctx context.Context, emit func(Candidate))
+
+// WordlistProducer combines every word of a wordlist with every target
+func WordlistProducer(targets, wordlist []string) Producer {
+	return func(ctx context.Context, emit func(Candidate)) {
+		for _, target := range targets {
+			for _, word := range wordlist {
+				if ctx.Err() != nil {
+					return
+				}
+				emit(Candidate{Name: word + "." + target})
+			}
+		}
+	}
+}
+
+// ReaderProducer combines every line of a wordlist with every target without
+// holding the wordlist in memory; open is called once per target
+// Lines are normalized like the words of loaded wordlists, invalid ones are counted once
+func ReaderProducer(targets []string, open func() (io.Reader, error)) Producer {
+	return func(ctx context.Context, emit func(Candidate)) {
+		for i, target := range targets {
+			reader, err := open()
+			if err != nil {
+				fmt.Printf("× Failed to load wordlist: %v\n", err)
+				return
+			}
+
+			invalid := 0
+			lines := bufio.NewScanner(reader)
+			for lines.Scan() {
+				if ctx.Err() != nil {
+					return
+				}
+				line := strings.TrimSpace(lines.Text())
+				if line == "" {
+					continue
+				}
+				if word := utils.NormalizeWord(line); word != "" {
+					emit(Candidate{Name: word + "." + target})
+				} else {
+					invalid++
+				}
+			}
+			if err := lines.Err(); err != nil {
+				fmt.Printf("× Failed to read wordlist: %v\n", err)
+				return
+			}
+			if i == 0 && invalid > 0 {
+				fmt.Printf("» Skipped %d invalid wordlist entries\n", invalid)
+			}
+		}
+	}
+}
+
+// ListProducer emits a fixed list of candidates
+func ListProducer(candidates []Candidate) Producer {
+	return func(ctx context.Context, emit func(Candidate)) {
+		for _, candidate := range candidates {
+			if ctx.Err() != nil {
+				return
+			}
+			emit(candidate)
+		}
+	}
+}
+
+// Engine checks the candidates of any producer through the stages shared by
+// every scan: exclusion, resolution with resolver failover, enrichment,
+// takeover checks and output
+// Which enrichment stages run is set by Options; RDAP lookups and takeover checks
+// run on workers of their own (Options.TakeoverWorkers) fed by the DNS workers
+type Engine struct {
+	Options       LookupOptions                // Resolvers, cache and enrichment stages
+	Workers       int                          // Concurrent checks when no pool is shared
+	Pool          *utils.WorkerPool            // Optional pool shared with other runs, Workers is then ignored
+	Pause         time.Duration                // Pause of a worker after each check
+	Limiter       *utils.DomainRateLimiter     // Optional spacing of checks per limit key, see LookupOptions.LimitBy
+	Exclude       *utils.ExcludeList           // Candidates never queried
+	CountCoverage bool                         // Count candidates as checked in Options.Stats
+	Output        func(models.SubdomainResult) // Called concurrently for every subdomain found
+	Progress      func()                       // Called once per candidate checked or excluded
+	Skipped       func(Candidate)              // Called for candidates dropped once the time budget runs out
+}
+
+// Run checks every candidate of produce and returns the subdomains found,
+// in the order they were found
+func (e *Engine) Run(ctx context.Context, produce Producer) []models.SubdomainResult {
+	var mu sync.Mutex
+	var found []models.SubdomainResult
+
+	// Each run is one batch of lookups, traced under the stage that started it
+	ctx, span := telemetry.Span(ctx, "dns batch")
+	defer func() {
+		span.SetAttributes(attribute.Int("found", len(found)))
+		span.End()
+	}()
+	e.Options.trace = ctx
+	e.Options.limiter = e.Limiter
+	e.Options.pause = e.Pause
+	emit := func(result models.SubdomainResult) {
+		if e.Output != nil {
+			e.Output(result)
+		}
+		mu.Lock()
+		found = append(found, result)
+		mu.Unlock()
+	}
+
+	// Found subdomains go through the takeover workers before being emitted,
+	// so slow HTTP requests do not hold up lookups
+	takeovers := newTakeoverStage(e.Options, emit)
+	if takeovers != nil {
+		e.Options.deferTakeover = true
+		emit = takeovers.submit
+	}
+	// RDAP lookups come first, registries answering slowly do not hold up lookups either
+	networks := newRDAPStage(e.Options, emit)
+	if networks != nil {
+		e.Options.deferRDAP = true
+		emit = networks.submit
+	}
+
+	var wg sync.WaitGroup
+
+	// A shared pool already runs its workers, candidates are submitted as tasks
+	if e.Pool != nil {
+		produce(ctx, func(candidate Candidate) {
+			if !e.admit(candidate) {
+				return
+			}
+			e.wait(candidate)
+
+			wg.Add(1)
+			err := e.Pool.Submit(func() interface{} {
+				defer wg.Done()
+				// Tasks still queued when the time budget runs out are dropped
+				if !e.expired(candidate) {
+					e.check(candidate, emit)
+				}
+				return nil
+			})
+			// A closed pool does not run the task
+			if err != nil {
+				wg.Done()
+			}
+		})
+		wg.Wait()
+		networks.close()
+		takeovers.close()
+		return found
+	}
+
+	candidates := make(chan Candidate, 100)
+	go func() {
+		defer close(candidates)
+		produce(ctx, func(candidate Candidate) {
+			select {
+			case candidates <- candidate:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	workers := e.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for candidate := range candidates {
+				if !e.admit(candidate) {
+					continue
+				}
+				e.wait(candidate)
+				e.check(candidate, emit)
+
+				if e.Pause > 0 {
+					time.Sleep(e.Pause)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	networks.close()
+	takeovers.close()
+	return found
+}
+
+// resultStage runs one check of found subdomains on a bounded pool of workers,
+// then passes them on; a full queue blocks the workers submitting
+type resultStage struct {
+	queue chan models.SubdomainResult
+	wg    sync.WaitGroup
+}
+
+// newResultStage starts workers running check on each result, handing checked results to next
+func newResultStage(workers int, check func(*models.SubdomainResult), next func(models.SubdomainResult)) *resultStage {
+	stage := &resultStage{queue: make(chan models.SubdomainResult, workers)}
+	for i := 0; i < workers; i++ {
+		stage.wg.Add(1)
+		go func() {
+			defer stage.wg.Done()
+			for result := range stage.queue {
+				check(&result)
+				next(result)
+			}
+		}()
+	}
+	return stage
+}
+
+// newTakeoverStage starts the takeover workers of opts, handing checked results to next
+// Returns nil when takeover checks are disabled
+func newTakeoverStage(opts LookupOptions, next func(models.SubdomainResult)) *resultStage {
+	if opts.Client == nil {
+		return nil
+	}
+
+	workers := opts.TakeoverWorkers
+	if workers <= 0 {
+		workers = DefaultTakeoverWorkers
+	}
+	return newResultStage(workers, opts.recordTakeover, next)
+}
+
+// rdapWorkers is the number of results waiting for their networks at once in an engine;
+// the client caches networks and caps the requests it sends itself
+const rdapWorkers = 10
+
+// newRDAPStage starts the RDAP workers of opts, handing results with their networks to next
+// Returns nil when RDAP lookups are disabled
+func newRDAPStage(opts LookupOptions, next func(models.SubdomainResult)) *resultStage {
+	if opts.RDAP == nil {
+		return nil
+	}
+	return newResultStage(rdapWorkers, opts.recordNetworks, next)
+}
+
+// submit queues a found subdomain for the check of the stage
+func (s *resultStage) submit(result models.SubdomainResult) {
+	s.queue <- result
+}
+
+// close waits for the queued checks to finish, once every submit returned
+func (s *resultStage) close() {
+	if s == nil {
+		return
+	}
+	close(s.queue)
+	s.wg.Wait()
+}
+
+// expired reports whether the time budget ran out before candidate was checked
+func (e *Engine) expired(candidate Candidate) bool {
+	if !budgetExceeded(e.Options.deadline) {
+		return false
+	}
+	if e.Skipped != nil {
+		e.Skipped(candidate)
+	}
+	return true
+}
+
+// admit applies the time budget, validation, exclusion and query budget stages
+// Returns false for candidates that are not looked up
+func (e *Engine) admit(candidate Candidate) bool {
+	if e.expired(candidate) {
+		return false
+	}
+
+	// Names no server could hold are never queried
+	if !utils.IsValidHostname(candidate.Name) {
+		if e.CountCoverage && e.Options.Stats != nil {
+			e.Options.Stats.recordChecked()
+			e.Options.Stats.recordInvalid()
+		}
+		if e.Progress != nil {
+			e.Progress()
+		}
+		return false
+	}
+
+	// Out-of-scope candidates are never queried
+	if e.Exclude.Matches(candidate.Name) {
+		if e.CountCoverage && e.Options.Stats != nil {
+			e.Options.Stats.recordChecked()
+			e.Options.Stats.recordExcluded()
+		}
+		if e.Progress != nil {
+			e.Progress()
+		}
+		return false
+	}
+
+	// Candidates past the query budget are dropped like those past the time budget
+	if !e.Options.queries.spend() {
+		if e.Skipped != nil {
+			e.Skipped(candidate)
+		}
+		return false
+	}
+	return true
+}
+
+// wait applies the rate limits of the candidate's root domain
+func (e *Engine) wait(candidate Candidate) {
+	if e.Limiter == nil && e.Options.backoff == nil {
+		return
+	}
+	if e.Limiter != nil {
+		e.Limiter.Wait(e.Options.limitKey(candidate.Name))
+	}
+	// Keys whose lookups keep failing are slowed down, see LookupOptions.backoff
+	if e.Options.backoff != nil {
+		e.Options.backoff.wait(e.Options.backoffKey(candidate.Name))
+	}
+}
+
+// check runs the resolution, enrichment and output stages on one candidate
+func (e *Engine) check(candidate Candidate, emit func(models.SubdomainResult)) {
+	processCandidate(candidate, e.Options, emit)
+
+	if e.CountCoverage && e.Options.Stats != nil {
+		e.Options.Stats.recordChecked()
+	}
+	if e.Progress != nil {
+		e.Progress()
+	}
+}
@@ -0,0 +1,29 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// Errors a scan stops with, wrapped around their cause: callers tell them apart
+// with errors.Is and still have the underlying error to show
+var (
+	ErrScanFailed       = errors.New("scan failed")                             // Any other reason a scan could not run
+	ErrWordlistNotFound = errors.New("wordlist file not found")                 // --wordlist names a missing file
+	ErrWordlistRead     = errors.New("failed to read wordlist")                 // The wordlist file exists but cannot be read
+	ErrWordlistFetch    = errors.New("failed to download the default wordlist") // No --wordlist, and the default list is unreachable
+	ErrResolverLoad     = errors.New("failed to load resolvers")                // A resolver file is missing or holds an invalid entry
+)
+
+// wordlistError wraps the failure to load the wordlist at path, the default list when empty
+func wordlistError(path string, err error) error {
+	switch {
+	case path == "":
+		return fmt.Errorf("%w: %v", ErrWordlistFetch, err)
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("%w: %s", ErrWordlistNotFound, path)
+	default:
+		return fmt.Errorf("%w %s: %v", ErrWordlistRead, path, err)
+	}
+}
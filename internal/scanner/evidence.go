@@ -0,0 +1,97 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/telemetry"
+	"github.com/fkr00t/subcollector/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultEvidenceDir receives takeover evidence when neither a directory nor a workspace is given
+const DefaultEvidenceDir = "takeover-evidence"
+
+// saveTakeoverEvidence writes the CNAME chain and HTTP exchange behind a takeover finding
+// to a file in dir and returns its path
+func saveTakeoverEvidence(dir string, result models.SubdomainResult, chain []string, evidence *TakeoverEvidence) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Takeover evidence for %s\n", result.Subdomain)
+	fmt.Fprintf(&buf, "Service:  %s\n", result.Takeover)
+	fmt.Fprintf(&buf, "Pattern:  %s\n", evidence.Pattern)
+	fmt.Fprintf(&buf, "Captured: %s\n", time.Now().UTC().Format(time.RFC3339))
+	if len(result.IPs) > 0 {
+		fmt.Fprintf(&buf, "IPs:      %s\n", strings.Join(result.IPs, ", "))
+	}
+
+	buf.WriteString("\n## CNAME chain\n")
+	if len(chain) == 0 {
+		buf.WriteString("(none)\n")
+	} else {
+		buf.WriteString(result.Subdomain + "\n")
+		for _, target := range chain {
+			buf.WriteString("  -> " + target + "\n")
+		}
+	}
+
+	buf.WriteString("\n## Request\n")
+	buf.Write(evidence.Request)
+	buf.WriteString("\n## Response\n")
+	buf.Write(evidence.Response)
+	if !bytes.HasSuffix(evidence.Response, []byte("\n")) {
+		buf.WriteString("\n")
+	}
+
+	path := filepath.Join(dir, evidenceFileName(result))
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// evidenceFileName names the evidence file of a finding after the host and service
+func evidenceFileName(result models.SubdomainResult) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, result.Subdomain)
+	return fmt.Sprintf("%s_%s.txt", name, result.Takeover)
+}
+
+// recordTakeover checks a result for takeover and keeps the evidence of a match
+func (o LookupOptions) recordTakeover(result *models.SubdomainResult) {
+	_, span := telemetry.Span(o.trace, "takeover check", attribute.String("subdomain", result.Subdomain))
+	defer span.End()
+
+	evidence := CheckTakeover(o.Client, result)
+	if result.Takeover != "" {
+		span.SetAttributes(attribute.String("service", result.Takeover))
+	}
+	if evidence == nil || o.EvidenceDir == "" {
+		return
+	}
+
+	// The chain is already known when grouping, otherwise it is queried for the evidence
+	chain := result.CNAME
+	if !o.Group {
+		chain = utils.ResolveCNAMEChain(result.Subdomain, o.QueryResolver)
+	}
+
+	path, err := saveTakeoverEvidence(o.EvidenceDir, *result, chain, evidence)
+	if err != nil {
+		fmt.Printf("\r\033[K× Failed to save takeover evidence for %s: %v\n", result.Subdomain, err)
+		return
+	}
+	result.Evidence = path
+}
@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fingerprint describes a takeover-prone service in a fingerprints file
+//
+//   - service: example_pages
+//     pattern: "This site is not claimed"
+//     cname: [.example-pages.com]
+type Fingerprint struct {
+	Service string   `yaml:"service"`
+	Pattern string   `yaml:"pattern"` // Text of the page served for unclaimed names
+	CNAMEs  []string `yaml:"cname"`   // Suffixes of the CNAME targets of the service
+}
+
+// fingerprintSet is the set of takeover patterns and CNAME suffixes checks are made against
+type fingerprintSet struct {
+	patterns map[string]string // Service -> pattern, as TakeoverPatterns
+	cnames   []takeoverCNAME
+}
+
+// takeoverCNAME maps a CNAME target suffix to the service serving it
+type takeoverCNAME struct {
+	suffix  string
+	service string
+}
+
+// fingerprints is the set in use, swapped as a whole so checks running
+// while a file is reloaded finish with the set they started with
+var fingerprints atomic.Pointer[fingerprintSet]
+
+func init() {
+	fingerprints.Store(builtinFingerprints())
+}
+
+// builtinFingerprints returns the set of TakeoverPatterns and takeoverCNAMEs
+func builtinFingerprints() *fingerprintSet {
+	set := &fingerprintSet{patterns: make(map[string]string, len(TakeoverPatterns))}
+	for service, pattern := range TakeoverPatterns {
+		set.patterns[service] = pattern
+	}
+	set.cnames = append(set.cnames, takeoverCNAMEs...)
+	return set
+}
+
+// currentFingerprints returns the set takeover checks use
+func currentFingerprints() *fingerprintSet {
+	return fingerprints.Load()
+}
+
+// LoadFingerprints adds the services of a fingerprints file to the built-in ones and
+// returns how many it holds; a service already known gets the pattern of the file
+// Nothing changes when the file cannot be read or holds an invalid entry
+func LoadFingerprints(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read takeover fingerprints: %v", err)
+	}
+	var entries []Fingerprint
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return 0, fmt.Errorf("invalid takeover fingerprints in %s: %v", path, err)
+	}
+
+	set := builtinFingerprints()
+	var cnames []takeoverCNAME
+	for i, entry := range entries {
+		service := strings.TrimSpace(entry.Service)
+		if service == "" || entry.Pattern == "" {
+			return 0, fmt.Errorf("invalid takeover fingerprint %d in %s, service and pattern are required", i+1, path)
+		}
+		set.patterns[service] = entry.Pattern
+		for _, suffix := range entry.CNAMEs {
+			suffix = strings.ToLower(strings.Trim(strings.TrimSpace(suffix), "."))
+			if suffix == "" {
+				continue
+			}
+			cnames = append(cnames, takeoverCNAME{suffix: "." + suffix, service: service})
+		}
+	}
+	// Suffixes of the file are matched before the built-in ones, so they can narrow them
+	set.cnames = append(cnames, set.cnames...)
+
+	fingerprints.Store(set)
+	return len(entries), nil
+}
@@ -0,0 +1,200 @@
+package scanner
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/interop"
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/output"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// ExecuteImport loads massdns/zdns output and runs the enabled checks (takeover, TLS,
+// ports, DNSSEC, ...) on it, then displays and saves the results like an active scan
+// When domains are given, only names within them are kept
+func ExecuteImport(config ActiveScanConfig, domains []string) ([]models.SubdomainResult, error) {
+	fmt.Printf("\n» Importing %s\n", config.ImportFile)
+	config.Metadata.StartedAt = time.Now()
+
+	if err := checkResolvers(config.Resolvers, config.VerifyResolvers); err != nil {
+		return nil, err
+	}
+
+	imported, err := interop.LoadResolverOutput(config.ImportFile)
+	if err != nil {
+		fmt.Printf("× %v\n", err)
+		return nil, err
+	}
+
+	// Keep in-scope names only
+	var results []models.SubdomainResult
+	for _, result := range imported {
+		if config.Exclude.Matches(result.Subdomain) {
+			continue
+		}
+		if len(domains) > 0 && !inDomains(result.Subdomain, domains) {
+			continue
+		}
+		results = append(results, result)
+	}
+	fmt.Printf("» %d names imported, %d in scope\n\n", len(imported), len(results))
+
+	// Nothing is resolved, so resolvers need no canary checks
+	config.CanaryInterval = 0
+	opts := newLookupOptions(
+		processResolvers(config.Resolvers),
+		models.NewDNSCache(),
+		setupTakeoverClient(config.Takeover, config.Proxy, config.TakeoverHTTP),
+		config,
+		NewLookupStats(),
+	)
+	opts.Scope = domains
+	// Imported CNAME chains are used as they are instead of being queried again
+	opts.Group = false
+
+	source := "import:" + filepath.Base(config.ImportFile)
+	workers := config.NumWorkers
+	if workers <= 0 {
+		workers = 10
+	}
+
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				result := &results[index]
+				addresses := result.IPs
+				if !opts.ShowIP {
+					result.IPs = nil
+				}
+				result.Source = source
+				result.Provider = utils.DetectProvider(result.CNAME)
+				enrichResult(result, addresses, nil, opts)
+
+				if config.Filter.Allows(result.Subdomain) {
+					output.DisplayResult(*result, config.ShowIP)
+				}
+			}
+		}()
+	}
+	for i := range results {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	// Bulk resolver output is where flaky and poisoned answers show up most
+	results = verifyConsensus(results, config)
+	results = reportFilteredResults(results, config.Filter)
+	models.TagResults(results, config.Tags)
+	models.RateResults(results)
+
+	config.Sink.Begin(config.ImportFile, config.Metadata)
+	for _, result := range results {
+		config.Sink.Add(result)
+	}
+	config.Sink.Close()
+
+	// Brief summary
+	fmt.Printf("\n» Imported %d subdomains\n", len(results))
+	if config.Group || config.HTTP || config.Tech {
+		reportHostGroups(results)
+	}
+
+	// Save results if requested
+	saveActiveResults(config, strings.Join(domains, ","), results)
+
+	return results, nil
+}
+
+// ExportMassdns writes the brute-force candidates and resolvers of a scan as massdns input files
+// The massdns output can then be brought back with ExecuteImport
+func ExportMassdns(config ActiveScanConfig, domains []string) error {
+	wordlist, err := loadWordlist(config.WordlistPath)
+	if err != nil {
+		return err
+	}
+	if err := checkResolvers(config.Resolvers); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(config.ExportDir, 0755); err != nil {
+		fmt.Printf("× Failed to create %s: %v\n", config.ExportDir, err)
+		return err
+	}
+
+	var candidates []string
+	for _, domain := range domains {
+		for _, word := range wordlist {
+			candidate := word + "." + domain
+			if !config.Exclude.Matches(candidate) {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	// massdns expects bare addresses, the default port is implied, and only speaks UDP
+	resolvers := processResolvers(config.Resolvers)
+	if len(resolvers) == 0 {
+		resolvers = []string{utils.SystemResolver()}
+	}
+	var resolverLines []string
+	skipped := 0
+	for _, resolver := range resolvers {
+		parsed, err := utils.ParseResolver(resolver)
+		if err != nil || parsed.Protocol != utils.ResolverUDP {
+			skipped++
+			continue
+		}
+		resolver = parsed.Address
+		if host, port, err := net.SplitHostPort(resolver); err == nil && port == "53" {
+			resolver = host
+		}
+		resolverLines = append(resolverLines, resolver)
+	}
+	if skipped > 0 {
+		fmt.Printf("» Skipped %d TCP, TLS and HTTPS resolvers massdns cannot use\n", skipped)
+	}
+	if len(resolverLines) == 0 {
+		fmt.Println("× No UDP resolvers to export")
+		return fmt.Errorf("no UDP resolvers")
+	}
+
+	candidatesFile := filepath.Join(config.ExportDir, "candidates.txt")
+	resolversFile := filepath.Join(config.ExportDir, "resolvers.txt")
+	if err := output.SaveLines(candidatesFile, candidates); err != nil {
+		fmt.Printf("× Failed to save candidates: %v\n", err)
+		return err
+	}
+	if err := output.SaveLines(resolversFile, resolverLines); err != nil {
+		fmt.Printf("× Failed to save resolvers: %v\n", err)
+		return err
+	}
+
+	fmt.Printf("» Wrote %d candidates to %s\n", len(candidates), candidatesFile)
+	fmt.Printf("» Wrote %d resolvers to %s\n", len(resolverLines), resolversFile)
+	fmt.Printf("» Run: massdns -r %s -t A -o S -w massdns.txt %s\n", resolversFile, candidatesFile)
+	if len(domains) == 1 {
+		fmt.Printf("» Then: subcollector active --import massdns.txt -d %s\n", domains[0])
+	}
+	return nil
+}
+
+// inDomains reports whether a host is one of the domains or a subdomain of one of them
+func inDomains(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || utils.IsSubdomainOf(host, domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// What the rate limit, backoff and concurrency cap of a lookup are charged to
+const (
+	LimitByRoot      = "root"      // Root domain of the candidate
+	LimitByAuthority = "authority" // Authoritative nameserver of the candidate's zone, shared by roots hosted together
+	LimitByResolver  = "resolver"  // Resolver the candidate is looked up through
+	LimitByParent    = "parent"    // Zone directly above the candidate, each recursion level being its own key
+)
+
+// ValidateLimitBy checks the key lookups are limited by
+func ValidateLimitBy(limitBy string) error {
+	switch limitBy {
+	case "", LimitByRoot, LimitByAuthority, LimitByResolver, LimitByParent:
+		return nil
+	}
+	return fmt.Errorf("invalid limit key %q, use %s, %s, %s or %s", limitBy, LimitByRoot, LimitByAuthority, LimitByResolver, LimitByParent)
+}
+
+// authorityKey is the limit key of a zone, looked up once however many workers ask
+type authorityKey struct {
+	once sync.Once
+	key  string
+}
+
+// limitKey returns the key the lookups of name are charged to
+func (o LookupOptions) limitKey(name string) string {
+	return o.keyBy(o.LimitBy, name)
+}
+
+// backoffKey returns the key the adaptive delays of name are charged to,
+// the limit key unless the backoff is keyed on its own (see BackoffConfig.By)
+func (o LookupOptions) backoffKey(name string) string {
+	if o.backoff == nil || o.backoff.by == "" {
+		return o.limitKey(name)
+	}
+	return o.keyBy(o.backoff.by, name)
+}
+
+// keyBy returns the key of name when limits are charged to limitBy
+// Zones whose authoritative server cannot be found are limited by their root domain
+func (o LookupOptions) keyBy(limitBy, name string) string {
+	switch limitBy {
+	case LimitByAuthority:
+		zone := o.zoneOf(name)
+		if zone == "" {
+			zone = utils.ExtractRootDomain(name)
+		}
+		if o.authorityKeys == nil || o.dangling == nil {
+			return zone
+		}
+		entry, _ := o.authorityKeys.LoadOrStore(zone, &authorityKey{})
+		authority := entry.(*authorityKey)
+		authority.once.Do(func() {
+			authority.key = zone
+			if server := o.authoritativeServer(zone); server != "" {
+				authority.key = "ns:" + server
+			}
+		})
+		return authority.key
+	case LimitByResolver:
+		if resolvers := utils.RouteResolvers(name, o.Resolvers); len(resolvers) > 0 {
+			return "resolver:" + resolvers[0]
+		}
+		return "resolver:system"
+	case LimitByParent:
+		return "parent:" + utils.ParentZone(name)
+	default:
+		return utils.ExtractRootDomain(name)
+	}
+}
@@ -0,0 +1,50 @@
+package scanner
+
+import (
+	"github.com/fkr00t/subcollector/internal/email"
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// danglingMail reports the mail exchangers and SPF includes of a subdomain that
+// anyone could claim: whoever owns an MX receives the mail of the subdomain, and
+// whoever owns an SPF include may send mail as it
+// The records collected by the mail analysis are used when it ran
+func (o LookupOptions) danglingMail(result *models.SubdomainResult) []models.DanglingRecord {
+	var mx, includes []string
+	switch {
+	case result.Email != nil:
+		mx, includes = result.Email.MX, result.Email.SPFIncludes
+	case !o.Email:
+		mx, includes = email.MailHosts(result.Subdomain, o.QueryResolver)
+	}
+
+	var findings []models.DanglingRecord
+	for _, host := range mx {
+		if finding, ok := o.danglingMailHost(models.DanglingMX, host); ok {
+			findings = append(findings, finding)
+		}
+	}
+	for _, domain := range includes {
+		if finding, ok := o.danglingMailHost(models.DanglingSPF, domain); ok {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// danglingMailHost checks a mail exchanger or SPF include once per scan, most
+// subdomains share the mail hosts of their domain
+func (o LookupOptions) danglingMailHost(kind, host string) (models.DanglingRecord, bool) {
+	var finding models.DanglingRecord
+	if cached, ok := o.dangling.mail.Load(host); ok {
+		finding = cached.(models.DanglingRecord)
+	} else {
+		finding.Severity, finding.Detail, _ = o.claimableHost(host)
+		o.dangling.mail.Store(host, finding)
+	}
+	if finding.Severity == "" {
+		return models.DanglingRecord{}, false
+	}
+	finding.Type, finding.Target = kind, host
+	return finding, true
+}
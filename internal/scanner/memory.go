@@ -0,0 +1,326 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+const (
+	// memoryCheckInterval is the time between two looks at the heap
+	memoryCheckInterval = time.Second
+
+	// memoryPressure is the share of --max-memory at which structures are spilled
+	memoryPressure = 0.8
+)
+
+// memoryGuard watches the heap of a scan and spills its largest structures once
+// the heap nears the limit; the limit is also given to the garbage collector
+type memoryGuard struct {
+	limit   int64
+	mu      sync.Mutex
+	spills  []func()
+	spilled bool
+	stop    chan struct{}
+	done    sync.WaitGroup
+}
+
+// startMemoryGuard watches the heap until Stop, nil when limit is not positive
+func startMemoryGuard(limit int64) *memoryGuard {
+	if limit <= 0 {
+		return nil
+	}
+	debug.SetMemoryLimit(limit)
+
+	g := &memoryGuard{limit: limit, stop: make(chan struct{})}
+	g.done.Add(1)
+	go func() {
+		defer g.done.Done()
+		ticker := time.NewTicker(memoryCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-g.stop:
+				return
+			case <-ticker.C:
+				g.check()
+			}
+		}
+	}()
+	return g
+}
+
+// onPressure registers a spill run once, when the heap first nears the limit
+func (g *memoryGuard) onPressure(spill func()) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.spilled {
+		// Structures created after the spill start spilled
+		spill()
+		return
+	}
+	g.spills = append(g.spills, spill)
+}
+
+// check spills the registered structures when the heap is above memoryPressure of the limit
+func (g *memoryGuard) check() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if float64(stats.HeapAlloc) < memoryPressure*float64(g.limit) {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.spilled {
+		return
+	}
+	g.spilled = true
+	fmt.Printf("\r\033[K» Memory at %s of %s, spilling results and lookup answers to disk\n",
+		utils.FormatByteSize(int64(stats.HeapAlloc)), utils.FormatByteSize(g.limit))
+	for _, spill := range g.spills {
+		spill()
+	}
+	g.spills = nil
+	debug.FreeOSMemory()
+}
+
+// Stop ends the watch and gives the garbage collector its default limit back
+func (g *memoryGuard) Stop() {
+	if g == nil {
+		return
+	}
+	close(g.stop)
+	g.done.Wait()
+	debug.SetMemoryLimit(-1)
+}
+
+// spillCache is the DNS cache of memory-capped scans
+// Once spilled, names that do not exist are only kept as 64-bit hashes, which
+// is most of a brute-force scan, while the few found names keep their answers
+type spillCache struct {
+	models.CacheCounters
+	entries sync.Map // Name -> models.DNSResult
+	mu      sync.RWMutex
+	missing map[uint64]struct{} // Hashes of names that do not exist, nil until spilled
+}
+
+// newSpillCache creates a cache compacted when guard sees memory pressure
+func newSpillCache(guard *memoryGuard) *spillCache {
+	c := &spillCache{}
+	guard.onPressure(c.compact)
+	return c
+}
+
+// Store saves the answer of a name
+func (c *spillCache) Store(subdomain string, result models.DNSResult) {
+	if !result.Found {
+		c.mu.Lock()
+		if c.missing != nil {
+			c.missing[nameHash(subdomain)] = struct{}{}
+			c.mu.Unlock()
+			return
+		}
+		c.mu.Unlock()
+	}
+	c.entries.Store(subdomain, result)
+}
+
+// Load returns the answer of a name
+func (c *spillCache) Load(subdomain string) (models.DNSResult, bool) {
+	if value, ok := c.entries.Load(subdomain); ok {
+		return value.(models.DNSResult), c.Count(true)
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if _, ok := c.missing[nameHash(subdomain)]; ok {
+		return models.DNSResult{Found: false}, c.Count(true)
+	}
+	return models.DNSResult{}, c.Count(false)
+}
+
+// Range calls fn for each answer until it returns false
+// Names that do not exist are left out once spilled, only their hashes are kept
+func (c *spillCache) Range(fn func(subdomain string, result models.DNSResult) bool) {
+	c.entries.Range(func(key, value interface{}) bool {
+		return fn(key.(string), value.(models.DNSResult))
+	})
+}
+
+// compact moves the names that do not exist to the hash set
+func (c *spillCache) compact() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.missing == nil {
+		c.missing = make(map[uint64]struct{})
+	}
+	c.entries.Range(func(key, value interface{}) bool {
+		if !value.(models.DNSResult).Found {
+			c.missing[nameHash(key.(string))] = struct{}{}
+			c.entries.Delete(key)
+		}
+		return true
+	})
+}
+
+// Close does nothing, the cache only lives in memory
+func (c *spillCache) Close() error {
+	return nil
+}
+
+// newScanCache creates the DNS cache selected by config and seeds it with the
+// answers of config.Import. The memory cache is a spill cache, compacted when
+// guard sees memory pressure
+func newScanCache(config models.CacheConfig, guard *memoryGuard) (models.Cache, error) {
+	var cache models.Cache
+	if config.Type != "" && config.Type != models.CacheMemory {
+		var err error
+		if cache, err = models.NewCache(config); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		cache = newSpillCache(guard)
+	}
+
+	if config.Import != "" {
+		imported, err := models.ImportCache(cache, config.Import)
+		if err != nil {
+			cache.Close()
+			return nil, err
+		}
+		fmt.Printf("» Seeded the DNS cache with %d answers from %s\n", imported, config.Import)
+	}
+	return cache, nil
+}
+
+// closeCache exports the answers of the DNS cache of a scan when config asks for it,
+// adds its counters to stats and closes it, reporting answers that could not be saved
+func closeCache(cache models.Cache, config models.CacheConfig, stats *LookupStats) {
+	if config.Export != "" {
+		if exported, err := models.ExportCache(cache, config.Export); err != nil {
+			fmt.Printf("\r\033[K× %v\n", err)
+		} else {
+			fmt.Printf("\r\033[K» DNS cache exported to %s (%d answers)\n", config.Export, exported)
+		}
+	}
+	stats.addCacheStats(cache.Stats())
+	if err := cache.Close(); err != nil {
+		fmt.Printf("\r\033[K× Failed to save the DNS cache: %v\n", err)
+	}
+}
+
+// nameHash returns the FNV-1a hash of a name
+func nameHash(name string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return h.Sum64()
+}
+
+// resultSpool collects the results of a scan, in memory until the guard sees
+// memory pressure and in a temporary JSON lines file afterwards
+type resultSpool struct {
+	mu      sync.Mutex
+	results []models.SubdomainResult
+	file    *os.File
+	writer  *bufio.Writer
+	count   int
+	err     error
+}
+
+// newResultSpool creates a spool moved to disk when guard sees memory pressure
+func newResultSpool(guard *memoryGuard) *resultSpool {
+	s := &resultSpool{}
+	guard.onPressure(s.spill)
+	return s
+}
+
+// Append adds results to the spool
+func (s *resultSpool) Append(results ...models.SubdomainResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count += len(results)
+	if s.writer == nil {
+		s.results = append(s.results, results...)
+		return
+	}
+	s.write(results)
+}
+
+// write encodes results to the spool file, keeping the first error
+func (s *resultSpool) write(results []models.SubdomainResult) {
+	encoder := json.NewEncoder(s.writer)
+	for _, result := range results {
+		if err := encoder.Encode(result); err != nil && s.err == nil {
+			s.err = err
+		}
+	}
+}
+
+// spill moves the results held in memory to a temporary file
+// Results stay in memory when the file cannot be created
+func (s *resultSpool) spill() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writer != nil {
+		return
+	}
+	file, err := os.CreateTemp("", "subcollector-results-*.jsonl")
+	if err != nil {
+		fmt.Printf("\r\033[K× Failed to spill results: %v\n", err)
+		return
+	}
+	s.file = file
+	s.writer = bufio.NewWriter(file)
+	s.write(s.results)
+	s.results = nil
+}
+
+// Results returns every result appended, in order, and removes the spool file
+func (s *resultSpool) Results() ([]models.SubdomainResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writer == nil {
+		return s.results, nil
+	}
+	defer func() {
+		s.file.Close()
+		os.Remove(s.file.Name())
+		s.file, s.writer = nil, nil
+	}()
+
+	if err := s.writer.Flush(); err != nil && s.err == nil {
+		s.err = err
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	if _, err := s.file.Seek(0, 0); err != nil {
+		return nil, err
+	}
+
+	results := make([]models.SubdomainResult, 0, s.count)
+	decoder := json.NewDecoder(bufio.NewReader(s.file))
+	for decoder.More() {
+		var result models.SubdomainResult
+		if err := decoder.Decode(&result); err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/interop"
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/output"
+	"github.com/fkr00t/subcollector/internal/sink"
+	"github.com/fkr00t/subcollector/internal/upload"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// MergeConfig holds the configuration for merging result files
+type MergeConfig struct {
+	Files           []string // Result files of subcollector, amass, subfinder or plain lists
+	Domains         []string // Optional scope, other names are dropped
+	Resolve         bool     // Re-resolve every name and drop the ones that no longer exist
+	Resolvers       []string
+	NumWorkers      int
+	ShowIP          bool
+	OutputFile      string
+	JsonOutputFile  string
+	HTMLOutputFile  string
+	CSVOutputFile   string
+	XMLOutputFile   string
+	SARIFOutputFile string
+	Sink            sink.Sinks          // Optional destinations receiving the merged results
+	Tags            []string            // Labels added to every result (--tag)
+	Database        string              // Optional SQLite file or PostgreSQL URL storing the results
+	Upload          *upload.Target      // Optional bucket location receiving the output files
+	Sort            string              // Order of saved results, models.SortByName or models.SortByDiscovery
+	Format          *models.LineFormat  // Line format of the text output, nil for one subdomain per line
+	Exclude         *utils.ExcludeList  // Hosts dropped from results
+	Filter          *utils.ResultFilter // Match/filter rules applied before display and saving
+	Metadata        models.ScanMetadata // Tool and configuration details embedded in JSON output
+}
+
+// ExecuteMerge loads result files from several tools, deduplicates them and writes one report
+func ExecuteMerge(config MergeConfig) ([]models.SubdomainResult, error) {
+	fmt.Printf("\n» Merging %d files\n", len(config.Files))
+	config.Metadata.StartedAt = time.Now()
+
+	if err := checkResolvers(config.Resolvers); err != nil {
+		return nil, err
+	}
+
+	var sets [][]models.SubdomainResult
+	for _, path := range config.Files {
+		results, format, err := interop.LoadResultFile(path)
+		if err != nil {
+			fmt.Printf("× Failed to load %s: %v\n", path, err)
+			return nil, err
+		}
+		fmt.Printf("  %s: %d results (%s)\n", filepath.Base(path), len(results), format)
+		sets = append(sets, results)
+	}
+
+	// Deduplicate, then keep in-scope names only
+	var results []models.SubdomainResult
+	merged := models.MergeResults(sets...)
+	for _, result := range merged {
+		if result.Subdomain == "" || config.Exclude.Matches(result.Subdomain) {
+			continue
+		}
+		if len(config.Domains) > 0 && !inDomains(result.Subdomain, config.Domains) {
+			continue
+		}
+		results = append(results, result)
+	}
+	fmt.Printf("» %d unique subdomains, %d in scope\n\n", len(merged), len(results))
+
+	if config.Resolve {
+		results = resolveMerged(results, config)
+	}
+
+	models.SortResults(results, config.Sort)
+	for i := range results {
+		if results[i].Provider == "" {
+			tagProvider(&results[i], results[i].IPs)
+		}
+		if !config.ShowIP {
+			results[i].IPs = nil
+		}
+		if config.Filter.Allows(results[i].Subdomain) {
+			output.DisplayResult(results[i], config.ShowIP)
+		}
+	}
+
+	results = reportFilteredResults(results, config.Filter)
+	models.TagResults(results, config.Tags)
+	models.RateResults(results)
+
+	config.Sink.Begin(strings.Join(config.Domains, ","), config.Metadata)
+	for _, result := range results {
+		config.Sink.Add(result)
+	}
+	config.Sink.Close()
+	fmt.Printf("\n» Merged %d subdomains\n", len(results))
+
+	// Save results if requested
+	config.Metadata.FinishedAt = time.Now()
+	domain := strings.Join(config.Domains, ",")
+	if config.OutputFile != "" || config.JsonOutputFile != "" {
+		output.SaveResults(config.OutputFile, config.JsonOutputFile, domain, results, config.Metadata, config.Format)
+		fmt.Printf("» Results saved\n")
+	}
+	saveHTMLReport(config.HTMLOutputFile, domain, results, config.Metadata)
+	saveCSV(config.CSVOutputFile, results, config.Metadata)
+	saveXML(config.XMLOutputFile, domain, results, config.Metadata)
+	saveSARIF(config.SARIFOutputFile, results, config.Metadata)
+	saveToDatabase(config.Database, config.Domains, results, config.Metadata)
+	uploadOutputs(config.Upload, config.Domains, config.Metadata, config.OutputFile, config.JsonOutputFile,
+		config.HTMLOutputFile, config.CSVOutputFile, config.XMLOutputFile, config.SARIFOutputFile)
+
+	return results, nil
+}
+
+// resolveMerged re-resolves merged names with the active lookup engine
+// Names answering NXDOMAIN are dropped, other failures keep the data read from the files
+func resolveMerged(results []models.SubdomainResult, config MergeConfig) []models.SubdomainResult {
+	fmt.Printf("» Re-resolving %d subdomains\n", len(results))
+
+	resolvers := processResolvers(config.Resolvers)
+	stats := NewLookupStats()
+	alive := make([]bool, len(results))
+
+	workers := config.NumWorkers
+	if workers <= 0 {
+		workers = 10
+	}
+
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				addresses, status := resolveSubdomain(results[index].Subdomain, resolvers, stats)
+				switch status {
+				case utils.StatusResolved:
+					results[index].IPs = addresses
+					results[index].Provider, results[index].Region = utils.DetectProvider(results[index].CNAME), ""
+					results[index].Networks = nil
+					alive[index] = true
+				case utils.StatusNXDomain:
+					alive[index] = false
+				default:
+					alive[index] = true
+				}
+			}
+		}()
+	}
+	for i := range results {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var resolved []models.SubdomainResult
+	for i, result := range results {
+		if alive[i] {
+			resolved = append(resolved, result)
+		}
+	}
+
+	fmt.Printf("» Lookups: %s\n", stats.Summary())
+	if dropped := len(results) - len(resolved); dropped > 0 {
+		fmt.Printf("» Dropped %d subdomains that no longer exist\n\n", dropped)
+	}
+	return resolved
+}
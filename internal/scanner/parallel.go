@@ -0,0 +1,256 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cheggaaa/pb/v3"
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/output"
+	"github.com/fkr00t/subcollector/internal/telemetry"
+	"github.com/fkr00t/subcollector/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// sharedScanState holds everything domains scanned in parallel have in common
+type sharedScanState struct {
+	wordlist []string
+	opts     LookupOptions
+	pool     *utils.WorkerPool
+	limiter  *utils.DomainRateLimiter
+	bar      *pb.ProgressBar
+	writer   *output.ResultWriter
+	stats    *LookupStats
+}
+
+// ExecuteParallelActiveScan runs active scans for several domains concurrently
+// All domains share one pool of lookup workers and one combined progress bar,
+// while rate limiting is applied separately for each root domain
+func ExecuteParallelActiveScan(config ActiveScanConfig, domains []string, parallel int) ([]models.SubdomainResult, error) {
+	config.Metadata.StartedAt = time.Now()
+	config.startBudget()
+
+	// The stages of the scan are traced under one span
+	ctx, span := telemetry.Span(context.Background(), "parallel active scan", attribute.StringSlice("domains", domains))
+	defer span.End()
+	config.trace = ctx
+
+	if err := checkResolvers(config.Resolvers, config.VerifyResolvers); err != nil {
+		telemetry.Fail(span, err)
+		return nil, err
+	}
+
+	// Domains scanned together share one workspace
+	ws, err := openWorkspace(&config, "parallel")
+	if err != nil {
+		return nil, err
+	}
+	defer ws.Close()
+
+	config.Sink.Begin(reportTarget(config.Metadata, domains), config.Metadata)
+	defer config.Sink.Close()
+
+	fmt.Printf("\n» Scanning %d domains (%d in parallel)\n\n", len(domains), parallel)
+
+	_, loadSpan := telemetry.Span(ctx, "load wordlist", attribute.String("path", config.WordlistPath))
+	wordlist, err := loadWordlist(config.WordlistPath)
+	loadSpan.SetAttributes(attribute.Int("words", len(wordlist)))
+	telemetry.Fail(loadSpan, err)
+	loadSpan.End()
+	if err != nil {
+		telemetry.Fail(span, err)
+		return nil, err
+	}
+
+	// Each worker used to sleep RateLimit after every lookup, so spreading the
+	// same throughput over one root domain keeps per-target pressure unchanged
+	interval := time.Duration(config.RateLimit) * time.Millisecond
+	if config.NumWorkers > 0 {
+		interval /= time.Duration(config.NumWorkers)
+	}
+
+	state := &sharedScanState{
+		wordlist: wordlist,
+		pool:     utils.NewWorkerPool(config.NumWorkers, config.NumWorkers*2),
+		limiter:  utils.NewDomainRateLimiter(interval),
+		stats:    NewLookupStats(),
+	}
+
+	// Lookup answers and results move to disk when the heap nears --max-memory
+	guard := startMemoryGuard(config.MaxMemory)
+	defer guard.Stop()
+	spool := newResultSpool(guard)
+	cache, err := newScanCache(config.Cache, guard)
+	if err != nil {
+		return nil, err
+	}
+
+	state.opts = newLookupOptions(
+		processResolvers(config.Resolvers),
+		cache,
+		setupTakeoverClient(config.Takeover, config.Proxy, config.TakeoverHTTP),
+		config,
+		state.stats,
+	)
+	state.opts.Scope = domains
+
+	totalTasks := len(domains) * len(wordlist)
+	fmt.Printf("» Checking %d subdomains\n", totalTasks)
+
+	state.bar = utils.CreateProgressBar(totalTasks)
+	state.writer = output.NewResultWriter(state.bar, config.ShowIP)
+	state.writer.SetFilter(config.Filter)
+	state.pool.Start()
+	state.bar.Start()
+
+	// Scan up to `parallel` domains at the same time
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, parallel)
+
+	for _, d := range domains {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(target string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			results := scanDomainShared(target, config, state)
+			state.writer.WriteLine(fmt.Sprintf("» %s done: %d subdomains", target, len(results)))
+
+			spool.Append(results...)
+		}(d)
+	}
+
+	wg.Wait()
+	if err := state.pool.CloseAndWait(); err != nil {
+		state.writer.WriteLine(fmt.Sprintf("× %v", err))
+	}
+	state.bar.Finish()
+
+	allResults, err := spool.Results()
+	if err != nil {
+		fmt.Printf("× Failed to read spilled results: %v\n", err)
+	}
+
+	// Candidates lost to transient failures get a second chance
+	retried := retryPass(state.opts, config)
+	config.workspace.Checkpoint(retried)
+	allResults = append(allResults, retried...)
+
+	// Results answered by a resolver dropped mid-scan are checked again
+	allResults = revalidateSuspects(allResults, state.opts)
+	closeCache(cache, config.Cache, state.stats)
+	allResults = verifyConsensus(allResults, config)
+
+	// Nested root domains (example.com and dev.example.com) find the same subdomains
+	allResults = models.MergeResults(allResults)
+	allResults = reportFilteredResults(allResults, config.Filter)
+	span.SetAttributes(attribute.Int("found", len(allResults)))
+
+	// Brief summary
+	fmt.Printf("\n» Found %d subdomains across %d domains\n", len(allResults), len(domains))
+	reportDomainCounts(domains, allResults)
+	reportLookupStats(state.stats, config.RecheckFile)
+	reportCoverage(&config, state.stats)
+	if config.Group || config.HTTP || config.Tech {
+		reportHostGroups(allResults)
+	}
+	reportEmailPosture(&config, domains, allResults)
+
+	// Save combined results if requested
+	saveCombinedResults(config, domains, allResults)
+
+	return allResults, nil
+}
+
+// saveCombinedResults writes the outputs of a run covering several domains
+func saveCombinedResults(config ActiveScanConfig, domains []string, results []models.SubdomainResult) {
+	_, span := telemetry.Span(config.trace, "save results", attribute.Int("results", len(results)))
+	defer span.End()
+
+	target := reportTarget(config.Metadata, domains)
+	config.Metadata.FinishedAt = time.Now()
+	models.TagResults(results, config.Tags)
+	models.RateResults(results)
+	models.SortResults(results, config.Sort)
+	if config.OutputFile != "" || config.JsonOutputFile != "" {
+		output.SaveResults(config.OutputFile, config.JsonOutputFile, target, results, config.Metadata, config.Format)
+		fmt.Printf("» Results saved\n")
+	}
+	saveHTMLReport(config.HTMLOutputFile, target, results, config.Metadata)
+	saveCSV(config.CSVOutputFile, results, config.Metadata)
+	saveXML(config.XMLOutputFile, target, results, config.Metadata)
+	saveSARIF(config.SARIFOutputFile, results, config.Metadata)
+	saveToDatabase(config.Database, domains, results, config.Metadata)
+
+	saveWorkspace(config.workspace, target, results, config.Metadata)
+
+	files := append([]string{config.OutputFile, config.JsonOutputFile, config.HTMLOutputFile, config.CSVOutputFile,
+		config.XMLOutputFile, config.SARIFOutputFile}, workspaceOutputs(config.workspace)...)
+	uploadOutputs(config.Upload, domains, config.Metadata, files...)
+}
+
+// scanDomainShared enumerates one domain (including recursion levels)
+// by submitting lookups to the shared worker pool
+func scanDomainShared(domain string, config ActiveScanConfig, state *sharedScanState) []models.SubdomainResult {
+	var results []models.SubdomainResult
+	level := 1
+	toScan := []string{domain}
+
+	// Candidates left when the budget runs out are not submitted
+	ctx, cancel := budgetContext(config.Context, config.deadline, config.queries)
+	defer cancel()
+
+	engine := &Engine{
+		Options:       state.opts,
+		Pool:          state.pool,
+		Limiter:       state.limiter,
+		Exclude:       config.Exclude,
+		CountCoverage: true,
+		Output: func(result models.SubdomainResult) {
+			state.writer.WriteResult(result)
+			config.publish(result)
+		},
+		Progress: func() { state.bar.Increment() },
+	}
+
+	for len(toScan) > 0 {
+		// Levels after the first were not part of the initial total
+		if level > 1 {
+			state.bar.AddTotal(int64(len(toScan) * len(state.wordlist)))
+		}
+		state.stats.addPlanned(len(toScan) * len(state.wordlist))
+
+		log := scannerLog.With("domain", domain).With("level", level)
+		log.Info("Brute-forcing %d names under %d parents", len(toScan)*len(state.wordlist), len(toScan))
+		levelCtx, levelSpan := telemetry.Span(config.trace, "scan level",
+			attribute.String("domain", domain), attribute.Int("level", level), attribute.Int("parents", len(toScan)))
+		levelResults := engine.Run(telemetry.Join(ctx, levelCtx), WordlistProducer(toScan, state.wordlist))
+		levelSpan.SetAttributes(attribute.Int("found", len(levelResults)))
+		levelSpan.End()
+		log.Info("Finished the level: %d found", len(levelResults))
+
+		// Process results of this level for the next level if recursive
+		results = append(results, levelResults...)
+		config.workspace.Checkpoint(levelResults)
+		if config.budgetSpent() {
+			break
+		}
+		if config.Recursive {
+			toScan = state.opts.recursionParents(deeperParents(resultNames(levelResults), level, config.Depth, config.DepthRules))
+			// High-value parents are fed to the shared pool first
+			sortParents(toScan, domain)
+			level++
+		} else {
+			toScan = []string{}
+		}
+	}
+
+	if config.ServiceRecords && !config.budgetSpent() {
+		results = append(results, serviceRecordPass(domain, results, state.opts, config)...)
+	}
+
+	return results
+}
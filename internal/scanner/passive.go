@@ -2,32 +2,88 @@ package scanner
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"io"
-	"net"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/fkr00t/subcollector/internal/models"
 	"github.com/fkr00t/subcollector/internal/output"
+	"github.com/fkr00t/subcollector/internal/sink"
+	"github.com/fkr00t/subcollector/internal/sources"
+	"github.com/fkr00t/subcollector/internal/upload"
 	"github.com/fkr00t/subcollector/internal/utils"
-	"github.com/projectdiscovery/subfinder/v2/pkg/runner"
+	"github.com/fkr00t/subcollector/internal/workspace"
 )
 
 // PassiveScanConfig holds configuration for passive scanning
 type PassiveScanConfig struct {
-	Domain         string
-	ShowIP         bool
-	StreamResults  bool
-	OutputFile     string
-	JsonOutputFile string
+	Domain          string
+	ShowIP          bool
+	StreamResults   bool
+	OutputFile      string
+	JsonOutputFile  string
+	HTMLOutputFile  string              // Optional standalone HTML report
+	CSVOutputFile   string              // Optional CSV output with one row per subdomain
+	XMLOutputFile   string              // Optional XML output with the same data as JSON
+	SARIFOutputFile string              // Optional SARIF log of takeover and dangling DNS findings
+	Sink            sink.Sinks          // Optional destinations receiving the results
+	Tags            []string            // Labels added to every result (--tag)
+	Database        string              // Optional SQLite file or PostgreSQL URL storing the results
+	Upload          *upload.Target      // Optional bucket location receiving the output files
+	Sort            string              // Order of saved results, models.SortByName or models.SortByDiscovery
+	Format          *models.LineFormat  // Line format of the text output, nil for one subdomain per line
+	Exclude         *utils.ExcludeList  // Hosts dropped from results
+	Filter          *utils.ResultFilter // Match/filter rules applied before display and saving
+	Metadata        models.ScanMetadata // Tool and configuration details embedded in JSON output
+	Workspace       string              // Base directory of per-run artifact directories
+	Sources         []sources.Source    // Passive sources queried, the default sources when empty
+	SourceOptions   sources.Options     // Time limits of the passive sources
+	Verify          bool                // Resolve every finding and record whether it still exists
+	DropUnresolved  bool                // Drop findings answering NXDOMAIN, implies Verify
+	Resolvers       []string            // Resolvers used by ShowIP and Verify, the system resolver when empty
+	NumWorkers      int                 // Concurrent lookups of ShowIP and Verify
+	RateLimit       int                 // Milliseconds between lookups of one root domain, spread over the workers
+	SourceReport    string              // Optional JSON file receiving what each passive source contributed
+	Context         context.Context     // Ends the scan early when done, such as when an API client is gone (nil for none)
 }
 
 // ExecutePassiveScan runs a passive scan with the provided configuration
-func ExecutePassiveScan(config PassiveScanConfig) {
+// Returns the results that passed the exclude and match/filter rules
+func ExecutePassiveScan(config PassiveScanConfig) ([]models.SubdomainResult, error) {
+	config.Metadata.StartedAt = time.Now()
+
+	if err := checkResolvers(config.Resolvers); err != nil {
+		return nil, err
+	}
+
+	if len(config.Sources) == 0 {
+		defaults, err := sources.Select(nil, nil)
+		if err != nil {
+			fmt.Printf("× %v\n", err)
+			return nil, err
+		}
+		config.Sources = defaults
+	}
+
+	// Collect logs and artifacts of this run when a workspace is used
+	ws, err := workspace.Create(config.Workspace, config.Domain, config.Metadata.StartedAt)
+	if err != nil {
+		fmt.Printf("× %v\n", err)
+		return nil, err
+	}
+	if err := ws.CaptureOutput(); err != nil {
+		fmt.Printf("× Failed to capture output: %v\n", err)
+	}
+	defer ws.Close()
+
+	config.Sink.Begin(config.Domain, config.Metadata)
+	defer config.Sink.Close()
+
 	// Display a minimalist scan header (mirip dengan active scanning)
 	fmt.Printf("\n» Scanning %s (passive mode)\n", config.Domain)
 
@@ -46,6 +102,35 @@ func ExecutePassiveScan(config PassiveScanConfig) {
 	if config.JsonOutputFile != "" {
 		passiveFlags = append(passiveFlags, fmt.Sprintf("json:%s", config.JsonOutputFile))
 	}
+	if config.HTMLOutputFile != "" {
+		passiveFlags = append(passiveFlags, fmt.Sprintf("html:%s", config.HTMLOutputFile))
+	}
+	if config.CSVOutputFile != "" {
+		passiveFlags = append(passiveFlags, fmt.Sprintf("csv:%s", config.CSVOutputFile))
+	}
+	if config.XMLOutputFile != "" {
+		passiveFlags = append(passiveFlags, fmt.Sprintf("xml:%s", config.XMLOutputFile))
+	}
+	if config.SARIFOutputFile != "" {
+		passiveFlags = append(passiveFlags, fmt.Sprintf("sarif:%s", config.SARIFOutputFile))
+	}
+	if config.Upload != nil {
+		passiveFlags = append(passiveFlags, fmt.Sprintf("upload:%s", config.Upload))
+	}
+	if ws != nil {
+		passiveFlags = append(passiveFlags, fmt.Sprintf("workspace:%s", ws.Dir))
+	}
+	if config.SourceOptions.Timeout > 0 || len(config.SourceOptions.Timeouts) > 0 {
+		passiveFlags = append(passiveFlags, "source-timeout")
+	}
+	if config.SourceOptions.Cache != nil {
+		passiveFlags = append(passiveFlags, "source-cache")
+	}
+	if config.DropUnresolved {
+		passiveFlags = append(passiveFlags, "drop-unresolved")
+	} else if config.Verify {
+		passiveFlags = append(passiveFlags, "verify")
+	}
 
 	// Display the flags used, if any
 	if len(passiveFlags) > 0 {
@@ -65,16 +150,40 @@ func ExecutePassiveScan(config PassiveScanConfig) {
 		outputFile := config.OutputFile
 		if config.JsonOutputFile != "" {
 			outputFile = config.JsonOutputFile
-			go output.BatchSaveResultsJSON(outputFile, config.Domain, resultsChan, doneChan)
+			go output.BatchSaveResultsJSON(outputFile, config.Domain, config.Metadata, resultsChan, doneChan)
 		} else {
-			go output.BatchSaveResultsText(outputFile, resultsChan, doneChan)
+			go output.BatchSaveResultsText(outputFile, config.Format, resultsChan, doneChan)
 		}
 	}
 
-	results, err := passiveScan(config.Domain, config.ShowIP)
-	if err != nil {
-		fmt.Printf("× Passive scan failed for %s: %v\n", config.Domain, err)
-		return
+	results, sourceStats := passiveScan(config.Context, config.Domain, config.Sources, config.SourceOptions)
+	config.Metadata.Sources = sourceReports(sourceStats)
+
+	// Drop out-of-scope hosts before they are displayed or saved
+	if config.Exclude.Len() > 0 {
+		var inScope []models.SubdomainResult
+		for _, result := range results {
+			if !config.Exclude.Matches(result.Subdomain) {
+				inScope = append(inScope, result)
+			}
+		}
+		if dropped := len(results) - len(inScope); dropped > 0 {
+			fmt.Printf("» Excluded %d out-of-scope subdomains\n", dropped)
+		}
+		results = inScope
+	}
+
+	// Keep only results selected by match/filter rules
+	results = reportFilteredResults(results, config.Filter)
+
+	if config.ShowIP || config.Verify || config.DropUnresolved {
+		results = resolvePassiveResults(results, config)
+	}
+	models.SortResults(results, config.Sort)
+	models.TagResults(results, config.Tags)
+	models.RateResults(results)
+	for _, result := range results {
+		config.Sink.Add(result)
 	}
 
 	// Stream results if enabled
@@ -99,31 +208,48 @@ func ExecutePassiveScan(config PassiveScanConfig) {
 
 		// Save results if requested
 		if (config.OutputFile != "" || config.JsonOutputFile != "") && !config.StreamResults {
-			output.SaveResults(config.OutputFile, config.JsonOutputFile, config.Domain, results)
+			config.Metadata.FinishedAt = time.Now()
+			output.SaveResults(config.OutputFile, config.JsonOutputFile, config.Domain, results, config.Metadata, config.Format)
 			fmt.Printf("» Results saved\n")
 		}
 	}
 
+	config.Metadata.FinishedAt = time.Now()
+	saveHTMLReport(config.HTMLOutputFile, config.Domain, results, config.Metadata)
+	saveCSV(config.CSVOutputFile, results, config.Metadata)
+	saveXML(config.XMLOutputFile, config.Domain, results, config.Metadata)
+	saveSARIF(config.SARIFOutputFile, results, config.Metadata)
+	saveToDatabase(config.Database, []string{config.Domain}, results, config.Metadata)
+	saveWorkspace(ws, config.Domain, results, config.Metadata)
+
+	files := append([]string{config.OutputFile, config.JsonOutputFile, config.HTMLOutputFile, config.CSVOutputFile,
+		config.XMLOutputFile, config.SARIFOutputFile}, workspaceOutputs(ws)...)
+	uploadOutputs(config.Upload, []string{config.Domain}, config.Metadata, files...)
+
 	// Brief summary at the end, similar to active scanning
 	fmt.Printf("\n» Found %d subdomains\n", len(results))
+	reportSourceStats(sourceStats)
+	saveSourceReport(config.SourceReport, config.Domain, config.Metadata)
+
+	return results, nil
 }
 
-// passiveScan performs passive subdomain enumeration using subfinder
+// passiveScan performs passive subdomain enumeration using the given sources
 // Uses external sources to find subdomains without direct interaction with the target
-func passiveScan(domain string, showIP bool) ([]models.SubdomainResult, error) {
+// The sources stop when parent is done, a nil parent stands for context.Background()
+func passiveScan(parent context.Context, domain string, list []sources.Source, opts sources.Options) ([]models.SubdomainResult, []sources.Stats) {
 	fmt.Printf("» Starting passive scan for %s\n", domain)
-	fmt.Printf("» Querying passive sources...\n")
+	fmt.Printf("» Querying %d passive sources...\n", len(list))
 
-	// Create a progress bar for consistent UI with active scanning
-	bar := utils.CreateProgressBar(100) // Menggunakan 100 sebagai placeholder karena kita tidak tahu pasti berapa banyak hasil
+	// The bar advances as sources finish
+	bar := utils.CreateProgressBar(len(list))
 	bar.Start()
 
-	// Setup countdown timer for consistent feedback
-	updateTicker := time.NewTicker(500 * time.Millisecond)
-	defer updateTicker.Stop()
-
 	// Create a context that we can cancel
-	ctx, cancel := context.WithCancel(context.Background())
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
 	defer cancel()
 
 	// Handle interrupt signal for clean exit
@@ -132,75 +258,251 @@ func passiveScan(domain string, showIP bool) ([]models.SubdomainResult, error) {
 	go func() {
 		select {
 		case <-interruptChan:
-			cancel() // Cancel context to stop progress updater
+			cancel()
 			bar.Finish()
 			fmt.Println("\nBye!")
-			os.Exit(0)
+			utils.Exit(0)
 		case <-ctx.Done():
 			return
 		}
 	}()
 
-	// Progress updater goroutine
-	go func() {
-		progress := 0
-		for {
-			select {
-			case <-ctx.Done():
+	// Hosts in discovery order with the sources that reported them
+	var hosts []string
+	found := make(map[string][]string)
+	suffix := "." + strings.ToLower(domain)
+
+	// Each source reports on its own line above the bar as it finishes
+	writer := output.NewResultWriter(bar, false)
+	opts.Done = func(s sources.Stats) {
+		writer.WriteLine(sourceStatusLine(s))
+		bar.Increment()
+	}
+	stats := sources.Run(ctx, domain, list, opts, func(finding sources.Finding) {
+		host := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(finding.Host), "."))
+		host = strings.TrimPrefix(host, "*.")
+		// Sources sometimes return hosts of other domains
+		if host != strings.ToLower(domain) && !strings.HasSuffix(host, suffix) {
+			return
+		}
+		names, ok := found[host]
+		if !ok {
+			hosts = append(hosts, host)
+		}
+		for _, name := range names {
+			if name == finding.Source {
 				return
-			case <-updateTicker.C:
-				progress += 2
-				if progress > 95 {
-					progress = 95 // Cap at 95% until we're done
-				}
-				bar.SetCurrent(int64(progress))
 			}
 		}
-	}()
+		found[host] = append(names, finding.Source)
+	})
 
-	options := &runner.Options{
-		Threads:            10,
-		Timeout:            30,
-		MaxEnumerationTime: 10,
-		Silent:             true,
+	// Count the hosts of each source and those only a single source reported
+	index := make(map[string]int, len(stats))
+	for i := range stats {
+		index[stats[i].Source] = i
+	}
+	for _, names := range found {
+		for _, name := range names {
+			stats[index[name]].Hosts++
+		}
+		if len(names) == 1 {
+			stats[index[names[0]]].Unique++
+		}
 	}
 
-	runnerInstance, err := runner.NewRunner(options)
-	if err != nil {
-		bar.Finish()
-		signal.Stop(interruptChan)
-		return nil, err
+	var subdomains []models.SubdomainResult
+	for _, host := range hosts {
+		names := found[host]
+		sort.Strings(names)
+		subdomains = append(subdomains, models.SubdomainResult{Subdomain: host, Source: strings.Join(names, ",")})
 	}
 
-	results, err := runnerInstance.EnumerateSingleDomain(domain, []io.Writer{io.Discard})
-	if err != nil {
-		bar.Finish()
-		signal.Stop(interruptChan)
-		return nil, err
+	// Clean up signal handling
+	signal.Stop(interruptChan)
+	bar.Finish()
+
+	fmt.Printf("» Found %d subdomains via passive sources\n", len(subdomains))
+
+	return subdomains, stats
+}
+
+// resolvePassiveResults resolves passive findings on the worker pool with the
+// configured resolvers, rate limited per root domain like active scans
+// Addresses are kept with ShowIP; with Verify each finding records whether it
+// still exists, and DropUnresolved drops the ones answering NXDOMAIN. Names
+// without an authoritative answer are always kept
+func resolvePassiveResults(results []models.SubdomainResult, config PassiveScanConfig) []models.SubdomainResult {
+	verify := config.Verify || config.DropUnresolved
+	fmt.Printf("» Resolving %d subdomains\n", len(results))
+
+	stats := NewLookupStats()
+	opts := newLookupOptions(processResolvers(config.Resolvers), models.NewDNSCache(), nil, ActiveScanConfig{Domain: config.Domain, ShowIP: config.ShowIP}, stats)
+	opts.KeepUnresolved = true
+
+	workers := config.NumWorkers
+	if workers <= 0 {
+		workers = 10
 	}
+	interval := time.Duration(config.RateLimit) * time.Millisecond / time.Duration(workers)
 
-	var subdomains []models.SubdomainResult
-	for result := range results {
-		subdomainResult := models.SubdomainResult{Subdomain: result}
+	// Results are checked concurrently, each one goes back to its discovery position
+	index := make(map[string]int, len(results))
+	candidates := make([]Candidate, len(results))
+	for i, result := range results {
+		index[result.Subdomain] = i
+		candidates[i] = Candidate{Name: result.Subdomain, Source: result.Source}
+	}
 
-		if showIP {
-			ips, err := net.LookupHost(result)
-			if err == nil {
-				subdomainResult.IPs = ips
+	engine := &Engine{
+		Options: opts,
+		Workers: workers,
+		Limiter: utils.NewDomainRateLimiter(interval),
+		Output: func(result models.SubdomainResult) {
+			i, ok := index[result.Subdomain]
+			if !ok {
+				return
 			}
+			results[i] = result
+		},
+	}
+	ctx := config.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	engine.Run(ctx, ListProducer(candidates))
+
+	counts := make(map[string]int)
+	var kept []models.SubdomainResult
+	for _, result := range results {
+		counts[result.Resolution]++
+		if config.DropUnresolved && result.Resolution == models.ResolutionUnresolvable {
+			continue
+		}
+		if !verify {
+			result.Resolution = ""
 		}
+		kept = append(kept, result)
+	}
 
-		subdomains = append(subdomains, subdomainResult)
+	if verify {
+		fmt.Printf("» %d resolvable, %d unresolvable, %d unknown\n",
+			counts[models.ResolutionResolvable], counts[models.ResolutionUnresolvable], counts[models.ResolutionUnknown])
+	}
+	fmt.Printf("» Lookups: %s\n", stats.Summary())
+	if dropped := len(results) - len(kept); dropped > 0 {
+		fmt.Printf("» Dropped %d subdomains that no longer exist\n", dropped)
 	}
+	fmt.Println()
+	return kept
+}
 
-	// Clean up signal handling
-	signal.Stop(interruptChan)
+// sourceStatusLine describes how a passive source finished
+// A source that reported errors and no hostnames is considered failed
+func sourceStatusLine(s sources.Stats) string {
+	elapsed := s.Duration.Round(100 * time.Millisecond)
+	switch {
+	case s.Cached:
+		return fmt.Sprintf("» %s cached: %d found", s.Source, s.Found)
+	case s.TimedOut:
+		return fmt.Sprintf("× %s timed out after %s (%d found)", s.Source, elapsed, s.Found)
+	case s.Errors > 0 && s.Found == 0:
+		return fmt.Sprintf("× %s failed after %s: %v", s.Source, elapsed, s.LastErr)
+	case s.Errors > 0:
+		return fmt.Sprintf("» %s done in %s: %d found, %d errors", s.Source, elapsed, s.Found, s.Errors)
+	default:
+		return fmt.Sprintf("» %s done in %s: %d found", s.Source, elapsed, s.Found)
+	}
+}
 
-	// Completed!
-	bar.SetCurrent(100)
-	bar.Finish()
+// reportSourceStats prints what each passive source contributed, the sources
+// finding the most hosts nobody else found first, and the source the scan waited for
+func reportSourceStats(stats []sources.Stats) {
+	if len(stats) == 0 {
+		return
+	}
 
-	fmt.Printf("» Found %d subdomains via passive sources\n", len(subdomains))
+	sorted := make([]sources.Stats, len(stats))
+	copy(sorted, stats)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Unique != sorted[j].Unique {
+			return sorted[i].Unique > sorted[j].Unique
+		}
+		return sorted[i].Hosts > sorted[j].Hosts
+	})
+
+	fmt.Println("» Sources:")
+	slowest := sorted[0]
+	for _, s := range sorted {
+		line := fmt.Sprintf("  %-18s %5d hosts %5d unique %8s", s.Source, s.Hosts, s.Unique, s.Duration.Round(100*time.Millisecond))
+		if s.Cached {
+			line += "  cached"
+		}
+		if s.TimedOut {
+			line += "  timed out"
+		}
+		if s.Retries > 0 {
+			line += fmt.Sprintf("  %d retries", s.Retries)
+		}
+		if s.Errors > 0 {
+			line += fmt.Sprintf("  %d errors (last: %v)", s.Errors, s.LastErr)
+		}
+		fmt.Println(line)
+		if s.Duration > slowest.Duration {
+			slowest = s
+		}
+	}
+
+	// Sources run at the same time, so the slowest one sets the length of the scan
+	if len(sorted) > 1 {
+		fmt.Printf("» Slowest source: %s (%s)\n", slowest.Source, slowest.Duration.Round(100*time.Millisecond))
+	}
+}
 
-	return subdomains, nil
+// sourceReports converts the stats of the passive sources for the scan metadata
+func sourceReports(stats []sources.Stats) []models.SourceReport {
+	var reports []models.SourceReport
+	for _, s := range stats {
+		report := models.SourceReport{
+			Source:   s.Source,
+			Hosts:    s.Hosts,
+			Unique:   s.Unique,
+			Found:    s.Found,
+			Errors:   s.Errors,
+			Seconds:  s.Duration.Seconds(),
+			TimedOut: s.TimedOut,
+			Retries:  s.Retries,
+			Cached:   s.Cached,
+		}
+		if s.LastErr != nil {
+			report.LastError = s.LastErr.Error()
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// saveSourceReport writes what each passive source contributed to a JSON file
+// Nothing is written when path is empty
+func saveSourceReport(path, domain string, metadata models.ScanMetadata) {
+	if path == "" {
+		return
+	}
+	report := struct {
+		Domain      string                `json:"domain"`
+		StartedAt   time.Time             `json:"started_at"`
+		FinishedAt  time.Time             `json:"finished_at"`
+		Fingerprint string                `json:"config_fingerprint,omitempty"`
+		Sources     []models.SourceReport `json:"sources"`
+	}{domain, metadata.StartedAt, metadata.FinishedAt, metadata.Config.Fingerprint, metadata.Sources}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		fmt.Printf("× Failed to save source report: %v\n", err)
+		return
+	}
+	fmt.Printf("» Source report saved to %s\n", path)
 }
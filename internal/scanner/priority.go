@@ -0,0 +1,125 @@
+package scanner
+
+import (
+	"container/heap"
+	"sort"
+	"strings"
+)
+
+// InterestingKeywords mark subdomains whose children are scanned first when recursing
+// They usually front APIs, remote access and internal tooling
+var InterestingKeywords = []string{
+	"api", "admin", "vpn", "dev", "staging", "stage", "stg", "test", "qa", "uat",
+	"internal", "intranet", "corp", "auth", "sso", "login", "portal", "dashboard",
+	"git", "gitlab", "jenkins", "ci", "jira", "confluence", "remote", "secure", "beta",
+}
+
+// queuedParent is a discovered subdomain waiting to be used as a recursion parent
+type queuedParent struct {
+	Name        string
+	Level       int  // Level at which the parent is scanned
+	interesting bool // Contains one of InterestingKeywords
+	labels      int  // Number of labels, shallower names first
+	order       int  // Discovery order, keeps ties stable
+}
+
+// before reports whether p should be scanned before other
+// Interesting names come first, then names with fewer labels, then shorter names
+func (p queuedParent) before(other queuedParent) bool {
+	if p.interesting != other.interesting {
+		return p.interesting
+	}
+	if p.labels != other.labels {
+		return p.labels < other.labels
+	}
+	if len(p.Name) != len(other.Name) {
+		return len(p.Name) < len(other.Name)
+	}
+	return p.order < other.order
+}
+
+// parentHeap implements heap.Interface over queued parents
+type parentHeap []queuedParent
+
+func (h parentHeap) Len() int            { return len(h) }
+func (h parentHeap) Less(i, j int) bool  { return h[i].before(h[j]) }
+func (h parentHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *parentHeap) Push(x interface{}) { *h = append(*h, x.(queuedParent)) }
+func (h *parentHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// recursionQueue hands out recursion parents by priority instead of level by level
+// so high-value names are expanded early on long or time-boxed scans
+type recursionQueue struct {
+	domain string
+	items  parentHeap
+	added  int
+}
+
+// newRecursionQueue creates an empty queue for subdomains of domain
+func newRecursionQueue(domain string) *recursionQueue {
+	return &recursionQueue{domain: domain}
+}
+
+// Push queues a subdomain to be scanned at the given level
+func (q *recursionQueue) Push(name string, level int) {
+	heap.Push(&q.items, newQueuedParent(name, q.domain, level, q.added))
+	q.added++
+}
+
+// Pop returns the parent to scan next
+func (q *recursionQueue) Pop() (queuedParent, bool) {
+	if len(q.items) == 0 {
+		return queuedParent{}, false
+	}
+	return heap.Pop(&q.items).(queuedParent), true
+}
+
+// Len returns the number of queued parents
+func (q *recursionQueue) Len() int {
+	return len(q.items)
+}
+
+// sortParents orders the parents of a recursion level by priority, in place
+func sortParents(names []string, domain string) {
+	parents := make([]queuedParent, len(names))
+	for i, name := range names {
+		parents[i] = newQueuedParent(name, domain, 0, i)
+	}
+	sort.Slice(parents, func(i, j int) bool { return parents[i].before(parents[j]) })
+	for i, parent := range parents {
+		names[i] = parent.Name
+	}
+}
+
+// newQueuedParent computes the priority of a subdomain of domain
+func newQueuedParent(name, domain string, level, order int) queuedParent {
+	prefix := strings.TrimSuffix(strings.TrimSuffix(name, domain), ".")
+	return queuedParent{
+		Name:        name,
+		Level:       level,
+		interesting: hasInterestingKeyword(prefix),
+		labels:      strings.Count(name, ".") + 1,
+		order:       order,
+	}
+}
+
+// hasInterestingKeyword reports whether a name contains one of InterestingKeywords
+// as a whole token, so "api-v2" and "dev1" match but "rapid" does not
+func hasInterestingKeyword(name string) bool {
+	tokens := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r < 'a' || r > 'z'
+	})
+	for _, token := range tokens {
+		for _, keyword := range InterestingKeywords {
+			if token == keyword {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/output"
+)
+
+// reportTarget names the target of a run covering several domains in reports:
+// the project name, or the domains separated by commas
+func reportTarget(metadata models.ScanMetadata, domains []string) string {
+	if metadata.Project != "" {
+		return metadata.Project
+	}
+	return strings.Join(domains, ",")
+}
+
+// reportDomainCounts prints the subdomains, takeovers and dangling records found under each root domain
+func reportDomainCounts(domains []string, results []models.SubdomainResult) {
+	if len(domains) < 2 {
+		return
+	}
+	opts := LookupOptions{Scope: domains}
+	byDomain := make(map[string][]models.SubdomainResult)
+	for _, result := range results {
+		zone := opts.zoneOf(result.Subdomain)
+		byDomain[zone] = append(byDomain[zone], result)
+	}
+
+	fmt.Printf("\n  %-40s %10s %9s %8s\n", "DOMAIN", "SUBDOMAINS", "TAKEOVERS", "DANGLING")
+	for _, domain := range domains {
+		counts := models.CountResults(byDomain[domain])
+		fmt.Printf("  %-40s %10d %9d %8d\n", domain, counts.Subdomains, counts.Takeovers, counts.Dangling)
+	}
+	if outside := byDomain[""]; len(outside) > 0 {
+		fmt.Printf("  %-40s %10d\n", "(outside the listed domains)", len(outside))
+	}
+}
+
+// ExecutePassiveProject runs a passive scan of every domain of a project and writes one combined report
+// Subdomains found under several root domains are reported once; a failed domain does not stop the others
+func ExecutePassiveProject(config PassiveScanConfig, domains []string) ([]models.SubdomainResult, error) {
+	config.Metadata.StartedAt = time.Now()
+	target := reportTarget(config.Metadata, domains)
+	fmt.Printf("\n» Project %s: passive scan of %d domains\n", target, len(domains))
+
+	// Each domain is only displayed, outputs cover the whole project
+	domainConfig := config
+	domainConfig.OutputFile, domainConfig.JsonOutputFile, domainConfig.StreamResults = "", "", false
+	domainConfig.HTMLOutputFile, domainConfig.CSVOutputFile, domainConfig.XMLOutputFile, domainConfig.SARIFOutputFile = "", "", "", ""
+	domainConfig.Database, domainConfig.Upload, domainConfig.Sink = "", nil, nil
+
+	var sets [][]models.SubdomainResult
+	var failed []string
+	for _, domain := range domains {
+		domainConfig.Domain = domain
+		results, err := ExecutePassiveScan(domainConfig)
+		if err != nil {
+			failed = append(failed, domain)
+		}
+		sets = append(sets, results)
+	}
+
+	results := models.MergeResults(sets...)
+	models.TagResults(results, config.Tags)
+	models.RateResults(results)
+	models.SortResults(results, config.Sort)
+
+	fmt.Printf("\n» Found %d subdomains across %d domains\n", len(results), len(domains))
+	reportDomainCounts(domains, results)
+
+	config.Metadata.FinishedAt = time.Now()
+	config.Sink.Begin(target, config.Metadata)
+	for _, result := range results {
+		config.Sink.Add(result)
+	}
+	config.Sink.Close()
+
+	if config.OutputFile != "" || config.JsonOutputFile != "" {
+		output.SaveResults(config.OutputFile, config.JsonOutputFile, target, results, config.Metadata, config.Format)
+		fmt.Printf("» Results saved\n")
+	}
+	saveHTMLReport(config.HTMLOutputFile, target, results, config.Metadata)
+	saveCSV(config.CSVOutputFile, results, config.Metadata)
+	saveXML(config.XMLOutputFile, target, results, config.Metadata)
+	saveSARIF(config.SARIFOutputFile, results, config.Metadata)
+	saveToDatabase(config.Database, domains, results, config.Metadata)
+	uploadOutputs(config.Upload, domains, config.Metadata, config.OutputFile, config.JsonOutputFile,
+		config.HTMLOutputFile, config.CSVOutputFile, config.XMLOutputFile, config.SARIFOutputFile)
+
+	if len(failed) > 0 {
+		fmt.Printf("× Passive scan failed for %s\n", strings.Join(failed, ", "))
+		return results, fmt.Errorf("%d domains could not be scanned: %w", len(failed), ErrScanFailed)
+	}
+	return results, nil
+}
@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// watchedWordlists holds the wordlists of long-running commands in memory, by path
+// Scans take the list as it was when they started, a reload only affects later ones
+var watchedWordlists sync.Map // Path -> []string
+
+// WatchReloads keeps the takeover fingerprints and the wordlist of a long-running command
+// in line with their files, so updating them needs no restart; either path may be empty
+// A file that fails to load, such as one caught half written, leaves the previous version in use
+func WatchReloads(fingerprintsPath, wordlistPath string) (*utils.FileWatcher, error) {
+	var paths []string
+	if fingerprintsPath != "" {
+		paths = append(paths, fingerprintsPath)
+	}
+	if wordlistPath != "" {
+		if _, err := reloadWordlist(wordlistPath); err != nil {
+			return nil, err
+		}
+		paths = append(paths, wordlistPath)
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	return utils.WatchFiles(paths, func(path string) {
+		log := scannerLog.With("path", path)
+		switch path {
+		case fingerprintsPath:
+			if count, err := LoadFingerprints(path); err != nil {
+				log.Warn("Kept the previous takeover fingerprints: %v", err)
+			} else {
+				log.Info("Reloaded %d takeover fingerprints", count)
+			}
+		case wordlistPath:
+			if words, err := reloadWordlist(path); err != nil {
+				log.Warn("Kept the previous wordlist: %v", err)
+			} else {
+				log.Info("Reloaded %d words", words)
+			}
+		}
+	})
+}
+
+// reloadWordlist reads the wordlist at path into watchedWordlists
+// Returns the number of words, an empty file is refused like a missing one
+func reloadWordlist(path string) (int, error) {
+	wordlist, err := readWordlist(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(wordlist) == 0 {
+		return 0, fmt.Errorf("%w %s: no valid words", ErrWordlistRead, path)
+	}
+	watchedWordlists.Store(path, wordlist)
+	return len(wordlist), nil
+}
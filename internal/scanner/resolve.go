@@ -0,0 +1,227 @@
+package scanner
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// scannerLog is the logger of the scanner, its messages carry module=scanner
+var scannerLog = utils.With("module", "scanner")
+
+// LookupStats counts lookup outcomes during a scan
+// Candidates that never received an authoritative answer are kept for re-checking
+type LookupStats struct {
+	resolved   int64
+	nxdomain   int64
+	servfail   int64
+	timeout    int64
+	other      int64
+	excluded   int64
+	invalid    int64
+	planned    int64
+	checked    int64
+	mutex      sync.Mutex
+	unresolved []string
+	cache      models.CacheStats // Use of the DNS cache, added when the scan closes it
+}
+
+// NewLookupStats creates a new instance of LookupStats
+func NewLookupStats() *LookupStats {
+	return &LookupStats{}
+}
+
+// record increments the counter matching a lookup status
+func (s *LookupStats) record(status utils.LookupStatus) {
+	switch status {
+	case utils.StatusResolved:
+		atomic.AddInt64(&s.resolved, 1)
+	case utils.StatusNXDomain:
+		atomic.AddInt64(&s.nxdomain, 1)
+	case utils.StatusServFail:
+		atomic.AddInt64(&s.servfail, 1)
+	case utils.StatusTimeout:
+		atomic.AddInt64(&s.timeout, 1)
+	default:
+		atomic.AddInt64(&s.other, 1)
+	}
+}
+
+// recordExcluded counts a candidate skipped because of the exclude list
+func (s *LookupStats) recordExcluded() {
+	atomic.AddInt64(&s.excluded, 1)
+}
+
+// recordInvalid counts a candidate skipped because it is not a valid hostname
+func (s *LookupStats) recordInvalid() {
+	atomic.AddInt64(&s.invalid, 1)
+}
+
+// addPlanned adds candidates the scan intends to check
+func (s *LookupStats) addPlanned(n int) {
+	atomic.AddInt64(&s.planned, int64(n))
+}
+
+// recordChecked counts a candidate handed to the workers or skipped by the exclude list
+func (s *LookupStats) recordChecked() {
+	atomic.AddInt64(&s.checked, 1)
+}
+
+// addChecked counts candidates checked elsewhere, such as by the nodes of a distributed scan
+func (s *LookupStats) addChecked(n int) {
+	atomic.AddInt64(&s.checked, int64(n))
+}
+
+// Coverage returns the number of checked and planned candidates
+func (s *LookupStats) Coverage() (checked, planned int64) {
+	return atomic.LoadInt64(&s.checked), atomic.LoadInt64(&s.planned)
+}
+
+// addUnresolved stores a candidate that never got an authoritative answer
+func (s *LookupStats) addUnresolved(subdomain string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.unresolved = append(s.unresolved, subdomain)
+}
+
+// takeUnresolved returns the candidates that need to be re-checked and clears the list
+func (s *LookupStats) takeUnresolved() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	unresolved := s.unresolved
+	s.unresolved = nil
+	return unresolved
+}
+
+// restoreUnresolved puts candidates taken with takeUnresolved back on the list
+func (s *LookupStats) restoreUnresolved(subdomains []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.unresolved = append(s.unresolved, subdomains...)
+}
+
+// Unresolved returns the candidates that need to be re-checked
+func (s *LookupStats) Unresolved() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.unresolved...)
+}
+
+// addCacheStats adds the counters of a closed DNS cache
+func (s *LookupStats) addCacheStats(cache models.CacheStats) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cache.Hits += cache.Hits
+	s.cache.Misses += cache.Misses
+	s.cache.Evictions += cache.Evictions
+}
+
+// CacheSummary returns the DNS cache counters, empty when the cache was not used
+func (s *LookupStats) CacheSummary() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	lookups := s.cache.Hits + s.cache.Misses
+	if lookups == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d hits (%.1f%%), %d misses, %d evictions",
+		s.cache.Hits, 100*float64(s.cache.Hits)/float64(lookups), s.cache.Misses, s.cache.Evictions)
+}
+
+// Summary returns a one-line description of lookup outcomes
+func (s *LookupStats) Summary() string {
+	summary := fmt.Sprintf("%d NXDOMAIN, %d SERVFAIL, %d timeouts, %d other errors",
+		atomic.LoadInt64(&s.nxdomain),
+		atomic.LoadInt64(&s.servfail),
+		atomic.LoadInt64(&s.timeout),
+		atomic.LoadInt64(&s.other),
+	)
+	if excluded := atomic.LoadInt64(&s.excluded); excluded > 0 {
+		summary += fmt.Sprintf(", %d excluded", excluded)
+	}
+	if invalid := atomic.LoadInt64(&s.invalid); invalid > 0 {
+		summary += fmt.Sprintf(", %d invalid", invalid)
+	}
+	return summary
+}
+
+// resolveSubdomain looks up a subdomain, failing over to the next resolver
+// when an answer is not authoritative (SERVFAIL, timeout, refused)
+// An NXDOMAIN answer is final and is not retried elsewhere
+func resolveSubdomain(subdomain string, resolvers []string, stats *LookupStats) ([]string, utils.LookupStatus) {
+	answer := lookupSubdomain(subdomain, resolvers, stats, scannerLog, false, nil)
+	return answer.addresses, answer.status
+}
+
+// lookupAnswer is the outcome of lookupSubdomain
+type lookupAnswer struct {
+	addresses []string
+	status    utils.LookupStatus
+	resolver  string          // Resolver that gave the final answer, empty when the system resolver was used
+	elapsed   time.Duration   // How long that resolver took to answer
+	records   utils.RecordSet // A and AAAA answer sections, only kept when asked for and never from the system resolver
+}
+
+// lookupSubdomain works like resolveSubdomain and also returns the resolver
+// that gave the final answer and how long it took to answer
+// With withRecords, the A and AAAA queries are sent directly so their answers are
+// kept: the CNAME chain and TTLs then cost no further query
+// Names under split DNS zones are looked up through the resolvers of their group
+// The first attempt is charged by the caller, failing over to another resolver is charged
+// to queries and stops once it is spent
+// Each attempt is logged to log at Debug level
+func lookupSubdomain(subdomain string, resolvers []string, stats *LookupStats, log *utils.Logger, withRecords bool, queries *queryBudget) lookupAnswer {
+	resolvers = utils.RouteResolvers(subdomain, resolvers)
+
+	var answer lookupAnswer
+	var err error
+	answer.status = utils.StatusError
+
+	if len(resolvers) > 0 {
+		for i, resolver := range resolvers {
+			if i > 0 && !queries.spend() {
+				break
+			}
+			start := time.Now()
+			if withRecords {
+				answer.addresses, answer.records, err = utils.LookupAnswers(subdomain, resolver)
+			} else {
+				answer.addresses, err = utils.LookupWithResolver(subdomain, resolver)
+			}
+			answer.elapsed = time.Since(start)
+			answer.status = utils.ClassifyLookupError(err)
+			answer.resolver = resolver
+			if utils.DebugEnabled() {
+				log.Debug("Lookup %s via %s: %s in %s", subdomain, resolver, answer.status, answer.elapsed.Round(time.Millisecond))
+			}
+			if answer.status.IsAuthoritative() {
+				break
+			}
+		}
+	} else {
+		// Use system default resolver
+		start := time.Now()
+		answer.addresses, err = utils.DefaultLookup(subdomain)
+		answer.elapsed = time.Since(start)
+		answer.status = utils.ClassifyLookupError(err)
+		if utils.DebugEnabled() {
+			log.Debug("Lookup %s via the system resolver: %s in %s", subdomain, answer.status, answer.elapsed.Round(time.Millisecond))
+		}
+	}
+
+	if stats != nil {
+		stats.record(answer.status)
+		if !answer.status.IsAuthoritative() {
+			stats.addUnresolved(subdomain)
+		}
+	}
+
+	return answer
+}
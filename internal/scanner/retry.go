@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/output"
+)
+
+// retryWorkerDivisor reduces the concurrency of the retry pass, transient
+// failures mostly come from resolvers overloaded by the main pass
+const retryWorkerDivisor = 4
+
+// retryPass looks up again, at reduced concurrency, the candidates whose lookups
+// ended without an authoritative answer (timeout, SERVFAIL, refused)
+// Under heavy load many of these failures are transient; candidates failing
+// again, or left when the time budget runs out, stay in the re-check list
+func retryPass(opts LookupOptions, config ActiveScanConfig) []models.SubdomainResult {
+	if opts.Stats == nil {
+		return nil
+	}
+	candidates := opts.Stats.takeUnresolved()
+	if len(candidates) == 0 {
+		return nil
+	}
+	if config.budgetSpent() {
+		opts.Stats.restoreUnresolved(candidates)
+		return nil
+	}
+
+	workers := config.NumWorkers / retryWorkerDivisor
+	if workers < 1 {
+		workers = 1
+	}
+	fmt.Printf("\n» Retrying %d candidates without an authoritative answer (%d workers)\n", len(candidates), workers)
+
+	// Names already reported, such as dangling delegations, need no second look
+	var retry []Candidate
+	for _, name := range candidates {
+		if opts.seen != nil {
+			if _, known := opts.seen.Load(name); known {
+				continue
+			}
+		}
+		retry = append(retry, Candidate{Name: name})
+	}
+
+	engine := &Engine{
+		Options: opts,
+		Workers: workers,
+		Pause:   time.Duration(config.RateLimit) * time.Millisecond,
+		Output: func(result models.SubdomainResult) {
+			if config.Filter.Allows(result.Subdomain) {
+				output.DisplayResult(result, config.ShowIP)
+			}
+			config.publish(result)
+		},
+		// Candidates left when the time budget runs out stay in the re-check list
+		Skipped: func(candidate Candidate) { opts.Stats.addUnresolved(candidate.Name) },
+	}
+	recovered := engine.Run(context.Background(), ListProducer(retry))
+
+	fmt.Printf("» Retry pass recovered %d subdomains\n", len(recovered))
+	return recovered
+}
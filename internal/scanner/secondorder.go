@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// recordSecondOrder checks the hosts serving the page resources of a probed
+// subdomain, adding a dangling record for each one anyone could claim
+// In-scope hosts are left to the scan itself
+func (o LookupOptions) recordSecondOrder(result *models.SubdomainResult) {
+	if result.HTTP == nil {
+		return
+	}
+	for _, host := range result.HTTP.Resources {
+		if o.inScope(host) || o.Exclude.Matches(host) {
+			continue
+		}
+		if finding, ok := o.danglingResource(host); ok {
+			result.Dangling = append(result.Dangling, finding)
+		}
+	}
+}
+
+// danglingResource checks a host serving page resources, once per scan since
+// many pages load from the same hosts
+func (o LookupOptions) danglingResource(host string) (models.DanglingRecord, bool) {
+	if cached, ok := o.dangling.resources.Load(host); ok {
+		finding := cached.(*models.DanglingRecord)
+		if finding == nil {
+			return models.DanglingRecord{}, false
+		}
+		return *finding, true
+	}
+
+	finding, ok := o.checkResourceHost(host)
+	if !ok {
+		o.dangling.resources.Store(host, (*models.DanglingRecord)(nil))
+		return models.DanglingRecord{}, false
+	}
+	o.dangling.resources.Store(host, &finding)
+	return finding, true
+}
+
+// checkResourceHost looks for the ways a resource host can be claimed: those
+// found in DNS by claimableHost, then the error page of an unclaimed resource
+// when takeover checks are enabled
+func (o LookupOptions) checkResourceHost(host string) (models.DanglingRecord, bool) {
+	finding := models.DanglingRecord{Type: models.DanglingResource, Target: host}
+
+	severity, detail, service := o.claimableHost(host)
+	if severity != "" {
+		finding.Severity, finding.Detail = severity, detail
+		return finding, true
+	}
+	if service == "" || o.Client == nil {
+		return models.DanglingRecord{}, false
+	}
+	// Resources are mostly loaded over HTTPS
+	probe := &models.SubdomainResult{Subdomain: host}
+	for _, scheme := range []string{"https", "http"} {
+		if evidence := fingerprintTakeover(o.Client, probe, scheme, service); evidence != nil {
+			finding.Severity = models.SeverityHigh
+			finding.Detail = probe.Takeover + " resource is unclaimed"
+			return finding, true
+		}
+	}
+	return models.DanglingRecord{}, false
+}
+
+// claimableHost looks for the ways a host outside the scan can be claimed through
+// DNS, from the cheapest to the most expensive: an unregistered domain, a CNAME
+// to a missing target and a missing name on a takeover-prone service
+// Returns the severity and detail of the finding, severity is empty when there is
+// none; service is the takeover-prone service the host points to, if any
+func (o LookupOptions) claimableHost(host string) (severity, detail, service string) {
+	if domain, ok := o.unregisteredDomain(host); ok {
+		return models.SeverityCritical, domain + " is not registered", ""
+	}
+
+	chain := utils.ResolveCNAMEChain(host, o.QueryResolver)
+	if cname, ok := o.danglingCNAME(chain); ok {
+		detail = "CNAME to missing " + cname.Target
+		if cname.Detail != "" {
+			detail += " (" + cname.Detail + ")"
+		}
+		return cname.Severity, detail, ""
+	}
+
+	// The host itself may be the name to claim, as with bucket.s3.amazonaws.com
+	service = TakeoverService(append([]string{host}, chain...))
+	if service != "" && len(chain) == 0 {
+		if missing, err := utils.IsNXDomain(host, o.QueryResolver); err == nil && missing {
+			return models.SeverityHigh, service + " name does not exist", service
+		}
+	}
+	return "", "", service
+}
@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/output"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// serviceRecordPass queries well-known SRV/TXT names under the root domain and
+// every discovered subdomain, and returns in-scope hosts not found so far
+// Hosts that do not resolve publicly are kept since they often are internal names
+func serviceRecordPass(domain string, found []models.SubdomainResult, opts LookupOptions, config ActiveScanConfig) []models.SubdomainResult {
+	resolver := utils.SystemResolver()
+	if len(opts.Resolvers) > 0 {
+		resolver = opts.Resolvers[0]
+	}
+
+	// Every name already known is skipped, new ones are queried in turn
+	seen := map[string]bool{domain: true}
+	toQuery := []string{domain}
+	for _, result := range found {
+		seen[result.Subdomain] = true
+		toQuery = append(toQuery, result.Subdomain)
+	}
+
+	workers := config.NumWorkers
+	if workers <= 0 {
+		workers = 10
+	}
+
+	var newResults []models.SubdomainResult
+	for len(toQuery) > 0 {
+		fmt.Printf("\n» Querying service records under %d names\n", len(toQuery))
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var next []string
+		nameChan := make(chan string)
+
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for name := range nameChan {
+					for _, leaked := range utils.DiscoverServiceHosts(name, resolver) {
+						host := leaked.Host
+						if host != domain && !utils.IsSubdomainOf(host, domain) {
+							continue
+						}
+
+						mu.Lock()
+						known := seen[host]
+						seen[host] = true
+						mu.Unlock()
+						if known || config.Exclude.Matches(host) {
+							continue
+						}
+
+						// Hosts past the query budget are reported without being resolved
+						var result models.SubdomainResult
+						resolved := false
+						if opts.queries.spend() {
+							result, resolved = checkSubdomain(host, opts)
+						}
+						if !resolved {
+							result = models.SubdomainResult{Subdomain: host}
+						}
+						result.Source = "srv:" + strings.ToLower(leaked.Record)
+
+						if config.Filter.Allows(host) {
+							output.DisplayResult(result, config.ShowIP)
+						}
+						config.publish(result)
+
+						mu.Lock()
+						newResults = append(newResults, result)
+						next = append(next, host)
+						mu.Unlock()
+					}
+				}
+			}()
+		}
+
+		for _, name := range toQuery {
+			nameChan <- name
+		}
+		close(nameChan)
+		wg.Wait()
+
+		toQuery = next
+	}
+
+	fmt.Printf("» Service records revealed %d new hosts\n", len(newResults))
+	return newResults
+}
@@ -0,0 +1,94 @@
+package scanner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/output"
+	"github.com/fkr00t/subcollector/internal/storage"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// ShowConfig holds the configuration for re-rendering a stored scan
+type ShowConfig struct {
+	Database        string // SQLite file or PostgreSQL URL holding the scan
+	ScanID          int64
+	ShowIP          bool
+	OutputFile      string
+	JsonOutputFile  string
+	HTMLOutputFile  string
+	CSVOutputFile   string
+	XMLOutputFile   string
+	SARIFOutputFile string
+	Sort            string              // Order of saved results, models.SortByName or models.SortByDiscovery
+	Format          *models.LineFormat  // Line format of the text output, nil for one subdomain per line
+	Filter          *utils.ResultFilter // Match/filter rules applied before display and saving
+}
+
+// ExecuteShow loads a scan from the database, prints its results and writes them in the requested formats
+func ExecuteShow(config ShowConfig) ([]models.SubdomainResult, error) {
+	store, err := storage.Open(config.Database)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	scan, err := store.Scan(config.ScanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scan #%d: %v", config.ScanID, err)
+	}
+	if scan.ID == 0 {
+		return nil, fmt.Errorf("no scan #%d in %s", config.ScanID, storage.Redact(config.Database))
+	}
+	results, err := store.ScanResults(scan.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results of scan #%d: %v", scan.ID, err)
+	}
+
+	// Annotations made after the scan are shown with its results
+	annotations, err := store.Annotations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read annotations: %v", err)
+	}
+	for i := range results {
+		if annotation, ok := annotations[results[i].Subdomain]; ok {
+			results[i].Tags = models.AddTags(results[i].Tags, annotation.Tags...)
+			if annotation.Note != "" {
+				results[i].Note = annotation.Note
+			}
+		}
+	}
+
+	fmt.Printf("\n» Scan #%d: %s scan of %s, %s (%s)\n\n", scan.ID, scan.Mode, scan.Domain,
+		scan.StartedAt.Local().Format("2006-01-02 15:04:05"), scan.FinishedAt.Sub(scan.StartedAt).Round(time.Second))
+
+	models.RateResults(results)
+	models.SortResults(results, config.Sort)
+	for _, result := range results {
+		if config.Filter.Allows(result.Subdomain) {
+			output.DisplayResult(result, config.ShowIP)
+		}
+	}
+	results = reportFilteredResults(results, config.Filter)
+	fmt.Printf("\n» %d subdomains\n", len(results))
+
+	// Outputs describe the original scan rather than this rendering
+	metadata := models.ScanMetadata{
+		Tool:       models.ToolInfo{Name: "subcollector", Version: scan.ToolVersion},
+		Mode:       scan.Mode,
+		StartedAt:  scan.StartedAt,
+		FinishedAt: scan.FinishedAt,
+		Config:     models.ScanConfigInfo{Fingerprint: scan.Fingerprint},
+	}
+	if config.OutputFile != "" || config.JsonOutputFile != "" {
+		output.SaveResults(config.OutputFile, config.JsonOutputFile, scan.Domain, results, metadata, config.Format)
+		fmt.Printf("» Results saved\n")
+	}
+	saveHTMLReport(config.HTMLOutputFile, scan.Domain, results, metadata)
+	saveCSV(config.CSVOutputFile, results, metadata)
+	saveXML(config.XMLOutputFile, scan.Domain, results, metadata)
+	saveSARIF(config.SARIFOutputFile, results, metadata)
+
+	return results, nil
+}
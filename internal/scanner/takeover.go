@@ -1,8 +1,9 @@
 package scanner
 
 import (
-	"io"
+	"context"
 	"net/http"
+	"net/http/httputil"
 	"strings"
 
 	"github.com/fkr00t/subcollector/internal/models"
@@ -78,20 +79,131 @@ var TakeoverPatterns = map[string]string{
 	"getresponse": "This landing page is unavailable or doesn't exist",
 }
 
+// takeoverCNAMEs maps CNAME target suffixes to the service of TakeoverPatterns
+// serving them, for the services whose names can be claimed by anyone
+// More specific suffixes must come before broader ones of the same service
+var takeoverCNAMEs = []takeoverCNAME{
+	{".s3.amazonaws.com", "aws_s3"},
+	{".amazonaws.com", "aws"},
+	{".cloudfront.net", "cloudfront"},
+	{".blob.core.windows.net", "azure_blob"},
+	{".azurewebsites.net", "azure"},
+	{".cloudapp.net", "azure"},
+	{".cloudapp.azure.com", "azure"},
+	{".trafficmanager.net", "azure"},
+	{".azureedge.net", "azure"},
+	{".storage.googleapis.com", "google_cloud_storage"},
+	{".digitaloceanspaces.com", "digitalocean_spaces"},
+	{".github.io", "github"},
+	{".herokuapp.com", "heroku"},
+	{".herokudns.com", "heroku"},
+	{".pantheonsite.io", "pantheon"},
+	{".acquia-sites.com", "acquia"},
+	{".ghost.io", "ghost"},
+	{".netlify.app", "netlify"},
+	{".netlify.com", "netlify"},
+	{".vercel.app", "vercel"},
+	{".vercel-dns.com", "vercel"},
+	{".firebaseapp.com", "firebase"},
+	{".web.app", "firebase"},
+	{".myshopify.com", "shopify"},
+	{".mybigcommerce.com", "bigcommerce"},
+	{".wixdns.net", "wix"},
+	{".squarespace.com", "squarespace"},
+	{".fastly.net", "fastly"},
+	{".wordpress.com", "wordpress"},
+	{".teamwork.com", "teamwork"},
+	{".helpjuice.com", "helpjuice"},
+	{".helpscoutdocs.com", "helpscout"},
+	{".zendesk.com", "zendesk"},
+	{".freshdesk.com", "freshdesk"},
+	{".custom.intercom.help", "intercom"},
+	{".cargocollective.com", "cargo"},
+	{".redirect.feedpress.me", "feedpress"},
+	{".surge.sh", "surge"},
+	{".proxy.webflow.com", "webflow"},
+	{".proxy-ssl.webflow.com", "webflow"},
+	{".statuspage.io", "statuspage"},
+	{".uservoice.com", "uservoice"},
+	{".thinkific.com", "thinkific"},
+	{".canny.io", "canny"},
+	{".stats.pingdom.com", "pingdom"},
+	{".tilda.ws", "tilda"},
+	{".unbouncepages.com", "unbounce"},
+	{".readme.io", "readme"},
+	{".gr8.com", "getresponse"},
+}
+
+// TakeoverService returns the takeover-prone service a CNAME chain points to,
+// checking the last target first
+// Returns an empty string when no target belongs to such a service
+func TakeoverService(chain []string) string {
+	cnames := currentFingerprints().cnames
+	for i := len(chain) - 1; i >= 0; i-- {
+		host := "." + strings.ToLower(strings.TrimSuffix(chain[i], "."))
+		for _, entry := range cnames {
+			if strings.HasSuffix(host, entry.suffix) {
+				return entry.service
+			}
+		}
+	}
+	return ""
+}
+
+// TakeoverEvidence is the HTTP exchange that matched a takeover pattern
+type TakeoverEvidence struct {
+	Pattern  string // Pattern found in the response body
+	Request  []byte // Request as sent, after redirects
+	Response []byte // Response headers and body
+}
+
 // CheckTakeover checks if a subdomain is vulnerable to takeover
 // Sends an HTTP request and checks for patterns indicating potential takeover
-func CheckTakeover(client *http.Client, result *models.SubdomainResult) {
-	resp, err := client.Get("http://" + result.Subdomain)
-	if err == nil {
-		defer resp.Body.Close()
-		body, err := io.ReadAll(resp.Body)
-		if err == nil {
-			for service, pattern := range TakeoverPatterns {
-				if strings.Contains(string(body), pattern) {
-					result.Takeover = service
-					break
-				}
-			}
+// Returns the matching exchange, or nil when no pattern matched
+func CheckTakeover(client *TakeoverClient, result *models.SubdomainResult) *TakeoverEvidence {
+	return fingerprintTakeover(client, result, "http", "")
+}
+
+// fingerprintTakeover requests the subdomain over scheme and checks the body for
+// takeover patterns, trying the pattern of the service expected from the CNAME
+// chain first since several services share a pattern
+// Returns the matching exchange, or nil when no pattern matched
+func fingerprintTakeover(client *TakeoverClient, result *models.SubdomainResult, scheme, expected string) *TakeoverEvidence {
+	// The evidence is dumped before the deadline is cancelled, dumping replays the request
+	ctx, cancel := context.WithTimeout(context.Background(), client.timeout)
+	defer cancel()
+
+	resp, body, err := client.get(ctx, scheme, result.Subdomain)
+	if err != nil {
+		return nil
+	}
+
+	patterns := currentFingerprints().patterns
+	if pattern, ok := patterns[expected]; ok && strings.Contains(string(body), pattern) {
+		result.Takeover = expected
+		return newTakeoverEvidence(resp, body, pattern)
+	}
+	for service, pattern := range patterns {
+		if strings.Contains(string(body), pattern) {
+			result.Takeover = service
+			return newTakeoverEvidence(resp, body, pattern)
 		}
 	}
+	return nil
+}
+
+// newTakeoverEvidence dumps the request and response of a matching exchange
+func newTakeoverEvidence(resp *http.Response, body []byte, pattern string) *TakeoverEvidence {
+	evidence := &TakeoverEvidence{Pattern: pattern}
+
+	// resp.Request is the last request sent when redirects were followed
+	if request, err := httputil.DumpRequestOut(resp.Request, false); err == nil {
+		evidence.Request = request
+	}
+
+	// The body was already consumed, so headers are dumped alone and the body appended
+	if header, err := httputil.DumpResponse(resp, false); err == nil {
+		evidence.Response = append(header, body...)
+	}
+	return evidence
 }
@@ -0,0 +1,199 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/interop"
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/output"
+	"github.com/fkr00t/subcollector/internal/telemetry"
+	"github.com/fkr00t/subcollector/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultTakeoverWorkers is the number of takeover checks running at once
+const DefaultTakeoverWorkers = 20
+
+// takeoverCheck holds what the hosts of a takeover check share
+type takeoverCheck struct {
+	client      *TakeoverClient
+	resolver    string // Resolver of the CNAME queries
+	evidenceDir string
+	trace       context.Context
+}
+
+// ExecuteTakeoverCheck runs only the takeover detection of active scans on the
+// hosts of a result file or host list, as read by ExecuteVerify: the CNAME chain
+// of each host is queried, hosts not pointing to a takeover-prone service are
+// skipped, the others are fingerprinted over HTTP then HTTPS and the evidence
+// of a match is saved. Nothing is enumerated and only findings are reported
+// Up to TakeoverWorkers hosts are checked at once, with the requests set by TakeoverHTTP
+func ExecuteTakeoverCheck(config ActiveScanConfig, inputFile string, domains []string) ([]models.SubdomainResult, error) {
+	config.Metadata.StartedAt = time.Now()
+	ctx, span := telemetry.Span(context.Background(), "check takeovers", attribute.String("input", inputFile))
+	defer span.End()
+	config.trace = ctx
+
+	if err := checkResolvers(config.Resolvers); err != nil {
+		telemetry.Fail(span, err)
+		return nil, err
+	}
+
+	fmt.Printf("\n» Checking %s for takeovers\n", inputFile)
+	loaded, format, err := interop.LoadResultFile(inputFile)
+	if err != nil {
+		fmt.Printf("× %v\n", err)
+		telemetry.Fail(span, err)
+		return nil, err
+	}
+
+	// Each in-scope host is checked once, findings of an earlier run are checked again
+	seen := make(map[string]bool, len(loaded))
+	var hosts []models.SubdomainResult
+	var names []string
+	for _, result := range loaded {
+		name := strings.TrimPrefix(result.Subdomain, "*.")
+		if name == "" || seen[name] || config.Exclude.Matches(name) {
+			continue
+		}
+		if len(domains) > 0 && !inDomains(name, domains) {
+			continue
+		}
+		seen[name] = true
+		result.Subdomain = name
+		result.Takeover = ""
+		result.Evidence = ""
+		hosts = append(hosts, result)
+		names = append(names, name)
+	}
+	fmt.Printf("» %d hosts read (%s), %d distinct in scope\n\n", len(loaded), format, len(hosts))
+
+	if len(domains) == 0 {
+		domains = registeredDomains(names)
+	}
+	target := strings.Join(domains, ",")
+
+	client, err := NewTakeoverClient(config.Proxy, config.TakeoverHTTP)
+	if err != nil {
+		fmt.Printf("× %v\n", err)
+		telemetry.Fail(span, err)
+		return nil, err
+	}
+
+	config.Sink.Begin(target, config.Metadata)
+	defer config.Sink.Close()
+
+	check := takeoverCheck{
+		client:      client,
+		resolver:    utils.SystemResolver(),
+		evidenceDir: config.EvidenceDir,
+		trace:       ctx,
+	}
+	if resolvers := processResolvers(config.Resolvers); len(resolvers) > 0 {
+		check.resolver = resolvers[0]
+	}
+	if check.evidenceDir == "" {
+		check.evidenceDir = DefaultEvidenceDir
+	}
+	workers := config.TakeoverWorkers
+	if workers <= 0 {
+		workers = DefaultTakeoverWorkers
+	}
+
+	bar := utils.CreateProgressBar(len(hosts))
+	writer := output.NewResultWriter(bar, config.ShowIP)
+	writer.SetFilter(config.Filter)
+	bar.Start()
+
+	candidates := make([]bool, len(hosts))
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				candidates[index] = check.check(&hosts[index])
+				if hosts[index].Takeover != "" {
+					result := hosts[index]
+					result.Tags = models.AddTags(result.Tags, config.Tags...)
+					result = models.RateResult(result)
+					writer.WriteResult(result)
+					config.publish(result)
+				}
+				bar.Increment()
+			}
+		}()
+	}
+	for i := range hosts {
+		indexes <- i
+		if config.RateLimit > 0 {
+			time.Sleep(time.Duration(config.RateLimit) * time.Millisecond)
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	bar.Finish()
+
+	var fingerprinted int
+	var results []models.SubdomainResult
+	for i, host := range hosts {
+		if candidates[i] {
+			fingerprinted++
+		}
+		if host.Takeover != "" {
+			results = append(results, host)
+		}
+	}
+
+	results = reportFilteredResults(results, config.Filter)
+	span.SetAttributes(attribute.Int("candidates", fingerprinted), attribute.Int("found", len(results)))
+
+	// Brief summary
+	fmt.Printf("\n» %d hosts point to takeover-prone services, %d possible takeovers\n", fingerprinted, len(results))
+	if len(results) > 0 {
+		fmt.Printf("» Evidence saved to %s\n", check.evidenceDir)
+	}
+
+	// Save results if requested
+	saveActiveResults(config, target, results)
+
+	return results, nil
+}
+
+// check fingerprints a host whose CNAME chain points to a takeover-prone service,
+// recording the chain, the service matched and the evidence file in the result
+// Returns false when the chain points to no such service and the host was skipped
+func (c takeoverCheck) check(result *models.SubdomainResult) bool {
+	_, span := telemetry.Span(c.trace, "takeover check", attribute.String("subdomain", result.Subdomain))
+	defer span.End()
+
+	// The chain is queried again, the one of an earlier run may have changed since
+	chain := utils.ResolveCNAMEChain(result.Subdomain, c.resolver)
+	service := TakeoverService(chain)
+	if service == "" {
+		return false
+	}
+	result.CNAME = chain
+
+	evidence := fingerprintTakeover(c.client, result, "http", service)
+	if evidence == nil {
+		evidence = fingerprintTakeover(c.client, result, "https", service)
+	}
+	if evidence == nil {
+		return true
+	}
+	span.SetAttributes(attribute.String("service", result.Takeover))
+
+	path, err := saveTakeoverEvidence(c.evidenceDir, *result, chain, evidence)
+	if err != nil {
+		fmt.Printf("\r\033[K× Failed to save takeover evidence for %s: %v\n", result.Subdomain, err)
+		return true
+	}
+	result.Evidence = path
+	return true
+}
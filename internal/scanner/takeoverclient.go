@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// How takeover fingerprinting requests handle redirects
+const (
+	RedirectFollow   = "follow"    // Follow up to 10 redirects to any host
+	RedirectSameHost = "same-host" // Follow redirects staying on the checked host, fingerprint the first leaving it
+	RedirectNone     = "none"      // Fingerprint the first response, a redirect included
+)
+
+// DefaultTakeoverTimeout is the time limit of each fingerprinting request
+const DefaultTakeoverTimeout = 5 * time.Second
+
+// DefaultTakeoverMaxBody is the number of bytes of a response body searched for takeover patterns
+const DefaultTakeoverMaxBody = 1 << 20
+
+// maxTakeoverRedirects is the number of redirects followed by one fingerprinting request
+const maxTakeoverRedirects = 10
+
+// TakeoverHTTPConfig configures the HTTP client of takeover fingerprinting
+// The zero value follows redirects, verifies certificates and speaks HTTP/2
+type TakeoverHTTPConfig struct {
+	Timeout      time.Duration // Deadline of each request, redirects and body included (0 for DefaultTakeoverTimeout)
+	Redirects    string        // Redirect policy, RedirectFollow when empty
+	Insecure     bool          // Skip certificate verification, unclaimed names often get the certificate of the provider
+	DisableHTTP2 bool          // Only speak HTTP/1.1
+	MaxBody      int64         // Bytes of a response body read (0 for DefaultTakeoverMaxBody)
+}
+
+// ValidateRedirectPolicy checks the redirect policy of takeover fingerprinting
+func ValidateRedirectPolicy(policy string) error {
+	switch policy {
+	case "", RedirectFollow, RedirectSameHost, RedirectNone:
+		return nil
+	}
+	return fmt.Errorf("invalid redirect policy %q, use %s, %s or %s", policy, RedirectFollow, RedirectSameHost, RedirectNone)
+}
+
+// TakeoverClient sends the requests of takeover fingerprinting
+type TakeoverClient struct {
+	client  *http.Client
+	timeout time.Duration
+	maxBody int64
+}
+
+// NewTakeoverClient creates the client of takeover checks, sending requests through proxy when given
+func NewTakeoverClient(proxy string, config TakeoverHTTPConfig) (*TakeoverClient, error) {
+	if err := ValidateRedirectPolicy(config.Redirects); err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		proxyURL, err := utils.ParseProxy(proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if config.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	// A non-nil empty map keeps the transport from upgrading to HTTP/2
+	if config.DisableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	client := &TakeoverClient{
+		client: &http.Client{
+			Transport:     utils.WrapTransport(transport),
+			CheckRedirect: redirectPolicy(config.Redirects),
+		},
+		timeout: config.Timeout,
+		maxBody: config.MaxBody,
+	}
+	if client.timeout <= 0 {
+		client.timeout = DefaultTakeoverTimeout
+	}
+	if client.maxBody <= 0 {
+		client.maxBody = DefaultTakeoverMaxBody
+	}
+	return client, nil
+}
+
+// redirectPolicy returns the CheckRedirect function of a redirect policy
+// Redirects that are not followed are returned as the response
+func redirectPolicy(policy string) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		switch {
+		case policy == RedirectNone:
+			return http.ErrUseLastResponse
+		case policy == RedirectSameHost && req.URL.Hostname() != via[0].URL.Hostname():
+			return http.ErrUseLastResponse
+		case len(via) >= maxTakeoverRedirects:
+			return fmt.Errorf("stopped after %d redirects", maxTakeoverRedirects)
+		}
+		return nil
+	}
+}
+
+// get requests scheme://host and returns the response with its body read up to the size cap
+// The body of the response is closed; ctx bounds the request and the redirects followed
+func (c *TakeoverClient) get(ctx context.Context, scheme, host string) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+host, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.maxBody))
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
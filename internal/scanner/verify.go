@@ -0,0 +1,95 @@
+package scanner
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// DefaultVerifyResolvers are the trusted resolvers used by the consensus pass when none are given
+var DefaultVerifyResolvers = []string{"1.1.1.1", "8.8.8.8", "9.9.9.9"}
+
+// verifyConsensus re-resolves every result through each trusted resolver and keeps
+// the ones confirmed by at least quorum of them; a quorum of 0 means a majority
+// Resolvers that disagree are recorded on the kept results
+func verifyConsensus(results []models.SubdomainResult, config ActiveScanConfig) []models.SubdomainResult {
+	if !config.Verify || len(results) == 0 {
+		return results
+	}
+
+	trusted := processResolvers(config.VerifyResolvers)
+	if len(trusted) == 0 {
+		trusted = DefaultVerifyResolvers
+	}
+	quorum := config.Quorum
+	if quorum <= 0 || quorum > len(trusted) {
+		quorum = len(trusted)/2 + 1
+	}
+
+	fmt.Printf("\n» Verifying %d results through %d resolvers (quorum %d)\n", len(results), len(trusted), quorum)
+
+	workers := config.NumWorkers
+	if workers <= 0 {
+		workers = 10
+	}
+
+	consensus := make([]*models.Consensus, len(results))
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				consensus[index] = resolveConsensus(results[index].Subdomain, trusted)
+			}
+		}()
+	}
+	for i := range results {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var kept []models.SubdomainResult
+	var dropped []int
+	for i, result := range results {
+		if consensus[i].Confirmed < quorum {
+			dropped = append(dropped, i)
+			continue
+		}
+		if len(consensus[i].Disagreements) > 0 {
+			result.Consensus = consensus[i]
+		}
+		kept = append(kept, result)
+	}
+
+	fmt.Printf("» %d confirmed, %d dropped\n", len(kept), len(dropped))
+	const maxShown = 10
+	for shown, index := range dropped {
+		if shown == maxShown {
+			fmt.Printf("    ... and %d more\n", len(dropped)-maxShown)
+			break
+		}
+		fmt.Printf("    - %s (%d/%d)\n", results[index].Subdomain, consensus[index].Confirmed, len(trusted))
+	}
+	return kept
+}
+
+// resolveConsensus asks every trusted resolver whether a host exists
+// Each vote goes to its own resolver, even for names split DNS routes elsewhere
+func resolveConsensus(host string, trusted []string) *models.Consensus {
+	consensus := &models.Consensus{Resolvers: len(trusted)}
+	for _, resolver := range trusted {
+		_, err := utils.LookupWithResolver(host, resolver)
+		status := utils.ClassifyLookupError(err)
+		if status == utils.StatusResolved {
+			consensus.Confirmed++
+			continue
+		}
+		consensus.Disagreements = append(consensus.Disagreements, resolver+": "+status.String())
+	}
+	return consensus
+}
@@ -0,0 +1,167 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/interop"
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/output"
+	"github.com/fkr00t/subcollector/internal/telemetry"
+	"github.com/fkr00t/subcollector/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/net/publicsuffix"
+)
+
+// ExecuteVerify resolves the subdomains of a list found by another tool (plain
+// lists, subfinder or amass JSON lines, subcollector JSON) and runs the enabled
+// checks (takeover, HTTP, TLS, ports, dangling DNS, ...) on them, then displays
+// and saves the results like an active scan. Nothing is enumerated
+// When domains are given, only names within them are kept
+func ExecuteVerify(config ActiveScanConfig, listFile string, domains []string) ([]models.SubdomainResult, error) {
+	config.Metadata.StartedAt = time.Now()
+	ctx, span := telemetry.Span(context.Background(), "verify list", attribute.String("list", listFile))
+	defer span.End()
+	config.trace = ctx
+
+	if err := checkResolvers(config.Resolvers, config.VerifyResolvers); err != nil {
+		telemetry.Fail(span, err)
+		return nil, err
+	}
+
+	fmt.Printf("\n» Verifying %s\n", listFile)
+	loaded, format, err := interop.LoadResultFile(listFile)
+	if err != nil {
+		fmt.Printf("× %v\n", err)
+		telemetry.Fail(span, err)
+		return nil, err
+	}
+
+	// Each in-scope name is checked once, wildcard entries stand for their parent
+	seen := make(map[string]bool, len(loaded))
+	var candidates []Candidate
+	for _, result := range loaded {
+		name := strings.TrimPrefix(result.Subdomain, "*.")
+		if name == "" || seen[name] || config.Exclude.Matches(name) {
+			continue
+		}
+		if len(domains) > 0 && !inDomains(name, domains) {
+			continue
+		}
+		seen[name] = true
+		candidates = append(candidates, Candidate{Name: name, Source: result.Source})
+	}
+	fmt.Printf("» %d names read (%s), %d distinct in scope\n\n", len(loaded), format, len(candidates))
+
+	// Without -d/-l the names are scoped to their registered domains
+	if len(domains) == 0 {
+		names := make([]string, len(candidates))
+		for i, candidate := range candidates {
+			names[i] = candidate.Name
+		}
+		domains = registeredDomains(names)
+	}
+	target := strings.Join(domains, ",")
+
+	config.Sink.Begin(target, config.Metadata)
+	defer config.Sink.Close()
+
+	stats := NewLookupStats()
+	opts := newLookupOptions(
+		processResolvers(config.Resolvers),
+		models.NewDNSCache(),
+		setupTakeoverClient(config.Takeover, config.Proxy, config.TakeoverHTTP),
+		config,
+		stats,
+	)
+	opts.Scope = domains
+	// Names that do not resolve are reported too, so they can be counted
+	opts.KeepUnresolved = true
+
+	bar := utils.CreateProgressBar(len(candidates))
+	writer := output.NewResultWriter(bar, config.ShowIP)
+	writer.SetFilter(config.Filter)
+	bar.Start()
+
+	engine := &Engine{
+		Options: opts,
+		Workers: config.NumWorkers,
+		Pause:   time.Duration(config.RateLimit) * time.Millisecond,
+		Output: func(result models.SubdomainResult) {
+			if !live(result) {
+				return
+			}
+			result.Tags = models.AddTags(result.Tags, config.Tags...)
+			writer.WriteResult(models.RateResult(result))
+			config.publish(result)
+		},
+		Progress: func() { bar.Increment() },
+	}
+	checked := engine.Run(ctx, ListProducer(candidates))
+	bar.Finish()
+
+	counts := make(map[string]int)
+	// Names that do not exist are only kept on request, past the consensus pass
+	var results, dead []models.SubdomainResult
+	for _, result := range checked {
+		counts[result.Resolution]++
+		switch {
+		case !live(result):
+			if config.KeepUnresolved {
+				dead = append(dead, result)
+			}
+		case config.KeepUnresolved:
+			results = append(results, result)
+		default:
+			result.Resolution = ""
+			results = append(results, result)
+		}
+	}
+
+	results = append(verifyConsensus(results, config), dead...)
+	results = reportFilteredResults(results, config.Filter)
+	span.SetAttributes(attribute.Int("found", len(results)))
+
+	// Brief summary
+	fmt.Printf("\n» %d live, %d do not exist, %d without an authoritative answer\n",
+		len(checked)-counts[models.ResolutionUnresolvable]-counts[models.ResolutionUnknown],
+		counts[models.ResolutionUnresolvable], counts[models.ResolutionUnknown])
+	reportLookupStats(stats, config.RecheckFile)
+	if config.Group || config.HTTP || config.Tech {
+		reportHostGroups(results)
+	}
+	reportEmailPosture(&config, domains, results)
+
+	// Save results if requested
+	saveActiveResults(config, target, results)
+
+	return results, nil
+}
+
+// live reports whether a checked name exists; names that no longer resolve but
+// carry a finding, such as a dangling CNAME, have no resolution and count as live
+func live(result models.SubdomainResult) bool {
+	return result.Resolution != models.ResolutionUnresolvable && result.Resolution != models.ResolutionUnknown
+}
+
+// registeredDomains returns the registered domains (example.co.uk for
+// a.b.example.co.uk) of the names, sorted
+func registeredDomains(names []string) []string {
+	seen := make(map[string]bool)
+	var domains []string
+	for _, name := range names {
+		domain, err := publicsuffix.EffectiveTLDPlusOne(name)
+		if err != nil {
+			domain = name
+		}
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	sort.Strings(domains)
+	return domains
+}
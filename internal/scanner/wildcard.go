@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// wildcardProbes is the number of random names resolved to learn the answer of a wildcard
+// Wildcards behind load balancers rotate addresses, two answers cover most of them
+const wildcardProbes = 2
+
+// wildcardCache remembers per-scan answers of the recursion guard
+type wildcardCache struct {
+	answers   sync.Map // Name -> addresses random names under it resolve to, nil when they do not exist
+	delegated sync.Map // Name -> whether its parent zone delegates it to nameservers of its own
+}
+
+// recursionSkip returns why the names under a found subdomain are not worth brute forcing, "" when they are
+// Names under it are skipped when random ones resolve: either it is a zone of its own, delegated to
+// its own nameservers, with a wildcard record, or a wildcard above it answers for everything below
+func (o LookupOptions) recursionSkip(name string) string {
+	if o.wildcards == nil || len(o.wildcardAnswer(name)) == 0 {
+		return ""
+	}
+	if o.delegated(name) {
+		return "its zone has a wildcard record"
+	}
+	return "covered by a wildcard record above it"
+}
+
+// wildcardAnswer returns the addresses random names under name resolve to, nil when they do not exist
+func (o LookupOptions) wildcardAnswer(name string) []string {
+	if cached, ok := o.wildcards.answers.Load(name); ok {
+		return cached.([]string)
+	}
+
+	var answer []string
+	for i := 0; i < wildcardProbes && o.queries.spend(); i++ {
+		addresses, status := resolveSubdomain(wildcardLabel()+"."+name, o.Resolvers, nil)
+		if status != utils.StatusResolved {
+			break
+		}
+		answer = append(answer, addresses...)
+	}
+	o.wildcards.answers.Store(name, answer)
+	return answer
+}
+
+// delegated reports whether the scanned root domain delegates name to nameservers of its own
+func (o LookupOptions) delegated(name string) bool {
+	if cached, ok := o.wildcards.delegated.Load(name); ok {
+		return cached.(bool)
+	}
+
+	var delegated bool
+	if zone := o.zoneOf(name); zone != "" && zone != name {
+		if server := o.authoritativeServer(zone); server != "" {
+			nameservers, err := utils.QueryDelegation(name, server)
+			delegated = err == nil && len(nameservers) > 0
+		}
+	}
+	o.wildcards.delegated.Store(name, delegated)
+	return delegated
+}
+
+// recursionParents returns the found subdomains worth brute forcing under, printing the others
+func (o LookupOptions) recursionParents(names []string) []string {
+	var parents []string
+	for _, name := range names {
+		if reason := o.recursionSkip(name); reason != "" {
+			fmt.Printf("» Not recursing under %s: %s\n", name, reason)
+			continue
+		}
+		parents = append(parents, name)
+	}
+	return parents
+}
+
+// resultNames returns the subdomains of results
+func resultNames(results []models.SubdomainResult) []string {
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, result.Subdomain)
+	}
+	return names
+}
+
+// wildcardLabel returns a label no zone is expected to contain
+func wildcardLabel() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "sc-" + hex.EncodeToString(buf)
+}
@@ -1,106 +1,414 @@
 package scanner
 
 import (
+	"context"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/cheggaaa/pb/v3"
+	"github.com/fkr00t/subcollector/internal/cloud"
+	"github.com/fkr00t/subcollector/internal/email"
 	"github.com/fkr00t/subcollector/internal/models"
-	"github.com/fkr00t/subcollector/internal/output"
+	"github.com/fkr00t/subcollector/internal/probe"
+	"github.com/fkr00t/subcollector/internal/rdap"
 	"github.com/fkr00t/subcollector/internal/utils"
 )
 
-// Worker is a concurrent worker function for active scanning
-// Processes subdomains from a channel and sends results to another channel
-// Each worker handles DNS lookups and optional takeover checks
-func Worker(
-	subdomainChan <-chan string, // Channel to receive subdomains to check
-	resultChan chan<- models.SubdomainResult, // Channel to send results
-	resolvers []string, // List of DNS resolvers to use
-	cache *models.DNSCache, // Cache to avoid duplicate lookups
-	client *http.Client, // HTTP client for takeover detection
-	bar *pb.ProgressBar, // Progress bar for visual feedback
-	resultWriter *output.ResultWriter, // Writer for real-time result display
-	wg *sync.WaitGroup, // WaitGroup for synchronization
-	showIP bool, // Whether to include IP addresses in results
-	rateLimit int, // Rate limiting in milliseconds between requests
-	streamOutput chan<- models.SubdomainResult, // Channel for streaming results
-) {
-	defer wg.Done()
-
-	for subdomain := range subdomainChan {
-		var result models.SubdomainResult
-
-		// Check cache first
-		if cachedResult, ok := cache.Load(subdomain); ok {
-			// Use cached DNS result if available
-			if cachedResult.Found {
-				result = models.SubdomainResult{Subdomain: subdomain, IPs: cachedResult.IPs}
-				if client != nil {
-					// Check for potential takeover
-					CheckTakeover(client, &result)
-				}
-				resultChan <- result
+// LookupOptions bundles everything needed to check a single candidate
+type LookupOptions struct {
+	Resolvers       []string        // List of DNS resolvers to use
+	Cache           models.Cache    // Cache to avoid duplicate lookups
+	Client          *TakeoverClient // HTTP client for takeover detection (nil disables it)
+	ShowIP          bool            // Whether to include IP addresses in results (always on when grouping)
+	DNSSEC          bool            // Whether to record the DNSSEC validation status
+	TLS             bool            // Whether to grab the certificate served on port 443
+	Ports           []int           // TCP ports to check on resolved addresses (empty disables it)
+	Group           bool            // Whether to record the CNAME chain and provider used for grouping
+	HTTP            *http.Client    // HTTP client for web server fingerprinting (nil disables it)
+	RDAP            *rdap.Client    // Client looking up the networks of resolved IPs (nil disables it)
+	Tech            bool            // Whether to detect the technologies of probed web servers
+	SecondOrder     bool            // Whether to check the hosts serving page resources of probed web servers
+	Dangling        bool            // Whether to look for CNAMEs, addresses and delegations left dangling
+	Email           bool            // Whether to analyze the MX, SPF, DKIM and DMARC records
+	KeepUnresolved  bool            // Whether to report names that do not resolve, with their Resolution
+	QueryResolver   string          // Resolver used for DNSSEC and CNAME queries
+	EvidenceDir     string          // Directory receiving takeover evidence files
+	TakeoverWorkers int             // Takeover checks running at once in an engine (0 for DefaultTakeoverWorkers)
+	Stats           *LookupStats    // Counters for lookup outcomes
+	LimitBy         string          // What rate limits, backoff and concurrency caps are charged to, LimitByRoot when empty
 
-				// Write results in real-time
-				if resultWriter != nil {
-					resultWriter.WriteResult(result)
-				}
+	Scope     []string                 // Root domains newly observed hosts must belong to
+	Exclude   *utils.ExcludeList       // Hosts never queried nor reported
+	seen      *sync.Map                // Hosts already reported, shared by all workers
+	ports     *sync.Map                // Open ports per address, many subdomains share addresses
+	deadline  time.Time                // End of the time budget, queued candidates are dropped after it
+	queries   *queryBudget             // Lookups left to the scan, candidates and follow-up queries are dropped once spent (nil for no cap)
+	guard     *resolverGuard           // Drops resolvers caught lying by canary queries (nil disables it)
+	dangling  *danglingCache           // Answers shared by dangling record checks
+	wildcards *wildcardCache           // Answers shared by the recursion guard (nil disables it)
+	backoff   *adaptiveBackoff         // Slows down limit keys whose lookups keep failing (nil disables it)
+	trace     context.Context          // Span of the DNS batch running, parent of the takeover checks; ends with the scan
+	limiter   *utils.DomainRateLimiter // Spacing of the lookups per limit key, follow-up queries wait for it too (nil for none)
+	pause     time.Duration            // Pause of a worker after each lookup, also taken before follow-up queries
 
-				if streamOutput != nil {
-					streamOutput <- result
-				}
+	deferTakeover bool // Takeover checks are left to the takeover workers of the engine
+	deferRDAP     bool // RDAP lookups are left to the RDAP workers of the engine, results carry their addresses until then
+
+	authorities   *utils.KeyedSemaphore // Caps the lookups running at once per limit key (nil for no cap)
+	authorityKeys *sync.Map             // Zone -> *authorityKey, limit keys of LimitByAuthority
+}
+
+// newLookupOptions creates LookupOptions for a scan
+func newLookupOptions(resolvers []string, cache models.Cache, client *TakeoverClient, config ActiveScanConfig, stats *LookupStats) LookupOptions {
+	opts := LookupOptions{
+		Resolvers:   resolvers,
+		Cache:       cache,
+		Client:      client,
+		ShowIP:      config.ShowIP || config.Group,
+		DNSSEC:      config.DNSSEC,
+		TLS:         config.TLS,
+		Ports:       config.Ports,
+		Group:       config.Group,
+		Stats:       stats,
+		EvidenceDir: config.EvidenceDir,
+		Scope:       []string{config.Domain},
+		Exclude:     config.Exclude,
+		seen:        &sync.Map{},
+		ports:       &sync.Map{},
+		deadline:    config.deadline,
+		queries:     config.queries,
+		Dangling:    config.Dangling,
+		Email:       config.Email,
+		dangling:    &danglingCache{},
+		wildcards:   &wildcardCache{},
+	}
+
+	if config.HTTP || config.Tech || config.SecondOrder {
+		opts.HTTP = probe.NewHTTPClient(config.Proxy)
+		opts.Tech = config.Tech
+		opts.SecondOrder = config.SecondOrder
+	}
+
+	if config.RDAP {
+		opts.RDAP = rdap.ClientFor(config.Proxy)
+	}
+
+	opts.LimitBy = config.LimitBy
+	opts.TakeoverWorkers = config.TakeoverWorkers
+	opts.authorities = utils.NewKeyedSemaphore(config.MaxPerAuthority)
+	opts.authorityKeys = &sync.Map{}
+
+	// The backoff starts at the rate limit of the scan
+	backoff := config.Backoff
+	if backoff.BaseDelay <= 0 {
+		backoff.BaseDelay = time.Duration(config.RateLimit) * time.Millisecond
+	}
+	opts.backoff = newAdaptiveBackoff(backoff)
+
+	if config.CanaryInterval > 0 && len(resolvers) > 0 {
+		opts.guard = newResolverGuard(resolvers, config.CanaryInterval)
+	}
+
+	if client != nil && opts.EvidenceDir == "" {
+		opts.EvidenceDir = DefaultEvidenceDir
+	}
+
+	// DNSSEC status is only meaningful when asked to a validating resolver
+	// Takeover evidence, CNAME chains and TTLs are queried through it too
+	if len(resolvers) > 0 {
+		opts.QueryResolver = resolvers[0]
+	} else {
+		opts.QueryResolver = utils.SystemResolver()
+	}
+
+	return opts
+}
+
+// processCandidate checks a candidate and then every new in-scope host
+// its certificate names, calling emit once per subdomain found
+func processCandidate(candidate Candidate, opts LookupOptions, emit func(models.SubdomainResult)) {
+	queue := []models.SubdomainResult{{Subdomain: candidate.Name, Source: candidate.Source}}
+
+	for len(queue) > 0 {
+		candidate := queue[0]
+		queue = queue[1:]
+
+		result, found := checkSubdomain(candidate.Subdomain, opts)
+		if !found || !opts.claim(result.Subdomain) {
+			continue
+		}
+		result.Source = candidate.Source
+		emit(result)
+
+		// Certificate SANs feed the scan with names the wordlist may not contain
+		for _, host := range opts.certHosts(result) {
+			queue = append(queue, models.SubdomainResult{Subdomain: host, Source: "tls-san:" + result.Subdomain})
+		}
+	}
+}
+
+// claim marks a host as reported, returning false if it already was
+func (o LookupOptions) claim(host string) bool {
+	if o.seen == nil {
+		return true
+	}
+	_, loaded := o.seen.LoadOrStore(host, true)
+	return !loaded
+}
+
+// certHosts returns the SAN hostnames of a result that are in scope and not reported yet
+func (o LookupOptions) certHosts(result models.SubdomainResult) []string {
+	if result.TLS == nil {
+		return nil
+	}
+
+	var hosts []string
+	for _, san := range result.TLS.SANs {
+		host := strings.ToLower(strings.TrimPrefix(san, "*."))
+		if !o.inScope(host) || o.Exclude.Matches(host) {
+			continue
+		}
+		if o.seen != nil {
+			if _, known := o.seen.Load(host); known {
+				continue
 			}
-		} else {
-			var addresses []string
-			var err error
-			if len(resolvers) > 0 {
-				// Try each resolver until one succeeds
-				for _, resolver := range resolvers {
-					addresses, err = utils.LookupWithResolver(subdomain, resolver)
-					if err == nil {
-						break
-					}
-				}
-			} else {
-				// Use default system resolver
-				addresses, err = utils.DefaultLookup(subdomain)
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// inScope reports whether a host belongs to one of the scanned root domains
+func (o LookupOptions) inScope(host string) bool {
+	return inDomains(host, o.Scope)
+}
+
+// zoneOf returns the scanned root domain a subdomain belongs to, the longest when nested
+func (o LookupOptions) zoneOf(subdomain string) string {
+	var zone string
+	for _, root := range o.Scope {
+		if inDomains(subdomain, []string{root}) && len(root) > len(zone) {
+			zone = root
+		}
+	}
+	return zone
+}
+
+// checkSubdomain resolves a single candidate, consulting the cache first
+// Returns the result and whether the subdomain exists
+func checkSubdomain(subdomain string, opts LookupOptions) (models.SubdomainResult, bool) {
+	var result models.SubdomainResult
+	var addresses []string
+	var records utils.RecordSet
+
+	// Check cache first
+	if cachedResult, ok := opts.Cache.Load(subdomain); ok {
+		// Use cached DNS result if available
+		if !cachedResult.Found {
+			if opts.KeepUnresolved {
+				return models.SubdomainResult{Subdomain: subdomain, Resolution: models.ResolutionUnresolvable}, true
 			}
+			return models.SubdomainResult{}, false
+		}
+		result = models.SubdomainResult{Subdomain: subdomain, IPs: cachedResult.IPs}
+		addresses = cachedResult.IPs
+	} else {
+		// Resolvers caught lying are left out
+		resolvers := opts.Resolvers
+		if opts.guard != nil {
+			resolvers = opts.guard.Active()
+		}
 
-			if err == nil {
-				// Subdomain exists
-				cache.Store(subdomain, models.DNSResult{Found: true, IPs: addresses})
-				result = models.SubdomainResult{Subdomain: subdomain}
-				if showIP {
-					result.IPs = addresses
-				}
-				if client != nil {
-					// Check for potential takeover
-					CheckTakeover(client, &result)
-				}
-				resultChan <- result
+		var status utils.LookupStatus
+		var answeredBy string
+		var elapsed time.Duration
+		var key, backoffKey string
+		if opts.authorities != nil {
+			key = opts.limitKey(subdomain)
+		}
+		if opts.backoff != nil {
+			backoffKey = opts.backoffKey(subdomain)
+		}
+		// Lookups of concurrent scans are told apart by their root domain
+		log := scannerLog
+		if utils.DebugEnabled() {
+			if zone := opts.zoneOf(subdomain); zone != "" {
+				log = log.With("domain", zone)
+			}
+		}
 
-				// Write results in real-time
-				if resultWriter != nil {
-					resultWriter.WriteResult(result)
-				}
+		// The answers of dangling checks are kept, they hold the CNAME chain of names that do not resolve
+		opts.authorities.Acquire(key)
+		answer := lookupSubdomain(subdomain, resolvers, opts.Stats, log, opts.Dangling, opts.queries)
+		opts.authorities.Release(key)
+		addresses, status, answeredBy, elapsed = answer.addresses, answer.status, answer.resolver, answer.elapsed
+		records = answer.records
+		opts.backoff.record(backoffKey, status)
 
-				if streamOutput != nil {
-					streamOutput <- result
+		if status != utils.StatusResolved {
+			// Subdomain doesn't exist
+			// Non-authoritative failures are not cached so they can be re-checked
+			if status == utils.StatusNXDomain {
+				opts.Cache.Store(subdomain, models.DNSResult{Found: false})
+			}
+			// Names that do not resolve can still point at something claimable
+			if opts.Dangling {
+				if result, ok := opts.checkUnresolved(subdomain, status, records); ok {
+					return result, true
 				}
-			} else {
-				// Subdomain doesn't exist
-				cache.Store(subdomain, models.DNSResult{Found: false})
 			}
+			if opts.KeepUnresolved {
+				return models.SubdomainResult{Subdomain: subdomain, Resolution: resolutionOf(status)}, true
+			}
+			return models.SubdomainResult{}, false
+		}
+
+		// Subdomain exists
+		opts.Cache.Store(subdomain, models.DNSResult{Found: true, IPs: addresses})
+		if opts.guard != nil {
+			opts.guard.recordAnswer(subdomain, answeredBy)
 		}
+		result = models.SubdomainResult{Subdomain: subdomain}
+		if opts.ShowIP || opts.deferRDAP {
+			result.IPs = addresses
+		}
+
+		// Response time and TTL hint at load balancers, anycast and freshly created records
+		// A and AAAA are queried together, their answers also hold the CNAME chain
+		result.ResponseTime = float64(elapsed.Microseconds()) / 1000
+		recordResolver := answeredBy
+		if recordResolver == "" {
+			recordResolver = opts.QueryResolver
+		}
+		if records == nil && opts.queries.spend() {
+			records, _ = utils.QueryTypes(subdomain, utils.AddressTypes, recordResolver)
+		}
+		result.TTL, _ = records.TTL()
+	}
+
+	if opts.KeepUnresolved {
+		result.Resolution = models.ResolutionResolvable
+	}
+	enrichResult(&result, addresses, records, opts)
+	return result, true
+}
 
-		// Update progress bar
-		bar.Increment()
+// resolutionOf maps the status of a failed lookup to the Resolution of a kept name
+func resolutionOf(status utils.LookupStatus) string {
+	if status == utils.StatusNXDomain {
+		return models.ResolutionUnresolvable
+	}
+	return models.ResolutionUnknown
+}
 
-		// Rate limiter
-		if rateLimit > 0 {
-			time.Sleep(time.Duration(rateLimit) * time.Millisecond)
+// enrichResult runs the optional per-result checks on a found subdomain
+// addresses are the resolved IPs, which the result only carries when ShowIP is set
+// records are the address answers of the subdomain, nil when they were not queried
+func enrichResult(result *models.SubdomainResult, addresses []string, records utils.RecordSet, opts LookupOptions) {
+	if opts.DNSSEC {
+		result.DNSSEC = string(utils.CheckDNSSEC(result.Subdomain, opts.QueryResolver))
+	}
+
+	if opts.Group {
+		if records != nil {
+			result.CNAME = records.CNAMEChain(result.Subdomain)
+		} else {
+			result.CNAME = utils.ResolveCNAMEChain(result.Subdomain, opts.QueryResolver)
 		}
+		result.Provider = utils.DetectProvider(result.CNAME)
+	}
+
+	tagProvider(result, addresses)
+
+	if opts.RDAP != nil && !opts.deferRDAP {
+		// Networks are cached by the client, most addresses cost no request
+		result.Networks = opts.RDAP.LookupAll(opts.scanContext(), addresses)
+	}
+
+	if opts.TLS {
+		// Hosts without TLS on 443 are common, a failed handshake is not an error
+		result.TLS, _ = probe.GrabCertificate(result.Subdomain, 443, probe.DefaultTLSTimeout)
+	}
+
+	if len(opts.Ports) > 0 {
+		result.Ports = opts.openPorts(addresses)
+	}
+
+	if opts.HTTP != nil {
+		result.HTTP = probe.ProbeHTTP(opts.HTTP, result.Subdomain, opts.Tech, opts.SecondOrder)
+	}
+
+	if opts.Email {
+		result.Email = email.Analyze(result.Subdomain, opts.zoneOf(result.Subdomain), opts.QueryResolver)
+	}
+
+	if opts.Dangling {
+		opts.recordDangling(result, addresses)
+	}
+
+	if opts.SecondOrder {
+		opts.recordSecondOrder(result)
+	}
+
+	if opts.Client != nil && !opts.deferTakeover {
+		// Check for potential takeover, keeping the evidence of a match
+		opts.recordTakeover(result)
+	}
+}
+
+// recordNetworks looks up the networks of the addresses a result carries for the RDAP workers,
+// then drops the addresses unless they are shown
+func (o LookupOptions) recordNetworks(result *models.SubdomainResult) {
+	result.Networks = o.RDAP.LookupAll(o.scanContext(), result.IPs)
+	if !o.ShowIP {
+		result.IPs = nil
+	}
+}
+
+// scanContext returns the context of the DNS batch running, which ends with the scan
+func (o LookupOptions) scanContext() context.Context {
+	if o.trace == nil {
+		return context.Background()
+	}
+	return o.trace
+}
+
+// tagProvider records the cloud provider and region owning the resolved addresses
+// A provider already detected from the CNAME chain is kept as it is more specific
+func tagProvider(result *models.SubdomainResult, addresses []string) {
+	match, ok := cloud.Default().LookupAll(addresses)
+	if !ok {
+		return
+	}
+	if result.Provider == "" {
+		result.Provider = match.Provider
+	}
+	result.Region = match.Region
+}
+
+// openPorts scans the configured ports on every address and returns the union of open ports
+// Each address is only scanned once per scan
+func (o LookupOptions) openPorts(addresses []string) []int {
+	found := make(map[int]bool)
+	for _, ip := range addresses {
+		var open []int
+		if cached, ok := o.ports.Load(ip); ok {
+			open = cached.([]int)
+		} else {
+			open = probe.ScanPorts(ip, o.Ports, probe.DefaultPortTimeout)
+			o.ports.Store(ip, open)
+		}
+		for _, port := range open {
+			found[port] = true
+		}
+	}
+
+	var ports []int
+	for port := range found {
+		ports = append(ports, port)
 	}
+	sort.Ints(ports)
+	return ports
 }
@@ -0,0 +1,76 @@
+package server
+
+import (
+	pb "github.com/fkr00t/subcollector/api/subcollector/v1"
+	"github.com/fkr00t/subcollector/internal/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toProto converts a result to its message, field for field with the JSON output
+func toProto(result models.SubdomainResult) *pb.SubdomainResult {
+	msg := &pb.SubdomainResult{
+		Subdomain:  result.Subdomain,
+		Ips:        result.IPs,
+		Ttl:        result.TTL,
+		ResponseMs: result.ResponseTime,
+		Takeover:   result.Takeover,
+		Dnssec:     result.DNSSEC,
+		Source:     result.Source,
+		Cname:      result.CNAME,
+		Provider:   result.Provider,
+		Region:     result.Region,
+		Evidence:   result.Evidence,
+		Unverified: result.Unverified,
+		Resolution: result.Resolution,
+	}
+	for _, port := range result.Ports {
+		msg.Ports = append(msg.Ports, int32(port))
+	}
+
+	if cert := result.TLS; cert != nil {
+		msg.Tls = &pb.Certificate{
+			Subject:   cert.Subject,
+			Issuer:    cert.Issuer,
+			Sans:      cert.SANs,
+			NotBefore: timestamppb.New(cert.NotBefore),
+			NotAfter:  timestamppb.New(cert.NotAfter),
+		}
+	}
+	if consensus := result.Consensus; consensus != nil {
+		msg.Consensus = &pb.Consensus{
+			Confirmed:     int32(consensus.Confirmed),
+			Resolvers:     int32(consensus.Resolvers),
+			Disagreements: consensus.Disagreements,
+		}
+	}
+	if http := result.HTTP; http != nil {
+		msg.Http = &pb.HTTPInfo{
+			Url:         http.URL,
+			StatusCode:  int32(http.StatusCode),
+			Title:       http.Title,
+			Server:      http.Server,
+			FaviconUrl:  http.FaviconURL,
+			FaviconMmh3: http.FaviconHash,
+		}
+		for _, tech := range http.Technologies {
+			msg.Http.Technologies = append(msg.Http.Technologies, &pb.Technology{Name: tech.Name, Category: tech.Category, Version: tech.Version})
+		}
+	}
+	for _, record := range result.Dangling {
+		msg.Dangling = append(msg.Dangling, &pb.DanglingRecord{Type: record.Type, Severity: record.Severity, Target: record.Target, Detail: record.Detail})
+	}
+	if email := result.Email; email != nil {
+		msg.Email = &pb.EmailPosture{
+			Domain:        email.Domain,
+			Mx:            email.MX,
+			Spf:           email.SPF,
+			SpfIncludes:   email.SPFIncludes,
+			Dmarc:         email.DMARC,
+			DkimSelectors: email.DKIM,
+		}
+		for _, issue := range email.Issues {
+			msg.Email.Issues = append(msg.Email.Issues, &pb.EmailIssue{Issue: issue.Issue, Severity: issue.Severity, Detail: issue.Detail})
+		}
+	}
+	return msg
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	pb "github.com/fkr00t/subcollector/api/subcollector/v1"
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/scanner"
+	"github.com/fkr00t/subcollector/internal/sink"
+	"github.com/fkr00t/subcollector/internal/sources"
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// Scan is a validated scan request with the configuration it runs with
+type Scan struct {
+	Domain string
+	Mode   pb.ScanMode
+
+	words   []string
+	active  scanner.ActiveScanConfig
+	passive scanner.PassiveScanConfig
+}
+
+// NewScan validates a request and applies its fields to the base configurations:
+// values given by the request replace those of the base, enabled checks are added to them
+func NewScan(req *pb.ScanRequest, active scanner.ActiveScanConfig, passive scanner.PassiveScanConfig) (*Scan, error) {
+	domain := utils.CleanDomain(req.GetDomain())
+	// Shards of a distributed scan are brute forced under parents found by the coordinator, www. included
+	if len(req.GetWords()) > 0 {
+		domain = strings.TrimSpace(req.GetDomain())
+	}
+	if domain == "" {
+		return nil, errors.New("a domain is required")
+	}
+	for _, word := range req.GetWords() {
+		if word == "" || strings.ContainsAny(word, " \t\r\n") {
+			return nil, fmt.Errorf("invalid word %q", word)
+		}
+	}
+	// Files of the server are never read on behalf of a request: resolvers are
+	// addresses or URLs and exclusions are patterns, none of them a path
+	for _, resolver := range req.GetResolvers() {
+		if err := checkResolver(resolver); err != nil {
+			return nil, err
+		}
+	}
+	// Exclusions of the request replace those of the server
+	var exclude *utils.ExcludeList
+	if len(req.GetExclude()) > 0 {
+		for _, pattern := range req.GetExclude() {
+			if !strings.HasPrefix(pattern, "re:") && strings.ContainsAny(pattern, `/\`) {
+				return nil, fmt.Errorf("invalid exclude pattern %q, files cannot be given in requests", pattern)
+			}
+		}
+		list, err := utils.NewExcludeListFromPatterns(req.GetExclude())
+		if err != nil {
+			return nil, err
+		}
+		exclude = list
+	}
+	var selected []sources.Source
+	if len(req.GetSources()) > 0 {
+		list, err := sources.Select(req.GetSources(), nil)
+		if err != nil {
+			return nil, err
+		}
+		selected = list
+	}
+	mode := req.GetMode()
+	if mode == pb.ScanMode_SCAN_MODE_UNSPECIFIED {
+		mode = pb.ScanMode_SCAN_MODE_ACTIVE
+	}
+
+	scan := &Scan{Domain: domain, Mode: mode, words: req.GetWords()}
+	if mode == pb.ScanMode_SCAN_MODE_PASSIVE {
+		config := passive
+		config.Domain = domain
+		config.Verify = config.Verify || req.GetVerify()
+		if selected != nil {
+			config.Sources = selected
+		}
+		if len(req.GetResolvers()) > 0 {
+			config.Resolvers = req.GetResolvers()
+		}
+		if req.GetWorkers() > 0 {
+			config.NumWorkers = int(req.GetWorkers())
+		}
+		if req.GetRateLimitMs() > 0 {
+			config.RateLimit = int(req.GetRateLimitMs())
+		}
+		if exclude != nil {
+			config.Exclude = exclude
+		}
+		config.Metadata.Mode = "passive"
+		scan.passive = config
+		return scan, nil
+	}
+
+	config := active
+	config.Domain = domain
+	config.ShowIP = true
+	if req.GetWordlist() != "" {
+		config.WordlistPath = req.GetWordlist()
+	}
+	if req.GetDepth() != 0 {
+		config.Depth = int(req.GetDepth())
+	}
+	// Copied so concurrent scans never append to the same array
+	config.Ports = append([]int(nil), config.Ports...)
+	for _, port := range req.GetPorts() {
+		config.Ports = append(config.Ports, int(port))
+	}
+	if req.GetTimeoutSeconds() > 0 {
+		config.TimeoutTotal = time.Duration(req.GetTimeoutSeconds()) * time.Second
+	}
+	config.Recursive = config.Recursive || req.GetRecursive()
+	config.Takeover = config.Takeover || req.GetTakeover()
+	config.DNSSEC = config.DNSSEC || req.GetDnssec()
+	config.TLS = config.TLS || req.GetTls()
+	config.HTTP = config.HTTP || req.GetHttp() || req.GetTech()
+	config.Tech = config.Tech || req.GetTech()
+	config.Dangling = config.Dangling || req.GetDangling()
+	config.Verify = config.Verify || req.GetVerify()
+	if len(req.GetResolvers()) > 0 {
+		config.Resolvers = req.GetResolvers()
+	}
+	if req.GetWorkers() > 0 {
+		config.NumWorkers = int(req.GetWorkers())
+	}
+	if req.GetRateLimitMs() > 0 {
+		config.RateLimit = int(req.GetRateLimitMs())
+	}
+	if exclude != nil {
+		config.Exclude = exclude
+	}
+	config.Metadata.Mode = "active"
+	scan.active = config
+	return scan, nil
+}
+
+// checkResolver accepts the IP address of a resolver, with an optional port, or a tcp://,
+// tls:// or https:// URL; anything else could name a file of the server
+func checkResolver(resolver string) error {
+	invalid := fmt.Errorf("invalid resolver %q, use an IP address or a tcp://, tls:// or https:// URL", resolver)
+	parsed, err := utils.ParseResolver(resolver)
+	if err != nil {
+		return invalid
+	}
+	if parsed.Protocol == utils.ResolverUDP {
+		if _, err := netip.ParseAddr(parsed.Host); err != nil {
+			return invalid
+		}
+	}
+	return nil
+}
+
+// ClientError returns the error of a scan as told to its client: failures to read
+// a file of the server are reported by their kind, never with its path or content
+func ClientError(err error) string {
+	for _, kind := range []error{scanner.ErrResolverLoad, scanner.ErrWordlistNotFound, scanner.ErrWordlistRead} {
+		if errors.Is(err, kind) {
+			return kind.Error()
+		}
+	}
+	return err.Error()
+}
+
+// ModeName returns the mode of the scan as written in outputs: active or passive
+func (s *Scan) ModeName() string {
+	if s.Mode == pb.ScanMode_SCAN_MODE_PASSIVE {
+		return "passive"
+	}
+	return "active"
+}
+
+// Metadata returns the metadata the scan starts with
+func (s *Scan) Metadata() models.ScanMetadata {
+	if s.Mode == pb.ScanMode_SCAN_MODE_PASSIVE {
+		return s.passive.Metadata
+	}
+	return s.active.Metadata
+}
+
+// Run runs the scan and returns its results
+// The scan stops looking up new names once ctx is done and returns what it found so far
+// When results is not nil, it replaces the sinks of the base configuration and receives every result as it is confirmed
+func (s *Scan) Run(ctx context.Context, results sink.Sink) ([]models.SubdomainResult, error) {
+	if s.Mode == pb.ScanMode_SCAN_MODE_PASSIVE {
+		config := s.passive
+		config.Context = ctx
+		if results != nil {
+			config.Sink = sink.Sinks{results}
+		}
+		return scanner.ExecutePassiveScan(config)
+	}
+
+	config := s.active
+	config.Context = ctx
+	if results != nil {
+		config.Sink = sink.Sinks{results}
+	}
+	// Shards of a distributed scan bring their words with them
+	if len(s.words) > 0 {
+		path, err := writeWords(s.words)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store words: %v", err)
+		}
+		defer os.Remove(path)
+		config.WordlistPath = path
+	}
+	return scanner.ExecuteActiveScan(config)
+}
+
+// writeWords stores the words of a request in a temporary wordlist
+func writeWords(words []string) (string, error) {
+	file, err := os.CreateTemp("", "subcollector-words-*.txt")
+	if err != nil {
+		return "", err
+	}
+	_, err = file.WriteString(strings.Join(words, "\n") + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/fkr00t/subcollector/api/subcollector/v1"
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/scanner"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Defaults of the gRPC server
+const (
+	DefaultAddress  = "127.0.0.1:50051"
+	DefaultMaxScans = 1
+)
+
+// Options configures the gRPC server
+type Options struct {
+	Address  string // Listen address
+	MaxScans int    // Scans run at once, further calls wait for a free slot
+	Token    string // Bearer token clients must send, no authentication when empty
+	CertFile string // TLS certificate, plaintext when empty
+	KeyFile  string
+
+	// Settings of every scan, request fields override or extend them
+	Active  scanner.ActiveScanConfig
+	Passive scanner.PassiveScanConfig
+}
+
+// Serve listens on the configured address and runs scans requested over gRPC until the listener fails
+func Serve(opts Options) error {
+	if opts.MaxScans <= 0 {
+		opts.MaxScans = DefaultMaxScans
+	}
+
+	// Coordinators ping quiet streams to notice nodes that went away
+	serverOptions := []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: 10 * time.Second, PermitWithoutStream: true}),
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificate: %v", err)
+		}
+		serverOptions = append(serverOptions, grpc.Creds(creds))
+	}
+	if opts.Token != "" {
+		serverOptions = append(serverOptions,
+			grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				if err := authorize(ctx, opts.Token); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}),
+			grpc.StreamInterceptor(func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if err := authorize(stream.Context(), opts.Token); err != nil {
+					return err
+				}
+				return handler(srv, stream)
+			}),
+		)
+	}
+
+	listener, err := net.Listen("tcp", opts.Address)
+	if err != nil {
+		return err
+	}
+
+	server := grpc.NewServer(serverOptions...)
+	pb.RegisterScanServiceServer(server, &scanService{active: opts.Active, passive: opts.Passive, slots: make(chan struct{}, opts.MaxScans)})
+	// Lets grpcurl and similar tools discover the API without the .proto file
+	reflection.Register(server)
+
+	fmt.Printf("» gRPC API listening on %s (max scans: %d, tls: %t, token: %t)\n",
+		listener.Addr(), opts.MaxScans, opts.CertFile != "", opts.Token != "")
+	return server.Serve(listener)
+}
+
+// authorize checks the bearer token of a call
+func authorize(ctx context.Context, token string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		given, found := strings.CutPrefix(value, "Bearer ")
+		if found && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
+
+// scanService implements the ScanService of the .proto
+type scanService struct {
+	pb.UnimplementedScanServiceServer
+	active  scanner.ActiveScanConfig
+	passive scanner.PassiveScanConfig
+	slots   chan struct{} // One entry per running scan
+}
+
+// Scan runs one scan and streams its results
+func (s *scanService) Scan(req *pb.ScanRequest, stream pb.ScanService_ScanServer) error {
+	scan, err := NewScan(req, s.active, s.passive)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Queue behind running scans until the client gives up
+	select {
+	case s.slots <- struct{}{}:
+	case <-stream.Context().Done():
+		return status.FromContextError(stream.Context().Err()).Err()
+	}
+	defer func() { <-s.slots }()
+
+	results := &streamSink{stream: stream}
+	started := &pb.ScanStarted{Domain: scan.Domain, Mode: scan.Mode, StartedAt: timestamppb.Now(), ToolVersion: s.active.Metadata.Tool.Version}
+	if err := stream.Send(&pb.ScanEvent{Event: &pb.ScanEvent_Started{Started: started}}); err != nil {
+		return err
+	}
+
+	// A client that cancels or disconnects ends the scan, which frees its slot
+	_, scanErr := scan.Run(stream.Context(), results)
+
+	if err := stream.Context().Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	if err := results.err(); err != nil {
+		return err
+	}
+	finished := &pb.ScanFinished{Results: int32(results.count()), FinishedAt: timestamppb.Now()}
+	if scanErr != nil {
+		finished.Error = ClientError(scanErr)
+	}
+	return stream.Send(&pb.ScanEvent{Event: &pb.ScanEvent_Finished{Finished: finished}})
+}
+
+// streamSink sends each result to the client as soon as the scan confirms it
+// Send blocks while the client does not read, which holds back the scan workers
+type streamSink struct {
+	stream pb.ScanService_ScanServer
+
+	mu      sync.Mutex // Send must not be called concurrently
+	sent    int
+	sendErr error
+}
+
+func (s *streamSink) Begin(string, models.ScanMetadata) {}
+
+func (s *streamSink) Add(result models.SubdomainResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Once the client is gone the remaining results are dropped
+	if s.sendErr != nil {
+		return
+	}
+	if err := s.stream.Send(&pb.ScanEvent{Event: &pb.ScanEvent_Result{Result: toProto(result)}}); err != nil {
+		s.sendErr = err
+		return
+	}
+	s.sent++
+}
+
+func (s *streamSink) Close() error { return nil }
+
+func (s *streamSink) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sent
+}
+
+func (s *streamSink) err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sendErr
+}
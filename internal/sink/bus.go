@@ -0,0 +1,195 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// Defaults of the message bus sink
+const (
+	busQueueSize  = 4096             // Results waiting to be published before Add blocks
+	busBatchSize  = 200              // Most messages sent in one request
+	busTimeout    = 10 * time.Second // Connection and request timeout
+	busRetryDelay = 2 * time.Second
+)
+
+// message is one result ready to be published
+type message struct {
+	key   []byte // Subdomain, the Kafka partition key
+	value []byte // JSON document
+}
+
+// publisher delivers messages to a broker
+type publisher interface {
+	// publish sends a batch and waits for the broker to accept it
+	publish(messages []message) error
+	// close drops the connection, the next publish reconnects
+	close()
+}
+
+// Bus publishes every result as a JSON message to a Kafka topic or a NATS subject as soon as it is confirmed
+// Results are sent in the background, batching those confirmed while the previous request was in flight
+// All methods are safe to call on a nil Bus and then do nothing
+type Bus struct {
+	destination string // Printable location, without credentials
+	publisher   publisher
+
+	sending sync.RWMutex // Held by Add while queueing, Close waits for it before closing the queue
+	queue   chan message
+	done    chan struct{}
+
+	mu        sync.Mutex
+	scan      scanInfo
+	published int
+	failed    int
+	lastErr   error
+}
+
+// NewBus creates a sink publishing to rawURL, either kafka://broker[,broker...]/topic
+// or nats://[user:pass@|token@]host:4222/subject
+func NewBus(rawURL string) (*Bus, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid message bus URL %q, expected kafka://broker:9092/topic or nats://host:4222/subject", rawURL)
+	}
+	name := strings.Trim(u.Path, "/")
+
+	bus := &Bus{}
+	switch u.Scheme {
+	case "kafka":
+		if !validKafkaTopic(name) {
+			return nil, fmt.Errorf("invalid Kafka topic %q, use letters, digits, '.', '_' and '-'", name)
+		}
+		bus.publisher = newKafkaPublisher(strings.Split(u.Host, ","), name)
+	case "nats":
+		if !validNATSSubject(name) {
+			return nil, fmt.Errorf("invalid NATS subject %q, use dot separated tokens without spaces or wildcards", name)
+		}
+		bus.publisher = newNATSPublisher(u.Host, name, u.User)
+	default:
+		return nil, fmt.Errorf("unsupported message bus %q, expected kafka:// or nats://", u.Scheme)
+	}
+
+	u.User = nil
+	bus.destination = u.String()
+	return bus, nil
+}
+
+// Begin starts publishing the results of a scan of target
+// The sink can be reused by calling Begin again after Close
+func (b *Bus) Begin(target string, metadata models.ScanMetadata) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	b.scan = newScanInfo(target, metadata)
+	b.published, b.failed, b.lastErr = 0, 0, nil
+	b.mu.Unlock()
+
+	b.sending.Lock()
+	b.queue = make(chan message, busQueueSize)
+	b.done = make(chan struct{})
+	queue, done := b.queue, b.done
+	b.sending.Unlock()
+
+	go b.publishLoop(queue, done)
+}
+
+// Add queues a result for publishing
+func (b *Bus) Add(result models.SubdomainResult) {
+	if b == nil {
+		return
+	}
+
+	b.sending.RLock()
+	defer b.sending.RUnlock()
+	if b.queue == nil {
+		return
+	}
+
+	b.mu.Lock()
+	value, err := json.Marshal(b.scan.document(result))
+	if err != nil {
+		b.failed++
+		b.lastErr = err
+	}
+	b.mu.Unlock()
+	if err == nil {
+		// Blocks while the queue is full, slowing the scan down to the pace of the broker
+		b.queue <- message{key: []byte(result.Subdomain), value: value}
+	}
+}
+
+// Close publishes the queued results, disconnects and reports what was published
+func (b *Bus) Close() error {
+	if b == nil {
+		return nil
+	}
+
+	b.sending.Lock()
+	queue, done := b.queue, b.done
+	b.queue = nil
+	b.sending.Unlock()
+	if queue == nil {
+		return nil
+	}
+	close(queue)
+	<-done
+	b.publisher.close()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failed > 0 {
+		fmt.Printf("× %d results could not be published to %s: %v\n", b.failed, b.destination, b.lastErr)
+	}
+	if b.published > 0 {
+		fmt.Printf("» Published %d results to %s\n", b.published, b.destination)
+	}
+	return b.lastErr
+}
+
+// publishLoop sends queued messages until the queue is closed
+func (b *Bus) publishLoop(queue chan message, done chan struct{}) {
+	defer close(done)
+
+	for first := range queue {
+		batch := []message{first}
+	drain:
+		for len(batch) < busBatchSize {
+			select {
+			case msg, ok := <-queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, msg)
+			default:
+				break drain
+			}
+		}
+
+		// A restarted or briefly unreachable broker gets one more chance on a new connection
+		err := b.publisher.publish(batch)
+		if err != nil {
+			b.publisher.close()
+			time.Sleep(busRetryDelay)
+			err = b.publisher.publish(batch)
+		}
+
+		b.mu.Lock()
+		if err != nil {
+			b.publisher.close()
+			b.failed += len(batch)
+			b.lastErr = err
+		} else {
+			b.published += len(batch)
+		}
+		b.mu.Unlock()
+	}
+}
@@ -0,0 +1,489 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kafka protocol versions used: the oldest still accepted by Kafka 4 brokers,
+// which older brokers (0.11 and later) understand too
+const (
+	kafkaProduceKey      = 0
+	kafkaProduceVersion  = 3
+	kafkaMetadataKey     = 3
+	kafkaMetadataVersion = 4
+	kafkaClientID        = "subcollector"
+	kafkaMetadataRetries = 5 // Attempts while a newly created topic has no leader yet
+)
+
+// kafkaErrors names the error codes a producer commonly receives
+var kafkaErrors = map[int16]string{
+	2:  "CORRUPT_MESSAGE",
+	3:  "UNKNOWN_TOPIC_OR_PARTITION",
+	5:  "LEADER_NOT_AVAILABLE",
+	6:  "NOT_LEADER_OR_FOLLOWER",
+	7:  "REQUEST_TIMED_OUT",
+	10: "MESSAGE_TOO_LARGE",
+	19: "NOT_ENOUGH_REPLICAS",
+	29: "TOPIC_AUTHORIZATION_FAILED",
+	35: "UNSUPPORTED_VERSION",
+	87: "INVALID_RECORD",
+}
+
+// kafkaError describes a Kafka error code
+func kafkaError(code int16) error {
+	if name, ok := kafkaErrors[code]; ok {
+		return fmt.Errorf("kafka error %s (%d)", name, code)
+	}
+	return fmt.Errorf("kafka error %d", code)
+}
+
+// kafkaPublisher produces messages to a topic, keyed by subdomain
+// Messages are partitioned like the default Java producer, so all results of a subdomain land in one partition
+type kafkaPublisher struct {
+	bootstrap []string
+	topic     string
+
+	leaders []int32          // Leader of each partition, by partition index
+	brokers map[int32]string // Address of each broker, by node ID
+	conns   map[int32]net.Conn
+	nextID  int32
+}
+
+func newKafkaPublisher(bootstrap []string, topic string) *kafkaPublisher {
+	for i, address := range bootstrap {
+		if !strings.Contains(address, ":") {
+			bootstrap[i] = address + ":9092"
+		}
+	}
+	return &kafkaPublisher{bootstrap: bootstrap, topic: topic}
+}
+
+// publish sends one produce request to the leader of each partition receiving messages
+func (k *kafkaPublisher) publish(messages []message) error {
+	if k.leaders == nil {
+		if err := k.refreshMetadata(); err != nil {
+			return err
+		}
+	}
+
+	// Group messages by leader, then by partition
+	byLeader := make(map[int32]map[int32][]message)
+	for _, msg := range messages {
+		partition := kafkaPartition(msg.key, len(k.leaders))
+		leader := k.leaders[partition]
+		if byLeader[leader] == nil {
+			byLeader[leader] = make(map[int32][]message)
+		}
+		byLeader[leader][partition] = append(byLeader[leader][partition], msg)
+	}
+
+	for leader, partitions := range byLeader {
+		if err := k.produce(leader, partitions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// produce sends the record batches of several partitions to their leader and checks every partition was written
+func (k *kafkaPublisher) produce(leader int32, partitions map[int32][]message) error {
+	var body kafkaEncoder
+	body.nullableString("") // No transactional ID
+	body.int16(-1)          // Wait for all in-sync replicas
+	body.int32(int32(busTimeout / time.Millisecond))
+	body.int32(1)
+	body.string(k.topic)
+	body.int32(int32(len(partitions)))
+	for partition, messages := range partitions {
+		body.int32(partition)
+		body.bytes(recordBatch(messages, time.Now()))
+	}
+
+	conn, err := k.conn(leader)
+	if err != nil {
+		return err
+	}
+	response, err := k.roundTrip(conn, kafkaProduceKey, kafkaProduceVersion, body.Bytes())
+	if err != nil {
+		return err
+	}
+
+	dec := kafkaDecoder{data: response}
+	for topics := dec.int32(); topics > 0; topics-- {
+		dec.string()
+		for count := dec.int32(); count > 0; count-- {
+			dec.int32() // Partition
+			code := dec.int16()
+			dec.int64() // Base offset
+			dec.int64() // Log append time
+			if code != 0 && dec.err == nil {
+				return kafkaError(code)
+			}
+		}
+	}
+	return dec.err
+}
+
+// refreshMetadata asks a bootstrap broker for the brokers and the partition leaders of the topic
+// A missing topic is created by brokers allowing auto creation, its leader is then elected after a moment
+func (k *kafkaPublisher) refreshMetadata() error {
+	var lastErr error
+	for attempt := 0; attempt < kafkaMetadataRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+		for _, address := range k.bootstrap {
+			if lastErr = k.fetchMetadata(address); lastErr == nil {
+				return nil
+			}
+		}
+		var retriable interface{ Retriable() bool }
+		if !errors.As(lastErr, &retriable) {
+			break
+		}
+	}
+	return lastErr
+}
+
+// leaderPendingError is returned while a partition of the topic has no leader
+type leaderPendingError struct{ code int16 }
+
+func (e leaderPendingError) Error() string   { return kafkaError(e.code).Error() }
+func (e leaderPendingError) Retriable() bool { return true }
+
+// fetchMetadata reads the metadata of the topic from one broker
+func (k *kafkaPublisher) fetchMetadata(address string) error {
+	conn, err := net.DialTimeout("tcp", address, busTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var body kafkaEncoder
+	body.int32(1)
+	body.string(k.topic)
+	body.int8(1) // Allow auto topic creation
+	response, err := k.roundTrip(conn, kafkaMetadataKey, kafkaMetadataVersion, body.Bytes())
+	if err != nil {
+		return err
+	}
+
+	dec := kafkaDecoder{data: response}
+	dec.int32() // Throttle time
+	brokers := make(map[int32]string)
+	for count := dec.int32(); count > 0 && dec.err == nil; count-- {
+		node := dec.int32()
+		host := dec.string()
+		port := dec.int32()
+		dec.nullableString() // Rack
+		brokers[node] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	dec.nullableString() // Cluster ID
+	dec.int32()          // Controller ID
+
+	var leaders []int32
+	for topics := dec.int32(); topics > 0 && dec.err == nil; topics-- {
+		topicErr := dec.int16()
+		name := dec.string()
+		dec.int8() // Internal
+		partitions := make(map[int32]int32)
+		for count := dec.int32(); count > 0 && dec.err == nil; count-- {
+			partitionErr := dec.int16()
+			index := dec.int32()
+			leader := dec.int32()
+			dec.int32Array() // Replicas
+			dec.int32Array() // In-sync replicas
+			if partitionErr == 5 || leader < 0 {
+				topicErr = 5
+			}
+			partitions[index] = leader
+		}
+		if name != k.topic {
+			continue
+		}
+		switch topicErr {
+		case 0:
+		case 3, 5:
+			return leaderPendingError{topicErr}
+		default:
+			return kafkaError(topicErr)
+		}
+		leaders = make([]int32, len(partitions))
+		for index, leader := range partitions {
+			if int(index) >= len(leaders) {
+				return fmt.Errorf("kafka metadata lists partition %d of %d", index, len(partitions))
+			}
+			leaders[index] = leader
+		}
+	}
+	if dec.err != nil {
+		return dec.err
+	}
+	if len(leaders) == 0 {
+		return leaderPendingError{3}
+	}
+
+	k.leaders, k.brokers = leaders, brokers
+	return nil
+}
+
+// conn returns the connection to a broker, opening it when needed
+func (k *kafkaPublisher) conn(node int32) (net.Conn, error) {
+	if conn, ok := k.conns[node]; ok {
+		return conn, nil
+	}
+	address, ok := k.brokers[node]
+	if !ok {
+		return nil, fmt.Errorf("kafka metadata does not list broker %d", node)
+	}
+	conn, err := net.DialTimeout("tcp", address, busTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if k.conns == nil {
+		k.conns = make(map[int32]net.Conn)
+	}
+	k.conns[node] = conn
+	return conn, nil
+}
+
+// roundTrip sends one request and returns the response body following the correlation ID
+func (k *kafkaPublisher) roundTrip(conn net.Conn, apiKey, apiVersion int16, body []byte) ([]byte, error) {
+	k.nextID++
+	var header kafkaEncoder
+	header.int16(apiKey)
+	header.int16(apiVersion)
+	header.int32(k.nextID)
+	header.string(kafkaClientID)
+
+	frame := make([]byte, 4, 4+header.Len()+len(body))
+	binary.BigEndian.PutUint32(frame, uint32(header.Len()+len(body)))
+	frame = append(append(frame, header.Bytes()...), body...)
+
+	conn.SetDeadline(time.Now().Add(busTimeout))
+	if _, err := conn.Write(frame); err != nil {
+		return nil, err
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	response := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, err
+	}
+	if len(response) < 4 || int32(binary.BigEndian.Uint32(response)) != k.nextID {
+		return nil, fmt.Errorf("kafka response does not match request %d", k.nextID)
+	}
+	return response[4:], nil
+}
+
+// close drops every connection and the metadata, the next publish starts over from the bootstrap brokers
+func (k *kafkaPublisher) close() {
+	for _, conn := range k.conns {
+		conn.Close()
+	}
+	k.conns, k.leaders, k.brokers = nil, nil, nil
+}
+
+// recordBatch encodes messages as an uncompressed v2 record batch
+func recordBatch(messages []message, now time.Time) []byte {
+	timestamp := now.UnixMilli()
+
+	var records []byte
+	for i, msg := range messages {
+		var record []byte
+		record = append(record, 0)                     // Attributes
+		record = binary.AppendVarint(record, 0)        // Timestamp delta
+		record = binary.AppendVarint(record, int64(i)) // Offset delta
+		record = binary.AppendVarint(record, int64(len(msg.key)))
+		record = append(record, msg.key...)
+		record = binary.AppendVarint(record, int64(len(msg.value)))
+		record = append(record, msg.value...)
+		record = binary.AppendVarint(record, 0) // Headers
+
+		records = binary.AppendVarint(records, int64(len(record)))
+		records = append(records, record...)
+	}
+
+	// Fields covered by the CRC, from the attributes to the end of the batch
+	var tail kafkaEncoder
+	tail.int16(0) // No compression, create time timestamps
+	tail.int32(int32(len(messages) - 1))
+	tail.int64(timestamp)
+	tail.int64(timestamp)
+	tail.int64(-1) // No producer ID
+	tail.int16(-1) // No producer epoch
+	tail.int32(-1) // No base sequence
+	tail.int32(int32(len(messages)))
+	tail.Write(records)
+
+	var batch kafkaEncoder
+	batch.int64(0)                             // Base offset, assigned by the broker
+	batch.int32(int32(4 + 1 + 4 + tail.Len())) // Length of the rest of the batch
+	batch.int32(-1)                            // Partition leader epoch
+	batch.int8(2)                              // Magic
+	batch.int32(int32(crc32.Checksum(tail.Bytes(), crc32.MakeTable(crc32.Castagnoli))))
+	batch.Write(tail.Bytes())
+	return batch.Bytes()
+}
+
+// kafkaPartition picks the partition of a key like the default Java partitioner
+func kafkaPartition(key []byte, partitions int) int32 {
+	return int32((murmur2(key) & 0x7fffffff) % uint32(partitions))
+}
+
+// murmur2 is the hash of the Java client's default partitioner
+func murmur2(data []byte) uint32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+		r    = 24
+	)
+	length := len(data)
+	h := uint32(seed) ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h
+}
+
+// validKafkaTopic reports whether name is a legal topic name
+func validKafkaTopic(name string) bool {
+	if name == "" || name == "." || name == ".." || len(name) > 249 {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// kafkaEncoder writes big-endian protocol fields
+type kafkaEncoder struct {
+	bytes.Buffer
+}
+
+func (e *kafkaEncoder) int8(v int8)   { e.WriteByte(byte(v)) }
+func (e *kafkaEncoder) int16(v int16) { e.Buffer.Write(binary.BigEndian.AppendUint16(nil, uint16(v))) }
+func (e *kafkaEncoder) int32(v int32) { e.Buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(v))) }
+func (e *kafkaEncoder) int64(v int64) { e.Buffer.Write(binary.BigEndian.AppendUint64(nil, uint64(v))) }
+
+func (e *kafkaEncoder) string(v string) {
+	e.int16(int16(len(v)))
+	e.WriteString(v)
+}
+
+// nullableString writes an empty string as null
+func (e *kafkaEncoder) nullableString(v string) {
+	if v == "" {
+		e.int16(-1)
+		return
+	}
+	e.string(v)
+}
+
+func (e *kafkaEncoder) bytes(v []byte) {
+	e.int32(int32(len(v)))
+	e.Buffer.Write(v)
+}
+
+// kafkaDecoder reads big-endian protocol fields, remembering the first error
+type kafkaDecoder struct {
+	data []byte
+	err  error
+}
+
+// next returns the following n bytes, or nil once the response is exhausted
+func (d *kafkaDecoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.data) < n {
+		d.err = errors.New("truncated kafka response")
+		return nil
+	}
+	field := d.data[:n]
+	d.data = d.data[n:]
+	return field
+}
+
+func (d *kafkaDecoder) int8() int8 {
+	if b := d.next(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	if b := d.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	if b := d.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	if b := d.next(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) string() string {
+	return string(d.next(int(d.int16())))
+}
+
+func (d *kafkaDecoder) nullableString() string {
+	length := d.int16()
+	if length < 0 {
+		return ""
+	}
+	return string(d.next(int(length)))
+}
+
+func (d *kafkaDecoder) int32Array() {
+	for count := d.int32(); count > 0 && d.err == nil; count-- {
+		d.int32()
+	}
+}
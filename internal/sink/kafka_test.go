@@ -0,0 +1,102 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+	"time"
+)
+
+func TestMurmur2(t *testing.T) {
+	// Values of the Java client (org.apache.kafka.common.utils.Utils.murmur2)
+	tests := []struct {
+		data string
+		want int32
+	}{
+		{"21", -973932308},
+		{"foobar", -790332482},
+		{"a-little-bit-long-string", -985981536},
+		{"a-little-bit-longer-string", -1486304829},
+		{"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8", -58897971},
+		{"abc", 479470107},
+	}
+	for _, test := range tests {
+		if got := int32(murmur2([]byte(test.data))); got != test.want {
+			t.Errorf("murmur2(%q) = %d, want %d", test.data, got, test.want)
+		}
+	}
+}
+
+func TestKafkaPartition(t *testing.T) {
+	for _, key := range []string{"", "www.example.com", "api.example.com"} {
+		first := kafkaPartition([]byte(key), 12)
+		if first < 0 || first >= 12 {
+			t.Fatalf("kafkaPartition(%q, 12) = %d, out of range", key, first)
+		}
+		if again := kafkaPartition([]byte(key), 12); again != first {
+			t.Fatalf("kafkaPartition(%q) changed from %d to %d", key, first, again)
+		}
+	}
+}
+
+func TestRecordBatch(t *testing.T) {
+	now := time.UnixMilli(1700000000123)
+	messages := []message{
+		{key: []byte("www.example.com"), value: []byte(`{"subdomain":"www.example.com"}`)},
+		{key: []byte("api.example.com"), value: []byte(`{"subdomain":"api.example.com"}`)},
+	}
+	batch := recordBatch(messages, now)
+
+	if offset := int64(binary.BigEndian.Uint64(batch[0:])); offset != 0 {
+		t.Errorf("base offset = %d, want 0", offset)
+	}
+	if length := int(binary.BigEndian.Uint32(batch[8:])); length != len(batch)-12 {
+		t.Errorf("batch length = %d, want %d", length, len(batch)-12)
+	}
+	if magic := batch[16]; magic != 2 {
+		t.Errorf("magic = %d, want 2", magic)
+	}
+	tail := batch[21:]
+	if crc := binary.BigEndian.Uint32(batch[17:]); crc != crc32.Checksum(tail, crc32.MakeTable(crc32.Castagnoli)) {
+		t.Errorf("CRC %08x does not cover the batch", crc)
+	}
+	if lastOffset := int32(binary.BigEndian.Uint32(tail[2:])); lastOffset != 1 {
+		t.Errorf("last offset delta = %d, want 1", lastOffset)
+	}
+	if first := int64(binary.BigEndian.Uint64(tail[6:])); first != now.UnixMilli() {
+		t.Errorf("first timestamp = %d, want %d", first, now.UnixMilli())
+	}
+	if count := int32(binary.BigEndian.Uint32(tail[36:])); count != 2 {
+		t.Fatalf("record count = %d, want 2", count)
+	}
+
+	// Each record reads back with its offset delta, key and value
+	records := bytes.NewReader(tail[40:])
+	for i, msg := range messages {
+		length, _ := binary.ReadVarint(records)
+		record := make([]byte, length)
+		records.Read(record)
+
+		fields := bytes.NewReader(record[1:]) // After the attributes
+		binary.ReadVarint(fields)             // Timestamp delta
+		if delta, _ := binary.ReadVarint(fields); delta != int64(i) {
+			t.Errorf("record %d: offset delta = %d", i, delta)
+		}
+		keyLength, _ := binary.ReadVarint(fields)
+		key := make([]byte, keyLength)
+		fields.Read(key)
+		valueLength, _ := binary.ReadVarint(fields)
+		value := make([]byte, valueLength)
+		fields.Read(value)
+		if headers, _ := binary.ReadVarint(fields); headers != 0 || fields.Len() != 0 {
+			t.Errorf("record %d: %d headers and %d trailing bytes", i, headers, fields.Len())
+		}
+		if !bytes.Equal(key, msg.key) || !bytes.Equal(value, msg.value) {
+			t.Errorf("record %d = %q: %q, want %q: %q", i, key, value, msg.key, msg.value)
+		}
+	}
+	if records.Len() != 0 {
+		t.Errorf("%d bytes left after the records", records.Len())
+	}
+}
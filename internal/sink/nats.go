@@ -0,0 +1,180 @@
+package sink
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// natsInfo is the part of the server INFO message the publisher needs
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+	MaxPayload  int  `json:"max_payload"`
+}
+
+// natsConnect is the CONNECT message identifying the client
+type natsConnect struct {
+	Verbose   bool   `json:"verbose"`
+	Pedantic  bool   `json:"pedantic"`
+	TLS       bool   `json:"tls_required"`
+	User      string `json:"user,omitempty"`
+	Pass      string `json:"pass,omitempty"`
+	AuthToken string `json:"auth_token,omitempty"`
+	Name      string `json:"name"`
+	Lang      string `json:"lang"`
+	Version   string `json:"version"`
+	Protocol  int    `json:"protocol"`
+}
+
+// natsPublisher publishes messages to a subject over the NATS client protocol
+type natsPublisher struct {
+	address string
+	subject string
+	user    *url.Userinfo // user:pass, or a token as user name
+
+	conn       net.Conn
+	reader     *bufio.Reader
+	maxPayload int
+}
+
+func newNATSPublisher(address, subject string, user *url.Userinfo) *natsPublisher {
+	if !strings.Contains(address, ":") {
+		address += ":4222"
+	}
+	return &natsPublisher{address: address, subject: subject, user: user}
+}
+
+// connect opens a connection and waits for the server to accept the credentials
+func (n *natsPublisher) connect() error {
+	conn, err := net.DialTimeout("tcp", n.address, busTimeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(busTimeout))
+	n.conn, n.reader = conn, bufio.NewReader(conn)
+
+	line, err := n.readLine()
+	if err != nil {
+		n.close()
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		n.close()
+		return fmt.Errorf("unexpected greeting from %s: %q", n.address, line)
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		n.close()
+		return fmt.Errorf("unreadable server info: %v", err)
+	}
+	n.maxPayload = info.MaxPayload
+
+	if info.TLSRequired {
+		host, _, _ := net.SplitHostPort(n.address)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tlsConn.Handshake(); err != nil {
+			n.close()
+			return err
+		}
+		n.conn, n.reader = tlsConn, bufio.NewReader(tlsConn)
+	}
+
+	hello := natsConnect{TLS: info.TLSRequired, Name: "subcollector", Lang: "go", Version: "1.0.0", Protocol: 1}
+	if n.user != nil {
+		if pass, ok := n.user.Password(); ok {
+			hello.User, hello.Pass = n.user.Username(), pass
+		} else {
+			hello.AuthToken = n.user.Username()
+		}
+	}
+	data, _ := json.Marshal(hello)
+	if _, err := fmt.Fprintf(n.conn, "CONNECT %s\r\nPING\r\n", data); err != nil {
+		n.close()
+		return err
+	}
+	if err := n.waitPong(); err != nil {
+		n.close()
+		return err
+	}
+	return nil
+}
+
+// publish writes every message, then waits for the answer to a PING so errors reported by the server are seen
+func (n *natsPublisher) publish(messages []message) error {
+	if n.conn == nil {
+		if err := n.connect(); err != nil {
+			return err
+		}
+	}
+	n.conn.SetDeadline(time.Now().Add(busTimeout))
+
+	writer := bufio.NewWriter(n.conn)
+	for _, msg := range messages {
+		if n.maxPayload > 0 && len(msg.value) > n.maxPayload {
+			return fmt.Errorf("result %s exceeds the %d bytes payload limit of the server", msg.key, n.maxPayload)
+		}
+		fmt.Fprintf(writer, "PUB %s %d\r\n", n.subject, len(msg.value))
+		writer.Write(msg.value)
+		writer.WriteString("\r\n")
+	}
+	writer.WriteString("PING\r\n")
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return n.waitPong()
+}
+
+// waitPong reads server messages until PONG, answering server PINGs and returning -ERR messages
+func (n *natsPublisher) waitPong() error {
+	for {
+		line, err := n.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := n.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS server error: %s", strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'"))
+		}
+		// +OK and INFO updates need no answer
+	}
+}
+
+// readLine reads one protocol line without its CRLF
+func (n *natsPublisher) readLine() (string, error) {
+	line, err := n.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (n *natsPublisher) close() {
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn, n.reader = nil, nil
+	}
+}
+
+// validNATSSubject reports whether subject can be published to: dot separated tokens without wildcards
+func validNATSSubject(subject string) bool {
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n*>") {
+		return false
+	}
+	for _, token := range strings.Split(subject, ".") {
+		if token == "" {
+			return false
+		}
+	}
+	return true
+}
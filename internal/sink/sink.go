@@ -0,0 +1,84 @@
+package sink
+
+import (
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// Sink receives the results of a scan as they are confirmed
+type Sink interface {
+	// Begin starts receiving the results of a scan of target
+	Begin(target string, metadata models.ScanMetadata)
+	// Add queues one result
+	Add(result models.SubdomainResult)
+	// Close delivers the queued results and reports what was sent
+	Close() error
+}
+
+// Sinks sends results to every sink it holds
+// A nil Sinks is valid and does nothing, so scans call it unconditionally
+type Sinks []Sink
+
+// Begin starts a scan on every sink
+func (s Sinks) Begin(target string, metadata models.ScanMetadata) {
+	for _, sink := range s {
+		sink.Begin(target, metadata)
+	}
+}
+
+// Add queues a result on every sink
+func (s Sinks) Add(result models.SubdomainResult) {
+	for _, sink := range s {
+		sink.Add(result)
+	}
+}
+
+// Close closes every sink, returning the first error
+func (s Sinks) Close() error {
+	var firstErr error
+	for _, sink := range s {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// scanInfo holds the scan fields added to every document
+type scanInfo struct {
+	target    string
+	metadata  models.ScanMetadata
+	startedAt string
+}
+
+// newScanInfo records the scan a sink receives results from
+func newScanInfo(target string, metadata models.ScanMetadata) scanInfo {
+	return scanInfo{target: target, metadata: metadata, startedAt: metadata.StartedAt.Format(time.RFC3339Nano)}
+}
+
+// document is the sent form of a result: the result fields plus the scan they come from
+type document struct {
+	models.SubdomainResult
+	Timestamp     time.Time `json:"@timestamp"` // When the result was confirmed
+	Target        string    `json:"target"`     // Domain or domain list given to the scan
+	Mode          string    `json:"mode"`
+	ScanStartedAt time.Time `json:"scan_started_at"`
+	Tool          string    `json:"tool"`
+	Version       string    `json:"tool_version"`
+	Fingerprint   string    `json:"config_fingerprint,omitempty"` // Equal for scans run with the same settings
+}
+
+// document wraps a result with the fields of the scan
+func (s scanInfo) document(result models.SubdomainResult) document {
+	return document{
+		SubdomainResult: result,
+		Timestamp:       time.Now(),
+		Target:          s.target,
+		Mode:            s.metadata.Mode,
+		ScanStartedAt:   s.metadata.StartedAt,
+		Tool:            s.metadata.Tool.Name,
+		Version:         s.metadata.Tool.Version,
+		Fingerprint:     s.metadata.Config.Fingerprint,
+	}
+}
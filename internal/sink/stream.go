@@ -0,0 +1,55 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// StreamJSONL is the format of Stream: one JSON result per line (NDJSON)
+const StreamJSONL = "jsonl"
+
+// Stream writes every confirmed result as one JSON line as soon as it is added,
+// so a program reading the output acts on findings while the scan runs
+type Stream struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	err     error
+}
+
+// NewStream creates a sink writing results to w in format (jsonl, or its alias ndjson)
+func NewStream(format string, w io.Writer) (*Stream, error) {
+	if format != StreamJSONL && format != "ndjson" {
+		return nil, fmt.Errorf("unknown output stream format %q, use jsonl", format)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &Stream{encoder: encoder}, nil
+}
+
+// Begin does nothing, lines only carry results
+func (s *Stream) Begin(target string, metadata models.ScanMetadata) {}
+
+// Add writes a result on its own line
+// After a failed write, such as a reader that went away, results are dropped
+func (s *Stream) Add(result models.SubdomainResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	s.err = s.encoder.Encode(result)
+}
+
+// Close reports the first failed write
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return fmt.Errorf("output stream: %v", s.err)
+	}
+	return nil
+}
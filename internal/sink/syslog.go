@@ -0,0 +1,277 @@
+package sink
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// DefaultSyslogFacility is the facility of syslog messages unless another is given
+const DefaultSyslogFacility = "local0"
+
+// syslogSDID names the structured data element of every message
+// 32473 is the enterprise number reserved for documentation (RFC 5612), as no number is registered
+const syslogSDID = "subcollector@32473"
+
+// Syslog message IDs, one per event type
+const (
+	syslogScanStart = "SCAN_START"
+	syslogFinding   = "FINDING"
+	syslogScanEnd   = "SCAN_END"
+)
+
+// syslogFacilities maps facility names to their codes
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11, "ntp": 12, "security": 13, "console": 14,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// Syslog severities used by the sink
+const (
+	syslogCritical = 2
+	syslogError    = 3
+	syslogWarning  = 4
+	syslogNotice   = 5
+	syslogInfo     = 6
+)
+
+// Syslog forwards scan lifecycle events and every confirmed result to a syslog server as RFC 5424 messages
+// UDP sends one datagram per message; TCP and TLS use octet counting framing (RFC 6587)
+// All methods are safe to call on a nil Syslog and then do nothing
+type Syslog struct {
+	network  string // udp, tcp or tls
+	address  string
+	facility int
+	hostname string
+
+	mu       sync.Mutex
+	conn     net.Conn
+	scan     scanInfo
+	active   bool
+	findings int // Results of the current scan
+	sent     int
+	failed   int
+	lastErr  error
+}
+
+// NewSyslog creates a sink sending to rawURL (udp://host:514, tcp://host:514 or tls://host:6514)
+// with the given facility name
+func NewSyslog(rawURL, facility string) (*Syslog, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid syslog target %q, expected udp://host:514, tcp://host:514 or tls://host:6514", rawURL)
+	}
+	s := &Syslog{network: u.Scheme, address: u.Host}
+	switch u.Scheme {
+	case "udp", "tcp":
+		if u.Port() == "" {
+			s.address = net.JoinHostPort(u.Hostname(), "514")
+		}
+	case "tls":
+		if u.Port() == "" {
+			s.address = net.JoinHostPort(u.Hostname(), "6514")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported syslog transport %q, expected udp, tcp or tls", u.Scheme)
+	}
+
+	if facility == "" {
+		facility = DefaultSyslogFacility
+	}
+	code, ok := syslogFacilities[strings.ToLower(facility)]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q, expected a name such as local0-local7, user, daemon or security", facility)
+	}
+	s.facility = code
+
+	s.hostname, _ = os.Hostname()
+	if s.hostname == "" {
+		s.hostname = "-"
+	}
+	return s, nil
+}
+
+// Begin sends the start event of a scan of target
+func (s *Syslog) Begin(target string, metadata models.ScanMetadata) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scan = newScanInfo(target, metadata)
+	s.active = true
+	s.findings, s.sent, s.failed, s.lastErr = 0, 0, 0, nil
+	s.send(syslogInfo, syslogScanStart,
+		[][2]string{{"target", target}, {"mode", metadata.Mode}, {"config", metadata.Config.Fingerprint}},
+		fmt.Sprintf("%s scan of %s started", metadata.Mode, target))
+}
+
+// Add sends a finding event for a result, with the syslog severity matching the severity of the result
+func (s *Syslog) Add(result models.SubdomainResult) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.active {
+		return
+	}
+
+	params := [][2]string{{"target", s.scan.target}, {"mode", s.scan.metadata.Mode}, {"subdomain", result.Subdomain}}
+	message := "subdomain found: " + result.Subdomain
+	if len(result.IPs) > 0 {
+		params = append(params, [2]string{"ips", strings.Join(result.IPs, ",")})
+		message += " (" + strings.Join(result.IPs, ", ") + ")"
+	}
+	if len(result.CNAME) > 0 {
+		params = append(params, [2]string{"cname", strings.Join(result.CNAME, ",")})
+	}
+	if result.Source != "" {
+		params = append(params, [2]string{"source", result.Source})
+	}
+
+	// Results are rated by the scanner, results added by other callers are rated here
+	rating := result.Severity
+	if rating == "" {
+		rating = models.RateResult(result).Severity
+	}
+	severity := syslogSeverity(rating)
+	params = append(params, [2]string{"severity", rating})
+	if result.Takeover != "" {
+		params = append(params, [2]string{"takeover", result.Takeover})
+		message += ", takeover possible through " + result.Takeover
+	}
+	for _, record := range result.Dangling {
+		params = append(params, [2]string{"dangling", record.Type + " " + record.Target})
+		message += fmt.Sprintf(", dangling %s record to %s", record.Type, record.Target)
+	}
+
+	s.findings++
+	s.send(severity, syslogFinding, params, message)
+}
+
+// Close sends the end event of the scan and reports what was forwarded
+func (s *Syslog) Close() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.active {
+		return nil
+	}
+	s.send(syslogInfo, syslogScanEnd,
+		[][2]string{{"target", s.scan.target}, {"mode", s.scan.metadata.Mode}, {"findings", fmt.Sprint(s.findings)}},
+		fmt.Sprintf("%s scan of %s finished with %d findings", s.scan.metadata.Mode, s.scan.target, s.findings))
+	s.active = false
+
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	if s.failed > 0 {
+		fmt.Printf("× %d syslog messages could not be sent to %s: %v\n", s.failed, s.address, s.lastErr)
+	}
+	if s.sent > 0 {
+		fmt.Printf("» Sent %d syslog messages to %s\n", s.sent, s.address)
+	}
+	return s.lastErr
+}
+
+// send formats and writes one message, reconnecting once when the connection was lost
+// Must be called with the lock held
+func (s *Syslog) send(severity int, msgID string, params [][2]string, message string) {
+	line := s.format(severity, msgID, params, message)
+	if s.network != "udp" {
+		line = fmt.Sprintf("%d %s", len(line), line)
+	}
+
+	err := s.write(line)
+	if err != nil && s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+		err = s.write(line)
+	}
+	if err != nil {
+		s.failed++
+		s.lastErr = err
+		return
+	}
+	s.sent++
+}
+
+// write sends raw bytes, connecting first when needed
+func (s *Syslog) write(line string) error {
+	if s.conn == nil {
+		var err error
+		dialer := &net.Dialer{Timeout: busTimeout}
+		if s.network == "tls" {
+			s.conn, err = tls.DialWithDialer(dialer, "tcp", s.address, &tls.Config{ServerName: hostOf(s.address)})
+		} else {
+			s.conn, err = dialer.Dial(s.network, s.address)
+		}
+		if err != nil {
+			s.conn = nil
+			return err
+		}
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(busTimeout))
+	_, err := s.conn.Write([]byte(line))
+	return err
+}
+
+// format builds an RFC 5424 message: <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG
+func (s *Syslog) format(severity int, msgID string, params [][2]string, message string) string {
+	var sd strings.Builder
+	sd.WriteString("[" + syslogSDID)
+	for _, param := range params {
+		fmt.Fprintf(&sd, ` %s="%s"`, param[0], escapeSDValue(param[1]))
+	}
+	sd.WriteString("]")
+
+	app := s.scan.metadata.Tool.Name
+	if app == "" {
+		app = "subcollector"
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		s.facility*8+severity, time.Now().Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname, app, os.Getpid(), msgID, sd.String(), message)
+}
+
+// escapeSDValue escapes the characters RFC 5424 reserves in structured data values
+func escapeSDValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
+
+// syslogSeverity maps a finding severity to a syslog severity
+func syslogSeverity(severity string) int {
+	switch severity {
+	case models.SeverityCritical:
+		return syslogCritical
+	case models.SeverityHigh:
+		return syslogError
+	case models.SeverityMedium:
+		return syslogWarning
+	}
+	return syslogNotice
+}
+
+// hostOf returns the host part of a host:port address
+func hostOf(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
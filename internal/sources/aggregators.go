@@ -0,0 +1,150 @@
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+const (
+	rapidDNSBaseURL     = "https://rapiddns.io"
+	hackerTargetBaseURL = "https://api.hackertarget.com"
+
+	// rapidDNSMaxPages bounds how many result pages are fetched per domain
+	rapidDNSMaxPages = 20
+)
+
+func init() {
+	Register(rapidDNSSource{baseURL: rapidDNSBaseURL, scraper: politeScraper})
+	Register(hackerTargetSource{baseURL: hackerTargetBaseURL, scraper: politeScraper})
+}
+
+// rapidDNSSource scrapes the subdomain tables of rapiddns.io
+type rapidDNSSource struct {
+	baseURL string
+	scraper *scraper
+}
+
+// Name returns the source name used in flags and the keys file
+func (s rapidDNSSource) Name() string {
+	return "rapiddns"
+}
+
+// Enumerate walks the result pages of domain until one adds no new hostnames
+func (s rapidDNSSource) Enumerate(ctx context.Context, domain string) <-chan Finding {
+	out := make(chan Finding)
+
+	go func() {
+		defer close(out)
+
+		seen := make(map[string]bool)
+		for page := 1; page <= rapidDNSMaxPages; page++ {
+			body, err := s.scraper.get(ctx, fmt.Sprintf("%s/subdomain/%s?page=%d", s.baseURL, url.PathEscape(domain), page))
+			if err != nil {
+				send(ctx, out, Finding{Err: err})
+				return
+			}
+
+			added := 0
+			for _, host := range utils.ExtractHosts(body, domain) {
+				if !seen[host] {
+					seen[host] = true
+					added++
+					send(ctx, out, Finding{Host: host})
+				}
+			}
+			if added == 0 || ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// hackerTargetSource reads the host search CSV of HackerTarget
+// Free use is limited to a few queries per day; a key lifts the limit
+type hackerTargetSource struct {
+	baseURL string
+	scraper *scraper
+}
+
+// Name returns the source name used in flags and the keys file
+func (s hackerTargetSource) Name() string {
+	return "hackertarget"
+}
+
+// Enumerate reports the hosts of the host,ip lines HackerTarget returns
+// With keys configured, an exceeded quota is retried with the next key
+func (s hackerTargetSource) Enumerate(ctx context.Context, domain string) <-chan Finding {
+	out := make(chan Finding)
+
+	go func() {
+		defer close(out)
+
+		ring := KeysFor(s.Name())
+		key := ring.Current()
+		for attempt := 0; attempt < max(1, ring.Len()); attempt++ {
+			hosts, err := s.hostSearch(ctx, domain, key)
+			if err != nil {
+				send(ctx, out, Finding{Err: err})
+				if IsRateLimited(err) && ring.Len() > 1 {
+					key = ring.Rotate(key)
+					continue
+				}
+				return
+			}
+			for _, host := range hosts {
+				send(ctx, out, Finding{Host: host})
+			}
+			return
+		}
+	}()
+
+	return out
+}
+
+// CheckKey runs a host search for a well-known domain with key
+func (s hackerTargetSource) CheckKey(ctx context.Context, key string) error {
+	_, err := s.hostSearch(ctx, keyCheckDomain, key)
+	return err
+}
+
+// hostSearch fetches and parses the host search CSV of domain
+func (s hackerTargetSource) hostSearch(ctx context.Context, domain, key string) ([]string, error) {
+	query := url.Values{"q": {domain}}
+	if key != "" {
+		query.Set("apikey", key)
+	}
+	body, err := s.scraper.get(ctx, s.baseURL+"/hostsearch/?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	// Failures are reported as a plain text line with status 200
+	text := strings.TrimSpace(string(body))
+	switch {
+	case strings.HasPrefix(strings.ToLower(text), "api count exceeded"):
+		return nil, fmt.Errorf("hackertarget: quota exceeded")
+	case strings.HasPrefix(strings.ToLower(text), "error"):
+		if strings.Contains(strings.ToLower(text), "no records") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("hackertarget: %s", text)
+	}
+
+	var hosts []string
+	lines := bufio.NewScanner(bytes.NewReader(body))
+	for lines.Scan() {
+		host, _, found := strings.Cut(strings.TrimSpace(lines.Text()), ",")
+		if found && host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, lines.Err()
+}
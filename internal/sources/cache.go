@@ -0,0 +1,135 @@
+package sources
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// cacheDayFormat names the directory of each day in the cache
+const cacheDayFormat = "2006-01-02"
+
+// DefaultCacheDir returns where the answers of passive sources are cached
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "subcollector", "sources")
+}
+
+// Cache keeps the hostnames each source reported for a domain on disk, in one
+// file per source, domain and day, so scans of the same scope repeated within
+// a few days reuse them instead of spending API quota
+// Only complete runs are cached, a source that failed or timed out is queried again
+type Cache struct {
+	dir  string
+	days int // Days an answer is reused, the day it was fetched included
+}
+
+// NewCache opens the cache in dir, reusing answers of the last days days
+// Days older than that are removed
+func NewCache(dir string, days int) (*Cache, error) {
+	if days <= 0 {
+		return nil, fmt.Errorf("invalid source cache age %d, must be at least 1 day", days)
+	}
+	if dir == "" {
+		dir = DefaultCacheDir()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create source cache: %v", err)
+	}
+
+	cache := &Cache{dir: dir, days: days}
+	cache.prune()
+	return cache, nil
+}
+
+// Load returns the hostnames cached for source and domain, the most recent day first
+func (c *Cache) Load(source, domain string) ([]string, bool) {
+	if !cacheable(domain) {
+		return nil, false
+	}
+	today := time.Now()
+	for age := 0; age < c.days; age++ {
+		file, err := os.Open(c.path(today.AddDate(0, 0, -age), source, domain))
+		if err != nil {
+			continue
+		}
+		var hosts []string
+		lines := bufio.NewScanner(file)
+		for lines.Scan() {
+			if host := strings.TrimSpace(lines.Text()); host != "" {
+				hosts = append(hosts, host)
+			}
+		}
+		err = lines.Err()
+		file.Close()
+		if err == nil {
+			return hosts, true
+		}
+	}
+	return nil, false
+}
+
+// Store saves the hostnames a source reported for domain today
+// The file is written under a temporary name first, concurrent scans never read half of it
+func (c *Cache) Store(source, domain string, hosts []string) error {
+	if !cacheable(domain) {
+		return nil
+	}
+	path := c.path(time.Now(), source, domain)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(temp)
+	for _, host := range hosts {
+		fmt.Fprintln(writer, host)
+	}
+	if err := writer.Flush(); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// path returns the cache file of source and domain on day
+func (c *Cache) path(day time.Time, source, domain string) string {
+	return filepath.Join(c.dir, day.Format(cacheDayFormat), strings.ToLower(source), strings.ToLower(domain)+".txt")
+}
+
+// prune removes the days no longer reused
+func (c *Cache) prune() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	oldest := time.Now().AddDate(0, 0, -c.days).Format(cacheDayFormat)
+	for _, entry := range entries {
+		if _, err := time.Parse(cacheDayFormat, entry.Name()); err != nil || !entry.IsDir() {
+			continue
+		}
+		// Day names sort in date order
+		if entry.Name() <= oldest {
+			os.RemoveAll(filepath.Join(c.dir, entry.Name()))
+		}
+	}
+}
+
+// cacheable reports whether a domain can name a cache file
+func cacheable(domain string) bool {
+	return domain != "" && !strings.ContainsAny(domain, `/\`) && !strings.Contains(domain, "..")
+}
@@ -0,0 +1,128 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// chaosBaseURL is the API root of the ProjectDiscovery Chaos dataset
+const chaosBaseURL = "https://dns.projectdiscovery.io"
+
+// chaosSource queries the subdomains ProjectDiscovery collects in Chaos, which
+// covers the public bug bounty programs; keys are free at cloud.projectdiscovery.io
+type chaosSource struct {
+	baseURL string
+	client  *http.Client
+}
+
+func init() {
+	Register(chaosSource{
+		baseURL: chaosBaseURL,
+		client:  &http.Client{Transport: throttled(utils.WrapServiceTransport(nil))},
+	})
+}
+
+// Name returns the source name used in flags and the keys file
+func (s chaosSource) Name() string {
+	return "chaos"
+}
+
+// NeedsKey reports true, the API rejects requests without a key
+func (s chaosSource) NeedsKey() bool {
+	return true
+}
+
+// Enumerate reports the subdomains Chaos lists for domain
+// A rate limited or rejected key is retried with the next one
+func (s chaosSource) Enumerate(ctx context.Context, domain string) <-chan Finding {
+	out := make(chan Finding)
+
+	go func() {
+		defer close(out)
+
+		ring := KeysFor(s.Name())
+		if ring.Len() == 0 {
+			send(ctx, out, Finding{Err: ErrNoKey})
+			return
+		}
+
+		key := ring.Current()
+		for attempt := 0; attempt < ring.Len(); attempt++ {
+			hosts, err := s.subdomains(ctx, domain, key)
+			if err != nil {
+				send(ctx, out, Finding{Err: err})
+				if keyState(err) != KeyFailed && ring.Len() > 1 {
+					key = ring.Rotate(key)
+					continue
+				}
+				return
+			}
+			for _, host := range hosts {
+				send(ctx, out, Finding{Host: host})
+			}
+			return
+		}
+	}()
+
+	return out
+}
+
+// CheckKey lists the subdomains of a well-known domain with key
+func (s chaosSource) CheckKey(ctx context.Context, key string) error {
+	_, err := s.subdomains(ctx, keyCheckDomain, key)
+	return err
+}
+
+// subdomains fetches the subdomains of domain, the API returns their labels
+// relative to domain, "*" standing for a wildcard record
+func (s chaosSource) subdomains(ctx context.Context, domain, key string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout())
+	defer cancel()
+
+	endpoint := fmt.Sprintf("%s/dns/%s/subdomains", s.baseURL, url.PathEscape(domain))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", key)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		// Domains outside the dataset
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("chaos returned %s", resp.Status)
+	}
+
+	var body struct {
+		Subdomains []string `json:"subdomains"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid chaos response: %v", err)
+	}
+
+	hosts := make([]string, 0, len(body.Subdomains))
+	for _, label := range body.Subdomains {
+		label = strings.TrimPrefix(strings.TrimSpace(label), "*.")
+		switch label {
+		case "", "*":
+			continue
+		}
+		hosts = append(hosts, label+"."+domain)
+	}
+	return hosts, nil
+}
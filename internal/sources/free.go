@@ -0,0 +1,171 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+const (
+	anubisBaseURL         = "https://jonlu.ca/anubis"
+	threatCrowdBaseURL    = "http://ci-www.threatcrowd.org/searchApi/v2"
+	bufferOverFreeBaseURL = "https://dns.bufferover.run"
+	bufferOverTLSBaseURL  = "https://tls.bufferover.run"
+)
+
+func init() {
+	Register(anubisSource{baseURL: anubisBaseURL, scraper: politeScraper})
+	Register(threatCrowdSource{baseURL: threatCrowdBaseURL, scraper: politeScraper})
+	Register(bufferOverSource{freeURL: bufferOverFreeBaseURL, tlsURL: bufferOverTLSBaseURL, scraper: politeScraper})
+}
+
+// sendHosts reports each hostname once, datasets often list a name several times
+func sendHosts(ctx context.Context, out chan<- Finding, hosts []string) {
+	seen := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		if host != "" && !seen[host] {
+			seen[host] = true
+			send(ctx, out, Finding{Host: host})
+		}
+	}
+}
+
+// anubisSource reads the subdomains AnubisDB collected for a domain
+type anubisSource struct {
+	baseURL string
+	scraper *scraper
+}
+
+// Name returns the source name used in flags
+func (s anubisSource) Name() string {
+	return "anubis"
+}
+
+// Enumerate reports the hostnames of the JSON array AnubisDB returns
+func (s anubisSource) Enumerate(ctx context.Context, domain string) <-chan Finding {
+	out := make(chan Finding)
+
+	go func() {
+		defer close(out)
+
+		body, err := s.scraper.get(ctx, s.baseURL+"/subdomains/"+url.PathEscape(domain))
+		if err != nil {
+			send(ctx, out, Finding{Err: err})
+			return
+		}
+		var hosts []string
+		if err := json.Unmarshal(body, &hosts); err != nil {
+			send(ctx, out, Finding{Err: fmt.Errorf("invalid anubis response: %v", err)})
+			return
+		}
+		sendHosts(ctx, out, hosts)
+	}()
+
+	return out
+}
+
+// threatCrowdSource reads the domain report of ThreatCrowd
+// Its answers are slow and sometimes missing; the time limit of the source bounds them
+type threatCrowdSource struct {
+	baseURL string
+	scraper *scraper
+}
+
+// Name returns the source name used in flags
+func (s threatCrowdSource) Name() string {
+	return "threatcrowd"
+}
+
+// Enumerate reports the subdomains listed in the domain report
+func (s threatCrowdSource) Enumerate(ctx context.Context, domain string) <-chan Finding {
+	out := make(chan Finding)
+
+	go func() {
+		defer close(out)
+
+		query := url.Values{"domain": {domain}}
+		body, err := s.scraper.get(ctx, s.baseURL+"/domain/report/?"+query.Encode())
+		if err != nil {
+			send(ctx, out, Finding{Err: err})
+			return
+		}
+		// Unknown domains come back with response_code 0 and no subdomains
+		var report struct {
+			Subdomains []string `json:"subdomains"`
+		}
+		if err := json.Unmarshal(body, &report); err != nil {
+			send(ctx, out, Finding{Err: fmt.Errorf("invalid threatcrowd response: %v", err)})
+			return
+		}
+		sendHosts(ctx, out, report.Subdomains)
+	}()
+
+	return out
+}
+
+// bufferOverSource reads the forward DNS and TLS certificate datasets of BufferOver
+// The free endpoint needs no key; a free key from bufferover.run unlocks the TLS dataset
+type bufferOverSource struct {
+	freeURL string
+	tlsURL  string
+	scraper *scraper
+}
+
+// Name returns the source name used in flags and the keys file
+func (s bufferOverSource) Name() string {
+	return "bufferover"
+}
+
+// Enumerate reports the in-scope hostnames of the answer, which lists them in
+// "ip,host" or certificate entries; with keys, a rate limited key is retried with the next one
+func (s bufferOverSource) Enumerate(ctx context.Context, domain string) <-chan Finding {
+	out := make(chan Finding)
+
+	go func() {
+		defer close(out)
+
+		ring := KeysFor(s.Name())
+		key := ring.Current()
+		for attempt := 0; attempt < max(1, ring.Len()); attempt++ {
+			hosts, err := s.search(ctx, domain, key)
+			if err != nil {
+				send(ctx, out, Finding{Err: err})
+				if IsRateLimited(err) && ring.Len() > 1 {
+					key = ring.Rotate(key)
+					continue
+				}
+				return
+			}
+			sendHosts(ctx, out, hosts)
+			return
+		}
+	}()
+
+	return out
+}
+
+// CheckKey searches the TLS dataset for a well-known domain with key
+func (s bufferOverSource) CheckKey(ctx context.Context, key string) error {
+	_, err := s.search(ctx, keyCheckDomain, key)
+	return err
+}
+
+// search queries the TLS dataset with a key, the free dataset without one
+func (s bufferOverSource) search(ctx context.Context, domain, key string) ([]string, error) {
+	query := url.Values{"q": {"." + domain}}
+	var body []byte
+	var err error
+	if key != "" {
+		body, err = s.scraper.getWithHeader(ctx, s.tlsURL+"/dns?"+query.Encode(), http.Header{"X-Api-Key": {key}})
+	} else {
+		body, err = s.scraper.get(ctx, s.freeURL+"/dns?"+query.Encode())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return utils.ExtractHosts(body, domain), nil
+}
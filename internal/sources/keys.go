@@ -0,0 +1,181 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoKey is reported by sources that need an API key when none is configured
+var ErrNoKey = errors.New("no API key configured")
+
+// DefaultKeysPath returns where provider API keys are read from
+func DefaultKeysPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "subcollector", "providers.yaml")
+}
+
+// KeyRing holds the API keys of one source and which of them is in use
+// Keys are used one at a time and rotated when the provider rate limits
+type KeyRing struct {
+	mu      sync.Mutex
+	keys    []string
+	current int
+}
+
+// NewKeyRing creates a key ring using the keys in order
+func NewKeyRing(keys []string) *KeyRing {
+	ring := &KeyRing{}
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" {
+			ring.keys = append(ring.keys, key)
+		}
+	}
+	return ring
+}
+
+// Len returns the number of keys, zero for a nil ring
+func (r *KeyRing) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.keys)
+}
+
+// Keys returns a copy of all keys
+func (r *KeyRing) Keys() []string {
+	if r == nil {
+		return nil
+	}
+	return append([]string(nil), r.keys...)
+}
+
+// Current returns the key in use, or "" without keys
+func (r *KeyRing) Current() string {
+	if r.Len() == 0 {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.keys[r.current]
+}
+
+// Rotate moves on from a rate limited key and returns the next one
+// Rotating a key that is no longer current leaves the ring unchanged, so
+// concurrent users hitting the same limit only advance it once
+func (r *KeyRing) Rotate(limited string) string {
+	if r.Len() == 0 {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.keys[r.current] == limited {
+		r.current = (r.current + 1) % len(r.keys)
+	}
+	return r.keys[r.current]
+}
+
+var (
+	keysMu sync.RWMutex
+	keys   = make(map[string]*KeyRing)
+)
+
+// LoadKeys reads provider API keys from a YAML file mapping source names to key lists
+// A missing file at the default path is not an error
+func LoadKeys(path string) error {
+	explicit := path != ""
+	if !explicit {
+		path = DefaultKeysPath()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read provider keys: %v", err)
+	}
+
+	var parsed map[string][]string
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return fmt.Errorf("invalid provider keys file %s: %v", path, err)
+	}
+
+	for name := range parsed {
+		if _, ok := Get(name); !ok {
+			return fmt.Errorf("unknown source %q in %s", name, path)
+		}
+	}
+	SetKeys(parsed)
+	return nil
+}
+
+// SetKeys replaces the API keys of all sources
+func SetKeys(byName map[string][]string) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	keys = make(map[string]*KeyRing)
+	for name, list := range byName {
+		if source, ok := Get(name); ok {
+			name = source.Name()
+		}
+		if ring := NewKeyRing(list); ring.Len() > 0 {
+			keys[strings.ToLower(name)] = ring
+		}
+	}
+}
+
+// KeysFor returns the key ring of a source, nil when it has no keys
+func KeysFor(name string) *KeyRing {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	return keys[strings.ToLower(name)]
+}
+
+// KeyUser is implemented by sources that only work with an API key
+type KeyUser interface {
+	NeedsKey() bool
+}
+
+// NeedsKey reports whether a source only works with an API key
+func NeedsKey(source Source) bool {
+	if k, ok := source.(KeyUser); ok {
+		return k.NeedsKey()
+	}
+	return false
+}
+
+// Usable reports whether a source can be queried with the configured keys
+func Usable(source Source) bool {
+	return !NeedsKey(source) || KeysFor(source.Name()).Len() > 0
+}
+
+// IsRateLimited reports whether an error looks like a rate limit or exhausted quota
+func IsRateLimited(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	for _, marker := range []string{"429", "rate limit", "ratelimit", "too many requests", "quota"} {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskKey shortens a key for display so it can be told apart without being leaked
+func MaskKey(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:4] + "…" + key[len(key)-4:]
+}
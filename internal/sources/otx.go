@@ -0,0 +1,117 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// otxBaseURL is the AlienVault OTX API root
+const otxBaseURL = "https://otx.alienvault.com/api/v1"
+
+// otxSource queries the passive DNS records AlienVault OTX collected for a domain
+// A key is optional and only raises the rate limit, so the source works without one
+type otxSource struct {
+	baseURL string
+	client  *http.Client
+}
+
+func init() {
+	Register(otxSource{
+		baseURL: otxBaseURL,
+		client:  &http.Client{Transport: throttled(utils.WrapServiceTransport(nil))},
+	})
+}
+
+// Name returns the source name used in flags and the keys file
+func (s otxSource) Name() string {
+	return "otx"
+}
+
+// Enumerate reports the hostnames of the passive DNS records of domain
+// With keys configured, a rate limited request is retried with the next key
+func (s otxSource) Enumerate(ctx context.Context, domain string) <-chan Finding {
+	out := make(chan Finding)
+
+	go func() {
+		defer close(out)
+
+		ring := KeysFor(s.Name())
+		key := ring.Current()
+		for attempt := 0; attempt < max(1, ring.Len()); attempt++ {
+			hosts, err := s.passiveDNS(ctx, domain, key)
+			if err != nil {
+				send(ctx, out, Finding{Err: err})
+				if IsRateLimited(err) && ring.Len() > 1 {
+					key = ring.Rotate(key)
+					continue
+				}
+				return
+			}
+			for _, host := range hosts {
+				send(ctx, out, Finding{Host: host})
+			}
+			return
+		}
+	}()
+
+	return out
+}
+
+// CheckKey queries the passive DNS records of a well-known domain with key
+func (s otxSource) CheckKey(ctx context.Context, key string) error {
+	_, err := s.passiveDNS(ctx, keyCheckDomain, key)
+	return err
+}
+
+// passiveDNS fetches the hostnames of the passive DNS records of domain
+func (s otxSource) passiveDNS(ctx context.Context, domain, key string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout())
+	defer cancel()
+
+	endpoint := fmt.Sprintf("%s/indicators/domain/%s/passive_dns", s.baseURL, url.PathEscape(domain))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if key != "" {
+		req.Header.Set("X-OTX-API-KEY", key)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("otx returned %s", resp.Status)
+	}
+
+	var body struct {
+		Error      string `json:"error"`
+		Detail     string `json:"detail"`
+		PassiveDNS []struct {
+			Hostname string `json:"hostname"`
+		} `json:"passive_dns"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid otx response: %v", err)
+	}
+	if body.Error != "" {
+		return nil, fmt.Errorf("otx: %s %s", body.Error, body.Detail)
+	}
+
+	hosts := make([]string, 0, len(body.PassiveDNS))
+	for _, record := range body.PassiveDNS {
+		if record.Hostname != "" {
+			hosts = append(hosts, record.Hostname)
+		}
+	}
+	return hosts, nil
+}
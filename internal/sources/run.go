@@ -0,0 +1,213 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+const (
+	// DefaultTimeout limits how long a single source may run
+	DefaultTimeout = 10 * time.Minute
+
+	// DefaultRequestTimeout limits each HTTP request made by a source
+	DefaultRequestTimeout = 30 * time.Second
+)
+
+var requestTimeout atomic.Int64
+
+func init() {
+	requestTimeout.Store(int64(DefaultRequestTimeout))
+}
+
+// SetRequestTimeout sets the time limit of each HTTP request made by sources
+func SetRequestTimeout(timeout time.Duration) error {
+	if timeout < time.Second {
+		return fmt.Errorf("invalid request timeout %s, must be at least 1s", timeout)
+	}
+	requestTimeout.Store(int64(timeout))
+	return nil
+}
+
+// RequestTimeout returns the time limit of each HTTP request made by sources
+func RequestTimeout() time.Duration {
+	return time.Duration(requestTimeout.Load())
+}
+
+// Options control how sources are run
+type Options struct {
+	Timeout  time.Duration            // Time limit of every source without its own
+	Timeouts map[string]time.Duration // Time limits of individual sources by name
+	Retries  int                      // Times a rate limited source is run again within its time limit
+	Cache    *Cache                   // Optional, answers reused instead of querying the sources
+	Done     func(Stats)              // Optional, called concurrently as each source finishes
+}
+
+// TimeoutFor returns the time limit of the named source
+func (o Options) TimeoutFor(name string) time.Duration {
+	if timeout, ok := o.Timeouts[strings.ToLower(name)]; ok && timeout > 0 {
+		return timeout
+	}
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	return DefaultTimeout
+}
+
+// ParseTimeouts builds Options from "30s" and "name=2m" entries
+// A bare duration sets the time limit of every source
+func ParseTimeouts(specs []string) (Options, error) {
+	var opts Options
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		name, value, named := strings.Cut(spec, "=")
+		if !named {
+			value = name
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || timeout <= 0 {
+			return opts, fmt.Errorf("invalid source timeout %q", spec)
+		}
+
+		if !named {
+			opts.Timeout = timeout
+			continue
+		}
+		source, ok := Get(name)
+		if !ok {
+			return opts, fmt.Errorf("unknown source %q in timeout %q", name, spec)
+		}
+		name = source.Name()
+		if opts.Timeouts == nil {
+			opts.Timeouts = make(map[string]time.Duration)
+		}
+		opts.Timeouts[name] = timeout
+	}
+	return opts, nil
+}
+
+// Stats summarizes what one source did during a run
+type Stats struct {
+	Source   string
+	Found    int           // Hostnames reported, including duplicates
+	Hosts    int           // Distinct in-scope hostnames, counted by the caller
+	Unique   int           // Hostnames no other source reported, counted by the caller
+	Errors   int           // Failures reported by the source
+	Duration time.Duration // Time until the source finished or was stopped
+	TimedOut bool          // Stopped by its time limit
+	Retries  int           // Runs repeated after the provider rate limited the source
+	Cached   bool          // Answered from the cache without querying the source
+	LastErr  error         // Most recent failure
+}
+
+// Run queries all sources concurrently and calls emit for every hostname found
+// emit is never called concurrently; the returned stats follow the order of sources
+func Run(ctx context.Context, domain string, list []Source, opts Options, emit func(Finding)) []Stats {
+	stats := make([]Stats, len(list))
+	findings := make(chan Finding, 100)
+
+	var wg sync.WaitGroup
+	for i, source := range list {
+		wg.Add(1)
+		go func(i int, source Source) {
+			defer wg.Done()
+			stats[i] = runSource(ctx, domain, source, opts, findings)
+			if opts.Done != nil {
+				opts.Done(stats[i])
+			}
+		}(i, source)
+	}
+
+	go func() {
+		wg.Wait()
+		close(findings)
+	}()
+
+	for finding := range findings {
+		emit(finding)
+	}
+	return stats
+}
+
+// sourcesLog is the logger of the passive sources, its messages carry module=sources
+var sourcesLog = utils.With("module", "sources")
+
+// runSource drains one source until it finishes or its time limit is reached
+// A run the provider rate limited is repeated up to opts.Retries times once the
+// provider lets requests through again; hostnames of a complete run are cached
+func runSource(ctx context.Context, domain string, source Source, opts Options, out chan<- Finding) Stats {
+	stats := Stats{Source: source.Name()}
+	start := time.Now()
+
+	log := sourcesLog.With("domain", domain).With("source", stats.Source)
+	if opts.Cache != nil {
+		if hosts, ok := opts.Cache.Load(stats.Source, domain); ok {
+			for _, host := range hosts {
+				send(ctx, out, Finding{Host: host, Source: stats.Source})
+			}
+			stats.Found = len(hosts)
+			stats.Cached = true
+			stats.Duration = time.Since(start)
+			log.Info("Source answered from cache: %d found", stats.Found)
+			return stats
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.TimeoutFor(stats.Source))
+	defer cancel()
+	ctx = withSource(ctx, stats.Source)
+
+	var hosts []string
+	seen := make(map[string]bool)
+	var failed bool // Whether the last run reported an error
+	for attempt := 0; ; attempt++ {
+		failed = false
+		rateLimited := false
+		for finding := range source.Enumerate(ctx, domain) {
+			finding.Source = source.Name()
+			if finding.Err != nil {
+				stats.Errors++
+				stats.LastErr = finding.Err
+				failed = true
+				rateLimited = rateLimited || IsRateLimited(finding.Err)
+				log.Debug("Source error: %v", finding.Err)
+				continue
+			}
+			stats.Found++
+			if !seen[finding.Host] {
+				seen[finding.Host] = true
+				hosts = append(hosts, finding.Host)
+			}
+			out <- finding
+		}
+		if !rateLimited || attempt >= opts.Retries || ctx.Err() != nil {
+			break
+		}
+
+		// The next run waits in the queue of the source, behind a Retry-After the provider sent
+		delay := DefaultRetryDelay << attempt
+		sourceThrottle.holdOff(stats.Source, delay)
+		stats.Retries++
+		log.Info("Source rate limited, retrying in %s", delay)
+	}
+
+	stats.Duration = time.Since(start)
+	stats.TimedOut = errors.Is(ctx.Err(), context.DeadlineExceeded)
+	if opts.Cache != nil && !failed && ctx.Err() == nil {
+		if err := opts.Cache.Store(stats.Source, domain, hosts); err != nil {
+			log.Debug("Failed to cache source answer: %v", err)
+		}
+	}
+	log.Info("Source finished in %s: %d found, %d errors", stats.Duration.Round(time.Millisecond), stats.Found, stats.Errors)
+	return stats
+}
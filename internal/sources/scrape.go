@@ -0,0 +1,76 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+const (
+	// scrapeInterval is the minimum time between two requests to the same site
+	scrapeInterval = 2 * time.Second
+
+	// maxScrapeSize caps how much of a page is read
+	maxScrapeSize = 20 << 20
+
+	// scrapeUserAgent identifies subcollector to the scraped sites
+	scrapeUserAgent = "subcollector (+https://github.com/fkr00t/subcollector)"
+)
+
+// scraper fetches pages of free aggregator sites politely
+// Requests to the same site are spaced out, whichever source makes them
+type scraper struct {
+	client  *http.Client
+	limiter *utils.DomainRateLimiter
+}
+
+// politeScraper is shared by all scraper-based sources
+var politeScraper = &scraper{
+	client:  &http.Client{Transport: throttled(utils.WrapServiceTransport(nil))},
+	limiter: utils.NewDomainRateLimiter(scrapeInterval),
+}
+
+// get fetches a page once the site's rate limit allows it
+func (s *scraper) get(ctx context.Context, rawURL string) ([]byte, error) {
+	return s.getWithHeader(ctx, rawURL, nil)
+}
+
+// getWithHeader fetches a page sending extra headers, such as an optional API key
+func (s *scraper) getWithHeader(ctx context.Context, rawURL string, header http.Header) ([]byte, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	s.limiter.Wait(parsed.Host)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", scrapeUserAgent)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", parsed.Host, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxScrapeSize))
+}
@@ -0,0 +1,139 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Finding is one hostname reported by a passive source
+// Err is set instead of Host when the source reports a failure
+type Finding struct {
+	Host   string
+	Source string
+	Err    error
+}
+
+// Source is a passive data source listing subdomains of a domain
+// The returned channel is closed once the source is done or ctx is cancelled
+type Source interface {
+	Name() string
+	Enumerate(ctx context.Context, domain string) <-chan Finding
+}
+
+// Defaulter is implemented by sources that are not queried unless selected
+type Defaulter interface {
+	Default() bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Source)
+)
+
+// Register adds a source to the registry under its lowercase name
+// Registering the same name twice replaces the earlier source
+func Register(source Source) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(source.Name())] = source
+}
+
+// Get returns the registered source with the given name
+// Names of replaced subfinder sources return their built-in replacement
+func Get(name string) (Source, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	name = strings.ToLower(strings.TrimSpace(name))
+	source, ok := registry[name]
+	if !ok && replacedSources[name] != "" {
+		source, ok = registry[replacedSources[name]]
+	}
+	return source, ok
+}
+
+// Names returns the names of all registered sources in alphabetical order
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsDefault reports whether a source is queried when no sources are selected
+func IsDefault(source Source) bool {
+	if d, ok := source.(Defaulter); ok {
+		return d.Default()
+	}
+	return true
+}
+
+// Select returns the sources to query, in alphabetical order
+// An empty include list selects the default sources that have the API keys they
+// need and "all" selects every source that can be queried; excluded names are
+// removed afterwards
+func Select(include, exclude []string) ([]Source, error) {
+	var selected []string
+	switch {
+	case len(include) == 0:
+		for _, name := range Names() {
+			if source, _ := Get(name); IsDefault(source) && Usable(source) {
+				selected = append(selected, name)
+			}
+		}
+	case len(include) == 1 && strings.EqualFold(strings.TrimSpace(include[0]), "all"):
+		for _, name := range Names() {
+			if source, _ := Get(name); Usable(source) {
+				selected = append(selected, name)
+			}
+		}
+	default:
+		for _, name := range include {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			source, ok := Get(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown source %q (available: %s)", name, strings.Join(Names(), ", "))
+			}
+			selected = append(selected, source.Name())
+		}
+	}
+
+	skip := make(map[string]bool)
+	for _, name := range exclude {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		source, ok := Get(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown source %q (available: %s)", name, strings.Join(Names(), ", "))
+		}
+		skip[source.Name()] = true
+	}
+
+	sort.Strings(selected)
+	var result []Source
+	seen := make(map[string]bool)
+	for _, name := range selected {
+		if skip[name] || seen[name] {
+			continue
+		}
+		seen[name] = true
+		source, _ := Get(name)
+		result = append(result, source)
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no passive sources selected")
+	}
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package sources
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// DefaultKeyCheckTimeout limits how long checking a single key may take
+const DefaultKeyCheckTimeout = 30 * time.Second
+
+// Key states reported by CheckKeys
+const (
+	KeyValid       = "valid"
+	KeyRateLimited = "rate limited"
+	KeyInvalid     = "invalid"
+	KeyFailed      = "error"
+	KeyUnchecked   = "unchecked" // The source cannot check keys
+)
+
+// KeyChecker is implemented by sources able to tell whether an API key works
+type KeyChecker interface {
+	CheckKey(ctx context.Context, key string) error
+}
+
+// KeyStatus is the outcome of checking one API key
+type KeyStatus struct {
+	Key   string
+	State string
+	Err   error
+}
+
+// CheckKeys checks every configured key of a source one after another
+func CheckKeys(source Source, timeout time.Duration) []KeyStatus {
+	ring := KeysFor(source.Name())
+	checker, canCheck := source.(KeyChecker)
+
+	var statuses []KeyStatus
+	for _, key := range ring.Keys() {
+		status := KeyStatus{Key: key, State: KeyUnchecked}
+		if canCheck {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			status.Err = checker.CheckKey(ctx, key)
+			cancel()
+			status.State = keyState(status.Err)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// keyState classifies the error returned when checking a key
+func keyState(err error) string {
+	if err == nil {
+		return KeyValid
+	}
+	if IsRateLimited(err) {
+		return KeyRateLimited
+	}
+	message := strings.ToLower(err.Error())
+	for _, marker := range []string{"401", "403", "unauthorized", "forbidden", "invalid"} {
+		if strings.Contains(message, marker) {
+			return KeyInvalid
+		}
+	}
+	return KeyFailed
+}
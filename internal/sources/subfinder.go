@@ -0,0 +1,177 @@
+package sources
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+	"github.com/projectdiscovery/ratelimit"
+	"github.com/projectdiscovery/subfinder/v2/pkg/passive"
+	"github.com/projectdiscovery/subfinder/v2/pkg/subscraping"
+)
+
+// keyCheckDomain is queried to find out whether an API key works
+const keyCheckDomain = "example.com"
+
+// subfinderSource adapts one subfinder scraping source to the Source interface
+type subfinderSource struct {
+	source subscraping.Source
+}
+
+// replacedSources are subfinder sources superseded by a built-in source
+var replacedSources = map[string]string{
+	"alienvault":   "otx",
+	"anubis":       "anubis",
+	"bufferover":   "bufferover",
+	"chaos":        "chaos",
+	"hackertarget": "hackertarget",
+	"rapiddns":     "rapiddns",
+	"threatcrowd":  "threatcrowd",
+}
+
+func init() {
+	for _, source := range passive.AllSources {
+		if _, replaced := replacedSources[source.Name()]; replaced {
+			continue
+		}
+		Register(subfinderSource{source: source})
+	}
+}
+
+// Name returns the lowercase name of the wrapped source
+func (s subfinderSource) Name() string {
+	return strings.ToLower(s.source.Name())
+}
+
+// Default reports whether subfinder queries the source by default
+func (s subfinderSource) Default() bool {
+	return s.source.IsDefault()
+}
+
+// NeedsKey reports whether the wrapped source only works with an API key
+func (s subfinderSource) NeedsKey() bool {
+	return s.source.NeedsKey()
+}
+
+// Enumerate runs the wrapped source with its own session and rate limiter
+// Sources needing a key use the configured keys one at a time, moving on to
+// the next key when the provider answers with a rate limit
+func (s subfinderSource) Enumerate(ctx context.Context, domain string) <-chan Finding {
+	out := make(chan Finding)
+
+	go func() {
+		defer close(out)
+
+		if !s.source.NeedsKey() {
+			s.run(ctx, s.instance(), domain, out)
+			return
+		}
+
+		ring := KeysFor(s.Name())
+		if ring.Len() == 0 {
+			send(ctx, out, Finding{Err: ErrNoKey})
+			return
+		}
+
+		key := ring.Current()
+		for attempt := 0; attempt < ring.Len(); attempt++ {
+			source := s.instance()
+			source.AddApiKeys([]string{key})
+			if !s.run(ctx, source, domain, out) || ctx.Err() != nil {
+				return
+			}
+			key = ring.Rotate(key)
+		}
+	}()
+
+	return out
+}
+
+// CheckKey runs the wrapped source once with a single key
+// Returns the first error the source reported, nil when the key worked
+func (s subfinderSource) CheckKey(ctx context.Context, key string) error {
+	source := s.instance()
+	source.AddApiKeys([]string{key})
+
+	out := make(chan Finding)
+	go func() {
+		defer close(out)
+		s.run(ctx, source, keyCheckDomain, out)
+	}()
+
+	var firstErr error
+	for finding := range out {
+		if finding.Err != nil && firstErr == nil {
+			firstErr = finding.Err
+		}
+	}
+	if firstErr == nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return firstErr
+}
+
+// instance returns a new object of the wrapped source's type
+// Subfinder sources keep their API keys and statistics in the object, so each run
+// gets one of its own and concurrent scans and key checks never share a key
+func (s subfinderSource) instance() subscraping.Source {
+	return reflect.New(reflect.TypeOf(s.source).Elem()).Interface().(subscraping.Source)
+}
+
+// run forwards the findings of one run of source, an instance of the wrapped source
+// Returns whether the provider rate limited the run
+func (s subfinderSource) run(ctx context.Context, source subscraping.Source, domain string, out chan<- Finding) bool {
+	limiter, err := ratelimit.NewMultiLimiter(ctx, &ratelimit.Options{
+		Key:         s.source.Name(),
+		IsUnlimited: true,
+		MaxCount:    math.MaxUint32,
+		Duration:    time.Millisecond,
+	})
+	if err != nil {
+		send(ctx, out, Finding{Err: err})
+		return false
+	}
+
+	// Subfinder sessions take their request timeout in whole seconds
+	session, err := subscraping.NewSession(domain, "", limiter, int(RequestTimeout()/time.Second))
+	if err != nil {
+		limiter.Stop()
+		send(ctx, out, Finding{Err: err})
+		return false
+	}
+	defer session.Close()
+	session.Client.Transport = throttled(utils.WrapServiceTransport(session.Client.Transport))
+
+	rateLimited := false
+
+	// The session looks up the rate limiter of a request by source name
+	ctx = context.WithValue(ctx, subscraping.CtxSourceArg, s.source.Name())
+	for result := range source.Run(ctx, domain, session) {
+		var finding Finding
+		switch result.Type {
+		case subscraping.Subdomain:
+			finding.Host = result.Value
+		case subscraping.Error:
+			finding.Err = result.Error
+			rateLimited = rateLimited || IsRateLimited(result.Error)
+		default:
+			continue
+		}
+		// Findings after cancellation are dropped, but the source is still
+		// drained so its goroutine can finish
+		send(ctx, out, finding)
+	}
+
+	return rateLimited
+}
+
+// send delivers a finding unless ctx is cancelled first
+func send(ctx context.Context, out chan<- Finding, finding Finding) {
+	select {
+	case out <- finding:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,217 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRetryDelay is how long a rate limited source waits before its first retry
+// Each further retry waits twice as long, unless the provider said how long
+const DefaultRetryDelay = 30 * time.Second
+
+// DefaultRetries is the number of times a rate limited source is run again
+const DefaultRetries = 2
+
+// RateLimit is the request budget of a provider
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// String formats the limit as accepted by SetRateLimits (example: 4/m)
+func (r RateLimit) String() string {
+	unit := r.Per.String()
+	for name, per := range rateUnits {
+		if per == r.Per {
+			unit = name
+		}
+	}
+	return fmt.Sprintf("%d/%s", r.Requests, unit)
+}
+
+// interval is the time between two requests spread evenly over the budget
+func (r RateLimit) interval() time.Duration {
+	return r.Per / time.Duration(r.Requests)
+}
+
+// rateUnits are the units of rate limits
+var rateUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+}
+
+// defaultRateLimits are the documented limits of free API plans
+// Providers without a documented limit are only held back when they answer 429
+var defaultRateLimits = map[string]RateLimit{
+	"virustotal":     {4, time.Minute},
+	"shodan":         {1, time.Second},
+	"github":         {30, time.Minute},
+	"securitytrails": {1, time.Second},
+	"censys":         {24, time.Minute},
+}
+
+// sourceThrottle spaces out the requests of every source, whichever scan makes them
+var sourceThrottle = newThrottle(defaultRateLimits)
+
+// SetRateLimits overrides the rate limits of sources from "name=4/m" entries
+// The unit is s, m, h or d; "name=none" removes the limit of a source
+func SetRateLimits(specs []string) error {
+	limits := make(map[string]RateLimit, len(defaultRateLimits))
+	for name, limit := range defaultRateLimits {
+		limits[name] = limit
+	}
+
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(spec, "=")
+		if !ok {
+			return fmt.Errorf("invalid source rate limit %q, expected name=requests/unit", spec)
+		}
+		source, ok := Get(name)
+		if !ok {
+			return fmt.Errorf("unknown source %q in rate limit %q", name, spec)
+		}
+
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "none" {
+			delete(limits, source.Name())
+			continue
+		}
+		count, unit, _ := strings.Cut(value, "/")
+		requests, err := strconv.Atoi(count)
+		per, known := rateUnits[unit]
+		if err != nil || requests <= 0 || !known {
+			return fmt.Errorf("invalid source rate limit %q, expected name=requests/unit with unit s, m, h or d", spec)
+		}
+		limits[source.Name()] = RateLimit{Requests: requests, Per: per}
+	}
+
+	sourceThrottle.setLimits(limits)
+	return nil
+}
+
+// RateLimitOf returns the rate limit of a source, false when it has none
+func RateLimitOf(name string) (RateLimit, bool) {
+	sourceThrottle.mu.Lock()
+	defer sourceThrottle.mu.Unlock()
+	limit, ok := sourceThrottle.limits[strings.ToLower(name)]
+	return limit, ok
+}
+
+// throttle queues the requests of each source so they respect its rate limit,
+// and holds the queue of a source back when its provider asks to retry later
+type throttle struct {
+	mu     sync.Mutex
+	limits map[string]RateLimit
+	next   map[string]time.Time // Source -> earliest time of its next request
+}
+
+// newThrottle creates a throttle enforcing limits by source name
+func newThrottle(limits map[string]RateLimit) *throttle {
+	t := &throttle{next: make(map[string]time.Time)}
+	t.setLimits(limits)
+	return t
+}
+
+// setLimits replaces the rate limits, the queues already waiting are kept
+func (t *throttle) setLimits(limits map[string]RateLimit) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits = limits
+}
+
+// wait blocks until a request of source is allowed or ctx is cancelled
+// Requests are served in the order they arrive, each one reserving the next slot
+func (t *throttle) wait(ctx context.Context, source string) error {
+	t.mu.Lock()
+	now := time.Now()
+	slot := t.next[source]
+	if slot.Before(now) {
+		slot = now
+	}
+	if limit, ok := t.limits[source]; ok {
+		t.next[source] = slot.Add(limit.interval())
+	}
+	t.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// holdOff delays the next request of source until at least delay from now
+func (t *throttle) holdOff(source string, delay time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := time.Now().Add(delay); until.After(t.next[source]) {
+		t.next[source] = until
+	}
+}
+
+// sourceKey is the context key of the name of the source making a request
+type sourceKey struct{}
+
+// withSource marks the requests made with ctx as made by source
+func withSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// throttledTransport waits for the turn of the source making each request
+// A 429 answer holds the source back for the time the provider asks for
+type throttledTransport struct {
+	next http.RoundTripper
+}
+
+// throttled wraps a transport so requests made by sources respect their rate limits
+// Requests made outside of a source run, such as key checks, are not delayed
+func throttled(next http.RoundTripper) http.RoundTripper {
+	return throttledTransport{next: next}
+}
+
+// RoundTrip sends the request once the rate limit of its source allows it
+func (t throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	source, _ := req.Context().Value(sourceKey{}).(string)
+	if source == "" {
+		return t.next.RoundTrip(req)
+	}
+	if err := sourceThrottle.wait(req.Context(), source); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		sourceThrottle.holdOff(source, retryAfter(resp.Header.Get("Retry-After")))
+	}
+	return resp, err
+}
+
+// retryAfter parses a Retry-After header, in seconds or as a date
+// DefaultRetryDelay is returned when the header is missing or invalid
+func retryAfter(header string) time.Duration {
+	if seconds, err := strconv.Atoi(strings.TrimSpace(header)); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil && time.Until(date) > 0 {
+		return time.Until(date)
+	}
+	return DefaultRetryDelay
+}
@@ -0,0 +1,44 @@
+package sources
+
+import (
+	"context"
+
+	"github.com/fkr00t/subcollector/internal/crawl"
+)
+
+// webSource extracts hostnames hardcoded in the target's pages and JavaScript bundles
+// Single page applications often reference api., cdn. or ws. hosts that never
+// show up in DNS datasets; unlike other sources it contacts the target itself
+type webSource struct {
+	opts crawl.Options
+}
+
+func init() {
+	Register(webSource{})
+}
+
+// Name returns the source name used in flags
+func (s webSource) Name() string {
+	return "web"
+}
+
+// Default reports false because the source sends requests to the target
+func (s webSource) Default() bool {
+	return false
+}
+
+// Enumerate crawls the target and reports every in-scope hostname mentioned
+func (s webSource) Enumerate(ctx context.Context, domain string) <-chan Finding {
+	out := make(chan Finding)
+
+	go func() {
+		defer close(out)
+
+		pages := crawl.Crawl(ctx, domain, s.opts)
+		for _, host := range crawl.Hosts(pages, domain) {
+			send(ctx, out, Finding{Host: host})
+		}
+	}()
+
+	return out
+}
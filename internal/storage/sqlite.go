@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	_ "modernc.org/sqlite" // Pure Go SQLite driver
+)
+
+// sqliteSchema creates the tables used to keep scan history
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS scans (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	domain       TEXT NOT NULL,
+	mode         TEXT NOT NULL,
+	tool_version TEXT NOT NULL DEFAULT '',
+	started_at   TIMESTAMP NOT NULL,
+	finished_at  TIMESTAMP NOT NULL,
+	subdomains   INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_scans_domain ON scans(domain, id);
+
+CREATE TABLE IF NOT EXISTS results (
+	scan_id   INTEGER NOT NULL REFERENCES scans(id) ON DELETE CASCADE,
+	subdomain TEXT NOT NULL,
+	ips       TEXT NOT NULL DEFAULT '',
+	takeover  TEXT NOT NULL DEFAULT '',
+	data      TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (scan_id, subdomain)
+);
+
+CREATE TABLE IF NOT EXISTS annotations (
+	subdomain  TEXT PRIMARY KEY,
+	tags       TEXT NOT NULL DEFAULT '',
+	note       TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMP NOT NULL
+);
+`
+
+// sqliteColumns were added to the schema later, databases created before lack them
+// Results stored without data only have their addresses and takeover
+var sqliteColumns = []struct{ table, column, definition string }{
+	{"scans", "tool_version", "TEXT NOT NULL DEFAULT ''"},
+	{"scans", "config_fingerprint", "TEXT NOT NULL DEFAULT ''"},
+	{"results", "data", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// SQLiteStore keeps scan results in a local SQLite database
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens (or creates) the SQLite database at path
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	// SQLite allows a single writer, avoid "database is locked" errors
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create database schema: %v", err)
+	}
+	if err := addSQLiteColumns(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to upgrade database schema: %v", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// addSQLiteColumns adds the columns missing from a database created by an older version
+func addSQLiteColumns(db *sql.DB) error {
+	for _, c := range sqliteColumns {
+		var count int
+		err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", c.table, c.column).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// SaveScan stores a finished scan and its results
+// Returns the ID of the new scan
+func (s *SQLiteStore) SaveScan(domain string, metadata models.ScanMetadata, results []models.SubdomainResult) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"INSERT INTO scans (domain, mode, tool_version, config_fingerprint, started_at, finished_at, subdomains) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		domain, metadata.Mode, metadata.Tool.Version, metadata.Config.Fingerprint, metadata.StartedAt.UTC(), metadata.FinishedAt.UTC(), len(results),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store scan: %v", err)
+	}
+
+	scanID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO results (scan_id, subdomain, ips, takeover, data) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, result := range results {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := stmt.Exec(scanID, result.Subdomain, strings.Join(result.IPs, ","), result.Takeover, string(data)); err != nil {
+			return 0, fmt.Errorf("failed to store result %s: %v", result.Subdomain, err)
+		}
+	}
+
+	return scanID, tx.Commit()
+}
+
+// LatestScan returns the most recent scan of a domain and its results
+// Returns a zero scan ID when the domain was never scanned
+func (s *SQLiteStore) LatestScan(domain string) (int64, []models.SubdomainResult, error) {
+	var scanID int64
+	err := s.db.QueryRow("SELECT id FROM scans WHERE domain = ? ORDER BY id DESC LIMIT 1", domain).Scan(&scanID)
+	if err == sql.ErrNoRows {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	results, err := s.ScanResults(scanID)
+	return scanID, results, err
+}
+
+// ScanResults returns the results stored for a scan
+func (s *SQLiteStore) ScanResults(scanID int64) ([]models.SubdomainResult, error) {
+	rows, err := s.db.Query("SELECT subdomain, ips, takeover, data FROM results WHERE scan_id = ? ORDER BY subdomain", scanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.SubdomainResult
+	for rows.Next() {
+		var result models.SubdomainResult
+		var ips, data string
+		if err := rows.Scan(&result.Subdomain, &ips, &result.Takeover, &data); err != nil {
+			return nil, err
+		}
+		if data != "" {
+			if err := json.Unmarshal([]byte(data), &result); err != nil {
+				return nil, err
+			}
+		} else if ips != "" {
+			result.IPs = strings.Split(ips, ",")
+		}
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// sqliteScanColumns selects a ScanSummary, see scanSummary
+const sqliteScanColumns = `s.id, s.domain, s.mode, s.tool_version, s.config_fingerprint, s.started_at, s.finished_at, s.subdomains,
+	(SELECT COUNT(*) FROM results r WHERE r.scan_id = s.id AND r.takeover <> '')`
+
+// Scans lists stored scans newest first, of one domain unless domain is empty, at most limit unless it is 0
+func (s *SQLiteStore) Scans(domain string, limit int) ([]ScanSummary, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query("SELECT "+sqliteScanColumns+" FROM scans s WHERE ? = '' OR s.domain = ? ORDER BY s.id DESC LIMIT ?",
+		domain, domain, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scans []ScanSummary
+	for rows.Next() {
+		scan, err := scanSummary(rows)
+		if err != nil {
+			return nil, err
+		}
+		scans = append(scans, scan)
+	}
+	return scans, rows.Err()
+}
+
+// Scan returns a stored scan, a zero ID when there is none
+func (s *SQLiteStore) Scan(scanID int64) (ScanSummary, error) {
+	scan, err := scanSummary(s.db.QueryRow("SELECT "+sqliteScanColumns+" FROM scans s WHERE s.id = ?", scanID))
+	if err == sql.ErrNoRows {
+		return ScanSummary{}, nil
+	}
+	return scan, err
+}
+
+// Annotations returns the annotations of every annotated subdomain
+func (s *SQLiteStore) Annotations() (map[string]Annotation, error) {
+	rows, err := s.db.Query("SELECT subdomain, tags, note, updated_at FROM annotations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	annotations := make(map[string]Annotation)
+	for rows.Next() {
+		var subdomain, tags string
+		var annotation Annotation
+		if err := rows.Scan(&subdomain, &tags, &annotation.Note, &annotation.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if tags != "" {
+			annotation.Tags = strings.Split(tags, ",")
+		}
+		annotations[subdomain] = annotation
+	}
+	return annotations, rows.Err()
+}
+
+// Annotate stores the annotation of a subdomain, removing it when it has neither tags nor a note
+func (s *SQLiteStore) Annotate(subdomain string, annotation Annotation) error {
+	if len(annotation.Tags) == 0 && annotation.Note == "" {
+		_, err := s.db.Exec("DELETE FROM annotations WHERE subdomain = ?", subdomain)
+		return err
+	}
+	_, err := s.db.Exec("INSERT OR REPLACE INTO annotations (subdomain, tags, note, updated_at) VALUES (?, ?, ?, ?)",
+		subdomain, strings.Join(annotation.Tags, ","), annotation.Note, annotation.UpdatedAt.UTC())
+	return err
+}
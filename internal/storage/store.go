@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+)
+
+// ScanSummary describes a stored scan
+type ScanSummary struct {
+	ID          int64
+	Domain      string
+	Mode        string
+	ToolVersion string
+	Fingerprint string // Config fingerprint of the scan, empty for scans stored before it was recorded
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	Subdomains  int
+	Takeovers   int
+}
+
+// Annotation holds the labels and note given to a subdomain with the annotate command
+// They are kept apart from scans, so they apply to every scan that reported the subdomain
+type Annotation struct {
+	Tags      []string
+	Note      string
+	UpdatedAt time.Time
+}
+
+// Store keeps the history of scans and their results
+type Store interface {
+	// SaveScan stores a finished scan and its results, returning the ID of the new scan
+	SaveScan(domain string, metadata models.ScanMetadata, results []models.SubdomainResult) (int64, error)
+	// LatestScan returns the most recent scan of a domain and its results, a zero ID when there is none
+	LatestScan(domain string) (int64, []models.SubdomainResult, error)
+	// ScanResults returns the results stored for a scan
+	ScanResults(scanID int64) ([]models.SubdomainResult, error)
+	// Scans lists stored scans newest first, of one domain unless domain is empty, at most limit unless it is 0
+	Scans(domain string, limit int) ([]ScanSummary, error)
+	// Scan returns a stored scan, a zero ID when there is none
+	Scan(scanID int64) (ScanSummary, error)
+	// Annotations returns the annotations of every annotated subdomain
+	Annotations() (map[string]Annotation, error)
+	// Annotate stores the annotation of a subdomain, removing it when it has neither tags nor a note
+	Annotate(subdomain string, annotation Annotation) error
+	// Close closes the database
+	Close() error
+}
+
+// Open opens the database named by dsn: a postgres:// or postgresql:// URL
+// for a shared PostgreSQL database, anything else is the path of a local SQLite file
+func Open(dsn string) (Store, error) {
+	if IsPostgres(dsn) {
+		return OpenPostgres(dsn)
+	}
+	return OpenSQLite(dsn)
+}
+
+// IsPostgres reports whether dsn is a PostgreSQL connection URL
+func IsPostgres(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// Redact hides the password of a PostgreSQL URL so it can be printed
+func Redact(dsn string) string {
+	if !IsPostgres(dsn) {
+		return dsn
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "postgres://..."
+	}
+	return u.Redacted()
+}
+
+// scanSummary reads a row of id, domain, mode, tool version, start, end, subdomains and takeovers
+func scanSummary(row interface{ Scan(dest ...any) error }) (ScanSummary, error) {
+	var scan ScanSummary
+	err := row.Scan(&scan.ID, &scan.Domain, &scan.Mode, &scan.ToolVersion, &scan.Fingerprint, &scan.StartedAt, &scan.FinishedAt,
+		&scan.Subdomains, &scan.Takeovers)
+	return scan, err
+}
@@ -0,0 +1,126 @@
+// Package telemetry traces the stages of a run with OpenTelemetry spans
+// exported over OTLP. Until Start is called spans are not recorded, so
+// instrumented code costs next to nothing when tracing is off
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation names the tracer the spans are created with
+const instrumentation = "github.com/fkr00t/subcollector"
+
+var (
+	mu       sync.RWMutex
+	provider *sdktrace.TracerProvider
+	root     = context.Background()
+)
+
+// Start exports spans to the OTLP/HTTP collector at endpoint (example:
+// http://localhost:4318 or localhost:4318, plain HTTP unless https:// is given)
+// The trace joins the one of a calling pipeline given in TRACEPARENT, if any
+func Start(endpoint, version string) error {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return fmt.Errorf("failed to create the OTLP exporter: %v", err)
+	}
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", "subcollector"),
+		attribute.String("service.version", version),
+	)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	mu.Lock()
+	provider = tp
+	mu.Unlock()
+	return nil
+}
+
+// Enabled reports whether spans are exported
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return provider != nil
+}
+
+// StartRoot starts the span covering the whole run, parent of the spans
+// started without one; the span ends with End
+func StartRoot(name string, attrs ...attribute.KeyValue) trace.Span {
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), envCarrier{})
+	ctx, span := otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+
+	mu.Lock()
+	root = ctx
+	mu.Unlock()
+	return span
+}
+
+// Span starts a span named after a stage, child of the span carried by ctx or
+// of the root span when ctx carries none; ctx may be nil
+func Span(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		mu.RLock()
+		ctx = trace.ContextWithSpan(ctx, trace.SpanFromContext(root))
+		mu.RUnlock()
+	}
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Join returns ctx carrying the span of from, so work cancelled through ctx
+// is traced under the stage of from
+func Join(ctx, from context.Context) context.Context {
+	if from == nil {
+		return ctx
+	}
+	return trace.ContextWithSpan(ctx, trace.SpanFromContext(from))
+}
+
+// Fail marks span as failed with err, when err is not nil
+func Fail(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// envCarrier reads the trace context of a calling pipeline from the
+// TRACEPARENT and TRACESTATE environment variables
+type envCarrier struct{}
+
+func (envCarrier) Get(key string) string { return os.Getenv(strings.ToUpper(key)) }
+func (envCarrier) Set(string, string)    {}
+func (envCarrier) Keys() []string        { return []string{"traceparent", "tracestate"} }
+
+// Shutdown exports the spans still buffered and stops the exporter
+func Shutdown(ctx context.Context) error {
+	mu.Lock()
+	tp := provider
+	provider = nil
+	mu.Unlock()
+	if tp == nil {
+		return nil
+	}
+	return tp.Shutdown(ctx)
+}
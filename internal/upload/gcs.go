@@ -0,0 +1,39 @@
+package upload
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// gcsEndpoint serves the XML API of Google Cloud Storage, overridable with STORAGE_EMULATOR_HOST
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// gcsUploader puts objects with an OAuth access token from GOOGLE_OAUTH_ACCESS_TOKEN,
+// for example the output of "gcloud auth print-access-token"
+func (t *Target) gcsUploader() (func(key string, data []byte, contentType string) error, error) {
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	endpoint := gcsEndpoint
+	if emulator := os.Getenv("STORAGE_EMULATOR_HOST"); emulator != "" {
+		endpoint = strings.TrimSuffix(emulator, "/")
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "http://" + endpoint
+		}
+	} else if token == "" {
+		return nil, fmt.Errorf("GCS upload requires GOOGLE_OAUTH_ACCESS_TOKEN (gcloud auth print-access-token)")
+	}
+
+	return func(key string, data []byte, contentType string) error {
+		req, err := http.NewRequest(http.MethodPut, endpoint+"/"+t.Bucket+"/"+escapeKey(key), bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", contentType)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return t.put(req)
+	}, nil
+}
@@ -0,0 +1,162 @@
+package upload
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultS3Region is used when neither AWS_REGION nor AWS_DEFAULT_REGION is set
+const defaultS3Region = "us-east-1"
+
+// s3Credentials are read from the standard AWS environment variables
+type s3Credentials struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	region       string
+	endpoint     string // Custom endpoint (MinIO, R2, ...), addressed path-style
+}
+
+// s3CredentialsFromEnv reads AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN,
+// AWS_REGION / AWS_DEFAULT_REGION and AWS_ENDPOINT_URL_S3 / AWS_ENDPOINT_URL
+func s3CredentialsFromEnv() (s3Credentials, error) {
+	creds := s3Credentials{
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		region:       firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		endpoint:     firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"),
+	}
+	if creds.accessKey == "" || creds.secretKey == "" {
+		return creds, fmt.Errorf("S3 upload requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	if creds.region == "" {
+		creds.region = defaultS3Region
+	}
+	return creds, nil
+}
+
+// s3Uploader puts objects with a SigV4 signed request
+func (t *Target) s3Uploader() (func(key string, data []byte, contentType string) error, error) {
+	creds, err := s3CredentialsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	objectURL := func(key string) string {
+		if creds.endpoint != "" {
+			return strings.TrimSuffix(creds.endpoint, "/") + "/" + t.Bucket + "/" + escapeKey(key)
+		}
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", t.Bucket, creds.region, escapeKey(key))
+	}
+
+	return func(key string, data []byte, contentType string) error {
+		req, err := http.NewRequest(http.MethodPut, objectURL(key), bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", contentType)
+		if creds.sessionToken != "" {
+			req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+		}
+		signV4(req, sha256Hex(data), creds, "s3", time.Now())
+		return t.put(req)
+	}, nil
+}
+
+// signV4 adds an AWS Signature Version 4 Authorization header covering the host and every header already set
+func signV4(req *http.Request, payloadHash string, creds s3Credentials, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + creds.region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretKey), day)
+	key = hmacSHA256(key, creds.region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery sorts and encodes query parameters the way SigV4 expects
+func canonicalQuery(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+// escapeKey percent-encodes an object key the way SigV4 expects, keeping the slashes:
+// every byte but letters, digits and -._~ is encoded, as S3 does when checking the signature
+func escapeKey(key string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(key) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '.', b == '_', b == '~', b == '/':
+			escaped.WriteByte(b)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// firstEnv returns the value of the first set environment variable
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,168 @@
+package upload
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds the upload of a single file
+const requestTimeout = 5 * time.Minute
+
+// Key template placeholders
+const (
+	PlaceholderDomain    = "{domain}"    // Scanned domain, "multi" when a scan covers several domains
+	PlaceholderMode      = "{mode}"      // active, passive or merge
+	PlaceholderTimestamp = "{timestamp}" // Scan start as YYYYMMDD-HHMMSS (UTC)
+	PlaceholderDate      = "{date}"      // Scan start as YYYY-MM-DD (UTC)
+	PlaceholderFile      = "{file}"      // Base name of the uploaded file
+)
+
+// Target is a bucket location receiving the output files of a scan
+// Locations are s3://bucket/key-template or gs://bucket/key-template;
+// a template without {file} is treated as a directory the files are placed in
+type Target struct {
+	Scheme   string // s3 or gs
+	Bucket   string
+	Template string // Object key template
+
+	client *http.Client
+}
+
+// Parse validates an upload location
+func Parse(location string) (*Target, error) {
+	if location == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(location)
+	if err != nil || (u.Scheme != "s3" && u.Scheme != "gs") || u.Host == "" {
+		return nil, fmt.Errorf("invalid upload location %q, expected s3://bucket/path or gs://bucket/path", location)
+	}
+
+	template := strings.TrimPrefix(u.Path, "/")
+	if !strings.Contains(template, PlaceholderFile) {
+		if template != "" && !strings.HasSuffix(template, "/") {
+			template += "/"
+		}
+		template += PlaceholderFile
+	}
+
+	target := &Target{Scheme: u.Scheme, Bucket: u.Host, Template: template, client: &http.Client{Timeout: requestTimeout}}
+	// Missing credentials are reported before the scan rather than after it
+	if _, err := target.uploader(); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// String returns the location of the target
+func (t *Target) String() string {
+	return t.Scheme + "://" + t.Bucket + "/" + t.Template
+}
+
+// Key expands the key template for one file of a scan
+func (t *Target) Key(file, domain, mode string, startedAt time.Time) string {
+	startedAt = startedAt.UTC()
+	replacer := strings.NewReplacer(
+		PlaceholderDomain, sanitize(domain),
+		PlaceholderMode, sanitize(mode),
+		PlaceholderTimestamp, startedAt.Format("20060102-150405"),
+		PlaceholderDate, startedAt.Format("2006-01-02"),
+		PlaceholderFile, filepath.Base(file),
+	)
+	return path.Clean(replacer.Replace(t.Template))
+}
+
+// sanitize keeps template values from adding path segments to keys
+func sanitize(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return strings.NewReplacer("/", "_", "\\", "_", ",", "_").Replace(value)
+}
+
+// Upload sends every given file that exists to the bucket
+// Empty names are skipped, so the output paths of a scan can be passed as they are
+// Returns the number of files uploaded and the first error
+func (t *Target) Upload(files []string, domain, mode string, startedAt time.Time) (int, error) {
+	if t == nil {
+		return 0, nil
+	}
+
+	uploader, err := t.uploader()
+	if err != nil {
+		return 0, err
+	}
+
+	uploaded := 0
+	var firstErr error
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		key := t.Key(file, domain, mode, startedAt)
+		if err := uploader(key, data, contentType(file)); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to upload %s to %s://%s/%s: %v", file, t.Scheme, t.Bucket, key, err)
+			}
+			continue
+		}
+		uploaded++
+	}
+	return uploaded, firstErr
+}
+
+// uploader returns the function putting one object with the credentials of the target's scheme
+func (t *Target) uploader() (func(key string, data []byte, contentType string) error, error) {
+	if t.Scheme == "gs" {
+		return t.gcsUploader()
+	}
+	return t.s3Uploader()
+}
+
+// put sends one object and reports non-2xx answers as errors
+func (t *Target) put(req *http.Request) error {
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// contentType returns the MIME type of an output file
+func contentType(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		return "application/json"
+	case ".sarif":
+		return "application/sarif+json"
+	case ".html", ".htm":
+		return "text/html; charset=utf-8"
+	case ".csv":
+		return "text/csv; charset=utf-8"
+	case ".xml":
+		return "application/xml"
+	}
+	return "text/plain; charset=utf-8"
+}
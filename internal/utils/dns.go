@@ -2,40 +2,213 @@ package utils
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net"
+	"os"
 	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// DefaultDNSTimeout is the time a single DNS query may take
+	DefaultDNSTimeout = 5 * time.Second
+
+	// DefaultDNSRetries is the number of times a query that timed out is repeated
+	DefaultDNSRetries = 1
+
+	// DefaultEDNSBufferSize is the UDP payload size advertised with EDNS0, the size
+	// recommended by DNS Flag Day 2020 to avoid IP fragmentation
+	DefaultEDNSBufferSize = 1232
+)
+
+var (
+	dnsTimeout     atomic.Int64
+	dnsRetries     atomic.Int64
+	ednsBufferSize atomic.Int64
 )
 
+func init() {
+	dnsTimeout.Store(int64(DefaultDNSTimeout))
+	dnsRetries.Store(DefaultDNSRetries)
+	ednsBufferSize.Store(DefaultEDNSBufferSize)
+}
+
+// SetDNSLimits sets the timeout of a single DNS query and how many times
+// a query that timed out is repeated against the same resolver
+func SetDNSLimits(timeout time.Duration, retries int) error {
+	if timeout <= 0 {
+		return fmt.Errorf("invalid DNS timeout %s, must be positive", timeout)
+	}
+	if retries < 0 {
+		return fmt.Errorf("invalid DNS retry count %d, must not be negative", retries)
+	}
+	dnsTimeout.Store(int64(timeout))
+	dnsRetries.Store(int64(retries))
+	return nil
+}
+
+// DNSTimeout returns the time a single DNS query may take
+func DNSTimeout() time.Duration {
+	return time.Duration(dnsTimeout.Load())
+}
+
+// SetEDNSBufferSize sets the UDP payload size advertised with EDNS0 in DNS queries,
+// 0 sends queries without EDNS0 and limits UDP answers to 512 bytes
+func SetEDNSBufferSize(size int) error {
+	if size != 0 && (size < dns.MinMsgSize || size > dns.MaxMsgSize) {
+		return fmt.Errorf("invalid EDNS0 buffer size %d, use 0 or %d to %d", size, dns.MinMsgSize, dns.MaxMsgSize)
+	}
+	ednsBufferSize.Store(int64(size))
+	return nil
+}
+
+// LookupStatus classifies the outcome of a single DNS lookup
+type LookupStatus int
+
+const (
+	StatusResolved LookupStatus = iota // Name resolved to at least one address
+	StatusNXDomain                     // Authoritative answer that the name does not exist
+	StatusServFail                     // Resolver reported a server failure
+	StatusTimeout                      // Resolver did not answer in time
+	StatusError                        // Any other failure (refused, network error, ...)
+)
+
+// String representation of lookup status
+func (s LookupStatus) String() string {
+	switch s {
+	case StatusResolved:
+		return "RESOLVED"
+	case StatusNXDomain:
+		return "NXDOMAIN"
+	case StatusServFail:
+		return "SERVFAIL"
+	case StatusTimeout:
+		return "TIMEOUT"
+	default:
+		return "ERROR"
+	}
+}
+
+// IsAuthoritative reports whether the status is a definitive answer
+// Non-authoritative outcomes should be retried on another resolver
+func (s LookupStatus) IsAuthoritative() bool {
+	return s == StatusResolved || s == StatusNXDomain
+}
+
+// ClassifyLookupError maps an error returned by a lookup to a LookupStatus
+func ClassifyLookupError(err error) LookupStatus {
+	if err == nil {
+		return StatusResolved
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		switch {
+		case dnsErr.IsNotFound:
+			return StatusNXDomain
+		case dnsErr.IsTimeout:
+			return StatusTimeout
+		case dnsErr.IsTemporary:
+			// The Go resolver reports SERVFAIL as a temporary "server misbehaving" error
+			return StatusServFail
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return StatusTimeout
+	}
+
+	return StatusError
+}
+
 // LookupWithResolver performs DNS lookup using a specific resolver
 // This allows more control over the DNS resolution process
 // Returns a slice of IP addresses and any errors encountered
+// Resolvers are reached over the protocol of their entry, see ParseResolver
 func LookupWithResolver(domain string, resolver string) ([]string, error) {
+	parsed, err := ParseResolver(resolver)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Protocol == ResolverHTTPS {
+		addresses, _, err := parsed.lookupByExchange(domain)
+		return addresses, err
+	}
+
 	r := &net.Resolver{
 		PreferGo: true,
 		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-			d := net.Dialer{}
-			return d.DialContext(ctx, "udp", resolver+":53")
+			// The Go resolver dials once per query
+			recordQuery(parsed.String(), domain)
+			return parsed.dial(ctx, network)
 		},
 	}
-	return r.LookupHost(context.Background(), domain)
+	return lookupHost(r, domain)
+}
+
+// LookupAnswers works like LookupWithResolver but sends the A and AAAA queries itself,
+// so their answer sections are returned too, see RecordSet
+// With NXDOMAIN, the answer holds the CNAME chain leading to the missing name
+func LookupAnswers(domain string, resolver string) ([]string, RecordSet, error) {
+	parsed, err := ParseResolver(resolver)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parsed.lookupByExchange(domain)
 }
 
 // DefaultLookup performs DNS lookup using the system's default resolver
+// While queries are recorded, the Go resolver is used so each query is seen
 func DefaultLookup(domain string) ([]string, error) {
-	return net.LookupHost(domain)
+	if queryRecorder.Load() != nil {
+		return lookupHost(recordingResolver(domain), domain)
+	}
+	return lookupHost(net.DefaultResolver, domain)
+}
+
+// lookupHost resolves a name with the configured timeout per attempt
+// Only timeouts are retried, other failures are answers of the resolver
+func lookupHost(r *net.Resolver, domain string) ([]string, error) {
+	var addresses []string
+	var err error
+	for attempt := int64(0); attempt <= dnsRetries.Load(); attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), DNSTimeout())
+		addresses, err = r.LookupHost(ctx, domain)
+		cancel()
+		if ClassifyLookupError(err) != StatusTimeout {
+			break
+		}
+	}
+	return addresses, err
 }
 
 // CleanDomain removes common prefixes and whitespace from a domain
 // This ensures consistent domain format for processing
+// Internationalized names are converted to punycode, invalid ones are left as given
 func CleanDomain(domain string) string {
 	domain = strings.TrimSpace(domain)
 	domain = strings.TrimPrefix(domain, "http://")
 	domain = strings.TrimPrefix(domain, "https://")
 	domain = strings.TrimPrefix(domain, "www.")
+	if !isASCII(domain) {
+		if ascii, err := ToASCII(domain); err == nil {
+			domain = ascii
+		}
+	}
 	return domain
 }
 
 // IsResolverFile checks if a resolver string is a file
+// Resolver entries contain dots too, so the file has to exist
 func IsResolverFile(resolver string) bool {
-	return strings.Contains(resolver, ".") && !strings.Contains(resolver, ",")
+	if strings.Contains(resolver, ",") || strings.Contains(resolver, "://") {
+		return false
+	}
+	info, err := os.Stat(resolver)
+	return err == nil && info.Mode().IsRegular()
 }
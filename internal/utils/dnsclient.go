@@ -0,0 +1,270 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// fallbackResolver is used when no resolver is configured and resolv.conf is unusable
+const fallbackResolver = "8.8.8.8"
+
+// ResolverAddress returns host:port for a resolver, defaulting to the port of its protocol
+func ResolverAddress(resolver string) string {
+	if r, err := ParseResolver(resolver); err == nil {
+		return r.Address
+	}
+	if _, _, err := net.SplitHostPort(resolver); err == nil {
+		return resolver
+	}
+	return net.JoinHostPort(resolver, "53")
+}
+
+// SystemResolver returns the first nameserver listed in /etc/resolv.conf
+func SystemResolver() string {
+	config, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+	if err != nil || len(config.Servers) == 0 {
+		return fallbackResolver
+	}
+	return config.Servers[0]
+}
+
+// Exchange sends a raw DNS query to a resolver, repeating it when it times out
+// The resolver may be any entry ParseResolver accepts
+// Returns the response and the round trip time
+func Exchange(msg *dns.Msg, resolver string) (*dns.Msg, time.Duration, error) {
+	r, err := ParseResolver(resolver)
+	if err != nil {
+		return nil, 0, err
+	}
+	return r.exchangeRetrying(msg)
+}
+
+// exchangeRetrying sends a query to the resolver, repeating it when it times out
+func (r Resolver) exchangeRetrying(msg *dns.Msg) (*dns.Msg, time.Duration, error) {
+	var resp *dns.Msg
+	var rtt time.Duration
+	var err error
+	for attempt := int64(0); attempt <= dnsRetries.Load(); attempt++ {
+		resp, rtt, err = r.exchange(msg, DNSTimeout())
+		if ClassifyLookupError(err) != StatusTimeout {
+			break
+		}
+	}
+	return resp, rtt, err
+}
+
+// QueryRecords queries a single record type for a name through a resolver
+// Returns the answer section; an NXDOMAIN answer returns no records and no error
+func QueryRecords(name string, qtype uint16, resolver string) ([]dns.RR, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	msg.RecursionDesired = true
+
+	resp, _, err := Exchange(msg, resolver)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("%s answered %s", resolver, dns.RcodeToString[resp.Rcode])
+	}
+
+	return resp.Answer, nil
+}
+
+// ResolveCNAMEChain returns the CNAME chain of a name, in resolution order
+// Returns nil when the name has no CNAME record
+func ResolveCNAMEChain(name, resolver string) []string {
+	records, err := QueryRecords(name, dns.TypeA, resolver)
+	if err != nil {
+		return nil
+	}
+	return cnameChain(name, records)
+}
+
+// cnameChain follows the CNAME records of an answer from name
+func cnameChain(name string, records []dns.RR) []string {
+	// Index CNAMEs by owner, answers are not guaranteed to be ordered
+	targets := make(map[string]string)
+	for _, rr := range records {
+		if cname, ok := rr.(*dns.CNAME); ok {
+			targets[strings.ToLower(cname.Hdr.Name)] = strings.ToLower(cname.Target)
+		}
+	}
+
+	var chain []string
+	current := strings.ToLower(dns.Fqdn(name))
+	for len(chain) < len(targets) {
+		target, ok := targets[current]
+		if !ok {
+			break
+		}
+		chain = append(chain, strings.TrimSuffix(target, "."))
+		current = target
+	}
+
+	return chain
+}
+
+// AddressTypes are the record types queried for the TTL and CNAME chain of a found name
+var AddressTypes = []uint16{dns.TypeA, dns.TypeAAAA}
+
+// RecordSet holds the answer sections of the queries of a name, per record type
+type RecordSet map[uint16][]dns.RR
+
+// QueryTypes sends one query per record type for a name, all at once, so several
+// types take about one round trip instead of one each
+// ANY is never sent: many servers refuse it or answer with a subset (RFC 8482)
+// Types whose query failed are left out; an error is returned when every query failed
+func QueryTypes(name string, qtypes []uint16, resolver string) (RecordSet, error) {
+	answers := make([][]dns.RR, len(qtypes))
+	errs := make([]error, len(qtypes))
+
+	var wg sync.WaitGroup
+	for i, qtype := range qtypes {
+		wg.Add(1)
+		go func(i int, qtype uint16) {
+			defer wg.Done()
+			answers[i], errs[i] = QueryRecords(name, qtype, resolver)
+		}(i, qtype)
+	}
+	wg.Wait()
+
+	set := make(RecordSet, len(qtypes))
+	var firstErr error
+	for i, qtype := range qtypes {
+		if errs[i] != nil {
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		set[qtype] = answers[i]
+	}
+	if len(set) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return set, nil
+}
+
+// TTL returns the lowest TTL of the address records of the set, in seconds
+// A records are used first, AAAA records when the name has no IPv4 address
+func (s RecordSet) TTL() (uint32, bool) {
+	for _, qtype := range AddressTypes {
+		found := false
+		var ttl uint32
+		for _, rr := range s[qtype] {
+			if rr.Header().Rrtype != qtype {
+				continue
+			}
+			if !found || rr.Header().Ttl < ttl {
+				ttl = rr.Header().Ttl
+			}
+			found = true
+		}
+		if found {
+			return ttl, true
+		}
+	}
+	return 0, false
+}
+
+// CNAMEChain returns the CNAME chain of name found in the address answers of the set
+// Returns nil when the name has no CNAME record
+func (s RecordSet) CNAMEChain(name string) []string {
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA, dns.TypeCNAME} {
+		if chain := cnameChain(name, s[qtype]); len(chain) > 0 {
+			return chain
+		}
+	}
+	return nil
+}
+
+// IsNXDomain reports whether a resolver answers that a name does not exist
+func IsNXDomain(name, resolver string) (bool, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), dns.TypeA)
+	msg.RecursionDesired = true
+
+	resp, _, err := Exchange(msg, resolver)
+	if err != nil {
+		return false, err
+	}
+	return resp.Rcode == dns.RcodeNameError, nil
+}
+
+// AuthoritativeServer returns the address of a nameserver authoritative for zone
+func AuthoritativeServer(zone, resolver string) (string, error) {
+	records, err := QueryRecords(zone, dns.TypeNS, resolver)
+	if err != nil {
+		return "", err
+	}
+
+	for _, rr := range records {
+		ns, ok := rr.(*dns.NS)
+		if !ok {
+			continue
+		}
+		if addresses, err := LookupWithResolver(ns.Ns, resolver); err == nil && len(addresses) > 0 {
+			return addresses[0], nil
+		}
+	}
+	return "", fmt.Errorf("no reachable nameserver for %s", zone)
+}
+
+// QueryDelegation asks an authoritative server of the parent zone which nameservers
+// a name is delegated to
+// The referral is read directly, so delegations are found even when the delegated
+// nameservers do not answer; returns nil when the name is not delegated
+func QueryDelegation(name, server string) ([]string, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), dns.TypeNS)
+	msg.RecursionDesired = false
+
+	resp, _, err := Exchange(msg, server)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := strings.ToLower(dns.Fqdn(name))
+	var nameservers []string
+	for _, rr := range append(resp.Answer, resp.Ns...) {
+		if ns, ok := rr.(*dns.NS); ok && strings.ToLower(ns.Hdr.Name) == owner {
+			nameservers = append(nameservers, strings.ToLower(strings.TrimSuffix(ns.Ns, ".")))
+		}
+	}
+	return nameservers, nil
+}
+
+// QueryAuthority asks a nameserver for the SOA record of zone without recursion
+// and reports whether it answers authoritatively for it; otherwise the reason
+// is returned (REFUSED, a referral elsewhere, ...)
+// An error means the nameserver did not answer at all
+func QueryAuthority(zone, server string) (bool, string, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(zone), dns.TypeSOA)
+	msg.RecursionDesired = false
+
+	resp, _, err := Exchange(msg, server)
+	if err != nil {
+		return false, "", err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return false, dns.RcodeToString[resp.Rcode], nil
+	}
+	if !resp.Authoritative {
+		return false, "not authoritative", nil
+	}
+
+	owner := strings.ToLower(dns.Fqdn(zone))
+	for _, rr := range resp.Answer {
+		if soa, ok := rr.(*dns.SOA); ok && strings.ToLower(soa.Hdr.Name) == owner {
+			return true, "", nil
+		}
+	}
+	return false, "no SOA record", nil
+}
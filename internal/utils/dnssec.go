@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"github.com/miekg/dns"
+)
+
+// DNSSECStatus is the validation state of a name as reported by a validating resolver
+type DNSSECStatus string
+
+const (
+	DNSSECSecure        DNSSECStatus = "secure"        // Answer validated (AD bit set)
+	DNSSECInsecure      DNSSECStatus = "insecure"      // Answer is not signed (unsigned delegation)
+	DNSSECBogus         DNSSECStatus = "bogus"         // Signatures exist but fail validation
+	DNSSECIndeterminate DNSSECStatus = "indeterminate" // Resolver does not validate or did not answer
+)
+
+// CheckDNSSEC asks a validating resolver about a name and derives its DNSSEC status
+// A validating resolver sets the AD bit for secure answers and returns SERVFAIL for bogus
+// ones; the SERVFAIL case is confirmed by repeating the query with checking disabled
+func CheckDNSSEC(domain, resolver string) DNSSECStatus {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(domain), dns.TypeA)
+	msg.AuthenticatedData = true
+	msg.SetEdns0(4096, true)
+
+	resp, _, err := Exchange(msg, resolver)
+	if err != nil {
+		return DNSSECIndeterminate
+	}
+
+	switch resp.Rcode {
+	case dns.RcodeSuccess, dns.RcodeNameError:
+		if resp.AuthenticatedData {
+			return DNSSECSecure
+		}
+		// Signatures without the AD bit mean the resolver is not validating
+		if hasSignatures(resp) {
+			return DNSSECIndeterminate
+		}
+		return DNSSECInsecure
+	case dns.RcodeServerFailure:
+		msg.Id = dns.Id()
+		msg.CheckingDisabled = true
+		resp, _, err = Exchange(msg, resolver)
+		if err == nil && resp.Rcode == dns.RcodeSuccess {
+			return DNSSECBogus
+		}
+	}
+
+	return DNSSECIndeterminate
+}
+
+// hasSignatures reports whether a response carries RRSIG records
+func hasSignatures(resp *dns.Msg) bool {
+	for _, section := range [][]dns.RR{resp.Answer, resp.Ns} {
+		for _, rr := range section {
+			if _, ok := rr.(*dns.RRSIG); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -3,22 +3,27 @@ package utils
 import (
 	"fmt"
 	"net"
+	"regexp"
 	"strconv"
 	"strings"
+
+	"golang.org/x/net/publicsuffix"
 )
 
 // ExtractRootDomain extracts the root domain from a subdomain
 // Used for rate limiting purposes
+// The public suffix list tells registrable domains apart (example.co.uk rather
+// than co.uk); names under no known suffix keep their last two labels
 func ExtractRootDomain(subdomain string) string {
-	parts := strings.Split(subdomain, ".")
-
-	// If it has only 1 part, return as-is
-	if len(parts) <= 1 {
-		return subdomain
+	subdomain = strings.TrimSuffix(subdomain, ".")
+	if root, err := publicsuffix.EffectiveTLDPlusOne(subdomain); err == nil {
+		return root
 	}
 
-	// If it has 2 parts, return the whole thing
-	if len(parts) == 2 {
+	parts := strings.Split(subdomain, ".")
+
+	// If it has only 1 or 2 parts, return as-is
+	if len(parts) <= 2 {
 		return subdomain
 	}
 
@@ -27,6 +32,19 @@ func ExtractRootDomain(subdomain string) string {
 	return strings.Join(parts[len(parts)-2:], ".")
 }
 
+// ParentZone returns the zone directly above a name (a.b.example.com -> b.example.com)
+// Names at or above their root domain return the root domain, so the parent
+// of a recursion level is never a public suffix
+func ParentZone(name string) string {
+	name = strings.TrimSuffix(name, ".")
+	root := ExtractRootDomain(name)
+	if len(name) <= len(root) {
+		return root
+	}
+	_, parent, _ := strings.Cut(name, ".")
+	return parent
+}
+
 // ParseCIDR extracts and validates a CIDR range
 func ParseCIDR(cidr string) (string, int, error) {
 	parts := strings.Split(cidr, "/")
@@ -86,6 +104,11 @@ func IsValidDomain(domain string) bool {
 		}
 	}
 
+	// Internationalized names are valid once converted to punycode
+	if !isASCII(domain) {
+		return false
+	}
+
 	// Domain must have at least one dot
 	if !strings.Contains(domain, ".") {
 		return false
@@ -101,7 +124,10 @@ func IsValidDomain(domain string) bool {
 		return false
 	}
 
-	// TLD must be all letters (simple check)
+	// TLD must be all letters (simple check), or the punycode of an internationalized TLD
+	if strings.HasPrefix(strings.ToLower(tld), "xn--") {
+		return len(tld) > len("xn--")
+	}
 	for _, c := range tld {
 		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) {
 			return false
@@ -110,3 +136,32 @@ func IsValidDomain(domain string) bool {
 
 	return true
 }
+
+// hostPattern matches hostnames ending in the given domain anywhere in a page
+func hostPattern(domain string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:[a-z0-9_](?:[a-z0-9_-]{0,61}[a-z0-9_])?\.)+` + regexp.QuoteMeta(domain) + `\b`)
+}
+
+// ExtractHosts returns the distinct hostnames of domain found in a page
+// Matches are lowercased and returned in order of first appearance
+func ExtractHosts(body []byte, domain string) []string {
+	var hosts []string
+	seen := make(map[string]bool)
+	for _, loc := range hostPattern(domain).FindAllIndex(body, -1) {
+		// Skip names that merely start with domain, like example.com.evil.org
+		if end := loc[1]; end+1 < len(body) && body[end] == '.' && isHostByte(body[end+1]) {
+			continue
+		}
+		host := strings.ToLower(string(body[loc[0]:loc[1]]))
+		if !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// isHostByte reports whether c can appear in a hostname label
+func isHostByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ExcludeList matches hosts that must be dropped from results and recursion
+// Supported patterns:
+//   - exact hosts:        honeypot.example.com
+//   - wildcards:          *.corp.example.com (any host under corp.example.com)
+//   - regular expressions: re:^dev[0-9]+\.
+type ExcludeList struct {
+	exact    map[string]bool
+	suffixes []string
+	regexes  []*regexp.Regexp
+	patterns []string
+}
+
+// NewExcludeList builds an ExcludeList from patterns
+// Each entry may also be a path to a file containing one pattern per line
+func NewExcludeList(entries []string) (*ExcludeList, error) {
+	patterns, err := ExpandPatterns(entries)
+	if err != nil {
+		return nil, err
+	}
+	return NewExcludeListFromPatterns(patterns)
+}
+
+// NewExcludeListFromPatterns builds an ExcludeList from patterns only, no entry is read as a file
+func NewExcludeListFromPatterns(patterns []string) (*ExcludeList, error) {
+	list := &ExcludeList{exact: make(map[string]bool)}
+
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if err := list.add(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
+
+// ExpandPatterns flattens pattern flag values
+// Entries pointing to a file are replaced by the patterns it contains (one per line, # comments)
+func ExpandPatterns(entries []string) ([]string, error) {
+	var patterns []string
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		// Load patterns from file if the entry points to one
+		if info, err := os.Stat(entry); err == nil && !info.IsDir() {
+			filePatterns, err := loadPatterns(entry)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read pattern file %s: %v", entry, err)
+			}
+			patterns = append(patterns, filePatterns...)
+			continue
+		}
+
+		patterns = append(patterns, entry)
+	}
+
+	return patterns, nil
+}
+
+// loadPatterns reads the patterns of a file, one per line
+// Blank lines and lines starting with # are skipped
+func loadPatterns(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return patterns, nil
+}
+
+// add parses a single pattern into the list
+func (l *ExcludeList) add(pattern string) error {
+	switch {
+	case strings.HasPrefix(pattern, "re:"):
+		re, err := regexp.Compile(strings.TrimPrefix(pattern, "re:"))
+		if err != nil {
+			return fmt.Errorf("invalid exclude regex %q: %v", pattern, err)
+		}
+		l.regexes = append(l.regexes, re)
+	case strings.HasPrefix(pattern, "*."):
+		l.suffixes = append(l.suffixes, strings.ToLower(strings.TrimPrefix(pattern, "*")))
+	default:
+		l.exact[strings.ToLower(strings.TrimSuffix(pattern, "."))] = true
+	}
+	l.patterns = append(l.patterns, pattern)
+	return nil
+}
+
+// Len returns the number of loaded patterns
+func (l *ExcludeList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.patterns)
+}
+
+// Patterns returns the loaded patterns, so they can be handed to another scanner
+func (l *ExcludeList) Patterns() []string {
+	if l == nil {
+		return nil
+	}
+	return l.patterns
+}
+
+// Matches reports whether a host is excluded
+// A nil list never matches
+func (l *ExcludeList) Matches(host string) bool {
+	if l == nil || len(l.patterns) == 0 {
+		return false
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if l.exact[host] {
+		return true
+	}
+	for _, suffix := range l.suffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	for _, re := range l.regexes {
+		if re.MatchString(host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandPatterns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exclude.txt")
+	os.WriteFile(path, []byte("# out of scope\nre:^dev\\d+\\.example\\.com$\n\n  *.corp.example.com  \nhoneypot.example.com\n"), 0o644)
+
+	tests := []struct {
+		entries []string
+		want    []string
+	}{
+		{nil, nil},
+		{[]string{"", "  "}, nil},
+		{[]string{"api*", " dev* "}, []string{"api*", "dev*"}},
+		{[]string{path}, []string{`re:^dev\d+\.example\.com$`, "*.corp.example.com", "honeypot.example.com"}},
+		{[]string{"www.example.com", path}, []string{"www.example.com", `re:^dev\d+\.example\.com$`, "*.corp.example.com", "honeypot.example.com"}},
+	}
+	for _, test := range tests {
+		got, err := ExpandPatterns(test.entries)
+		if err != nil {
+			t.Errorf("ExpandPatterns(%q) failed: %v", test.entries, err)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(test.want, "|") {
+			t.Errorf("ExpandPatterns(%q) = %q, want %q", test.entries, got, test.want)
+		}
+	}
+}
+
+func TestExcludeListFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exclude.txt")
+	os.WriteFile(path, []byte("re:^dev\\d+\\.example\\.com$\n*.corp.example.com\n"), 0o644)
+
+	list, err := NewExcludeList([]string{path})
+	if err != nil {
+		t.Fatalf("NewExcludeList failed: %v", err)
+	}
+	for host, want := range map[string]bool{
+		"dev12.example.com":    true,
+		"devx.example.com":     false,
+		"vpn.corp.example.com": true,
+		"www.example.com":      false,
+	} {
+		if got := list.Matches(host); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	exitHooks   []func()
+	exitHooksMu sync.Mutex
+)
+
+// OnExit registers a function run by Exit before the process ends, such as
+// flushing a file that would be unreadable if left half written
+func OnExit(hook func()) {
+	exitHooksMu.Lock()
+	defer exitHooksMu.Unlock()
+	exitHooks = append(exitHooks, hook)
+}
+
+// RunExitHooks runs the registered functions once, the latest first
+func RunExitHooks() {
+	exitHooksMu.Lock()
+	hooks := exitHooks
+	exitHooks = nil
+	exitHooksMu.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+}
+
+// Exit runs the registered functions and ends the process with code
+func Exit(code int) {
+	RunExitHooks()
+	os.Exit(code)
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ResultFilter selects which results are displayed and saved
+// Patterns use subfinder syntax (api*, *.dev.example.com) or raw regex with a re: prefix
+type ResultFilter struct {
+	match  []*regexp.Regexp // Keep only hosts matching one of these
+	filter []*regexp.Regexp // Drop hosts matching one of these
+}
+
+// NewResultFilter builds a ResultFilter from match and filter flag values
+// Returns nil when no patterns are given
+func NewResultFilter(match, filter []string) (*ResultFilter, error) {
+	matchRegexes, err := compileHostPatterns(match)
+	if err != nil {
+		return nil, err
+	}
+	filterRegexes, err := compileHostPatterns(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matchRegexes) == 0 && len(filterRegexes) == 0 {
+		return nil, nil
+	}
+
+	return &ResultFilter{match: matchRegexes, filter: filterRegexes}, nil
+}
+
+// compileHostPatterns expands pattern files and compiles each pattern
+func compileHostPatterns(entries []string) ([]*regexp.Regexp, error) {
+	patterns, err := ExpandPatterns(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	var regexes []*regexp.Regexp
+	for _, pattern := range patterns {
+		expr := pattern
+		if strings.HasPrefix(pattern, "re:") {
+			expr = strings.TrimPrefix(pattern, "re:")
+		} else {
+			// Wildcard pattern: escape dots, expand stars and anchor
+			expr = strings.ReplaceAll(expr, ".", "\\.")
+			expr = strings.ReplaceAll(expr, "*", ".*")
+			expr = "^" + expr + "$"
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		regexes = append(regexes, re)
+	}
+
+	return regexes, nil
+}
+
+// Allows reports whether a host passes the match and filter rules
+// A nil filter allows everything
+func (f *ResultFilter) Allows(host string) bool {
+	if f == nil {
+		return true
+	}
+
+	host = strings.ToLower(host)
+	for _, re := range f.filter {
+		if re.MatchString(host) {
+			return false
+		}
+	}
+
+	if len(f.match) == 0 {
+		return true
+	}
+	for _, re := range f.match {
+		if re.MatchString(host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var (
+	requestHeaders   http.Header
+	requestHeadersMu sync.RWMutex
+)
+
+// ParseHeaders parses "Name: value" header specifications
+func ParseHeaders(specs []string) (http.Header, error) {
+	header := make(http.Header)
+	for _, spec := range specs {
+		name, value, ok := strings.Cut(spec, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid header %q, expected \"Name: value\"", spec)
+		}
+		header.Add(name, strings.TrimSpace(value))
+	}
+	return header, nil
+}
+
+// SetRequestHeaders sets the headers added to every request sent to targets
+// They replace headers of the same name set by the request itself, like User-Agent
+// Requests to third-party services, such as passive sources, only get the User-Agent,
+// the other headers may hold engagement tokens meant for the targets
+func SetRequestHeaders(header http.Header) {
+	requestHeadersMu.Lock()
+	defer requestHeadersMu.Unlock()
+	requestHeaders = header.Clone()
+}
+
+// headerTransport adds the configured request headers before sending a request
+// Headers are read when the request is sent, so clients created before they are set apply them too
+type headerTransport struct {
+	base          http.RoundTripper
+	userAgentOnly bool // Requests go to a third-party service, not to a target
+}
+
+// WrapTransport returns a transport adding the configured request headers to every request
+// It is meant for requests to targets (probes, takeover checks, crawls)
+// A nil base stands for http.DefaultTransport
+func WrapTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return headerTransport{base: base}
+}
+
+// WrapServiceTransport returns a transport adding only the configured User-Agent,
+// for requests to third-party services such as passive sources and registries
+// A nil base stands for http.DefaultTransport
+func WrapServiceTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return headerTransport{base: base, userAgentOnly: true}
+}
+
+// RoundTrip sends a copy of the request carrying the configured headers
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestHeadersMu.RLock()
+	header := requestHeaders
+	requestHeadersMu.RUnlock()
+
+	if t.userAgentOnly {
+		if agent := header.Get("User-Agent"); agent != "" {
+			header = http.Header{"User-Agent": {agent}}
+		} else {
+			header = nil
+		}
+	}
+
+	if len(header) == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	for name, values := range header {
+		if strings.EqualFold(name, "Host") {
+			req.Host = values[0]
+			continue
+		}
+		req.Header[name] = values
+	}
+	return t.base.RoundTrip(req)
+}
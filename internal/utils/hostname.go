@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"strings"
+)
+
+// Length limits of RFC 1035
+const (
+	MaxLabelLength = 63  // Bytes of a single label
+	MaxNameLength  = 253 // Bytes of a name without the trailing dot
+)
+
+// NormalizeWord turns a wordlist entry into the labels prepended to a target
+// Case, surrounding dots, URL schemes, ports, paths and queries are dropped and
+// internationalized labels are converted to punycode (example: "https://API.Dev./login" -> "api.dev")
+// Returns "" when what is left is not a valid hostname part
+func NormalizeWord(word string) string {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if _, rest, ok := strings.Cut(word, "://"); ok {
+		word = rest
+	}
+	if i := strings.IndexAny(word, "/?#"); i >= 0 {
+		word = word[:i]
+	}
+	if i := strings.LastIndex(word, ":"); i >= 0 {
+		word = word[:i]
+	}
+	word = strings.Trim(word, ".")
+
+	if !isASCII(word) {
+		ascii, err := ToASCII(word)
+		if err != nil {
+			return ""
+		}
+		word = ascii
+	}
+	if word == "" || len(word) > MaxNameLength {
+		return ""
+	}
+	for _, label := range strings.Split(word, ".") {
+		if !IsValidLabel(label) {
+			return ""
+		}
+	}
+	return word
+}
+
+// IsValidHostname reports whether name fits the RFC 1035 length limits and is made of valid labels
+// A trailing dot is accepted
+func IsValidHostname(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" || len(name) > MaxNameLength {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if !IsValidLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsValidLabel reports whether a label is 1 to 63 letters, digits and inner hyphens
+// An underscore is accepted as the first character of service labels such as _dmarc or _sip
+func IsValidLabel(label string) bool {
+	if label == "" || len(label) > MaxLabelLength {
+		return false
+	}
+	body := strings.TrimPrefix(label, "_")
+	if body == "" || body[0] == '-' || body[len(body)-1] == '-' {
+		return false
+	}
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
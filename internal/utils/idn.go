@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// Forms in which internationalized domain names are displayed
+const (
+	IDNUnicode = "unicode" // bücher.example
+	IDNASCII   = "ascii"   // xn--bcher-kva.example
+)
+
+// idnProfile converts names with IDNA2008 rules: ß and ς are kept rather than mapped
+// to ss and σ as the transitional rules of IDNA2003 did
+var idnProfile = idna.New(idna.MapForLookup(), idna.Transitional(false), idna.BidiRule())
+
+// idnDisplay is the form of internationalized names in reports, see SetIDNDisplay
+var idnDisplay = IDNUnicode
+
+// SetIDNDisplay sets the form of internationalized names in the console and HTML reports
+func SetIDNDisplay(form string) error {
+	form = strings.ToLower(strings.TrimSpace(form))
+	if form != IDNUnicode && form != IDNASCII {
+		return fmt.Errorf("invalid IDN display form %q, use %s or %s", form, IDNUnicode, IDNASCII)
+	}
+	idnDisplay = form
+	return nil
+}
+
+// ToASCII converts a domain to the punycode form used in DNS queries (bücher.example -> xn--bcher-kva.example)
+func ToASCII(domain string) (string, error) {
+	ascii, err := idnProfile.ToASCII(domain)
+	if err != nil {
+		return "", fmt.Errorf("invalid internationalized domain %q: %v", domain, err)
+	}
+	return ascii, nil
+}
+
+// ToUnicode converts the punycode labels of a domain back to Unicode, the domain unchanged when they are invalid
+func ToUnicode(domain string) string {
+	if !strings.Contains(domain, "xn--") {
+		return domain
+	}
+	unicode, err := idnProfile.ToUnicode(domain)
+	if err != nil {
+		return domain
+	}
+	return unicode
+}
+
+// DisplayDomain returns a domain in the display form chosen with SetIDNDisplay
+func DisplayDomain(domain string) string {
+	if idnDisplay == IDNASCII {
+		return domain
+	}
+	return ToUnicode(domain)
+}
+
+// isASCII reports whether a string only holds ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
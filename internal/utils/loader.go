@@ -3,6 +3,8 @@ package utils
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -119,7 +121,8 @@ func LoadWordlistReader(filePath string) (io.Reader, error) {
 }
 
 // LoadResolvers reads a list of DNS resolvers from a file
-// Each resolver should be on a new line
+// Each resolver should be on a new line, as an address with an optional port or a
+// tcp://, tls:// or https:// URL (see ParseResolver)
 // Lines starting with # are treated as comments
 // Returns a slice of resolver addresses and any errors encountered
 func LoadResolvers(filePath string) ([]string, error) {
@@ -131,11 +134,17 @@ func LoadResolvers(filePath string) ([]string, error) {
 
 	var resolvers []string
 	scanner := bufio.NewScanner(file)
+	line := 0
 	for scanner.Scan() {
+		line++
 		resolver := strings.TrimSpace(scanner.Text())
-		if resolver != "" && !strings.HasPrefix(resolver, "#") {
-			resolvers = append(resolvers, resolver)
+		if resolver == "" || strings.HasPrefix(resolver, "#") {
+			continue
 		}
+		if _, err := ParseResolver(resolver); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filePath, line, err)
+		}
+		resolvers = append(resolvers, resolver)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -194,3 +203,18 @@ func CountLinesInFile(filePath string) (int, error) {
 
 	return count, nil
 }
+
+// FileSHA256 returns the hex SHA-256 checksum of the content of a file
+func FileSHA256(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
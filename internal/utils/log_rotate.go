@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// logBackupFormat is the timestamp added to the name of rotated log files
+const logBackupFormat = "20060102-150405.000"
+
+// RotatingFile is a log file appended to and moved aside once it grows past
+// MaxSize bytes or has been written to for longer than MaxAge
+// The MaxBackups most recent rotated files are kept, older ones are removed
+type RotatingFile struct {
+	Path       string        // Log file appended to
+	MaxSize    int64         // Size at which the file is rotated, 0 to never rotate on size
+	MaxAge     time.Duration // Age at which the file is rotated, 0 to never rotate on age
+	MaxBackups int           // Rotated files kept, 0 to keep all of them
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// OpenRotatingFile opens the log file at path in append mode, keeping the
+// lines of earlier runs; a file already older than maxAge is rotated on the first write
+func OpenRotatingFile(path string, maxSize int64, maxAge time.Duration, maxBackups int) (*RotatingFile, error) {
+	if maxSize < 0 || maxAge < 0 || maxBackups < 0 {
+		return nil, fmt.Errorf("log rotation limits cannot be negative")
+	}
+	r := &RotatingFile{Path: path, MaxSize: maxSize, MaxAge: maxAge, MaxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the log file and measures what it already holds
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+
+	r.file, r.size, r.opened = file, info.Size(), time.Now()
+	if r.size > 0 && r.MaxAge > 0 && time.Since(info.ModTime()) >= r.MaxAge {
+		r.opened = info.ModTime()
+	}
+	return nil
+}
+
+// Write appends p to the log file, rotating it first when p would go past
+// MaxSize or the file is older than MaxAge
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if r.size > 0 && ((r.MaxSize > 0 && r.size+int64(len(p)) > r.MaxSize) ||
+		(r.MaxAge > 0 && time.Since(r.opened) >= r.MaxAge)) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate moves the log file aside with a timestamp, starts a new one and
+// removes the rotated files past MaxBackups
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+
+	backup := r.Path + "." + time.Now().Format(logBackupFormat)
+	if err := os.Rename(r.Path, backup); err != nil {
+		return fmt.Errorf("failed to rotate log file: %v", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.opened = time.Now()
+	r.prune()
+	return nil
+}
+
+// prune removes the oldest rotated files once there are more than MaxBackups
+// Failures are ignored, the next rotation tries again
+func (r *RotatingFile) prune() {
+	if r.MaxBackups <= 0 {
+		return
+	}
+	backups, err := filepath.Glob(r.Path + ".*")
+	if err != nil {
+		return
+	}
+
+	var rotated []string
+	prefix := len(r.Path) + 1
+	for _, backup := range backups {
+		if _, err := time.Parse(logBackupFormat, backup[prefix:]); err == nil {
+			rotated = append(rotated, backup)
+		}
+	}
+	if len(rotated) <= r.MaxBackups {
+		return
+	}
+	// Timestamps sort in the order the files were rotated
+	sort.Strings(rotated)
+	for _, backup := range rotated[:len(rotated)-r.MaxBackups] {
+		os.Remove(backup)
+	}
+}
+
+// Close closes the log file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -22,19 +25,34 @@ const (
 
 // LoggerConfig is the configuration for Logger
 type LoggerConfig struct {
-	Level        LogLevel  // Minimum log level
-	OutputFile   string    // Path to output file (optional)
-	ColorEnabled bool      // Whether color is enabled
-	TimeFormat   string    // Timestamp format
-	Writer       io.Writer // Custom writer (optional, default: os.Stdout)
+	Level        LogLevel      // Minimum log level
+	OutputFile   string        // Path to output file (optional)
+	ColorEnabled bool          // Whether color is enabled
+	TimeFormat   string        // Timestamp format
+	Writer       io.Writer     // Custom writer (optional, default: os.Stdout)
+	MaxSize      int64         // Size at which the output file is rotated (optional)
+	MaxAge       time.Duration // Age at which the output file is rotated (optional)
+	MaxBackups   int           // Rotated output files kept (optional, default: all)
+	JSON         bool          // Whether messages are written as JSON lines
 }
 
 // Logger is a thread-safe structured logger
+// Child loggers created with With add their fields to every message and write
+// through the logger they come from, sharing its level, file and format
 type Logger struct {
 	config LoggerConfig
 	mu     sync.Mutex
-	file   *os.File
+	file   *RotatingFile
 	writer io.Writer
+	base   *Logger // Logger written through, nil for a root logger
+	global bool    // Writes through the global logger, resolved on each message
+	fields []Field
+}
+
+// Field is a key and value attached to the messages of a child logger
+type Field struct {
+	Key   string
+	Value interface{}
 }
 
 // String representation of log level
@@ -87,9 +105,9 @@ func NewLogger(config LoggerConfig) (*Logger, error) {
 
 	// Open output file if provided
 	if config.OutputFile != "" {
-		file, err := os.OpenFile(config.OutputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		file, err := OpenRotatingFile(config.OutputFile, config.MaxSize, config.MaxAge, config.MaxBackups)
 		if err != nil {
-			return nil, fmt.Errorf("failed to open log file: %v", err)
+			return nil, err
 		}
 		logger.file = file
 
@@ -107,8 +125,33 @@ func NewLogger(config LoggerConfig) (*Logger, error) {
 	return logger, nil
 }
 
+// With returns a child logger adding key=value to every message, after the
+// fields of l (example: With("module", "scanner").With("domain", "example.com"))
+func (l *Logger) With(key string, value interface{}) *Logger {
+	fields := make([]Field, len(l.fields), len(l.fields)+1)
+	copy(fields, l.fields)
+	child := &Logger{base: l.base, global: l.global, fields: append(fields, Field{key, value})}
+	if child.base == nil && !child.global {
+		child.base = l
+	}
+	return child
+}
+
+// root returns the logger the messages of l are written through
+func (l *Logger) root() *Logger {
+	switch {
+	case l.base != nil:
+		return l.base
+	case l.global:
+		return GetLogger()
+	default:
+		return l
+	}
+}
+
 // Close closes the logger and related file
 func (l *Logger) Close() error {
+	l = l.root()
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -118,16 +161,69 @@ func (l *Logger) Close() error {
 	return nil
 }
 
-// formatMessage formats a log message with level, timestamp, and color
-func (l *Logger) formatMessage(level LogLevel, message string) string {
-	timestamp := time.Now().Format(l.config.TimeFormat)
+// SetFile starts copying messages to the log file at path, rotated past maxSize
+// bytes or maxAge, and closes the previous one; an empty path stops the copy
+func (l *Logger) SetFile(path string, maxSize int64, maxAge time.Duration, maxBackups int) error {
+	var file *RotatingFile
+	if path != "" {
+		var err error
+		if file, err = OpenRotatingFile(path, maxSize, maxAge, maxBackups); err != nil {
+			return err
+		}
+	}
+
+	l = l.root()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		if l.writer == l.file {
+			l.writer = io.Discard
+		}
+		l.file.Close()
+	}
+	l.file = file
+	l.config.OutputFile, l.config.MaxSize, l.config.MaxAge, l.config.MaxBackups = path, maxSize, maxAge, maxBackups
+	return nil
+}
+
+// SetJSON switches messages between JSON lines and plain text
+func (l *Logger) SetJSON(enabled bool) {
+	l = l.root()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config.JSON = enabled
+}
+
+// logLine is a message written as a JSON line
+// Fields are nested so they cannot collide with the time, level or message
+type logLine struct {
+	Time    string          `json:"time"`
+	Level   string          `json:"level"`
+	Message string          `json:"message"`
+	Fields  json.RawMessage `json:"fields,omitempty"`
+}
+
+// formatMessage formats a log message with level, timestamp and fields, in color
+// when color is set and the logger writes plain text to a terminal stream
+func (l *Logger) formatMessage(level LogLevel, message string, fields []Field, color bool) string {
+	now := time.Now()
 	levelStr := level.String()
 
-	// Basic format: [LEVEL] [TIME] message
-	formatted := fmt.Sprintf("[%s] [%s] %s", levelStr, timestamp, message)
+	if l.config.JSON {
+		entry := logLine{Time: now.Format(time.RFC3339Nano), Level: levelStr, Message: message}
+		if len(fields) > 0 {
+			entry.Fields = jsonFields(fields)
+		}
+		line, _ := json.Marshal(entry)
+		return string(line)
+	}
+	timestamp := now.Format(l.config.TimeFormat)
+
+	// Basic format: [LEVEL] [TIME] key=value message
+	formatted := fmt.Sprintf("[%s] [%s] %s%s", levelStr, timestamp, textFields(fields), message)
 
 	// Add color if enabled
-	if l.config.ColorEnabled && l.writer == os.Stdout {
+	if color && l.config.ColorEnabled && (l.writer == os.Stdout || l.writer == os.Stderr) {
 		colorCode := level.Color()
 		resetCode := "\033[0m"
 		formatted = fmt.Sprintf("%s%s%s", colorCode, formatted, resetCode)
@@ -136,10 +232,60 @@ func (l *Logger) formatMessage(level LogLevel, message string) string {
 	return formatted
 }
 
+// textFields returns fields as key=value pairs followed by a space, values
+// holding spaces or quotes are quoted
+func textFields(fields []Field) string {
+	var b strings.Builder
+	for _, field := range fields {
+		value := fmt.Sprint(field.Value)
+		if value == "" || strings.ContainsAny(value, " \t\"=") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, "%s=%s ", field.Key, value)
+	}
+	return b.String()
+}
+
+// jsonFields returns fields as a JSON object in their order, a later field
+// replacing an earlier one with the same key
+func jsonFields(fields []Field) json.RawMessage {
+	index := make(map[string]int, len(fields))
+	var kept []Field
+	for _, field := range fields {
+		if i, ok := index[field.Key]; ok {
+			kept[i] = field
+			continue
+		}
+		index[field.Key] = len(kept)
+		kept = append(kept, field)
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, field := range kept {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(field.Key)
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			value, _ = json.Marshal(fmt.Sprint(field.Value))
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes()
+}
+
 // log is an internal method for writing log messages
 func (l *Logger) log(level LogLevel, message string, args ...interface{}) {
+	fields := l.fields
+	l = l.root()
+
 	// Skip if level is lower than configuration
-	if level < l.config.Level {
+	if level < l.Level() {
 		return
 	}
 
@@ -148,24 +294,24 @@ func (l *Logger) log(level LogLevel, message string, args ...interface{}) {
 		message = fmt.Sprintf(message, args...)
 	}
 
+	// Write log to output with mutex for thread safety
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	// Format log message
-	formatted := l.formatMessage(level, message)
+	formatted := l.formatMessage(level, message, fields, true)
 
 	// Add newline if not already there
 	if !strings.HasSuffix(formatted, "\n") {
 		formatted += "\n"
 	}
 
-	// Write log to output with mutex for thread safety
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
 	fmt.Fprint(l.writer, formatted)
 
 	// If we have a file and custom writer, also write to file
 	if l.file != nil && l.writer != l.file {
 		// Format without color for file
-		plainFormatted := l.formatMessage(level, message)
+		plainFormatted := l.formatMessage(level, message, fields, false)
 		if !strings.HasSuffix(plainFormatted, "\n") {
 			plainFormatted += "\n"
 		}
@@ -178,6 +324,22 @@ func (l *Logger) log(level LogLevel, message string, args ...interface{}) {
 	}
 }
 
+// Level returns the minimum level of the messages written
+func (l *Logger) Level() LogLevel {
+	l = l.root()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.config.Level
+}
+
+// SetLevel changes the minimum level of the messages written
+func (l *Logger) SetLevel(level LogLevel) {
+	l = l.root()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config.Level = level
+}
+
 // Debug logs a message with Debug level
 func (l *Logger) Debug(message string, args ...interface{}) {
 	l.log(LevelDebug, message, args...)
@@ -232,6 +394,40 @@ func GetLogger() *Logger {
 
 // Helper functions for using the global logger directly
 
+// SetLogLevel changes the minimum level of the global logger
+func SetLogLevel(level LogLevel) {
+	GetLogger().SetLevel(level)
+}
+
+// With returns a child of the global logger adding key=value to every message
+// The global logger is looked up on each message, so package level children
+// follow the logger set up in main
+func With(key string, value interface{}) *Logger {
+	return (&Logger{global: true}).With(key, value)
+}
+
+// SetLogFile copies the messages of the global logger to a log file rotated past
+// maxSize bytes or maxAge, keeping maxBackups rotated files
+func SetLogFile(path string, maxSize int64, maxAge time.Duration, maxBackups int) error {
+	return GetLogger().SetFile(path, maxSize, maxAge, maxBackups)
+}
+
+// SetLogJSON switches the global logger between JSON lines and plain text
+func SetLogJSON(enabled bool) {
+	GetLogger().SetJSON(enabled)
+}
+
+// CloseLogger closes the log file of the global logger
+func CloseLogger() error {
+	return GetLogger().Close()
+}
+
+// DebugEnabled reports whether the global logger writes Debug messages,
+// so callers can skip preparing messages nobody reads
+func DebugEnabled() bool {
+	return GetLogger().Level() <= LevelDebug
+}
+
 // Debug logs a message with Debug level using the global logger
 func Debug(message string, args ...interface{}) {
 	GetLogger().Debug(message, args...)
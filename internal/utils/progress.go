@@ -2,7 +2,9 @@ package utils
 
 import (
 	"fmt"
+	"io"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/cheggaaa/pb/v3"
@@ -30,12 +32,30 @@ func CreateProgressBar(totalTasks int) *pb.ProgressBar {
 	bar.SetWidth(50)                          // Large width for visual detail
 	bar.SetMaxWidth(140)                      // Maximum width for dramatic display
 	bar.SetRefreshRate(time.Millisecond * 40) // Very smooth animation
+	// Quiet bars render nothing, also where results are printed around them
+	if quietProgress.Load() {
+		bar.SetTemplateString("")
+		bar.SetWriter(io.Discard)
+	}
 
 	return bar
 }
 
+// quietProgress hides progress bars and spinners, set with --quiet
+var quietProgress atomic.Bool
+
+// SetQuietProgress hides or shows the progress bars and spinners started afterwards
+func SetQuietProgress(quiet bool) {
+	quietProgress.Store(quiet)
+}
+
 // ShowLoading displays a spinner with modern cyberpunk aesthetics
 func ShowLoading(stopChan chan bool) {
+	if quietProgress.Load() {
+		<-stopChan
+		return
+	}
+
 	// Neon spinner with pulsing effect
 	pulseChars := []string{" ", "▂", "▃", "▄", "▅", "▆", "▇", "█", "▇", "▆", "▅", "▄"}
 
@@ -0,0 +1,78 @@
+package utils
+
+import "strings"
+
+// providerSuffixes maps CNAME target suffixes to the provider serving them
+// More specific suffixes must come before broader ones of the same provider
+var providerSuffixes = []struct {
+	suffix   string
+	provider string
+}{
+	{".cloudfront.net", "AWS CloudFront"},
+	{".elb.amazonaws.com", "AWS ELB"},
+	{".s3.amazonaws.com", "AWS S3"},
+	{".awsglobalaccelerator.com", "AWS Global Accelerator"},
+	{".elasticbeanstalk.com", "AWS Elastic Beanstalk"},
+	{".amazonaws.com", "AWS"},
+	{".azurewebsites.net", "Azure App Service"},
+	{".azureedge.net", "Azure CDN"},
+	{".azurefd.net", "Azure Front Door"},
+	{".trafficmanager.net", "Azure Traffic Manager"},
+	{".blob.core.windows.net", "Azure Blob Storage"},
+	{".cloudapp.net", "Azure"},
+	{".cloudapp.azure.com", "Azure"},
+	{".googlehosted.com", "Google"},
+	{".appspot.com", "Google App Engine"},
+	{".run.app", "Google Cloud Run"},
+	{".storage.googleapis.com", "Google Cloud Storage"},
+	{".firebaseapp.com", "Firebase"},
+	{".web.app", "Firebase"},
+	{".cdn.cloudflare.net", "Cloudflare"},
+	{".pages.dev", "Cloudflare Pages"},
+	{".workers.dev", "Cloudflare Workers"},
+	{".fastly.net", "Fastly"},
+	{".fastlylb.net", "Fastly"},
+	{".akamaiedge.net", "Akamai"},
+	{".akamai.net", "Akamai"},
+	{".edgekey.net", "Akamai"},
+	{".edgesuite.net", "Akamai"},
+	{".akamaihd.net", "Akamai"},
+	{".github.io", "GitHub Pages"},
+	{".herokuapp.com", "Heroku"},
+	{".herokudns.com", "Heroku"},
+	{".netlify.app", "Netlify"},
+	{".netlify.com", "Netlify"},
+	{".vercel-dns.com", "Vercel"},
+	{".vercel.app", "Vercel"},
+	{".myshopify.com", "Shopify"},
+	{".zendesk.com", "Zendesk"},
+	{".wpengine.com", "WP Engine"},
+	{".pantheonsite.io", "Pantheon"},
+	{".ghost.io", "Ghost"},
+	{".squarespace.com", "Squarespace"},
+	{".wixdns.net", "Wix"},
+	{".digitaloceanspaces.com", "DigitalOcean Spaces"},
+	{".ondigitalocean.app", "DigitalOcean App Platform"},
+	{".fly.dev", "Fly.io"},
+	{".onrender.com", "Render"},
+	{".readthedocs.io", "Read the Docs"},
+	{".statuspage.io", "Statuspage"},
+	{".helpscoutdocs.com", "Help Scout"},
+	{".freshdesk.com", "Freshdesk"},
+	{".incapdns.net", "Imperva"},
+	{".sucuri.net", "Sucuri"},
+}
+
+// DetectProvider returns the provider serving a CNAME chain, checking the last target first
+// Returns an empty string when no target matches a known provider
+func DetectProvider(chain []string) string {
+	for i := len(chain) - 1; i >= 0; i-- {
+		host := "." + strings.ToLower(strings.TrimSuffix(chain[i], "."))
+		for _, entry := range providerSuffixes {
+			if strings.HasSuffix(host, entry.suffix) {
+				return entry.provider
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// proxySchemes are the proxy protocols net/http can talk
+var proxySchemes = map[string]bool{"http": true, "https": true, "socks5": true, "socks5h": true}
+
+// ParseProxy parses a proxy URL such as http://127.0.0.1:8080 or socks5://proxy:1080
+// url.Parse takes "proxy:8080" for a URL of scheme "proxy", so the scheme and host are checked
+func ParseProxy(proxy string) (*url.URL, error) {
+	proxyURL, err := url.Parse(strings.TrimSpace(proxy))
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %v", proxy, err)
+	}
+	if !proxySchemes[strings.ToLower(proxyURL.Scheme)] || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q, expected http://host:port, https://host:port or socks5://host:port", proxy)
+	}
+	return proxyURL, nil
+}
@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OtherTarget labels queries for names outside every target, such as CNAME targets
+const OtherTarget = "other"
+
+// queryRecorder receives every DNS query sent while it is set
+var queryRecorder atomic.Pointer[QueryRecorder]
+
+// QueryRecorder counts the DNS queries sent each second, per resolver and per
+// target domain, as evidence of the rates a scan kept to
+type QueryRecorder struct {
+	mu        sync.Mutex
+	start     time.Time
+	targets   []string
+	resolvers map[string]map[int64]int64 // Resolver -> second since start -> queries
+	domains   map[string]map[int64]int64 // Target -> second since start -> queries
+}
+
+// StartQueryRecorder records the DNS queries sent from now on, attributing each
+// one to the longest of targets its name belongs to
+func StartQueryRecorder(targets []string) *QueryRecorder {
+	r := &QueryRecorder{
+		start:     time.Now(),
+		resolvers: make(map[string]map[int64]int64),
+		domains:   make(map[string]map[int64]int64),
+	}
+	for _, target := range targets {
+		r.targets = append(r.targets, strings.ToLower(strings.TrimSuffix(target, ".")))
+	}
+	queryRecorder.Store(r)
+	return r
+}
+
+// StopQueryRecorder stops recording queries
+func StopQueryRecorder() {
+	queryRecorder.Store(nil)
+}
+
+// recordQuery counts one query for name sent to resolver, when recording
+func recordQuery(resolver, name string) {
+	if r := queryRecorder.Load(); r != nil {
+		r.Record(resolver, name)
+	}
+}
+
+// Record counts one query for name sent to resolver
+func (r *QueryRecorder) Record(resolver, name string) {
+	target := r.targetOf(name)
+	second := int64(time.Since(r.start) / time.Second)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	countQuery(r.resolvers, resolver, second)
+	countQuery(r.domains, target, second)
+}
+
+// countQuery adds a query to the count of key in second
+func countQuery(counts map[string]map[int64]int64, key string, second int64) {
+	seconds, ok := counts[key]
+	if !ok {
+		seconds = make(map[int64]int64)
+		counts[key] = seconds
+	}
+	seconds[second]++
+}
+
+// targetOf returns the longest target name belongs to, OtherTarget for none
+func (r *QueryRecorder) targetOf(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	target := OtherTarget
+	for _, t := range r.targets {
+		if (name == t || strings.HasSuffix(name, "."+t)) && (target == OtherTarget || len(t) > len(target)) {
+			target = t
+		}
+	}
+	return target
+}
+
+// QueryRateReport summarizes the query rates of a scan
+type QueryRateReport struct {
+	StartedAt   time.Time    `json:"started_at"`
+	FinishedAt  time.Time    `json:"finished_at"`
+	Fingerprint string       `json:"config_fingerprint,omitempty"` // Config fingerprint of the scan
+	Total       QueryRates   `json:"total"`
+	Targets     []QueryRates `json:"targets"`
+	Resolvers   []QueryRates `json:"resolvers"`
+}
+
+// QueryRates are the queries of one target or resolver over a scan
+type QueryRates struct {
+	Name       string        `json:"name"`
+	Queries    int64         `json:"queries"`
+	AverageQPS float64       `json:"average_qps"` // Queries divided by the length of the scan
+	PeakQPS    int64         `json:"peak_qps"`    // Most queries sent within one second
+	PeakAt     time.Time     `json:"peak_at"`     // Start of the busiest second
+	Timeline   []QuerySecond `json:"timeline"`    // Seconds in which queries were sent
+}
+
+// QuerySecond is the number of queries sent within one second
+type QuerySecond struct {
+	Time    time.Time `json:"time"`
+	Queries int64     `json:"queries"`
+}
+
+// Report returns the rates recorded until now, the busiest targets and resolvers first
+func (r *QueryRecorder) Report() QueryRateReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := QueryRateReport{StartedAt: r.start, FinishedAt: time.Now()}
+	elapsed := report.FinishedAt.Sub(r.start).Seconds()
+	// Rates over less than a second are rates of that second
+	if elapsed < 1 {
+		elapsed = 1
+	}
+
+	total := make(map[int64]int64)
+	for _, seconds := range r.resolvers {
+		for second, queries := range seconds {
+			total[second] += queries
+		}
+	}
+	report.Total = r.rates("total", total, elapsed)
+	report.Targets = r.ratesOf(r.domains, elapsed)
+	report.Resolvers = r.ratesOf(r.resolvers, elapsed)
+	return report
+}
+
+// ratesOf returns the rates of every key of counts, the most queries first
+func (r *QueryRecorder) ratesOf(counts map[string]map[int64]int64, elapsed float64) []QueryRates {
+	rates := make([]QueryRates, 0, len(counts))
+	for name, seconds := range counts {
+		rates = append(rates, r.rates(name, seconds, elapsed))
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Queries != rates[j].Queries {
+			return rates[i].Queries > rates[j].Queries
+		}
+		return rates[i].Name < rates[j].Name
+	})
+	return rates
+}
+
+// rates computes the totals, average and peak of the queries sent each second
+func (r *QueryRecorder) rates(name string, seconds map[int64]int64, elapsed float64) QueryRates {
+	rates := QueryRates{Name: name, Timeline: make([]QuerySecond, 0, len(seconds))}
+	order := make([]int64, 0, len(seconds))
+	for second := range seconds {
+		order = append(order, second)
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i] < order[j] })
+
+	for _, second := range order {
+		queries := seconds[second]
+		at := r.start.Add(time.Duration(second) * time.Second)
+		rates.Queries += queries
+		rates.Timeline = append(rates.Timeline, QuerySecond{Time: at, Queries: queries})
+		if queries > rates.PeakQPS {
+			rates.PeakQPS, rates.PeakAt = queries, at
+		}
+	}
+	rates.AverageQPS = float64(rates.Queries) / elapsed
+	return rates
+}
+
+// Save writes the report to path, as CSV with one summary row per target and
+// resolver when the file ends in .csv and as JSON with the timelines otherwise
+func (report QueryRateReport) Save(path string) error {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return report.saveCSV(path)
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// saveCSV writes one row per target and resolver with its totals, average and peak
+func (report QueryRateReport) saveCSV(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"kind", "name", "queries", "average_qps", "peak_qps", "peak_at", "started_at", "finished_at", "config_fingerprint"})
+	row := func(kind string, rates QueryRates) {
+		peakAt := ""
+		if rates.PeakQPS > 0 {
+			peakAt = rates.PeakAt.Format(time.RFC3339)
+		}
+		w.Write([]string{kind, rates.Name, strconv.FormatInt(rates.Queries, 10),
+			strconv.FormatFloat(rates.AverageQPS, 'f', 2, 64), strconv.FormatInt(rates.PeakQPS, 10), peakAt,
+			report.StartedAt.Format(time.RFC3339), report.FinishedAt.Format(time.RFC3339), report.Fingerprint})
+	}
+	row("total", report.Total)
+	for _, rates := range report.Targets {
+		row("target", rates)
+	}
+	for _, rates := range report.Resolvers {
+		row("resolver", rates)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// recordingResolver returns a Go resolver using the system nameservers that
+// records every query it sends for name, so their rates can be reported
+func recordingResolver(name string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			recordQuery(address, name)
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// String returns the resolver as it is named in reports
+func (r Resolver) String() string {
+	switch r.Protocol {
+	case ResolverHTTPS:
+		return r.URL
+	case ResolverUDP:
+		return r.Address
+	default:
+		return fmt.Sprintf("%s://%s", r.Protocol, r.Address)
+	}
+}
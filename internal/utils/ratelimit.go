@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// DomainRateLimiter spaces out requests per key, usually a root domain
+// Requests for different keys do not delay each other
+type DomainRateLimiter struct {
+	interval time.Duration
+	next     map[string]time.Time
+	mutex    sync.Mutex
+}
+
+// NewDomainRateLimiter creates a limiter allowing one request per interval for each key
+func NewDomainRateLimiter(interval time.Duration) *DomainRateLimiter {
+	return &DomainRateLimiter{
+		interval: interval,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// Wait blocks until a request for the given key is allowed
+func (l *DomainRateLimiter) Wait(key string) {
+	if l.interval <= 0 {
+		return
+	}
+
+	// Reserve the next free slot for this key
+	l.mutex.Lock()
+	now := time.Now()
+	slot := l.next[key]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[key] = slot.Add(l.interval)
+	l.mutex.Unlock()
+
+	time.Sleep(time.Until(slot))
+}
+
+// KeyedSemaphore caps the requests running at once per key
+// Requests for different keys do not wait for each other
+type KeyedSemaphore struct {
+	limit int
+	slots sync.Map // Key -> chan struct{} holding one token per running request
+}
+
+// NewKeyedSemaphore creates a semaphore allowing limit concurrent requests per key
+// Returns nil, which never blocks, when limit is not positive
+func NewKeyedSemaphore(limit int) *KeyedSemaphore {
+	if limit <= 0 {
+		return nil
+	}
+	return &KeyedSemaphore{limit: limit}
+}
+
+// Acquire blocks until a request for the given key may run
+func (s *KeyedSemaphore) Acquire(key string) {
+	if s == nil {
+		return
+	}
+	slots, _ := s.slots.LoadOrStore(key, make(chan struct{}, s.limit))
+	slots.(chan struct{}) <- struct{}{}
+}
+
+// Release ends a request started with Acquire
+func (s *KeyedSemaphore) Release(key string) {
+	if s == nil {
+		return
+	}
+	if slots, ok := s.slots.Load(key); ok {
+		<-slots.(chan struct{})
+	}
+}
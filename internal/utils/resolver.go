@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Protocols resolvers are queried over
+const (
+	ResolverUDP   = "udp"   // 1.1.1.1 or 1.1.1.1:5353, plain DNS repeated over TCP when answers are truncated
+	ResolverTCP   = "tcp"   // tcp://9.9.9.9, plain DNS over TCP
+	ResolverTLS   = "tls"   // tls://8.8.8.8, DNS over TLS (RFC 7858) on port 853
+	ResolverHTTPS = "https" // https://dns.google/dns-query, DNS over HTTPS (RFC 8484)
+)
+
+// defaultPorts are the ports of each protocol when an entry gives none
+var defaultPorts = map[string]string{ResolverUDP: "53", ResolverTCP: "53", ResolverTLS: "853", ResolverHTTPS: "443"}
+
+// dohClient sends DNS over HTTPS queries; request headers meant for targets are not added
+var dohClient = &http.Client{}
+
+// Resolver is a parsed resolver entry
+type Resolver struct {
+	Protocol string // One of the Resolver protocol constants
+	Address  string // host:port the resolver is reached at
+	Host     string // Name or address of the resolver, verified against its certificate over TLS and HTTPS
+	URL      string // Endpoint of DNS over HTTPS resolvers
+}
+
+// ParseResolver parses a resolver entry: an address with an optional port,
+// or a tcp://, tls:// or https:// URL (example: tls://8.8.8.8, https://dns.google/dns-query)
+func ParseResolver(entry string) (Resolver, error) {
+	entry = strings.TrimSpace(entry)
+	scheme, rest, found := strings.Cut(entry, "://")
+	if !found {
+		scheme, rest = ResolverUDP, entry
+	}
+	scheme = strings.ToLower(scheme)
+
+	if scheme == ResolverHTTPS {
+		u, err := url.Parse(entry)
+		if err != nil || u.Hostname() == "" {
+			return Resolver{}, fmt.Errorf("invalid DNS over HTTPS resolver %q", entry)
+		}
+		port := u.Port()
+		if port == "" {
+			port = defaultPorts[ResolverHTTPS]
+		}
+		if u.Path == "" {
+			u.Path = "/dns-query"
+		}
+		return Resolver{Protocol: ResolverHTTPS, Address: net.JoinHostPort(u.Hostname(), port), Host: u.Hostname(), URL: u.String()}, nil
+	}
+
+	port, ok := defaultPorts[scheme]
+	if !ok {
+		return Resolver{}, fmt.Errorf("unknown protocol %q in resolver %q, use udp, tcp, tls or https", scheme, entry)
+	}
+	host := strings.TrimSuffix(rest, "/")
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host, port = h, p
+	}
+	host = strings.Trim(host, "[]")
+	if host == "" || strings.ContainsAny(host, "/ ") {
+		return Resolver{}, fmt.Errorf("invalid resolver %q", entry)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return Resolver{}, fmt.Errorf("invalid port in resolver %q", entry)
+	}
+	return Resolver{Protocol: scheme, Address: net.JoinHostPort(host, port), Host: host}, nil
+}
+
+// dial opens a connection to the resolver for the Go resolver
+// UDP entries use the network asked for, so truncated answers are retried over TCP
+func (r Resolver) dial(ctx context.Context, network string) (net.Conn, error) {
+	var d net.Dialer
+	switch r.Protocol {
+	case ResolverTCP:
+		return d.DialContext(ctx, "tcp", r.Address)
+	case ResolverTLS:
+		dialer := tls.Dialer{NetDialer: &d, Config: &tls.Config{ServerName: r.Host}}
+		return dialer.DialContext(ctx, "tcp", r.Address)
+	default:
+		return d.DialContext(ctx, network, r.Address)
+	}
+}
+
+// exchange sends one query to the resolver over its protocol
+func (r Resolver) exchange(msg *dns.Msg, timeout time.Duration) (*dns.Msg, time.Duration, error) {
+	if len(msg.Question) > 0 {
+		recordQuery(r.String(), msg.Question[0].Name)
+	}
+	switch r.Protocol {
+	case ResolverHTTPS:
+		return r.exchangeHTTPS(msg, timeout)
+	case ResolverTCP:
+		client := &dns.Client{Net: "tcp", Timeout: timeout}
+		return client.Exchange(msg, r.Address)
+	case ResolverTLS:
+		client := &dns.Client{Net: "tcp-tls", Timeout: timeout, TLSConfig: &tls.Config{ServerName: r.Host}}
+		return client.Exchange(msg, r.Address)
+	default:
+		return r.exchangeUDP(msg, timeout)
+	}
+}
+
+// exchangeUDP sends a query over UDP, advertising the EDNS0 buffer size unless the
+// query sets its own, and repeats it over TCP when the answer is truncated
+func (r Resolver) exchangeUDP(msg *dns.Msg, timeout time.Duration) (*dns.Msg, time.Duration, error) {
+	client := &dns.Client{Timeout: timeout}
+	if size := ednsBufferSize.Load(); size > 0 && msg.IsEdns0() == nil {
+		msg = msg.Copy()
+		msg.SetEdns0(uint16(size), false)
+		client.UDPSize = uint16(size)
+	}
+
+	resp, rtt, err := client.Exchange(msg, r.Address)
+	if err != nil || !resp.Truncated {
+		return resp, rtt, err
+	}
+	if len(msg.Question) > 0 {
+		recordQuery(r.String(), msg.Question[0].Name)
+	}
+	tcp := &dns.Client{Net: "tcp", Timeout: timeout}
+	resp, tcpRTT, err := tcp.Exchange(msg, r.Address)
+	return resp, rtt + tcpRTT, err
+}
+
+// exchangeHTTPS posts a query in wire format to a DNS over HTTPS endpoint
+// The message ID is sent as 0 so answers can be cached, as RFC 8484 recommends
+func (r Resolver) exchangeHTTPS(msg *dns.Msg, timeout time.Duration) (*dns.Msg, time.Duration, error) {
+	query := msg.Copy()
+	query.Id = 0
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(packed))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	start := time.Now()
+	resp, err := dohClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, dns.MaxMsgSize))
+	rtt := time.Since(start)
+	if err != nil {
+		return nil, rtt, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, rtt, fmt.Errorf("%s answered HTTP %d", r.URL, resp.StatusCode)
+	}
+
+	answer := new(dns.Msg)
+	if err := answer.Unpack(body); err != nil {
+		return nil, rtt, fmt.Errorf("invalid answer from %s: %v", r.URL, err)
+	}
+	answer.Id = msg.Id
+	return answer, rtt, nil
+}
+
+// lookupByExchange resolves the addresses of a name with A and AAAA queries
+// Failures are reported as *net.DNSError like the Go resolver does, so ClassifyLookupError applies
+// The answer sections are returned too, even with NXDOMAIN: the answer then holds the
+// CNAME chain leading to the name that does not exist
+func (r Resolver) lookupByExchange(domain string) ([]string, RecordSet, error) {
+	var addresses []string
+	records := make(RecordSet, len(AddressTypes))
+	found := false
+	for _, qtype := range AddressTypes {
+		msg := new(dns.Msg)
+		msg.SetQuestion(dns.Fqdn(domain), qtype)
+		msg.RecursionDesired = true
+
+		resp, _, err := r.exchangeRetrying(msg)
+		if err != nil {
+			return nil, nil, err
+		}
+		records[qtype] = resp.Answer
+		switch resp.Rcode {
+		case dns.RcodeSuccess:
+			found = true
+		case dns.RcodeNameError:
+			return nil, records, &net.DNSError{Err: "no such host", Name: domain, Server: r.String(), IsNotFound: true}
+		default:
+			return nil, records, &net.DNSError{Err: "server misbehaving", Name: domain, Server: r.String(), IsTemporary: true}
+		}
+		for _, rr := range resp.Answer {
+			switch record := rr.(type) {
+			case *dns.A:
+				addresses = append(addresses, record.A.String())
+			case *dns.AAAA:
+				addresses = append(addresses, record.AAAA.String())
+			}
+		}
+	}
+	if !found || len(addresses) == 0 {
+		return nil, records, &net.DNSError{Err: "no such host", Name: domain, Server: r.String(), IsNotFound: true}
+	}
+	return addresses, records, nil
+}
+
+// ExchangeTimeout sends a raw DNS query to a resolver entry over its protocol, once
+func ExchangeTimeout(msg *dns.Msg, resolver string, timeout time.Duration) (*dns.Msg, time.Duration, error) {
+	r, err := ParseResolver(resolver)
+	if err != nil {
+		return nil, 0, err
+	}
+	return r.exchange(msg, timeout)
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseResolver(t *testing.T) {
+	tests := []struct {
+		entry string
+		want  Resolver
+	}{
+		{"1.1.1.1", Resolver{Protocol: ResolverUDP, Address: "1.1.1.1:53", Host: "1.1.1.1"}},
+		{" 1.1.1.1:5353 ", Resolver{Protocol: ResolverUDP, Address: "1.1.1.1:5353", Host: "1.1.1.1"}},
+		{"2001:4860:4860::8888", Resolver{Protocol: ResolverUDP, Address: "[2001:4860:4860::8888]:53", Host: "2001:4860:4860::8888"}},
+		{"[2001:db8::1]:5353", Resolver{Protocol: ResolverUDP, Address: "[2001:db8::1]:5353", Host: "2001:db8::1"}},
+		{"tcp://9.9.9.9", Resolver{Protocol: ResolverTCP, Address: "9.9.9.9:53", Host: "9.9.9.9"}},
+		{"TLS://8.8.8.8", Resolver{Protocol: ResolverTLS, Address: "8.8.8.8:853", Host: "8.8.8.8"}},
+		{"tls://dns.quad9.net:8853/", Resolver{Protocol: ResolverTLS, Address: "dns.quad9.net:8853", Host: "dns.quad9.net"}},
+		{"https://dns.google", Resolver{Protocol: ResolverHTTPS, Address: "dns.google:443", Host: "dns.google", URL: "https://dns.google/dns-query"}},
+		{"https://doh.example.com:8443/resolve", Resolver{Protocol: ResolverHTTPS, Address: "doh.example.com:8443", Host: "doh.example.com", URL: "https://doh.example.com:8443/resolve"}},
+	}
+	for _, test := range tests {
+		got, err := ParseResolver(test.entry)
+		if err != nil {
+			t.Errorf("ParseResolver(%q) failed: %v", test.entry, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseResolver(%q) = %+v, want %+v", test.entry, got, test.want)
+		}
+	}
+}
+
+func TestParseResolverInvalid(t *testing.T) {
+	for _, entry := range []string{
+		"",
+		"quic://1.1.1.1",
+		"1.1.1.1:0",
+		"1.1.1.1:70000",
+		"1.1.1.1:dns",
+		"https://",
+		"tcp://",
+		"re:^dev\\d+\\.example\\.com$",
+		"resolvers/list.txt",
+	} {
+		if got, err := ParseResolver(entry); err == nil {
+			t.Errorf("ParseResolver(%q) = %+v, want an error", entry, got)
+		}
+	}
+}
+
+func TestLoadResolvers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolvers.txt")
+	os.WriteFile(path, []byte("# public\n1.1.1.1\n\n  tls://8.8.8.8  \nhttps://dns.google/dns-query\n"), 0o644)
+
+	got, err := LoadResolvers(path)
+	if err != nil {
+		t.Fatalf("LoadResolvers failed: %v", err)
+	}
+	want := []string{"1.1.1.1", "tls://8.8.8.8", "https://dns.google/dns-query"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("LoadResolvers = %v, want %v", got, want)
+	}
+
+	os.WriteFile(path, []byte("1.1.1.1\n1.1.1.1:99999\n"), 0o644)
+	if _, err := LoadResolvers(path); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Fatalf("LoadResolvers of a bad line returned %v, want an error naming line 2", err)
+	}
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// SRVServiceNames are well-known SRV owner prefixes queried under a domain
+var SRVServiceNames = []string{
+	"_autodiscover._tcp", "_sip._tcp", "_sip._udp", "_sip._tls", "_sips._tcp",
+	"_sipfederationtls._tcp", "_collab-edge._tls", "_h323cs._tcp", "_ldap._tcp",
+	"_ldaps._tcp", "_gc._tcp", "_kerberos._tcp", "_kerberos._udp", "_kpasswd._tcp",
+	"_xmpp-client._tcp", "_xmpp-server._tcp", "_jabber._tcp", "_matrix._tcp",
+	"_caldav._tcp", "_caldavs._tcp", "_carddav._tcp", "_carddavs._tcp",
+	"_imap._tcp", "_imaps._tcp", "_pop3._tcp", "_pop3s._tcp", "_submission._tcp",
+	"_smtp._tcp", "_http._tcp", "_https._tcp", "_vlmcs._tcp", "_ntp._udp",
+	"_turn._udp", "_turns._tcp", "_stun._udp", "_minecraft._tcp",
+}
+
+// TXTServiceNames are well-known TXT owner prefixes queried under a domain
+var TXTServiceNames = []string{
+	"_dmarc", "_acme-challenge", "_domainkey", "_mta-sts", "_smtp._tls",
+	"_github-challenge", "_gitlab-pages-verification-code", "_amazonses",
+	"_bimi", "_spf",
+}
+
+// hostnamePattern extracts hostnames from free-form TXT content
+var hostnamePattern = regexp.MustCompile(`(?i)\b(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}\b`)
+
+// ServiceHost is a hostname found in a service record
+type ServiceHost struct {
+	Host   string // Hostname referenced by the record
+	Record string // Owner name of the record (example: _sip._tcp.example.com)
+}
+
+// DiscoverServiceHosts queries well-known SRV and TXT names under a domain
+// and returns every hostname referenced by the answers
+func DiscoverServiceHosts(domain, resolver string) []ServiceHost {
+	var hosts []ServiceHost
+
+	for _, service := range SRVServiceNames {
+		owner := service + "." + domain
+		records, err := QueryRecords(owner, dns.TypeSRV, resolver)
+		if err != nil {
+			continue
+		}
+		for _, rr := range records {
+			if srv, ok := rr.(*dns.SRV); ok && srv.Target != "." {
+				hosts = append(hosts, ServiceHost{Host: strings.ToLower(strings.TrimSuffix(srv.Target, ".")), Record: owner})
+			}
+		}
+	}
+
+	for _, service := range TXTServiceNames {
+		owner := service + "." + domain
+		records, err := QueryRecords(owner, dns.TypeTXT, resolver)
+		if err != nil {
+			continue
+		}
+		for _, rr := range records {
+			txt, ok := rr.(*dns.TXT)
+			if !ok {
+				continue
+			}
+			for _, host := range hostnamePattern.FindAllString(strings.Join(txt.Txt, ""), -1) {
+				hosts = append(hosts, ServiceHost{Host: strings.ToLower(host), Record: owner})
+			}
+		}
+	}
+
+	return hosts
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits are the multipliers of byte sizes, memory sizes are binary so KB and KiB are the same
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30}, {"tib", 1 << 40},
+	{"kb", 1 << 10}, {"mb", 1 << 20}, {"gb", 1 << 30}, {"tb", 1 << 40},
+	{"k", 1 << 10}, {"m", 1 << 20}, {"g", 1 << 30}, {"t", 1 << 40},
+	{"b", 1},
+}
+
+// ParseByteSize parses a size such as 512MB, 1.5G or 2GiB into bytes
+// Units are binary (1KB = 1024 bytes); a bare number is a number of bytes
+func ParseByteSize(size string) (int64, error) {
+	value := strings.ToLower(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("invalid size %q, use a number of bytes or a unit (example: 512MB, 2GB)", size)
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which no int64 holds
+	bytes := number * float64(multiplier)
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", size)
+	}
+	return int64(bytes), nil
+}
+
+// FormatByteSize returns a size in the largest binary unit it reaches (example: 1.5GB)
+func FormatByteSize(bytes int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(bytes)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d%s", bytes, units[0])
+	}
+	return fmt.Sprintf("%.1f%s", value, units[unit])
+}
@@ -0,0 +1,72 @@
+package utils
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		size string
+		want int64
+	}{
+		{"0", 0},
+		{"1024", 1024},
+		{"512B", 512},
+		{"1k", 1 << 10},
+		{"1KB", 1 << 10},
+		{"1KiB", 1 << 10},
+		{"512MB", 512 << 20},
+		{" 1.5 G ", 3 << 29},
+		{"2GiB", 2 << 30},
+		{"1tb", 1 << 40},
+		{"1e3", 1000},
+	}
+	for _, test := range tests {
+		got, err := ParseByteSize(test.size)
+		if err != nil {
+			t.Errorf("ParseByteSize(%q) failed: %v", test.size, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseByteSize(%q) = %d, want %d", test.size, got, test.want)
+		}
+	}
+}
+
+func TestParseByteSizeInvalid(t *testing.T) {
+	for _, size := range []string{
+		"",
+		"MB",
+		"-1",
+		"-1GB",
+		"ten",
+		"1PB",
+		"inf",
+		"+Inf",
+		"infGB",
+		"nan",
+		"1e30",
+		"8388608TB",
+		"9223372036854775808",
+	} {
+		if got, err := ParseByteSize(size); err == nil {
+			t.Errorf("ParseByteSize(%q) = %d, want an error", size, got)
+		}
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1.0KB"},
+		{3 << 29, "1.5GB"},
+		{2 << 40, "2.0TB"},
+	}
+	for _, test := range tests {
+		if got := FormatByteSize(test.bytes); got != test.want {
+			t.Errorf("FormatByteSize(%d) = %q, want %q", test.bytes, got, test.want)
+		}
+	}
+}
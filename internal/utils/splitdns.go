@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/miekg/dns"
+)
+
+// SystemGroup is the resolver group of the nameservers listed in resolv.conf
+const SystemGroup = "system"
+
+// ResolvConfPath is the resolver configuration of the operating system
+const ResolvConfPath = "/etc/resolv.conf"
+
+// splitDNS holds the routes applied by RouteResolvers, nil when split DNS is off
+var splitDNS atomic.Pointer[SplitDNS]
+
+// SplitDNS sends names under some zones to their own resolvers, such as internal
+// zones only answered by internal resolvers in split-horizon networks
+type SplitDNS struct {
+	Groups map[string][]string // Resolvers per group name
+	Zones  map[string]string   // Group answering each zone and the names under it
+}
+
+// NewSplitDNS builds the routes of resolver groups (example: internal=10.0.0.53,10.0.0.54
+// or internal=resolvers.txt) and zones (example: corp.example.com=internal)
+// With system, the nameservers of resolv.conf form the system group and its search
+// domains are routed to them. Returns nil when nothing is routed
+func NewSplitDNS(groupSpecs, zoneSpecs []string, system bool) (*SplitDNS, error) {
+	if len(groupSpecs) == 0 && len(zoneSpecs) == 0 && !system {
+		return nil, nil
+	}
+	s := &SplitDNS{Groups: make(map[string][]string), Zones: make(map[string]string)}
+
+	if system {
+		servers, search, err := ReadResolvConf(ResolvConfPath)
+		if err != nil {
+			return nil, err
+		}
+		s.Groups[SystemGroup] = servers
+		for _, zone := range search {
+			s.Zones[zone] = SystemGroup
+		}
+	}
+
+	for _, spec := range groupSpecs {
+		name, value, ok := strings.Cut(spec, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" || strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("invalid resolver group %q, use name=resolver[,resolver...]", spec)
+		}
+		if _, exists := s.Groups[name]; exists {
+			return nil, fmt.Errorf("resolver group %q is defined twice", name)
+		}
+		resolvers, err := groupResolvers(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("resolver group %s: %v", name, err)
+		}
+		s.Groups[name] = resolvers
+	}
+
+	for _, spec := range zoneSpecs {
+		zone, group, ok := strings.Cut(spec, "=")
+		zone = strings.Trim(strings.ToLower(CleanDomain(zone)), ".")
+		group = strings.ToLower(strings.TrimSpace(group))
+		if !ok || zone == "" || group == "" {
+			return nil, fmt.Errorf("invalid split zone %q, use zone=group", spec)
+		}
+		if _, exists := s.Groups[group]; !exists {
+			return nil, fmt.Errorf("split zone %s uses the undefined resolver group %q", zone, group)
+		}
+		s.Zones[zone] = group
+	}
+	return s, nil
+}
+
+// groupResolvers reads the resolvers of a group: a file or a comma-separated list
+func groupResolvers(value string) ([]string, error) {
+	if IsResolverFile(value) {
+		return LoadResolvers(value)
+	}
+	var resolvers []string
+	for _, resolver := range strings.Split(value, ",") {
+		resolver = strings.TrimSpace(resolver)
+		if resolver == "" {
+			continue
+		}
+		if _, err := ParseResolver(resolver); err != nil {
+			return nil, err
+		}
+		resolvers = append(resolvers, resolver)
+	}
+	if len(resolvers) == 0 {
+		return nil, fmt.Errorf("no resolvers")
+	}
+	return resolvers, nil
+}
+
+// ReadResolvConf returns the nameservers and search domains of a resolv.conf file
+func ReadResolvConf(path string) ([]string, []string, error) {
+	config, err := dns.ClientConfigFromFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if len(config.Servers) == 0 {
+		return nil, nil, fmt.Errorf("%s lists no nameserver", path)
+	}
+
+	var servers []string
+	for _, server := range config.Servers {
+		if config.Port != "" && config.Port != "53" {
+			server = net.JoinHostPort(server, config.Port)
+		}
+		servers = append(servers, server)
+	}
+	var search []string
+	for _, domain := range config.Search {
+		if domain = strings.Trim(strings.ToLower(domain), "."); domain != "" {
+			search = append(search, domain)
+		}
+	}
+	return servers, search, nil
+}
+
+// SetSplitDNS sets the routes used by RouteResolvers, nil turns split DNS off
+func SetSplitDNS(s *SplitDNS) {
+	splitDNS.Store(s)
+}
+
+// Route returns the zone and group answering a name, the most specific zone wins
+// Returns empty strings when the name is not under a routed zone
+func (s *SplitDNS) Route(name string) (string, string) {
+	name = strings.Trim(strings.ToLower(name), ".")
+	for {
+		if group, ok := s.Zones[name]; ok {
+			return name, group
+		}
+		_, parent, found := strings.Cut(name, ".")
+		if !found {
+			return "", ""
+		}
+		name = parent
+	}
+}
+
+// Describe returns one line per routed zone, sorted (example: "corp.example.com -> internal (10.0.0.53)")
+func (s *SplitDNS) Describe() []string {
+	var lines []string
+	for zone, group := range s.Zones {
+		lines = append(lines, fmt.Sprintf("%s -> %s (%s)", zone, group, strings.Join(s.Groups[group], ", ")))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// RouteResolvers returns the resolvers a name is looked up with: the group of its
+// zone when split DNS routes it, the given resolvers otherwise
+func RouteResolvers(name string, resolvers []string) []string {
+	s := splitDNS.Load()
+	if s == nil {
+		return resolvers
+	}
+	if _, group := s.Route(name); group != "" {
+		return s.Groups[group]
+	}
+	return resolvers
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSettle is how long a file must stay untouched before its change is reported,
+// editors and copies write a file in several steps
+const watchSettle = 500 * time.Millisecond
+
+// FileWatcher reports changes of a set of files
+type FileWatcher struct {
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// WatchFiles calls onChange with the path of a file of paths once it was written
+// or created; the directories are watched rather than the files, so
+// files saved through a rename, as most editors and config tools do, are still followed
+func WatchFiles(paths []string, onChange func(path string)) (*FileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	watched := make(map[string]string) // Cleaned absolute path -> path as given
+	dirs := make(map[string]bool)
+	for _, path := range paths {
+		absolute, err := filepath.Abs(path)
+		if err != nil {
+			watcher.Close()
+			return nil, err
+		}
+		watched[absolute] = path
+		dir := filepath.Dir(absolute)
+		if !dirs[dir] {
+			if err := watcher.Add(dir); err != nil {
+				watcher.Close()
+				return nil, err
+			}
+			dirs[dir] = true
+		}
+	}
+
+	w := &FileWatcher{watcher: watcher, done: make(chan struct{})}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		pending := make(map[string]*time.Timer)
+		defer func() {
+			for _, timer := range pending {
+				timer.Stop()
+			}
+		}()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				path, ok := watched[filepath.Clean(event.Name)]
+				if !ok || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				if timer, ok := pending[path]; ok {
+					timer.Reset(watchSettle)
+					continue
+				}
+				pending[path] = time.AfterFunc(watchSettle, func() { onChange(path) })
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				Warn("File watcher: %v", err)
+			case <-w.done:
+				return
+			}
+		}
+	}()
+	return w, nil
+}
+
+// Close stops watching
+func (w *FileWatcher) Close() error {
+	if w == nil {
+		return nil
+	}
+	close(w.done)
+	err := w.watcher.Close()
+	w.wg.Wait()
+	return err
+}
@@ -1,104 +1,148 @@
 package utils
 
 import (
-	"context"
+	"errors"
+	"fmt"
 	"sync"
+
+	"golang.org/x/sync/errgroup"
 )
 
-// WorkerTask represents a job to be performed.
+// ErrPoolClosed is returned when a task is submitted to a pool after CloseAndWait
+var ErrPoolClosed = errors.New("worker pool is closed")
+
+// WorkerTask represents a job to be performed; non-nil results are sent to Results
 type WorkerTask func() interface{}
 
-// WorkerPool implements a reusable worker pool.
+// WorkerPool runs tasks on a fixed number of workers
+// The pool is started by Start or by the first Submit, and ended by CloseAndWait,
+// which every caller may call, any number of times
 type WorkerPool struct {
-	tasksChan     chan WorkerTask
-	resultsChan   chan interface{}
-	numWorkers    int
-	wg            sync.WaitGroup
-	ctx           context.Context
-	cancel        context.CancelFunc
-	isInitialized bool
+	tasks      chan WorkerTask
+	results    chan interface{}
+	numWorkers int
+	group      errgroup.Group
+	startOnce  sync.Once
+	closeOnce  sync.Once
+	mu         sync.RWMutex   // Guards closed while a submission registers in sending
+	sending    sync.WaitGroup // Submissions waiting for room in the queue
+	done       chan struct{}  // Closed by CloseAndWait, releases waiting submissions
+	closed     bool
+	err        error
 }
 
 // NewWorkerPool creates a new WorkerPool instance with the specified number of workers.
 func NewWorkerPool(numWorkers int, bufferSize int) *WorkerPool {
-	ctx, cancel := context.WithCancel(context.Background())
+	if numWorkers <= 0 {
+		numWorkers = 1
+	}
 	return &WorkerPool{
-		tasksChan:   make(chan WorkerTask, bufferSize),
-		resultsChan: make(chan interface{}, bufferSize),
-		numWorkers:  numWorkers,
-		ctx:         ctx,
-		cancel:      cancel,
+		tasks:      make(chan WorkerTask, bufferSize),
+		results:    make(chan interface{}, bufferSize),
+		numWorkers: numWorkers,
+		done:       make(chan struct{}),
 	}
 }
 
-// Start initiates the worker pool.
+// Start starts the workers, once
 func (wp *WorkerPool) Start() {
-	if wp.isInitialized {
-		return
-	}
+	wp.startOnce.Do(func() {
+		for i := 0; i < wp.numWorkers; i++ {
+			wp.group.Go(wp.worker)
+		}
+	})
+}
 
-	for i := 0; i < wp.numWorkers; i++ {
-		wp.wg.Add(1)
-		go wp.worker()
+// worker runs tasks until the task queue is closed and drained
+// A panicking task does not stop the worker; the first panic is returned
+// once the queue is drained, so CloseAndWait reports it
+func (wp *WorkerPool) worker() error {
+	var err error
+	for task := range wp.tasks {
+		result, taskErr := runTask(task)
+		if taskErr != nil && err == nil {
+			err = taskErr
+		}
+		if result != nil {
+			wp.results <- result
+		}
 	}
-	wp.isInitialized = true
+	return err
 }
 
-// worker is a goroutine that handles tasks.
-func (wp *WorkerPool) worker() {
-	defer wp.wg.Done()
-
-	for {
-		select {
-		case <-wp.ctx.Done():
-			return
-		case task, ok := <-wp.tasksChan:
-			if !ok {
-				return
-			}
-			result := task()
-			if result != nil {
-				select {
-				case wp.resultsChan <- result:
-				case <-wp.ctx.Done():
-					return
-				}
-			}
+// runTask runs a task, turning a panic into an error
+func runTask(task WorkerTask) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("worker task panicked: %v", r)
 		}
-	}
+	}()
+	return task(), nil
 }
 
-// AddTask adds a task to the worker pool.
-func (wp *WorkerPool) AddTask(task WorkerTask) {
+// Submit queues a task, starting the pool if needed, and blocks while the queue is full
+// Returns ErrPoolClosed, without running the task, once CloseAndWait was called,
+// including when it is called while Submit waits for room in the queue
+func (wp *WorkerPool) Submit(task WorkerTask) error {
+	wp.Start()
+
+	wp.mu.RLock()
+	if wp.closed {
+		wp.mu.RUnlock()
+		return ErrPoolClosed
+	}
+	wp.sending.Add(1)
+	wp.mu.RUnlock()
+	defer wp.sending.Done()
+
+	// The lock is not held while waiting, so a full queue cannot hold up CloseAndWait
 	select {
-	case <-wp.ctx.Done():
-		return
-	case wp.tasksChan <- task:
+	case wp.tasks <- task:
+		return nil
+	case <-wp.done:
+		return ErrPoolClosed
 	}
 }
 
-// Results returns a channel that receives results.
+// Results returns a channel that receives results, closed by CloseAndWait
+// Tasks returning results need a reader, or workers block once the channel is full
 func (wp *WorkerPool) Results() <-chan interface{} {
-	return wp.resultsChan
+	return wp.results
 }
 
-// Stop stops the worker pool and waits until all workers are done.
-func (wp *WorkerPool) Stop() {
-	wp.cancel() // Signal workers to stop
-	close(wp.tasksChan)
-	wp.wg.Wait() // Wait for all workers to exit
-	close(wp.resultsChan)
-	wp.isInitialized = false
-}
+// CloseAndWait stops accepting tasks, waits until every queued task has run and
+// closes Results. Later calls wait for the first one and return the same error:
+// the first panic of a task, if any
+func (wp *WorkerPool) CloseAndWait() error {
+	wp.closeOnce.Do(func() {
+		// Submissions waiting for room give up, the queue is closed once they returned
+		wp.mu.Lock()
+		wp.closed = true
+		close(wp.done)
+		wp.mu.Unlock()
+		wp.sending.Wait()
+		close(wp.tasks)
 
-// StopAndDrain stops the worker pool, waits until all workers are done,
-// and returns all unconsumed results.
-func (wp *WorkerPool) StopAndDrain() []interface{} {
-	wp.Stop()
+		wp.Start()
+		wp.err = wp.group.Wait()
+		close(wp.results)
+	})
+	return wp.err
+}
 
+// CloseAndDrain closes the pool like CloseAndWait and returns the results that
+// were not read from Results
+func (wp *WorkerPool) CloseAndDrain() ([]interface{}, error) {
 	var results []interface{}
-	for result := range wp.resultsChan {
-		results = append(results, result)
-	}
-	return results
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		for result := range wp.results {
+			results = append(results, result)
+		}
+	}()
+
+	err := wp.CloseAndWait()
+	<-drained
+	return results, err
 }
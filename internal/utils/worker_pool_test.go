@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolCloseAndWaitRepeated(t *testing.T) {
+	pool := NewWorkerPool(2, 4)
+	for i := 0; i < 4; i++ {
+		if err := pool.Submit(func() interface{} { return nil }); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+
+	// Every call, including concurrent ones, waits for the tasks and returns
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := pool.CloseAndWait(); err != nil {
+				t.Errorf("CloseAndWait: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := pool.CloseAndWait(); err != nil {
+		t.Fatalf("CloseAndWait after close: %v", err)
+	}
+	if _, open := <-pool.Results(); open {
+		t.Fatal("Results is still open after CloseAndWait")
+	}
+}
+
+func TestWorkerPoolSubmitAfterClose(t *testing.T) {
+	pool := NewWorkerPool(1, 1)
+	if err := pool.CloseAndWait(); err != nil {
+		t.Fatalf("CloseAndWait: %v", err)
+	}
+
+	ran := false
+	err := pool.Submit(func() interface{} {
+		ran = true
+		return nil
+	})
+	if !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("Submit after close returned %v, want ErrPoolClosed", err)
+	}
+	if ran {
+		t.Fatal("task submitted after close was run")
+	}
+}
+
+func TestWorkerPoolSubmitCloseRace(t *testing.T) {
+	for round := 0; round < 50; round++ {
+		pool := NewWorkerPool(4, 2)
+		var mu sync.Mutex
+		accepted, ran := 0, 0
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					err := pool.Submit(func() interface{} {
+						mu.Lock()
+						ran++
+						mu.Unlock()
+						return nil
+					})
+					if err == nil {
+						mu.Lock()
+						accepted++
+						mu.Unlock()
+					} else if !errors.Is(err, ErrPoolClosed) {
+						t.Errorf("Submit: %v", err)
+					}
+				}
+			}()
+		}
+		if err := pool.CloseAndWait(); err != nil {
+			t.Fatalf("CloseAndWait: %v", err)
+		}
+		wg.Wait()
+
+		// Accepted tasks all ran before CloseAndWait returned, rejected ones never did
+		if accepted != ran {
+			t.Fatalf("round %d: %d tasks accepted, %d ran", round, accepted, ran)
+		}
+	}
+}
+
+func TestWorkerPoolCloseWhileSubmitBlocked(t *testing.T) {
+	pool := NewWorkerPool(1, 1)
+	release := make(chan struct{})
+	// The worker blocks on its task and the queue fills up, so the next Submit waits
+	pool.Submit(func() interface{} { <-release; return nil })
+	pool.Submit(func() interface{} { return nil })
+
+	submitted := make(chan error, 1)
+	go func() {
+		submitted <- pool.Submit(func() interface{} { return nil })
+	}()
+	time.Sleep(50 * time.Millisecond)
+	closed := make(chan error, 1)
+	go func() {
+		closed <- pool.CloseAndWait()
+	}()
+
+	select {
+	case err := <-submitted:
+		if err != nil && !errors.Is(err, ErrPoolClosed) {
+			t.Fatalf("blocked Submit returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("blocked Submit did not return once CloseAndWait was called")
+	}
+	close(release)
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("CloseAndWait: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("CloseAndWait did not return")
+	}
+}
+
+func TestWorkerPoolPanicReported(t *testing.T) {
+	pool := NewWorkerPool(2, 4)
+	pool.Submit(func() interface{} { panic("boom") })
+	pool.Submit(func() interface{} { return "ok" })
+
+	results, err := pool.CloseAndDrain()
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("CloseAndDrain returned %v, want the panic", err)
+	}
+	if len(results) != 1 || results[0] != "ok" {
+		t.Fatalf("results = %v, want [ok]", results)
+	}
+	if again := pool.CloseAndWait(); again == nil || again.Error() != err.Error() {
+		t.Fatalf("later CloseAndWait returned %v, want %v", again, err)
+	}
+}
@@ -0,0 +1,163 @@
+package wordlist
+
+import (
+	"bufio"
+	"container/heap"
+	"io"
+	"os"
+	"sort"
+)
+
+// DefaultChunkSize is the number of entries sorted in memory before they are spilled to a temporary file
+const DefaultChunkSize = 1_000_000
+
+// Sort writes the distinct entries of the inputs kept by opts in byte order
+// Entries are sorted in chunks of chunkSize held in memory, spilled to temporary files
+// in tmpDir (the system default when empty) and merged, so any size can be sorted
+func Sort(inputs []string, w io.Writer, opts Options, tmpDir string, chunkSize int) (Counts, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	var counts Counts
+	var chunks []string
+	defer func() {
+		for _, chunk := range chunks {
+			os.Remove(chunk)
+		}
+	}()
+
+	words := make([]string, 0, min(chunkSize, 1024))
+	err := Each(inputs, func(line string) error {
+		counts.Read++
+		if word := opts.entry(line, &counts); word != "" {
+			words = append(words, word)
+		}
+		if len(words) < chunkSize {
+			return nil
+		}
+		chunk, err := spill(words, tmpDir)
+		if err != nil {
+			return err
+		}
+		chunks = append(chunks, chunk)
+		words = words[:0]
+		return nil
+	})
+	if err != nil {
+		return counts, err
+	}
+
+	out := bufio.NewWriter(w)
+	write := func(word string, last *string, first *bool) error {
+		if !*first && word == *last {
+			counts.Duplicates++
+			return nil
+		}
+		*first, *last = false, word
+		counts.Written++
+		_, err := out.WriteString(word + "\n")
+		return err
+	}
+
+	// Lists fitting in one chunk never touch the disk
+	if len(chunks) == 0 {
+		sort.Strings(words)
+		var last string
+		first := true
+		for _, word := range words {
+			if err := write(word, &last, &first); err != nil {
+				return counts, err
+			}
+		}
+		return counts, out.Flush()
+	}
+
+	if len(words) > 0 {
+		chunk, err := spill(words, tmpDir)
+		if err != nil {
+			return counts, err
+		}
+		chunks = append(chunks, chunk)
+	}
+	if err := merge(chunks, write); err != nil {
+		return counts, err
+	}
+	return counts, out.Flush()
+}
+
+// spill sorts words and writes them to a new temporary file, returning its path
+func spill(words []string, tmpDir string) (string, error) {
+	sort.Strings(words)
+	file, err := os.CreateTemp(tmpDir, "subcollector-wordlist-*")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	out := bufio.NewWriter(file)
+	for _, word := range words {
+		if _, err := out.WriteString(word + "\n"); err != nil {
+			return file.Name(), err
+		}
+	}
+	return file.Name(), out.Flush()
+}
+
+// chunkReader is the next line of a sorted chunk
+type chunkReader struct {
+	word  string
+	lines *bufio.Scanner
+}
+
+// chunkHeap orders chunk readers by their next line
+type chunkHeap []*chunkReader
+
+func (h chunkHeap) Len() int            { return len(h) }
+func (h chunkHeap) Less(i, j int) bool  { return h[i].word < h[j].word }
+func (h chunkHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *chunkHeap) Push(x interface{}) { *h = append(*h, x.(*chunkReader)) }
+func (h *chunkHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// merge reads sorted chunks in parallel and passes their lines to write in order
+func merge(chunks []string, write func(word string, last *string, first *bool) error) error {
+	var readers chunkHeap
+	for _, chunk := range chunks {
+		file, err := os.Open(chunk)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		lines := bufio.NewScanner(file)
+		lines.Buffer(make([]byte, 64*1024), maxLineSize)
+		if lines.Scan() {
+			readers = append(readers, &chunkReader{word: lines.Text(), lines: lines})
+		}
+	}
+	heap.Init(&readers)
+
+	var last string
+	first := true
+	for readers.Len() > 0 {
+		next := readers[0]
+		if err := write(next.word, &last, &first); err != nil {
+			return err
+		}
+		if next.lines.Scan() {
+			next.word = next.lines.Text()
+			heap.Fix(&readers, 0)
+		} else {
+			if err := next.lines.Err(); err != nil {
+				return err
+			}
+			heap.Pop(&readers)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package wordlist
+
+import (
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// LengthBuckets are the upper bounds of the length ranges counted by Collect, the last range is open
+var LengthBuckets = []int{3, 6, 10, 15, 20, 30}
+
+// Stats describes the entries of wordlists
+type Stats struct {
+	Lines      int64   // Non-empty lines
+	Invalid    int64   // Lines that are not valid hostname parts
+	Distinct   int64   // Distinct normalized entries
+	Duplicates int64   // Valid lines repeating an earlier entry once normalized
+	Changed    int64   // Valid lines rewritten by normalization (case, scheme, path, punycode, ...)
+	MinLength  int     // Length of the shortest entry
+	MaxLength  int     // Length of the longest entry
+	AvgLength  float64 // Average length of the distinct entries
+	Lengths    []int64 // Distinct entries per range of LengthBuckets, one more for longer entries
+
+	// Distinct entries per character class
+	Alpha   int64 // Letters only
+	Digit   int64 // Digits only
+	Alnum   int64 // Letters and digits, both present
+	Hyphen  int64 // Single labels with a hyphen
+	Dotted  int64 // Several labels
+	Service int64 // Labels starting with an underscore (_dmarc, _sip, ...)
+}
+
+// Collect reads the inputs once and describes their entries as scans would see them
+// Distinct entries are counted with 64-bit hashes, like Process
+func Collect(inputs []string) (Stats, error) {
+	stats := Stats{Lengths: make([]int64, len(LengthBuckets)+1)}
+	seen := make(map[uint64]struct{})
+	var total int64
+
+	err := Each(inputs, func(line string) error {
+		stats.Lines++
+		word := utils.NormalizeWord(line)
+		if word == "" {
+			stats.Invalid++
+			return nil
+		}
+		if word != line {
+			stats.Changed++
+		}
+		key := hashWord(word)
+		if _, dup := seen[key]; dup {
+			stats.Duplicates++
+			return nil
+		}
+		seen[key] = struct{}{}
+
+		stats.Distinct++
+		total += int64(len(word))
+		if stats.MinLength == 0 || len(word) < stats.MinLength {
+			stats.MinLength = len(word)
+		}
+		stats.MaxLength = max(stats.MaxLength, len(word))
+		bucket := len(LengthBuckets)
+		for i, bound := range LengthBuckets {
+			if len(word) <= bound {
+				bucket = i
+				break
+			}
+		}
+		stats.Lengths[bucket]++
+
+		switch {
+		case strings.Contains(word, "."):
+			stats.Dotted++
+		case strings.HasPrefix(word, "_"):
+			stats.Service++
+		case strings.Contains(word, "-"):
+			stats.Hyphen++
+		case inCharset(word, CharsetAlpha):
+			stats.Alpha++
+		case inCharset(word, CharsetDigit):
+			stats.Digit++
+		default:
+			stats.Alnum++
+		}
+		return nil
+	})
+	if stats.Distinct > 0 {
+		stats.AvgLength = float64(total) / float64(stats.Distinct)
+	}
+	return stats, err
+}
@@ -0,0 +1,179 @@
+package wordlist
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fkr00t/subcollector/internal/utils"
+)
+
+// Character sets entries may be restricted to
+const (
+	CharsetAny   = "any"   // Every entry valid in a hostname
+	CharsetAlpha = "alpha" // Letters only
+	CharsetDigit = "digit" // Digits only
+	CharsetAlnum = "alnum" // Letters and digits
+	CharsetLabel = "label" // Letters, digits and hyphens, a single label
+)
+
+// maxLineSize is the longest line read, longer ones are not hostnames anyway
+const maxLineSize = 1024 * 1024
+
+// Options select and rewrite the entries of wordlists
+type Options struct {
+	Raw       bool   // Keep entries as written (trimmed) instead of normalizing them like scans do
+	MinLength int    // Shortest entry kept, 0 for no limit
+	MaxLength int    // Longest entry kept, 0 for no limit
+	Charset   string // Characters entries may contain, one of the Charset constants
+	Dedupe    bool   // Write each entry once
+}
+
+// Validate checks the limits and the character set
+func (o Options) Validate() error {
+	switch o.Charset {
+	case "", CharsetAny, CharsetAlpha, CharsetDigit, CharsetAlnum, CharsetLabel:
+	default:
+		return fmt.Errorf("invalid charset %q, use %s, %s, %s, %s or %s", o.Charset, CharsetAny, CharsetAlpha, CharsetDigit, CharsetAlnum, CharsetLabel)
+	}
+	if o.MinLength < 0 || o.MaxLength < 0 {
+		return fmt.Errorf("length limits cannot be negative")
+	}
+	if o.MaxLength > 0 && o.MinLength > o.MaxLength {
+		return fmt.Errorf("minimum length %d is above the maximum length %d", o.MinLength, o.MaxLength)
+	}
+	return nil
+}
+
+// Counts summarizes what happened to the entries of a run
+type Counts struct {
+	Read       int64 // Non-empty lines read
+	Written    int64 // Entries written
+	Invalid    int64 // Entries that are not valid hostname parts, see utils.NormalizeWord
+	Filtered   int64 // Entries outside the length limits or the character set
+	Duplicates int64 // Entries already written
+}
+
+// Each calls fn with every non-empty line of the inputs, trimmed, in order; "-" reads stdin
+// Files are streamed, so their size is not bounded by memory
+func Each(inputs []string, fn func(line string) error) error {
+	for _, input := range inputs {
+		if err := eachLine(input, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eachLine streams the lines of one input
+func eachLine(input string, fn func(line string) error) error {
+	var reader io.Reader = os.Stdin
+	if input != "-" {
+		file, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	lines := bufio.NewScanner(reader)
+	lines.Buffer(make([]byte, 64*1024), maxLineSize)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %v", input, err)
+	}
+	return nil
+}
+
+// entry rewrites a line and applies the filters, returning "" with the counter to increment otherwise
+func (o Options) entry(line string, counts *Counts) string {
+	word := line
+	if !o.Raw {
+		if word = utils.NormalizeWord(line); word == "" {
+			counts.Invalid++
+			return ""
+		}
+	}
+	if o.MinLength > 0 && len(word) < o.MinLength || o.MaxLength > 0 && len(word) > o.MaxLength || !inCharset(word, o.Charset) {
+		counts.Filtered++
+		return ""
+	}
+	return word
+}
+
+// inCharset reports whether every character of word belongs to charset
+func inCharset(word, charset string) bool {
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		letter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		digit := c >= '0' && c <= '9'
+		var ok bool
+		switch charset {
+		case CharsetAlpha:
+			ok = letter
+		case CharsetDigit:
+			ok = digit
+		case CharsetAlnum:
+			ok = letter || digit
+		case CharsetLabel:
+			ok = letter || digit || c == '-'
+		default:
+			ok = true
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Process writes the entries of the inputs kept by opts, in the order they are read
+// Duplicates are detected with 64-bit hashes of the entries, so memory stays small on
+// multi-GB lists; Sort is exact and also bounded in memory
+func Process(inputs []string, w io.Writer, opts Options) (Counts, error) {
+	var counts Counts
+	seen := make(map[uint64]struct{})
+	out := bufio.NewWriter(w)
+
+	err := Each(inputs, func(line string) error {
+		counts.Read++
+		word := opts.entry(line, &counts)
+		if word == "" {
+			return nil
+		}
+		if opts.Dedupe {
+			key := hashWord(word)
+			if _, dup := seen[key]; dup {
+				counts.Duplicates++
+				return nil
+			}
+			seen[key] = struct{}{}
+		}
+		counts.Written++
+		_, err := out.WriteString(word + "\n")
+		return err
+	})
+	if err != nil {
+		return counts, err
+	}
+	return counts, out.Flush()
+}
+
+// hashWord returns the FNV-1a hash of a word
+func hashWord(word string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(word))
+	return h.Sum64()
+}
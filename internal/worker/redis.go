@@ -0,0 +1,190 @@
+package worker
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redisTimeout bounds connecting and every command that does not block on purpose
+const redisTimeout = 30 * time.Second
+
+// redisError is an error reply of the server
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// redisClient sends commands over the Redis protocol (RESP2)
+type redisClient struct {
+	address  string
+	useTLS   bool
+	username string
+	password string
+	db       int
+
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// newRedisClient parses redis://[user:password@]host[:port][/db], rediss:// for TLS
+func newRedisClient(rawURL string) (*redisClient, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Redis URL %q, expected redis://[:password@]host:port[/db]", rawURL)
+	}
+
+	c := &redisClient{address: u.Host, useTLS: u.Scheme == "rediss"}
+	if u.Port() == "" {
+		c.address = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		c.db, err = strconv.Atoi(db)
+		if err != nil || c.db < 0 {
+			return nil, fmt.Errorf("invalid Redis database %q", db)
+		}
+	}
+	return c, nil
+}
+
+// connect opens a connection, authenticates and selects the database
+func (c *redisClient) connect() error {
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var conn net.Conn
+	var err error
+	if c.useTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.address, &tls.Config{ServerName: hostOf(c.address)})
+	} else {
+		conn, err = dialer.Dial("tcp", c.address)
+	}
+	if err != nil {
+		return err
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+
+	// A password alone authenticates the default user
+	switch {
+	case c.username != "" && c.password != "":
+		_, err = c.do(0, "AUTH", c.username, c.password)
+	case c.password != "":
+		_, err = c.do(0, "AUTH", c.password)
+	case c.username != "":
+		_, err = c.do(0, "AUTH", c.username)
+	}
+	if err == nil && c.db != 0 {
+		_, err = c.do(0, "SELECT", strconv.Itoa(c.db))
+	}
+	if err != nil {
+		c.close()
+		return err
+	}
+	return nil
+}
+
+// hostOf returns the host part of an address
+func hostOf(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
+
+// Do runs one command, connecting first if needed
+// block is the time the command may block on the server (BLMOVE, XREADGROUP BLOCK), added to the timeout
+// Replies are strings, int64, nil or []any; error replies are returned as redisError
+func (c *redisClient) Do(block time.Duration, args ...string) (any, error) {
+	if c.conn == nil {
+		if err := c.connect(); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := c.do(block, args...)
+	// A broken connection is dropped, the next command opens a new one
+	if err != nil && !errors.As(err, new(redisError)) {
+		c.close()
+	}
+	return reply, err
+}
+
+func (c *redisClient) do(block time.Duration, args ...string) (any, error) {
+	c.conn.SetDeadline(time.Now().Add(redisTimeout + block))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+// readReply reads one reply, arrays recursively
+func (c *redisClient) readReply() (any, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bulk length %q", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid array length %q", line)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]any, count)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected reply %q", line)
+}
+
+// close drops the connection
+func (c *redisClient) close() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn, c.reader = nil, nil
+	}
+}
@@ -0,0 +1,344 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	pb "github.com/fkr00t/subcollector/api/subcollector/v1"
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/scanner"
+	"github.com/fkr00t/subcollector/internal/server"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Defaults of the Redis worker
+const (
+	DefaultQueue   = "subcollector:jobs"
+	DefaultResults = "subcollector:results"
+	DefaultGroup   = "subcollector"
+)
+
+const (
+	pollInterval   = 5 * time.Second // Time a pop blocks on an empty queue
+	reconnectDelay = 5 * time.Second // Pause after Redis could not be reached
+)
+
+// Job outcomes
+const (
+	StatusDone   = "done"
+	StatusFailed = "failed"
+)
+
+// Options configures a worker
+type Options struct {
+	RedisURL string
+	Queue    string // List or stream jobs are taken from
+	Results  string // List job results are pushed to
+	Stream   bool   // Read jobs from a stream with a consumer group instead of a list
+	Group    string // Consumer group of the stream
+	Name     string // Name of the worker in results and in the consumer group, the host name when empty
+	Drain    bool   // Exit once the queue is empty instead of waiting for more jobs
+
+	// Settings of every scan, the fields of a job override or extend them
+	Active  scanner.ActiveScanConfig
+	Passive scanner.PassiveScanConfig
+}
+
+// job is a payload taken from the queue
+type job struct {
+	payload string
+	entry   string // Stream entry ID, acknowledged once the job is done
+}
+
+// jobResult is pushed for every job: its ID and outcome, followed by the JSON output of the scan
+type jobResult struct {
+	ID     string `json:"id,omitempty"` // ID given in the job, the stream entry ID by default
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Worker string `json:"worker"`
+	models.OutputJSON
+}
+
+// worker takes jobs from Redis and runs them one after the other
+type worker struct {
+	opts      Options
+	redis     *redisClient
+	recovered bool // Stream entries left pending by a previous run were handled
+}
+
+// Run takes jobs from the queue and runs them until the queue is empty with Drain, or forever
+// A job is a domain name, or a JSON object with the fields of a ScanRequest (see api/subcollector/v1/scan.proto)
+// and an optional "id"; its result is pushed to the results list once the scan is finished
+func Run(opts Options) error {
+	if opts.Queue == "" {
+		opts.Queue = DefaultQueue
+	}
+	if opts.Results == "" {
+		opts.Results = DefaultResults
+	}
+	if opts.Group == "" {
+		opts.Group = DefaultGroup
+	}
+	if opts.Name == "" {
+		opts.Name, _ = os.Hostname()
+	}
+
+	client, err := newRedisClient(opts.RedisURL)
+	if err != nil {
+		return err
+	}
+	w := &worker{opts: opts, redis: client}
+	defer client.close()
+
+	// Bad addresses and credentials are reported right away
+	if _, err := client.Do(0, "PING"); err != nil {
+		return fmt.Errorf("failed to reach Redis: %v", err)
+	}
+	kind := "list"
+	if !opts.Stream {
+		if err := w.requeue(); err != nil {
+			return err
+		}
+	} else {
+		kind = "stream, group " + opts.Group
+		if err := w.createGroup(); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("» Worker %s waiting for jobs on %s (%s), results go to %s\n", opts.Name, opts.Queue, kind, opts.Results)
+
+	for {
+		next, err := w.next()
+		if err != nil {
+			fmt.Printf("× Failed to read jobs: %v, retrying in %s\n", err, reconnectDelay)
+			time.Sleep(reconnectDelay)
+			continue
+		}
+		if next == nil {
+			if opts.Drain {
+				fmt.Println("» Queue empty, exiting")
+				return nil
+			}
+			continue
+		}
+
+		result := w.run(next)
+		// The job stays in the processing list or pending in the group until its result is stored
+		for {
+			err := w.finish(next, result)
+			if err == nil {
+				break
+			}
+			fmt.Printf("× Failed to store the result of job %s: %v, retrying in %s\n", result.ID, err, reconnectDelay)
+			time.Sleep(reconnectDelay)
+		}
+	}
+}
+
+// processing is the list holding the jobs this worker took from a list queue until their result is stored
+func (w *worker) processing() string {
+	return w.opts.Queue + ":processing:" + w.opts.Name
+}
+
+// requeue pushes the jobs a previous run of this worker left in its processing list back to the head of the queue
+func (w *worker) requeue() error {
+	count := 0
+	for {
+		reply, err := w.redis.Do(0, "LMOVE", w.processing(), w.opts.Queue, "RIGHT", "LEFT")
+		if err != nil {
+			return fmt.Errorf("failed to requeue unfinished jobs: %v", err)
+		}
+		if reply == nil {
+			break
+		}
+		count++
+	}
+	if count > 0 {
+		fmt.Printf("» Requeued %d unfinished job(s) from %s\n", count, w.processing())
+	}
+	return nil
+}
+
+// createGroup creates the consumer group and the stream if needed; jobs already in the stream are included
+func (w *worker) createGroup() error {
+	_, err := w.redis.Do(0, "XGROUP", "CREATE", w.opts.Queue, w.opts.Group, "0", "MKSTREAM")
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %v", err)
+	}
+	return nil
+}
+
+// next waits for a job, returning nil when none arrived within the poll interval
+func (w *worker) next() (*job, error) {
+	if !w.opts.Stream {
+		reply, err := w.redis.Do(pollInterval, "BLMOVE", w.opts.Queue, w.processing(), "LEFT", "RIGHT", fmt.Sprint(int(pollInterval/time.Second)))
+		if err != nil || reply == nil {
+			return nil, err
+		}
+		payload, _ := reply.(string)
+		return &job{payload: payload}, nil
+	}
+
+	// Entries this consumer took before it was stopped come first
+	if !w.recovered {
+		reply, err := w.redis.Do(0, "XREADGROUP", "GROUP", w.opts.Group, w.opts.Name, "COUNT", "1", "STREAMS", w.opts.Queue, "0")
+		if err != nil {
+			return nil, err
+		}
+		if next := streamEntry(reply); next != nil {
+			return next, nil
+		}
+		w.recovered = true
+	}
+	reply, err := w.redis.Do(pollInterval, "XREADGROUP", "GROUP", w.opts.Group, w.opts.Name, "COUNT", "1",
+		"BLOCK", fmt.Sprint(pollInterval.Milliseconds()), "STREAMS", w.opts.Queue, ">")
+	if err != nil {
+		return nil, err
+	}
+	return streamEntry(reply), nil
+}
+
+// streamEntry extracts the first entry of an XREADGROUP reply: [[stream, [[id, [field, value, ...]]]]]
+// The payload is the "job" field, or the first field when there is none
+func streamEntry(reply any) *job {
+	streams, _ := reply.([]any)
+	if len(streams) == 0 {
+		return nil
+	}
+	stream, _ := streams[0].([]any)
+	if len(stream) < 2 {
+		return nil
+	}
+	entries, _ := stream[1].([]any)
+	if len(entries) == 0 {
+		return nil
+	}
+	entry, _ := entries[0].([]any)
+	if len(entry) < 2 {
+		return nil
+	}
+
+	next := &job{}
+	next.entry, _ = entry[0].(string)
+	// Fields are nil when the entry was deleted after it was delivered
+	fields, _ := entry[1].([]any)
+	for i := 0; i+1 < len(fields); i += 2 {
+		name, _ := fields[i].(string)
+		value, _ := fields[i+1].(string)
+		if name == "job" {
+			next.payload = value
+			break
+		}
+		if i == 0 {
+			next.payload = value
+		}
+	}
+	return next
+}
+
+// run parses and runs one job
+func (w *worker) run(next *job) jobResult {
+	result := jobResult{Status: StatusDone, Worker: w.opts.Name}
+	id, req, err := parseJob(next.payload)
+	result.ID = id
+	if result.ID == "" {
+		result.ID = next.entry
+	}
+	if err != nil {
+		return w.failed(result, err)
+	}
+	scan, err := server.NewScan(req, w.opts.Active, w.opts.Passive)
+	if err != nil {
+		return w.failed(result, err)
+	}
+
+	metadata := scan.Metadata()
+	metadata.Mode = scan.ModeName()
+	metadata.StartedAt = time.Now()
+	fmt.Printf("\n» Job %s: %s scan of %s\n", result.ID, metadata.Mode, scan.Domain)
+	results, err := scan.Run(context.Background(), nil)
+	metadata.FinishedAt = time.Now()
+	if err != nil {
+		result.Status, result.Error = StatusFailed, server.ClientError(err)
+	}
+
+	if results == nil {
+		results = []models.SubdomainResult{}
+	}
+	result.OutputJSON = models.OutputJSON{
+		SchemaVersion: models.OutputSchemaVersion,
+		Domain:        scan.Domain,
+		ScanMetadata:  metadata,
+		Counts:        models.CountResults(results),
+		Subdomains:    results,
+		Groups:        models.GroupResults(results),
+	}
+	return result
+}
+
+// failed records a job that could not be run
+func (w *worker) failed(result jobResult, err error) jobResult {
+	fmt.Printf("× Job %s rejected: %v\n", result.ID, err)
+	result.Status, result.Error = StatusFailed, err.Error()
+	result.Subdomains = []models.SubdomainResult{}
+	return result
+}
+
+// finish pushes the result of a job, then removes the job from the processing list or acknowledges it
+func (w *worker) finish(next *job, result jobResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if _, err := w.redis.Do(0, "RPUSH", w.opts.Results, string(data)); err != nil {
+		return err
+	}
+	if w.opts.Stream {
+		_, err = w.redis.Do(0, "XACK", w.opts.Queue, w.opts.Group, next.entry)
+	} else {
+		_, err = w.redis.Do(0, "LREM", w.processing(), "1", next.payload)
+	}
+	return err
+}
+
+// parseJob reads a job: a bare domain, or a JSON ScanRequest with an optional "id"
+// The mode may also be given as "active" or "passive"
+func parseJob(payload string) (string, *pb.ScanRequest, error) {
+	payload = strings.TrimSpace(payload)
+	if payload == "" {
+		return "", nil, errors.New("empty job")
+	}
+	if !strings.HasPrefix(payload, "{") {
+		return "", &pb.ScanRequest{Domain: payload}, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil {
+		return "", nil, fmt.Errorf("invalid job: %v", err)
+	}
+	var id string
+	if raw, ok := fields["id"]; ok {
+		if err := json.Unmarshal(raw, &id); err != nil {
+			return "", nil, errors.New(`invalid job: "id" must be a string`)
+		}
+		delete(fields, "id")
+	}
+	if raw, ok := fields["mode"]; ok {
+		var mode string
+		if json.Unmarshal(raw, &mode) == nil && !strings.HasPrefix(mode, "SCAN_MODE_") {
+			fields["mode"], _ = json.Marshal("SCAN_MODE_" + strings.ToUpper(mode))
+		}
+	}
+
+	data, _ := json.Marshal(fields)
+	req := &pb.ScanRequest{}
+	if err := protojson.Unmarshal(data, req); err != nil {
+		return id, nil, fmt.Errorf("invalid job: %v", err)
+	}
+	return id, req, nil
+}
@@ -0,0 +1,208 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fkr00t/subcollector/internal/models"
+	"github.com/fkr00t/subcollector/internal/output"
+)
+
+// Artifact file names inside a workspace
+const (
+	LogFile        = "scan.log"         // Everything printed during the run, without colors
+	CheckpointFile = "checkpoint.jsonl" // Results appended as they are confirmed, one JSON object per line
+	UnresolvedFile = "unresolved.txt"   // Candidates that never got an authoritative answer
+	ResultsText    = "results.txt"
+	ResultsJSON    = "results.json"
+	ResultsCSV     = "results.csv"
+	ResultsXML     = "results.xml"
+	FindingsSARIF  = "findings.sarif" // Takeover and dangling DNS findings for code scanning dashboards
+	ReportHTML     = "report.html"
+	IPsFile        = "ips.txt"  // Unique resolved addresses
+	EvidenceDir    = "evidence" // Directory of takeover evidence files
+)
+
+// ansiPattern matches terminal color sequences, which are stripped from the log
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// Workspace is a directory collecting every artifact of one run
+// All methods are safe to call on a nil Workspace and then do nothing
+type Workspace struct {
+	Dir string
+
+	mu      sync.Mutex
+	restore func()
+}
+
+// Create makes a timestamped workspace directory for a target under base
+// Returns nil when base is empty
+func Create(base, target string, startedAt time.Time) (*Workspace, error) {
+	if base == "" {
+		return nil, nil
+	}
+
+	dir := filepath.Join(base, sanitize(target), startedAt.Format("20060102-150405"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %v", err)
+	}
+	return &Workspace{Dir: dir}, nil
+}
+
+// sanitize turns a target into a safe directory name
+func sanitize(target string) string {
+	if target == "" {
+		return "scan"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, target)
+}
+
+// Path returns the path of an artifact in the workspace
+func (w *Workspace) Path(name string) string {
+	if w == nil {
+		return ""
+	}
+	return filepath.Join(w.Dir, name)
+}
+
+// CaptureOutput copies everything printed to stdout into the workspace log until Close
+func (w *Workspace) CaptureOutput() error {
+	if w == nil {
+		return nil
+	}
+
+	logFile, err := os.Create(w.Path(LogFile))
+	if err != nil {
+		return err
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		logFile.Close()
+		return err
+	}
+
+	original := os.Stdout
+	os.Stdout = writer
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := reader.Read(buf)
+			if n > 0 {
+				original.Write(buf[:n])
+				logFile.Write(ansiPattern.ReplaceAll(buf[:n], nil))
+			}
+			if err != nil {
+				if err != io.EOF {
+					fmt.Fprintf(original, "× Workspace log stopped: %v\n", err)
+				}
+				return
+			}
+		}
+	}()
+
+	w.restore = func() {
+		os.Stdout = original
+		writer.Close()
+		<-done
+		reader.Close()
+		logFile.Close()
+	}
+	return nil
+}
+
+// Close stops output capture
+func (w *Workspace) Close() {
+	if w == nil || w.restore == nil {
+		return
+	}
+	w.restore()
+	w.restore = nil
+}
+
+// Checkpoint appends confirmed results to the checkpoint file
+// so a crash or interrupt still leaves everything found so far on disk
+func (w *Workspace) Checkpoint(results []models.SubdomainResult) error {
+	if w == nil || len(results) == 0 {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	file, err := os.OpenFile(w.Path(CheckpointFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, result := range results {
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveResults writes the final results in every format, the HTML report and the resolved address list
+func (w *Workspace) SaveResults(domain string, results []models.SubdomainResult, metadata models.ScanMetadata) error {
+	if w == nil {
+		return nil
+	}
+
+	if err := output.SaveResults(w.Path(ResultsText), "", domain, results, metadata, nil); err != nil {
+		return err
+	}
+	if err := output.SaveResults("", w.Path(ResultsJSON), domain, results, metadata, nil); err != nil {
+		return err
+	}
+	if err := output.SaveCSV(w.Path(ResultsCSV), results, metadata); err != nil {
+		return err
+	}
+	if err := output.SaveXML(w.Path(ResultsXML), domain, results, metadata); err != nil {
+		return err
+	}
+	if err := output.SaveSARIF(w.Path(FindingsSARIF), results, metadata); err != nil {
+		return err
+	}
+	if err := output.SaveHTMLReport(w.Path(ReportHTML), domain, results, metadata); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	var ips []string
+	for _, result := range results {
+		for _, ip := range result.IPs {
+			if !seen[ip] {
+				seen[ip] = true
+				ips = append(ips, ip)
+			}
+		}
+	}
+	if len(ips) > 0 {
+		sort.Strings(ips)
+		if err := output.SaveLines(w.Path(IPsFile), ips); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}